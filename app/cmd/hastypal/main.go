@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/adriein/hastypal/internal"
 	"github.com/adriein/hastypal/internal/server"
+	"github.com/adriein/hastypal/pkg/constants"
 )
 
 func main() {
@@ -30,6 +33,32 @@ func main() {
 	}
 
 	switch os.Args[1] {
+	case "renew-google-channels":
+		if err := app.Modules.Google.RenewChannels(context.Background()); err != nil {
+			fmt.Printf("Failed to renew google calendar channels %v", err)
+			os.Exit(1)
+		}
+	case "set-telegram-webhook":
+		url := os.Getenv(constants.TelegramWebhookUrl)
+		secret := os.Getenv(constants.TelegramWebhookSecret)
+
+		if err := app.Modules.Telegram.RegisterWebhook(url, secret); err != nil {
+			fmt.Printf("Failed to register the telegram webhook %v", err)
+			os.Exit(1)
+		}
+	case "send-reminders":
+		if err := app.Modules.Reminder.SendDue(context.Background()); err != nil {
+			fmt.Printf("Failed to send the due reminders %v", err)
+			os.Exit(1)
+		}
+	case "outbox-dispatcher":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err := app.Modules.Outbox.Run(ctx); err != nil {
+			fmt.Printf("Outbox dispatcher failed %v", err)
+			os.Exit(1)
+		}
 	default:
 		fmt.Printf("Unknown command: %s\n", os.Args[1])
 		os.Exit(1)
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
 	"os"
 	"time"
@@ -42,6 +43,15 @@ func New(logger *slog.Logger) *sql.DB {
 	return database
 }
 
+const uniqueViolation = "23505"
+
+// IsUniqueViolation tells whether the statement failed on a unique constraint or index.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
 func CloseRowsSafely(rows *sql.Rows, err *error) {
 	if rowsErr := rows.Close(); rowsErr != nil && *err == nil {
 		*err = eris.Wrap(rowsErr, "Failed to close rows")
@@ -50,3 +60,26 @@ func CloseRowsSafely(rows *sql.Rows, err *error) {
 		*err = eris.Wrap(streamErr, "Database stream cut off")
 	}
 }
+
+// WithTransaction runs fn inside a transaction, committing when it returns nil and rolling back otherwise.
+func WithTransaction(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return eris.Wrap(err, "Failed to begin transaction")
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return eris.Wrapf(err, "Failed to rollback transaction: %v", rollbackErr)
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return eris.Wrap(err, "Failed to commit transaction")
+	}
+
+	return nil
+}
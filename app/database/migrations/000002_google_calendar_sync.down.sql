@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS ha_google_channel;
+DROP INDEX IF EXISTS idx_booking_event_id;
+ALTER TABLE booking DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE booking DROP COLUMN IF EXISTS event_id;
+ALTER TABLE booking DROP COLUMN IF EXISTS status;
+ALTER TABLE booking DROP COLUMN IF EXISTS chat_id;
@@ -0,0 +1,33 @@
+/*
+================================================================================
+BOOKING CALENDAR STATE
+================================================================================
+*/
+
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS chat_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'confirmed';
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS event_id VARCHAR(1024) NULL;
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS updated_at VARCHAR(60) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_booking_event_id ON booking(event_id);
+
+/*
+================================================================================
+GOOGLE CALENDAR PUSH NOTIFICATION CHANNELS
+================================================================================
+*/
+
+CREATE TABLE IF NOT EXISTS ha_google_channel (
+    hagc_id VARCHAR(64) PRIMARY KEY,
+    hagc_business_id BIGINT NOT NULL,
+    hagc_resource_id VARCHAR(255) NOT NULL,
+    hagc_calendar_id VARCHAR(255) NOT NULL,
+    hagc_token VARCHAR(64) NOT NULL,
+    hagc_sync_token VARCHAR(1024) NULL,
+    hagc_expiration TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hagc_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    hagc_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_google_channel_business FOREIGN KEY(hagc_business_id) REFERENCES ha_business(hab_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_google_channel_expiration ON ha_google_channel(hagc_expiration);
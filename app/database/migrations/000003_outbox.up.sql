@@ -0,0 +1,22 @@
+/*
+================================================================================
+TRANSACTIONAL OUTBOX
+================================================================================
+*/
+
+CREATE TABLE IF NOT EXISTS ha_outbox (
+    hao_id VARCHAR(36) PRIMARY KEY,
+    hao_type VARCHAR(60) NOT NULL,
+    hao_aggregate_id VARCHAR(36) NOT NULL,
+    hao_payload JSONB NOT NULL,
+    hao_status VARCHAR(20) NOT NULL,
+    hao_attempts INTEGER NOT NULL DEFAULT 0,
+    hao_last_error TEXT NULL,
+    hao_next_attempt_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hao_locked_until TIMESTAMP(0) WITH TIME ZONE NULL,
+    hao_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    hao_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON ha_outbox(hao_status, hao_next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON ha_outbox(hao_aggregate_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_google_channel_calendar;
+ALTER TABLE booking DROP COLUMN IF EXISTS calendar_id;
+ALTER TABLE booking DROP COLUMN IF EXISTS employee_id;
+ALTER TABLE ha_employees DROP COLUMN IF EXISTS hae_calendar_id;
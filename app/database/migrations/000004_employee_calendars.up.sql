@@ -0,0 +1,6 @@
+ALTER TABLE ha_employees ADD COLUMN IF NOT EXISTS hae_calendar_id VARCHAR(255) NULL;
+
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS employee_id BIGINT NULL;
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS calendar_id VARCHAR(255) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_google_channel_calendar ON ha_google_channel(hagc_business_id, hagc_calendar_id);
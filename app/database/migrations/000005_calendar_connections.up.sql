@@ -0,0 +1,23 @@
+/*
+================================================================================
+CALENDAR CONNECTIONS
+================================================================================
+*/
+
+CREATE TABLE IF NOT EXISTS ha_calendar_connection (
+    hacc_business_id BIGINT PRIMARY KEY,
+    hacc_provider VARCHAR(20) NOT NULL,
+    hacc_url VARCHAR(1024) NULL,
+    hacc_username VARCHAR(255) NULL,
+    hacc_password VARCHAR(255) NULL,
+    hacc_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    hacc_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_calendar_connection_business FOREIGN KEY(hacc_business_id) REFERENCES ha_business(hab_id)
+);
+
+-- Every business that already went through the Google OAuth flow keeps using Google.
+INSERT INTO ha_calendar_connection (hacc_business_id, hacc_provider, hacc_date_add, hacc_date_upd)
+SELECT hab_id, 'google', NOW(), NOW()
+FROM ha_business
+JOIN google_token ON google_token.business_id = CAST(hab_id AS VARCHAR)
+ON CONFLICT (hacc_business_id) DO NOTHING;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ha_booking_feed;
+
+ALTER TABLE booking DROP COLUMN IF EXISTS customer_name;
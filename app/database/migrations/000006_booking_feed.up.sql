@@ -0,0 +1,17 @@
+/*
+================================================================================
+BOOKING ICS FEED
+================================================================================
+*/
+
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS customer_name VARCHAR(255) NULL;
+
+CREATE TABLE IF NOT EXISTS ha_booking_feed (
+    habf_business_id BIGINT PRIMARY KEY,
+    habf_token VARCHAR(64) NOT NULL,
+    habf_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    habf_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_booking_feed_business FOREIGN KEY(habf_business_id) REFERENCES ha_business(hab_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_feed_token ON ha_booking_feed(habf_token);
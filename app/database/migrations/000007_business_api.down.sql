@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_service_catalog_business;
+
+DROP INDEX IF EXISTS idx_business_holidays_date;
+ALTER TABLE ha_business_holidays ALTER COLUMN habh_business_id DROP NOT NULL;
+ALTER TABLE ha_business_holidays DROP COLUMN IF EXISTS habh_name;
+ALTER TABLE ha_business_holidays DROP COLUMN IF EXISTS habh_date;
+ALTER TABLE ha_business_holidays DROP COLUMN IF EXISTS habh_id;
+
+DROP INDEX IF EXISTS idx_open_hours_business;
+ALTER TABLE ha_open_hours ALTER COLUMN haoh_business_id DROP NOT NULL;
+ALTER TABLE ha_open_hours DROP COLUMN IF EXISTS haoh_close;
+ALTER TABLE ha_open_hours DROP COLUMN IF EXISTS haoh_open;
+ALTER TABLE ha_open_hours DROP COLUMN IF EXISTS haoh_weekday;
+ALTER TABLE ha_open_hours DROP COLUMN IF EXISTS haoh_id;
@@ -0,0 +1,26 @@
+/*
+================================================================================
+BUSINESS API
+================================================================================
+*/
+
+-- Both tables were created without any data column, the rows they may hold carry no information.
+DELETE FROM ha_open_hours;
+DELETE FROM ha_business_holidays;
+
+ALTER TABLE ha_open_hours ADD COLUMN IF NOT EXISTS haoh_id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY;
+ALTER TABLE ha_open_hours ADD COLUMN IF NOT EXISTS haoh_weekday SMALLINT NOT NULL;
+ALTER TABLE ha_open_hours ADD COLUMN IF NOT EXISTS haoh_open TIME(0) NOT NULL;
+ALTER TABLE ha_open_hours ADD COLUMN IF NOT EXISTS haoh_close TIME(0) NOT NULL;
+ALTER TABLE ha_open_hours ALTER COLUMN haoh_business_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_open_hours_business ON ha_open_hours(haoh_business_id, haoh_weekday);
+
+ALTER TABLE ha_business_holidays ADD COLUMN IF NOT EXISTS habh_id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY;
+ALTER TABLE ha_business_holidays ADD COLUMN IF NOT EXISTS habh_date DATE NOT NULL;
+ALTER TABLE ha_business_holidays ADD COLUMN IF NOT EXISTS habh_name VARCHAR(255) NULL;
+ALTER TABLE ha_business_holidays ALTER COLUMN habh_business_id SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_business_holidays_date ON ha_business_holidays(habh_business_id, habh_date);
+
+CREATE INDEX IF NOT EXISTS idx_service_catalog_business ON ha_service_catalog(hasc_business_id);
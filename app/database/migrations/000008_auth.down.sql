@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS ha_refresh_token;
+
+DROP INDEX IF EXISTS idx_business_email;
+ALTER TABLE ha_business DROP COLUMN IF EXISTS hab_password;
@@ -0,0 +1,24 @@
+/*
+================================================================================
+AUTHENTICATION
+================================================================================
+*/
+
+ALTER TABLE ha_business ADD COLUMN IF NOT EXISTS hab_password VARCHAR(255) NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_business_email ON ha_business(LOWER(hab_email));
+
+CREATE TABLE IF NOT EXISTS ha_refresh_token (
+    hart_id VARCHAR(36) PRIMARY KEY,
+    hart_business_id BIGINT NOT NULL,
+    hart_family_id VARCHAR(36) NOT NULL,
+    hart_token_hash VARCHAR(64) NOT NULL,
+    hart_expires_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hart_revoked_at TIMESTAMP(0) WITH TIME ZONE NULL,
+    hart_replaced_by VARCHAR(36) NULL,
+    hart_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_refresh_token_business FOREIGN KEY(hart_business_id) REFERENCES ha_business(hab_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_token_hash ON ha_refresh_token(hart_token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_family ON ha_refresh_token(hart_family_id);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS ha_invitation;
+
+ALTER TABLE ha_business ADD COLUMN IF NOT EXISTS hab_password VARCHAR(255) NULL;
+
+UPDATE ha_business
+SET hab_password = hau_password
+FROM ha_user
+WHERE hau_business_id = hab_id AND hau_role = 'owner' AND LOWER(hau_email) = LOWER(hab_email);
+
+ALTER TABLE ha_refresh_token DROP COLUMN IF EXISTS hart_user_id;
+
+DROP TABLE IF EXISTS ha_user;
@@ -0,0 +1,72 @@
+/*
+================================================================================
+BUSINESS USERS
+================================================================================
+*/
+
+CREATE TABLE IF NOT EXISTS ha_user (
+    hau_id VARCHAR(36) PRIMARY KEY,
+    hau_business_id BIGINT NOT NULL,
+    hau_employee_id BIGINT NULL,
+    hau_name VARCHAR(255) NOT NULL,
+    hau_email VARCHAR(60) NOT NULL,
+    hau_password VARCHAR(255) NOT NULL,
+    hau_role VARCHAR(16) NOT NULL,
+    hau_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    hau_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_user_business FOREIGN KEY(hau_business_id) REFERENCES ha_business(hab_id),
+    CONSTRAINT fk_user_employee FOREIGN KEY(hau_employee_id) REFERENCES ha_employees(hae_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_email ON ha_user(LOWER(hau_email));
+CREATE INDEX IF NOT EXISTS idx_user_business ON ha_user(hau_business_id);
+
+-- The credentials of the existing businesses become their owner user.
+INSERT INTO ha_user (
+    hau_id,
+    hau_business_id,
+    hau_name,
+    hau_email,
+    hau_password,
+    hau_role,
+    hau_date_add,
+    hau_date_upd
+)
+SELECT
+    gen_random_uuid()::VARCHAR,
+    hab_id,
+    hab_name,
+    hab_email,
+    hab_password,
+    'owner',
+    NOW(),
+    NOW()
+FROM ha_business
+WHERE hab_password IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE ha_refresh_token ADD COLUMN IF NOT EXISTS hart_user_id VARCHAR(36) NULL;
+
+-- Sessions opened before the users existed belong to no user, their owners log in again.
+UPDATE ha_refresh_token
+SET hart_revoked_at = NOW()
+WHERE hart_revoked_at IS NULL;
+
+ALTER TABLE ha_business DROP COLUMN IF EXISTS hab_password;
+
+CREATE TABLE IF NOT EXISTS ha_invitation (
+    hai_id VARCHAR(36) PRIMARY KEY,
+    hai_business_id BIGINT NOT NULL,
+    hai_employee_id BIGINT NULL,
+    hai_email VARCHAR(60) NOT NULL,
+    hai_role VARCHAR(16) NOT NULL,
+    hai_token_hash VARCHAR(64) NOT NULL,
+    hai_invited_by VARCHAR(36) NOT NULL,
+    hai_expires_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hai_accepted_at TIMESTAMP(0) WITH TIME ZONE NULL,
+    hai_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_invitation_business FOREIGN KEY(hai_business_id) REFERENCES ha_business(hab_id),
+    CONSTRAINT fk_invitation_user FOREIGN KEY(hai_invited_by) REFERENCES ha_user(hau_id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitation_token_hash ON ha_invitation(hai_token_hash);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ha_business_chat;
+
+DROP TABLE IF EXISTS ha_chat_link_code;
+
+ALTER TABLE ha_business DROP COLUMN IF EXISTS hab_requires_approval;
@@ -0,0 +1,32 @@
+/*
+================================================================================
+BUSINESS TELEGRAM CHATS
+================================================================================
+*/
+
+-- Bookings of a business that requires manual approval stay pending until someone confirms them.
+ALTER TABLE ha_business ADD COLUMN IF NOT EXISTS hab_requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS ha_chat_link_code (
+    hacl_code VARCHAR(16) PRIMARY KEY,
+    hacl_business_id BIGINT NOT NULL,
+    hacl_user_id VARCHAR(36) NOT NULL,
+    hacl_expires_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hacl_used_at TIMESTAMP(0) WITH TIME ZONE NULL,
+    hacl_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_chat_link_code_business FOREIGN KEY(hacl_business_id) REFERENCES ha_business(hab_id),
+    CONSTRAINT fk_chat_link_code_user FOREIGN KEY(hacl_user_id) REFERENCES ha_user(hau_id) ON DELETE CASCADE
+);
+
+-- A chat receives the notifications of a single business, linking it again moves it.
+CREATE TABLE IF NOT EXISTS ha_business_chat (
+    habc_chat_id BIGINT PRIMARY KEY,
+    habc_business_id BIGINT NOT NULL,
+    habc_title VARCHAR(255) NOT NULL,
+    habc_linked_by VARCHAR(36) NULL,
+    habc_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_business_chat_business FOREIGN KEY(habc_business_id) REFERENCES ha_business(hab_id),
+    CONSTRAINT fk_business_chat_user FOREIGN KEY(habc_linked_by) REFERENCES ha_user(hau_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_business_chat_business ON ha_business_chat(habc_business_id);
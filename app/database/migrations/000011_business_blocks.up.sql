@@ -0,0 +1,19 @@
+/*
+================================================================================
+BUSINESS TIME BLOCKS
+================================================================================
+*/
+
+-- Time ranges the business takes out of its agenda without closing the whole day.
+CREATE TABLE IF NOT EXISTS ha_business_blocks (
+    habb_id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    habb_business_id BIGINT NOT NULL,
+    habb_start TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    habb_end TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    habb_reason VARCHAR(255) NULL,
+    habb_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT fk_business_blocks_business FOREIGN KEY(habb_business_id) REFERENCES ha_business(hab_id),
+    CONSTRAINT chk_business_blocks_range CHECK (habb_start < habb_end)
+);
+
+CREATE INDEX IF NOT EXISTS idx_business_blocks_business ON ha_business_blocks(habb_business_id, habb_start);
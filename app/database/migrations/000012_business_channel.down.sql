@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_business_channel;
+
+ALTER TABLE ha_business DROP COLUMN IF EXISTS hab_channel_link;
+ALTER TABLE ha_business DROP COLUMN IF EXISTS hab_channel_name;
+ALTER TABLE ha_business DROP COLUMN IF EXISTS hab_channel_id;
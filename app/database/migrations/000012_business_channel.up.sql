@@ -0,0 +1,12 @@
+/*
+================================================================================
+BUSINESS TELEGRAM CHANNEL
+================================================================================
+*/
+
+-- The channel where the business publishes its booking link, set when the bot is made admin of it.
+ALTER TABLE ha_business ADD COLUMN IF NOT EXISTS hab_channel_id BIGINT NULL;
+ALTER TABLE ha_business ADD COLUMN IF NOT EXISTS hab_channel_name VARCHAR(255) NULL;
+ALTER TABLE ha_business ADD COLUMN IF NOT EXISTS hab_channel_link VARCHAR(255) NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_business_channel ON ha_business(hab_channel_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_booking_source;
+
+ALTER TABLE booking DROP COLUMN IF EXISTS source;
+
+ALTER TABLE booking_session DROP COLUMN IF EXISTS source;
+ALTER TABLE booking_session DROP COLUMN IF EXISTS employee_id;
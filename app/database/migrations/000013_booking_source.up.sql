@@ -0,0 +1,13 @@
+/*
+================================================================================
+BOOKING SOURCE
+================================================================================
+*/
+
+-- What a deep link preselected for the conversation and the marketing source it came from.
+ALTER TABLE booking_session ADD COLUMN IF NOT EXISTS employee_id BIGINT NULL;
+ALTER TABLE booking_session ADD COLUMN IF NOT EXISTS source VARCHAR(32) NULL;
+
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS source VARCHAR(32) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_booking_source ON booking(business_id, source);
@@ -0,0 +1 @@
+ALTER TABLE ha_service_catalog DROP COLUMN IF EXISTS hasc_category;
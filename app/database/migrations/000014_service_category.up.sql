@@ -0,0 +1,8 @@
+/*
+================================================================================
+SERVICE CATEGORY
+================================================================================
+*/
+
+-- Groups the services of the catalog so the bot can show a menu per category to businesses with many of them.
+ALTER TABLE ha_service_catalog ADD COLUMN IF NOT EXISTS hasc_category VARCHAR(100) NULL;
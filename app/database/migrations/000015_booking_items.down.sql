@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ha_booking_item;
+
+ALTER TABLE booking_session DROP COLUMN IF EXISTS service_ids;
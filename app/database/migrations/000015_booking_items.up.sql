@@ -0,0 +1,21 @@
+/*
+================================================================================
+BOOKING ITEMS
+================================================================================
+*/
+
+-- The services picked in a conversation, comma separated. service_id keeps the first of them.
+ALTER TABLE booking_session ADD COLUMN IF NOT EXISTS service_ids VARCHAR(255) NULL;
+
+-- The services of a booking as they were in the catalog when it was made.
+CREATE TABLE IF NOT EXISTS ha_booking_item (
+    habi_booking_id VARCHAR(36) NOT NULL,
+    habi_position SMALLINT NOT NULL,
+    habi_service_id BIGINT NOT NULL,
+    habi_name VARCHAR(255) NOT NULL,
+    habi_price INTEGER NOT NULL,
+    habi_currency VARCHAR(10) NOT NULL,
+    habi_duration INTEGER NOT NULL,
+    CONSTRAINT pk_booking_item PRIMARY KEY(habi_booking_id, habi_position),
+    CONSTRAINT fk_booking_item_booking FOREIGN KEY(habi_booking_id) REFERENCES booking(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+ALTER TABLE booking DROP COLUMN IF EXISTS locale;
+
+ALTER TABLE booking_session DROP COLUMN IF EXISTS locale;
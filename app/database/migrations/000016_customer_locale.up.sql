@@ -0,0 +1,11 @@
+/*
+================================================================================
+CUSTOMER LOCALE
+================================================================================
+*/
+
+-- The language the customer talks to the bot in, so the messages sent later about the booking
+-- use it too. Rows without it fall back to the language of the business.
+ALTER TABLE booking_session ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NULL;
+
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NULL;
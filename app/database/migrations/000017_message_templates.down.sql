@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ha_business_message_templates;
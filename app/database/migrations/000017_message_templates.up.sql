@@ -0,0 +1,17 @@
+/*
+================================================================================
+BUSINESS MESSAGE TEMPLATES
+================================================================================
+*/
+
+-- The texts a business writes in its own tone instead of the catalog ones, one per message.
+-- habmt_body is a Go text/template validated when it is saved.
+CREATE TABLE IF NOT EXISTS ha_business_message_templates (
+    habmt_business_id BIGINT NOT NULL,
+    habmt_key VARCHAR(50) NOT NULL,
+    habmt_body TEXT NOT NULL,
+    habmt_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    habmt_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    CONSTRAINT pk_business_message_templates PRIMARY KEY(habmt_business_id, habmt_key),
+    CONSTRAINT fk_business_message_templates_business FOREIGN KEY(habmt_business_id) REFERENCES ha_business(hab_id)
+);
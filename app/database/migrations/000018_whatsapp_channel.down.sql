@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ha_whatsapp_contacts;
+
+ALTER TABLE booking DROP COLUMN IF EXISTS channel;
+
+ALTER TABLE booking_session DROP COLUMN IF EXISTS channel;
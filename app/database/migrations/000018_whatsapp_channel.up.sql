@@ -0,0 +1,20 @@
+/*
+================================================================================
+WHATSAPP CHANNEL
+================================================================================
+*/
+
+-- The chat the customer booked from, chat_id is the WhatsApp number of the customer for the
+-- whatsapp channel. Rows from before WhatsApp were all made on Telegram.
+ALTER TABLE booking_session ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'telegram';
+
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'telegram';
+
+-- The WhatsApp users that wrote to the business number. WhatsApp only lets free form messages
+-- reach them within 24 hours of the last message they sent, template messages otherwise.
+CREATE TABLE IF NOT EXISTS ha_whatsapp_contacts (
+    hawc_wa_id VARCHAR(20) PRIMARY KEY,
+    hawc_name VARCHAR(255) NULL,
+    hawc_last_inbound TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hawc_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL
+);
@@ -0,0 +1,9 @@
+/*
+================================================================================
+WEB WIDGET
+================================================================================
+*/
+
+-- The phone the customer leaves in the booking page of the business. The chats already know who
+-- the customer is, the bookings of the web have no chat to answer to.
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS customer_phone VARCHAR(30) NULL;
@@ -0,0 +1,9 @@
+/*
+================================================================================
+EMAIL NOTIFICATIONS
+================================================================================
+*/
+
+-- The email the customer leaves to get the confirmation of the booking with the calendar invite,
+-- optional as the chats can already reach the customer.
+ALTER TABLE booking ADD COLUMN IF NOT EXISTS customer_email VARCHAR(255) NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ha_reminder;
+DROP TABLE IF EXISTS ha_notification_settings;
+DROP TABLE IF EXISTS ha_notification_preference;
+DROP TABLE IF EXISTS ha_notification_delivery;
+DROP TABLE IF EXISTS ha_notification;
@@ -0,0 +1,63 @@
+/*
+================================================================================
+NOTIFICATION DISPATCHER
+================================================================================
+*/
+
+-- What the customers are told about their bookings. The id is that of what triggered the
+-- notification, the outbox message or the reminder, so its retries are the same notification.
+CREATE TABLE IF NOT EXISTS ha_notification (
+    han_id VARCHAR(36) PRIMARY KEY,
+    han_type VARCHAR(30) NOT NULL,
+    han_business_id INTEGER NOT NULL,
+    han_booking_id VARCHAR(36) NOT NULL,
+    han_recipient JSONB NOT NULL,
+    han_payload JSONB NOT NULL,
+    han_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_booking ON ha_notification(han_booking_id);
+
+-- Every attempt to send a notification through a channel, sent or failed.
+CREATE TABLE IF NOT EXISTS ha_notification_delivery (
+    hand_id VARCHAR(36) PRIMARY KEY,
+    hand_notification_id VARCHAR(36) NOT NULL REFERENCES ha_notification(han_id) ON DELETE CASCADE,
+    hand_channel VARCHAR(20) NOT NULL,
+    hand_address VARCHAR(255) NOT NULL,
+    hand_attempt INTEGER NOT NULL,
+    hand_status VARCHAR(20) NOT NULL,
+    hand_error TEXT NULL,
+    hand_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_delivery ON ha_notification_delivery(hand_notification_id, hand_channel);
+
+-- The channels a customer wants to be told through, the customer being their chat or phone.
+CREATE TABLE IF NOT EXISTS ha_notification_preference (
+    hanp_business_id INTEGER NOT NULL,
+    hanp_customer_key VARCHAR(60) NOT NULL,
+    hanp_channels TEXT[] NOT NULL,
+    hanp_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    PRIMARY KEY (hanp_business_id, hanp_customer_key)
+);
+
+-- The channels a business lets its notifications go through.
+CREATE TABLE IF NOT EXISTS ha_notification_settings (
+    hans_business_id INTEGER PRIMARY KEY,
+    hans_channels TEXT[] NOT NULL,
+    hans_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL
+);
+
+-- The reminder of a confirmed booking, one per booking. Rescheduling the booking schedules it
+-- again under a new id, a new notification.
+CREATE TABLE IF NOT EXISTS ha_reminder (
+    har_id VARCHAR(36) PRIMARY KEY,
+    har_booking_id VARCHAR(36) NOT NULL UNIQUE,
+    har_scheduled_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    har_sent BOOLEAN NOT NULL DEFAULT FALSE,
+    har_sent_at TIMESTAMP(0) WITH TIME ZONE NULL,
+    har_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    har_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminder_due ON ha_reminder(har_sent, har_scheduled_at);
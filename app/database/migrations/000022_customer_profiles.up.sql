@@ -0,0 +1,27 @@
+/*
+================================================================================
+CUSTOMER PROFILES
+================================================================================
+*/
+
+-- Who books with a business from a chat, created with their first booking. The chat is the
+-- Telegram chat or the WhatsApp number, the phone the one the customer shared when asked.
+CREATE TABLE IF NOT EXISTS ha_customer (
+    hac_id VARCHAR(36) PRIMARY KEY,
+    hac_business_id INTEGER NOT NULL,
+    hac_channel VARCHAR(20) NOT NULL,
+    hac_chat_id BIGINT NOT NULL,
+    hac_name VARCHAR(255) NOT NULL,
+    hac_phone VARCHAR(36) NULL,
+    hac_notes TEXT NOT NULL DEFAULT '',
+    hac_tags TEXT[] NOT NULL DEFAULT '{}',
+    hac_first_visit TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hac_last_visit TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hac_booking_count INTEGER NOT NULL DEFAULT 0,
+    hac_last_booking_id VARCHAR(36) NULL,
+    hac_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    hac_date_upd TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL,
+    UNIQUE (hac_business_id, hac_channel, hac_chat_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_chat ON ha_customer(hac_channel, hac_chat_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ha_google_oauth_state;
@@ -0,0 +1,16 @@
+/*
+================================================================================
+GOOGLE OAUTH STATE
+================================================================================
+*/
+
+-- One Google authorization in flight. The state is the random value sent to Google and checked
+-- on the callback, the verifier the PKCE secret that never leaves the server.
+CREATE TABLE IF NOT EXISTS ha_google_oauth_state (
+    hagos_state VARCHAR(64) PRIMARY KEY,
+    hagos_business_id INTEGER NOT NULL,
+    hagos_session_id VARCHAR(36) NOT NULL,
+    hagos_verifier VARCHAR(128) NOT NULL,
+    hagos_expiration TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    hagos_date_add TIMESTAMP(0) WITHOUT TIME ZONE NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE ha_calendar_connection ALTER COLUMN hacc_password TYPE VARCHAR(255);
@@ -0,0 +1,8 @@
+/*
+================================================================================
+CALENDAR PASSWORD ENCRYPTION
+================================================================================
+*/
+
+-- The CalDAV passwords are stored sealed with the app key, longer than the password itself.
+ALTER TABLE ha_calendar_connection ALTER COLUMN hacc_password TYPE TEXT;
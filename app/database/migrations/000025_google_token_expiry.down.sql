@@ -0,0 +1 @@
+ALTER TABLE google_token DROP COLUMN IF EXISTS expiry;
@@ -0,0 +1,8 @@
+/*
+================================================================================
+GOOGLE TOKEN EXPIRY
+================================================================================
+*/
+
+-- When the access token stops working, so it is refreshed before it is used and not after.
+ALTER TABLE google_token ADD COLUMN IF NOT EXISTS expiry VARCHAR(60) NULL;
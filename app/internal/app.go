@@ -9,7 +9,23 @@ import (
 	"os"
 
 	"github.com/adriein/hastypal/database"
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/caldav"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/calendarsync"
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/customer"
+	"github.com/adriein/hastypal/internal/feed"
+	"github.com/adriein/hastypal/internal/google"
+	"github.com/adriein/hastypal/internal/notification"
+	"github.com/adriein/hastypal/internal/outbox"
+	"github.com/adriein/hastypal/internal/reminder"
 	"github.com/adriein/hastypal/internal/telegram"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/adriein/hastypal/internal/web"
+	"github.com/adriein/hastypal/internal/whatsapp"
 	"github.com/adriein/hastypal/pkg/constants"
 	"github.com/adriein/hastypal/pkg/helper"
 	"github.com/adriein/hastypal/pkg/logger"
@@ -19,9 +35,22 @@ import (
 type ShutdownFunc func(context.Context) error
 
 type Modules struct {
-	Database *sql.DB
-	Logger   *slog.Logger
-	Telegram telegram.TelegramService
+	Database     *sql.DB
+	Logger       *slog.Logger
+	Telegram     telegram.TelegramService
+	Whatsapp     whatsapp.WhatsappService
+	Widget       *conversation.Engine
+	Google       google.GoogleService
+	Calendar     calendar.CalendarService
+	Feed         feed.FeedService
+	CalendarSync calendarsync.CalendarSyncService
+	Outbox       *outbox.Dispatcher
+	Notification notification.NotificationService
+	Customer     customer.CustomerService
+	Reminder     reminder.ReminderService
+	Business     business.BusinessService
+	Booking      booking.BookingService
+	Auth         auth.AuthService
 }
 
 type App struct {
@@ -44,11 +73,25 @@ func NewApp() *App {
 		constants.Env,
 		constants.Version,
 		constants.WhatsappBusinessApiToken,
+		constants.WhatsappApiUrl,
+		constants.WhatsappPhoneNumberId,
+		constants.WhatsappNumber,
+		constants.WhatsappAppSecret,
+		constants.WhatsappVerifyToken,
 		constants.TelegramApiToken,
 		constants.TelegramApiBotUrl,
+		constants.TelegramBotName,
+		constants.TelegramWebhookSecret,
 		constants.GoogleClientId,
 		constants.GoogleClientSecret,
+		constants.GoogleCalendarWebhookUrl,
+		constants.GoogleRedirectUrl,
 		constants.JwtKey,
+		constants.EncryptionKey,
+		constants.AppUrl,
+		constants.SmtpHost,
+		constants.SmtpPort,
+		constants.SmtpFrom,
 	)
 
 	if envCheckerErr := checker.Check(); envCheckerErr != nil {
@@ -69,10 +112,150 @@ func NewApp() *App {
 }
 
 func initModules(db *sql.DB, logger *slog.Logger) *Modules {
+	bot := telegram.NewTelegramBot(os.Getenv(constants.TelegramApiBotUrl), os.Getenv(constants.TelegramApiToken))
+	lang := translation.NewService()
+
+	businessRepository := business.NewPgBusinessRepository(db)
+	businessService := business.NewService(logger, businessRepository)
+	outboxRepository := outbox.NewPgOutboxRepository(db)
+
+	bookingService := booking.NewService(
+		logger,
+		booking.NewPgSessionRepository(db),
+		booking.NewPgBookingRepository(db, outboxRepository),
+	)
+	cipher, err := helper.NewCipher(os.Getenv(constants.EncryptionKey))
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	connectionRepository := calendar.NewPgConnectionRepository(db, cipher)
+	googleService := google.NewService(
+		logger,
+		google.NewGoogleApi(),
+		google.NewPgGoogleRepository(db),
+		connectionRepository,
+	)
+	calendarService := calendar.NewService(logger, connectionRepository, map[string]calendar.Provider{
+		calendar.ProviderGoogle: googleService,
+		calendar.ProviderCaldav: caldav.NewClient(logger, connectionRepository),
+	})
+
+	// Local sinks like MailHog do not offer STARTTLS, anything else has to unless told otherwise.
+	emailSender, err := notification.NewSmtpSender(notification.SmtpConfig{
+		Host:       os.Getenv(constants.SmtpHost),
+		Port:       os.Getenv(constants.SmtpPort),
+		Username:   os.Getenv(constants.SmtpUsername),
+		Password:   os.Getenv(constants.SmtpPassword),
+		From:       os.Getenv(constants.SmtpFrom),
+		RequireTls: os.Getenv(constants.SmtpRequireTls) != "false",
+	})
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	authService := auth.NewService(
+		logger,
+		businessService,
+		auth.NewPgUserRepository(db, businessRepository),
+		auth.NewPgInvitationRepository(db),
+		auth.NewPgRefreshTokenRepository(db),
+		notification.NewEmailInvitationSender(emailSender, businessService, lang),
+		[]byte(os.Getenv(constants.JwtKey)),
+		os.Getenv(constants.AppUrl),
+	)
+	customerService := customer.NewService(logger, customer.NewPgCustomerRepository(db))
+
+	// Every channel runs the same booking dialogue, each in its own format.
+	conversationEngine := func(channel conversation.Channel) *conversation.Engine {
+		return conversation.NewEngine(logger, businessService, bookingService, calendarService, customerService, lang, channel)
+	}
+
+	telegramService := telegram.NewService(
+		logger,
+		businessService,
+		bookingService,
+		authService,
+		customerService,
+		lang,
+		telegram.NewPgChatRepository(db),
+		bot,
+		conversationEngine(telegram.ConversationChannel),
+		os.Getenv(constants.TelegramBotName),
+	)
+
+	whatsappService := whatsapp.NewService(
+		logger,
+		businessService,
+		bookingService,
+		lang,
+		whatsapp.NewPgContactRepository(db),
+		whatsapp.NewCloudApi(
+			os.Getenv(constants.WhatsappApiUrl),
+			os.Getenv(constants.WhatsappBusinessApiToken),
+			os.Getenv(constants.WhatsappPhoneNumberId),
+		),
+		conversationEngine(whatsapp.ConversationChannel),
+		os.Getenv(constants.WhatsappNumber),
+	)
+
+	senders := map[string]notification.ChannelSender{
+		notification.ChannelTelegram: telegramService,
+		notification.ChannelWhatsapp: whatsappService,
+		notification.ChannelEmail:    notification.NewEmailChannel(emailSender),
+	}
+
+	// SMS is optional, without a gateway the customers are not routed to it.
+	if gatewayUrl := os.Getenv(constants.SmsGatewayUrl); gatewayUrl != "" {
+		senders[notification.ChannelSms] = notification.NewSmsChannel(
+			gatewayUrl,
+			os.Getenv(constants.SmsGatewayToken),
+			os.Getenv(constants.SmsFrom),
+		)
+	}
+
+	notificationService := notification.NewService(
+		logger,
+		notification.NewPgNotificationRepository(db),
+		bookingService,
+		businessService,
+		authService,
+		lang,
+		emailSender,
+		senders,
+		os.Getenv(constants.AppUrl),
+	)
+
+	reminderService := reminder.NewService(logger, reminder.NewPgReminderRepository(db), notificationService)
+
+	dispatcher := outbox.NewDispatcher(logger, outboxRepository)
+	dispatcher.Register(outbox.CalendarEventCreate, calendarEventHandler(bookingService, businessService, calendarService, lang))
+	dispatcher.Register(outbox.CalendarEventUpdate, calendarEventUpdateHandler(bookingService, businessService, calendarService, lang))
+	dispatcher.Register(outbox.CalendarEventDelete, calendarEventDeleteHandler(bookingService, calendarService))
+	dispatcher.Register(outbox.ReminderCreate, reminderHandler(reminderService))
+	dispatcher.Register(outbox.BusinessNotification, businessNotificationHandler(telegramService))
+	dispatcher.Register(outbox.EmailNotification, emailNotificationHandler(notificationService))
+	dispatcher.Register(outbox.CustomerNotification, customerNotificationHandler(notificationService))
+
 	return &Modules{
-		Database: db,
-		Logger:   logger,
-		Telegram: nil,
+		Database:     db,
+		Logger:       logger,
+		Telegram:     telegramService,
+		Whatsapp:     whatsappService,
+		Widget:       conversationEngine(web.WidgetChannel),
+		Google:       googleService,
+		Calendar:     calendarService,
+		Feed:         feed.NewService(logger, feed.NewPgFeedRepository(db), lang),
+		CalendarSync: calendarsync.NewService(logger, googleService, bookingService),
+		Outbox:       dispatcher,
+		Notification: notificationService,
+		Customer:     customerService,
+		Reminder:     reminderService,
+		Business:     businessService,
+		Booking:      bookingService,
+		Auth:         authService,
 	}
 }
 
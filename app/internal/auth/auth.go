@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/adriein/hastypal/pkg/helper"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	InvalidCredentials = eris.New("Invalid credentials")
+	InvalidToken       = eris.New("Invalid token")
+	RefreshTokenReused = eris.New("Refresh token already used")
+)
+
+const (
+	Issuer          = "hastypal"
+	TokenType       = "Bearer"
+	AccessTokenTtl  = 15 * time.Minute
+	RefreshTokenTtl = 30 * 24 * time.Hour
+	refreshBytes    = 32
+)
+
+// Claims are carried by the access tokens. Access tokens are not stored, logging out revokes
+// the refresh tokens and the access token dies with its short TTL, as does a role change.
+type Claims struct {
+	BusinessID int    `json:"bid"`
+	UserID     string `json:"uid"`
+	Role       Role   `json:"role"`
+	EmployeeID int    `json:"eid,omitempty"`
+	SessionID  string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// RefreshToken is one link of a rotation chain. Every refresh revokes the token used and issues
+// the next one of the same family, so reusing a revoked token gives away a stolen one and the
+// whole family is revoked.
+type RefreshToken struct {
+	ID         string
+	BusinessID int
+	UserID     string
+	FamilyID   string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string
+	DateAdd    time.Time
+}
+
+// NewRefreshToken returns the token to store along with the raw value handed to the client.
+func NewRefreshToken(user *User, familyID string) (*RefreshToken, string, error) {
+	random := make([]byte, refreshBytes)
+
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", eris.Wrap(err, "Error generating the refresh token")
+	}
+
+	raw := base64.RawURLEncoding.EncodeToString(random)
+	now := time.Now().UTC()
+
+	if familyID == "" {
+		familyID = helper.Uuid().String()
+	}
+
+	return &RefreshToken{
+		ID:         helper.Uuid().String(),
+		BusinessID: user.BusinessID,
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		TokenHash:  HashToken(raw),
+		ExpiresAt:  now.Add(RefreshTokenTtl),
+		DateAdd:    now,
+	}, raw, nil
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// HashToken is what gets stored of a refresh token, a leaked table does not leak usable tokens.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+
+	return hex.EncodeToString(sum[:])
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"github.com/adriein/hastypal/pkg/constants"
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimsFrom returns the claims the authentication middleware stored for the request.
+func ClaimsFrom(ctx *gin.Context) (*Claims, bool) {
+	value, exists := ctx.Get(constants.ClaimsContextKey)
+
+	if !exists {
+		return nil, false
+	}
+
+	claims, ok := value.(*Claims)
+
+	return claims, ok
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/rotisserie/eris"
+)
+
+type InvitationRepository interface {
+	Save(ctx context.Context, invitation *Invitation) error
+	GetByHash(ctx context.Context, tokenHash string) (*Invitation, error)
+	GetPending(ctx context.Context, businessID int) ([]*Invitation, error)
+	Accept(ctx context.Context, invitation *Invitation, user *User) error
+}
+
+type PgInvitationRepository struct {
+	connection *sql.DB
+}
+
+func NewPgInvitationRepository(connection *sql.DB) *PgInvitationRepository {
+	return &PgInvitationRepository{
+		connection: connection,
+	}
+}
+
+const selectInvitationQuery = `
+	SELECT
+		hai_id,
+		hai_business_id,
+		COALESCE(hai_employee_id, 0),
+		hai_email,
+		hai_role,
+		hai_token_hash,
+		hai_invited_by,
+		hai_expires_at,
+		hai_accepted_at,
+		hai_date_add
+	FROM
+		ha_invitation
+`
+
+func (r *PgInvitationRepository) Save(ctx context.Context, invitation *Invitation) error {
+	query := `
+		INSERT INTO ha_invitation (
+			hai_id,
+			hai_business_id,
+			hai_employee_id,
+			hai_email,
+			hai_role,
+			hai_token_hash,
+			hai_invited_by,
+			hai_expires_at,
+			hai_date_add
+		)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		invitation.ID,
+		invitation.BusinessID,
+		invitation.EmployeeID,
+		invitation.Email,
+		invitation.Role,
+		invitation.TokenHash,
+		invitation.InvitedBy,
+		invitation.ExpiresAt,
+		invitation.DateAdd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving invitation")
+	}
+
+	return nil
+}
+
+func (r *PgInvitationRepository) GetByHash(ctx context.Context, tokenHash string) (*Invitation, error) {
+	query := selectInvitationQuery + `WHERE hai_token_hash = $1;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	invitation, err := scanInvitation(r.connection.QueryRowContext(ctxTimeout, query, tokenHash))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, InvitationNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query invitation")
+	}
+
+	return invitation, nil
+}
+
+func (r *PgInvitationRepository) GetPending(ctx context.Context, businessID int) (invitations []*Invitation, err error) {
+	query := selectInvitationQuery + `
+		WHERE
+			hai_business_id = $1
+			AND hai_accepted_at IS NULL
+			AND hai_expires_at > NOW()
+		ORDER BY hai_date_add;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "Error fetching the invitations of business %d", businessID)
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	invitations = make([]*Invitation, 0)
+
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error scanning invitation")
+		}
+
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+// Accept marks the invitation as used and creates its user in one transaction. Only a pending
+// invitation can be accepted, so the same link cannot create two users.
+func (r *PgInvitationRepository) Accept(ctx context.Context, invitation *Invitation, user *User) error {
+	acceptQuery := `
+		UPDATE ha_invitation
+		SET hai_accepted_at = $2
+		WHERE hai_id = $1 AND hai_accepted_at IS NULL;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctxTimeout, acceptQuery, invitation.ID, user.DateAdd)
+
+		if err != nil {
+			return eris.Wrap(err, "Error accepting invitation")
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return eris.Wrap(err, "Error accepting invitation")
+		}
+
+		if affected == 0 {
+			return InvitationNotFound
+		}
+
+		return insertUser(ctxTimeout, tx, user)
+	})
+}
+
+func scanInvitation(row rowScanner) (*Invitation, error) {
+	var (
+		invitation Invitation
+		acceptedAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.BusinessID,
+		&invitation.EmployeeID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.TokenHash,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+		&acceptedAt,
+		&invitation.DateAdd,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if acceptedAt.Valid {
+		invitation.AcceptedAt = &acceptedAt.Time
+	}
+
+	return &invitation, nil
+}
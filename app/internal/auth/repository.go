@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/rotisserie/eris"
+)
+
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token *RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	Rotate(ctx context.Context, current *RefreshToken, next *RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+type PgRefreshTokenRepository struct {
+	connection *sql.DB
+}
+
+func NewPgRefreshTokenRepository(connection *sql.DB) *PgRefreshTokenRepository {
+	return &PgRefreshTokenRepository{
+		connection: connection,
+	}
+}
+
+const insertRefreshTokenQuery = `
+	INSERT INTO ha_refresh_token (
+		hart_id,
+		hart_business_id,
+		hart_user_id,
+		hart_family_id,
+		hart_token_hash,
+		hart_expires_at,
+		hart_date_add
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);
+`
+
+func (r *PgRefreshTokenRepository) Save(ctx context.Context, token *RefreshToken) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		insertRefreshTokenQuery,
+		token.ID,
+		token.BusinessID,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.DateAdd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving refresh token")
+	}
+
+	return nil
+}
+
+func (r *PgRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	query := `
+		SELECT
+			hart_id,
+			hart_business_id,
+			COALESCE(hart_user_id, ''),
+			hart_family_id,
+			hart_token_hash,
+			hart_expires_at,
+			hart_revoked_at,
+			COALESCE(hart_replaced_by, ''),
+			hart_date_add
+		FROM
+			ha_refresh_token
+		WHERE
+			hart_token_hash = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var (
+		token     RefreshToken
+		revokedAt sql.NullTime
+	)
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, tokenHash).Scan(
+		&token.ID,
+		&token.BusinessID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&revokedAt,
+		&token.ReplacedBy,
+		&token.DateAdd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, InvalidToken
+		}
+
+		return nil, eris.Wrap(err, "Failed to query refresh token")
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return &token, nil
+}
+
+// Rotate revokes the current token and stores its successor in one transaction. The revocation
+// only applies to a token that is still active, so two concurrent refreshes with the same token
+// cannot both succeed.
+func (r *PgRefreshTokenRepository) Rotate(ctx context.Context, current *RefreshToken, next *RefreshToken) error {
+	revokeQuery := `
+		UPDATE ha_refresh_token
+		SET
+			hart_revoked_at = $2,
+			hart_replaced_by = $3
+		WHERE
+			hart_id = $1 AND hart_revoked_at IS NULL;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctxTimeout, revokeQuery, current.ID, next.DateAdd, next.ID)
+
+		if err != nil {
+			return eris.Wrap(err, "Error revoking refresh token")
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return eris.Wrap(err, "Error revoking refresh token")
+		}
+
+		if affected == 0 {
+			return RefreshTokenReused
+		}
+
+		_, err = tx.ExecContext(
+			ctxTimeout,
+			insertRefreshTokenQuery,
+			next.ID,
+			next.BusinessID,
+			next.UserID,
+			next.FamilyID,
+			next.TokenHash,
+			next.ExpiresAt,
+			next.DateAdd,
+		)
+
+		if err != nil {
+			return eris.Wrap(err, "Error saving refresh token")
+		}
+
+		return nil
+	})
+}
+
+func (r *PgRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	query := `
+		UPDATE ha_refresh_token
+		SET hart_revoked_at = NOW()
+		WHERE hart_family_id = $1 AND hart_revoked_at IS NULL;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := r.connection.ExecContext(ctxTimeout, query, familyID); err != nil {
+		return eris.Wrap(err, "Error revoking refresh token family")
+	}
+
+	return nil
+}
+
+func (r *PgRefreshTokenRepository) RevokeAll(ctx context.Context, userID string) error {
+	query := `
+		UPDATE ha_refresh_token
+		SET hart_revoked_at = NOW()
+		WHERE hart_user_id = $1 AND hart_revoked_at IS NULL;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := r.connection.ExecContext(ctxTimeout, query, userID); err != nil {
+		return eris.Wrap(err, "Error revoking refresh tokens")
+	}
+
+	return nil
+}
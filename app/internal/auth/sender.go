@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+)
+
+// InvitationSender delivers the link of an invitation to the invited email.
+type InvitationSender interface {
+	SendInvitation(ctx context.Context, invitation *Invitation, businessName string, link string) error
+}
+
+// LogInvitationSender only records that an invitation was issued, for the setups without email.
+// The link carries the raw token, which is as good as the invitation itself, so it is never logged.
+type LogInvitationSender struct {
+	logger *slog.Logger
+}
+
+func NewLogInvitationSender(logger *slog.Logger) *LogInvitationSender {
+	return &LogInvitationSender{
+		logger: logger,
+	}
+}
+
+func (s *LogInvitationSender) SendInvitation(ctx context.Context, invitation *Invitation, businessName string, link string) error {
+	s.logger.Info(
+		"Invitation issued without an email to send it",
+		"invitation_id", invitation.ID,
+		"email", invitation.Email,
+	)
+
+	return nil
+}
@@ -0,0 +1,483 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rotisserie/eris"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// timingHash is compared against when the email is unknown, so the response time does not tell
+// which emails are registered.
+const timingHash = "$2a$10$Gsf1P5pfOhGX9p5YiZkQAeRvrJwFw9hCXdgmvUIW/lz/U/q14Aco."
+
+type AuthService interface {
+	Register(ctx context.Context, owner *business.Business, name string, password string) (*User, error)
+	Login(ctx context.Context, email string, password string) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, refreshToken string, everywhere bool) error
+	ParseAccessToken(accessToken string) (*Claims, error)
+	GetUser(ctx context.Context, businessID int, userID string) (*User, error)
+	GetUsers(ctx context.Context, businessID int) ([]*User, error)
+	GetInvitations(ctx context.Context, businessID int) ([]*Invitation, error)
+	Invite(ctx context.Context, inviter *Claims, email string, role Role, employeeID int) (*Invitation, error)
+	AcceptInvitation(ctx context.Context, token string, name string, password string) (*TokenPair, error)
+	ChangeRole(ctx context.Context, actor *Claims, userID string, role Role, employeeID int) (*User, error)
+	RemoveUser(ctx context.Context, actor *Claims, userID string) error
+}
+
+type Service struct {
+	logger      *slog.Logger
+	business    business.BusinessService
+	users       UserRepository
+	invitations InvitationRepository
+	tokens      RefreshTokenRepository
+	sender      InvitationSender
+	key         []byte
+	appUrl      string
+}
+
+func NewService(
+	logger *slog.Logger,
+	business business.BusinessService,
+	users UserRepository,
+	invitations InvitationRepository,
+	tokens RefreshTokenRepository,
+	sender InvitationSender,
+	key []byte,
+	appUrl string,
+) *Service {
+	return &Service{
+		logger:      logger,
+		business:    business,
+		users:       users,
+		invitations: invitations,
+		tokens:      tokens,
+		sender:      sender,
+		key:         key,
+		appUrl:      appUrl,
+	}
+}
+
+/*
+================================================================================
+SESSIONS
+================================================================================
+*/
+
+// Register creates the business and its first owner in one transaction. The email is checked up
+// front to answer a taken one before hashing the password.
+func (s *Service) Register(ctx context.Context, owner *business.Business, name string, password string) (*User, error) {
+	if _, err := s.users.GetByEmail(ctx, owner.Email); !errors.Is(err, UserNotFound) {
+		if err != nil {
+			return nil, eris.Wrap(err, "Error checking the email of the owner")
+		}
+
+		return nil, UserAlreadyExists
+	}
+
+	hash, err := hashPassword(password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	owner.DateAdd = time.Now().UTC()
+	owner.DateUpd = owner.DateAdd
+
+	user := NewUser(0, 0, name, owner.Email, hash, RoleOwner)
+
+	if err := s.users.SaveOwner(ctx, owner, user); err != nil {
+		return nil, eris.Wrap(err, "Error registering the business and its owner")
+	}
+
+	return user, nil
+}
+
+func (s *Service) Login(ctx context.Context, email string, password string) (*TokenPair, error) {
+	user, err := s.users.GetByEmail(ctx, email)
+
+	if err != nil {
+		if !errors.Is(err, UserNotFound) {
+			return nil, eris.Wrap(err, "Error fetching the user")
+		}
+
+		_ = bcrypt.CompareHashAndPassword([]byte(timingHash), []byte(password))
+
+		return nil, InvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, InvalidCredentials
+	}
+
+	s.logger.Info("User logged in", "business_id", user.BusinessID, "user_id", user.ID)
+
+	return s.openSession(ctx, user)
+}
+
+// Refresh trades a refresh token for a new pair. A token that was already traded is a sign it
+// leaked, so the whole family is revoked and both holders have to log in again. The user is
+// read again so the new access token carries its current role.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	current, err := s.tokens.GetByHash(ctx, HashToken(refreshToken))
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the refresh token")
+	}
+
+	if current.IsRevoked() {
+		return nil, s.revokeReused(ctx, current)
+	}
+
+	if current.IsExpired(time.Now()) {
+		return nil, InvalidToken
+	}
+
+	user, err := s.users.GetByID(ctx, current.BusinessID, current.UserID)
+
+	if err != nil {
+		if errors.Is(err, UserNotFound) {
+			return nil, InvalidToken
+		}
+
+		return nil, eris.Wrap(err, "Error fetching the user of the refresh token")
+	}
+
+	next, raw, err := NewRefreshToken(user, current.FamilyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokens.Rotate(ctx, current, next); err != nil {
+		if errors.Is(err, RefreshTokenReused) {
+			return nil, s.revokeReused(ctx, current)
+		}
+
+		return nil, eris.Wrap(err, "Error rotating the refresh token")
+	}
+
+	return s.tokenPair(user, next, raw)
+}
+
+func (s *Service) Logout(ctx context.Context, refreshToken string, everywhere bool) error {
+	current, err := s.tokens.GetByHash(ctx, HashToken(refreshToken))
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the refresh token")
+	}
+
+	if everywhere {
+		if err := s.tokens.RevokeAll(ctx, current.UserID); err != nil {
+			return eris.Wrap(err, "Error revoking the sessions of the user")
+		}
+
+		return nil
+	}
+
+	if err := s.tokens.RevokeFamily(ctx, current.FamilyID); err != nil {
+		return eris.Wrap(err, "Error revoking the session")
+	}
+
+	return nil
+}
+
+func (s *Service) ParseAccessToken(accessToken string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(
+		accessToken,
+		claims,
+		func(token *jwt.Token) (any, error) {
+			return s.key, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	)
+
+	if err != nil {
+		return nil, eris.Wrap(InvalidToken, err.Error())
+	}
+
+	return claims, nil
+}
+
+/*
+================================================================================
+USERS
+================================================================================
+*/
+
+func (s *Service) GetUser(ctx context.Context, businessID int, userID string) (*User, error) {
+	user, err := s.users.GetByID(ctx, businessID, userID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the user")
+	}
+
+	return user, nil
+}
+
+func (s *Service) GetUsers(ctx context.Context, businessID int) ([]*User, error) {
+	users, err := s.users.GetByBusinessID(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the users of the business")
+	}
+
+	return users, nil
+}
+
+func (s *Service) GetInvitations(ctx context.Context, businessID int) ([]*Invitation, error) {
+	invitations, err := s.invitations.GetPending(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the invitations of the business")
+	}
+
+	return invitations, nil
+}
+
+// Invite emails a link to join the business of the inviter with the given role. Managers can
+// only invite staff.
+func (s *Service) Invite(ctx context.Context, inviter *Claims, email string, role Role, employeeID int) (*Invitation, error) {
+	if !inviter.Role.CanAssign(role) {
+		return nil, RoleNotAllowed
+	}
+
+	if _, err := s.users.GetByEmail(ctx, email); !errors.Is(err, UserNotFound) {
+		if err != nil {
+			return nil, eris.Wrap(err, "Error checking the email of the invitation")
+		}
+
+		return nil, UserAlreadyExists
+	}
+
+	if employeeID != 0 {
+		if _, err := s.business.GetEmployee(ctx, inviter.BusinessID, employeeID); err != nil {
+			return nil, eris.Wrap(err, "Error fetching the employee of the invitation")
+		}
+	}
+
+	owner, err := s.business.GetBusinessByID(ctx, inviter.BusinessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the business of the invitation")
+	}
+
+	invitation, raw, err := NewInvitation(inviter.BusinessID, employeeID, email, role, inviter.UserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.invitations.Save(ctx, invitation); err != nil {
+		return nil, eris.Wrap(err, "Error storing the invitation")
+	}
+
+	if err := s.sender.SendInvitation(ctx, invitation, owner.Name, InvitationLink(s.appUrl, raw)); err != nil {
+		return nil, eris.Wrapf(err, "Error sending the invitation to %s", invitation.Email)
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation creates the user of the invitation and logs them in.
+func (s *Service) AcceptInvitation(ctx context.Context, token string, name string, password string) (*TokenPair, error) {
+	invitation, err := s.invitations.GetByHash(ctx, HashToken(token))
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the invitation")
+	}
+
+	if invitation.IsAccepted() {
+		return nil, InvitationNotFound
+	}
+
+	if invitation.IsExpired(time.Now()) {
+		return nil, InvitationExpired
+	}
+
+	hash, err := hashPassword(password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user := NewUser(invitation.BusinessID, invitation.EmployeeID, name, invitation.Email, hash, invitation.Role)
+
+	if err := s.invitations.Accept(ctx, invitation, user); err != nil {
+		return nil, eris.Wrap(err, "Error accepting the invitation")
+	}
+
+	s.logger.Info("Invitation accepted", "business_id", user.BusinessID, "user_id", user.ID, "role", user.Role)
+
+	return s.openSession(ctx, user)
+}
+
+// ChangeRole updates the role and the linked employee of a user. The actor must be able to assign
+// both the current and the new role, so managers cannot touch owners, and the business always
+// keeps one owner.
+func (s *Service) ChangeRole(ctx context.Context, actor *Claims, userID string, role Role, employeeID int) (*User, error) {
+	user, err := s.manageableUser(ctx, actor, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Role.CanAssign(role) {
+		return nil, RoleNotAllowed
+	}
+
+	if user.Role == RoleOwner && role != RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if employeeID != 0 {
+		if _, err := s.business.GetEmployee(ctx, user.BusinessID, employeeID); err != nil {
+			return nil, eris.Wrap(err, "Error fetching the employee of the user")
+		}
+	}
+
+	user.Role = role
+	user.EmployeeID = employeeID
+	user.DateUpd = time.Now().UTC()
+
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, eris.Wrap(err, "Error updating the role of the user")
+	}
+
+	return user, nil
+}
+
+// RemoveUser deletes the user and revokes their sessions, the access tokens already issued
+// expire on their own.
+func (s *Service) RemoveUser(ctx context.Context, actor *Claims, userID string) error {
+	user, err := s.manageableUser(ctx, actor, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if user.Role == RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	if err := s.tokens.RevokeAll(ctx, user.ID); err != nil {
+		return eris.Wrap(err, "Error revoking the sessions of the user")
+	}
+
+	if err := s.users.Delete(ctx, user); err != nil {
+		return eris.Wrap(err, "Error removing the user")
+	}
+
+	s.logger.Info("User removed", "business_id", user.BusinessID, "user_id", user.ID, "removed_by", actor.UserID)
+
+	return nil
+}
+
+func (s *Service) manageableUser(ctx context.Context, actor *Claims, userID string) (*User, error) {
+	user, err := s.users.GetByID(ctx, actor.BusinessID, userID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the user")
+	}
+
+	if !actor.Role.CanAssign(user.Role) {
+		return nil, RoleNotAllowed
+	}
+
+	return user, nil
+}
+
+func (s *Service) ensureAnotherOwner(ctx context.Context, owner *User) error {
+	users, err := s.users.GetByBusinessID(ctx, owner.BusinessID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the users of the business")
+	}
+
+	for _, user := range users {
+		if user.Role == RoleOwner && user.ID != owner.ID {
+			return nil
+		}
+	}
+
+	return LastOwner
+}
+
+func (s *Service) openSession(ctx context.Context, user *User) (*TokenPair, error) {
+	refresh, raw, err := NewRefreshToken(user, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokens.Save(ctx, refresh); err != nil {
+		return nil, eris.Wrap(err, "Error storing the refresh token")
+	}
+
+	return s.tokenPair(user, refresh, raw)
+}
+
+func (s *Service) revokeReused(ctx context.Context, token *RefreshToken) error {
+	s.logger.Warn("Refresh token reused, revoking its family", "business_id", token.BusinessID, "family_id", token.FamilyID)
+
+	if err := s.tokens.RevokeFamily(ctx, token.FamilyID); err != nil {
+		return eris.Wrap(err, "Error revoking the refresh token family")
+	}
+
+	return RefreshTokenReused
+}
+
+func (s *Service) tokenPair(user *User, refresh *RefreshToken, raw string) (*TokenPair, error) {
+	now := time.Now()
+
+	claims := &Claims{
+		BusinessID: user.BusinessID,
+		UserID:     user.ID,
+		Role:       user.Role,
+		EmployeeID: user.EmployeeID,
+		SessionID:  refresh.FamilyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTtl)),
+		},
+	}
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error signing the access token")
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: raw,
+		TokenType:    TokenType,
+		ExpiresIn:    int(AccessTokenTtl.Seconds()),
+	}, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return "", eris.Wrap(err, "Error hashing the password")
+	}
+
+	return string(hash), nil
+}
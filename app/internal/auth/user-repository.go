@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/rotisserie/eris"
+)
+
+type UserRepository interface {
+	Save(ctx context.Context, user *User) error
+	SaveOwner(ctx context.Context, owner *business.Business, user *User) error
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, user *User) error
+	GetByID(ctx context.Context, businessID int, userID string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByBusinessID(ctx context.Context, businessID int) ([]*User, error)
+}
+
+type PgUserRepository struct {
+	connection *sql.DB
+	businesses business.BusinessRepository
+}
+
+func NewPgUserRepository(connection *sql.DB, businesses business.BusinessRepository) *PgUserRepository {
+	return &PgUserRepository{
+		connection: connection,
+		businesses: businesses,
+	}
+}
+
+const insertUserQuery = `
+	INSERT INTO ha_user (
+		hau_id,
+		hau_business_id,
+		hau_employee_id,
+		hau_name,
+		hau_email,
+		hau_password,
+		hau_role,
+		hau_date_add,
+		hau_date_upd
+	)
+	VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9);
+`
+
+const selectUserQuery = `
+	SELECT
+		hau_id,
+		hau_business_id,
+		COALESCE(hau_employee_id, 0),
+		hau_name,
+		hau_email,
+		hau_password,
+		hau_role,
+		hau_date_add,
+		hau_date_upd
+	FROM
+		ha_user
+`
+
+func (r *PgUserRepository) Save(ctx context.Context, user *User) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return insertUser(ctxTimeout, r.connection, user)
+}
+
+// SaveOwner stores a new business and its first user in the same transaction, so a failed
+// registration leaves neither of them behind.
+func (r *PgUserRepository) SaveOwner(ctx context.Context, owner *business.Business, user *User) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		if err := r.businesses.Add(ctxTimeout, tx, owner); err != nil {
+			return eris.Wrap(err, "Error saving the business of the owner")
+		}
+
+		user.BusinessID = owner.Id
+
+		return insertUser(ctxTimeout, tx, user)
+	})
+}
+
+func (r *PgUserRepository) Update(ctx context.Context, user *User) error {
+	query := `
+		UPDATE ha_user
+		SET
+			hau_employee_id = NULLIF($2, 0),
+			hau_name = $3,
+			hau_password = $4,
+			hau_role = $5,
+			hau_date_upd = $6
+		WHERE
+			hau_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		user.ID,
+		user.EmployeeID,
+		user.Name,
+		user.Password,
+		user.Role,
+		user.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error updating user")
+	}
+
+	return nil
+}
+
+// Delete removes the user along with the invitations they sent.
+func (r *PgUserRepository) Delete(ctx context.Context, user *User) error {
+	query := `DELETE FROM ha_user WHERE hau_id = $1;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := r.connection.ExecContext(ctxTimeout, query, user.ID); err != nil {
+		return eris.Wrap(err, "Error deleting user")
+	}
+
+	return nil
+}
+
+func (r *PgUserRepository) GetByID(ctx context.Context, businessID int, userID string) (*User, error) {
+	query := selectUserQuery + `WHERE hau_business_id = $1 AND hau_id = $2;`
+
+	return r.getOne(ctx, query, businessID, userID)
+}
+
+func (r *PgUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	query := selectUserQuery + `WHERE LOWER(hau_email) = LOWER($1);`
+
+	return r.getOne(ctx, query, email)
+}
+
+func (r *PgUserRepository) GetByBusinessID(ctx context.Context, businessID int) (users []*User, err error) {
+	query := selectUserQuery + `WHERE hau_business_id = $1 ORDER BY hau_date_add;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "Error fetching the users of business %d", businessID)
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	users = make([]*User, 0)
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error scanning user")
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *PgUserRepository) getOne(ctx context.Context, query string, args ...any) (*User, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	user, err := scanUser(r.connection.QueryRowContext(ctxTimeout, query, args...))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, UserNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query user")
+	}
+
+	return user, nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertUser(ctx context.Context, connection execer, user *User) error {
+	_, err := connection.ExecContext(
+		ctx,
+		insertUserQuery,
+		user.ID,
+		user.BusinessID,
+		user.EmployeeID,
+		user.Name,
+		user.Email,
+		user.Password,
+		user.Role,
+		user.DateAdd,
+		user.DateUpd,
+	)
+
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return UserAlreadyExists
+		}
+
+		return eris.Wrap(err, "Error saving user")
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
+	var user User
+
+	err := row.Scan(
+		&user.ID,
+		&user.BusinessID,
+		&user.EmployeeID,
+		&user.Name,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.DateAdd,
+		&user.DateUpd,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/pkg/helper"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	UserNotFound       = eris.New("User not found")
+	UserAlreadyExists  = eris.New("A user with this email already exists")
+	InvitationNotFound = eris.New("Invitation not found")
+	InvitationExpired  = eris.New("Invitation expired")
+	RoleNotAllowed     = eris.New("Role not allowed")
+	LastOwner          = eris.New("A business needs at least one owner")
+)
+
+const (
+	InvitationTtl         = 7 * 24 * time.Hour
+	invitationTokenBytes  = 32
+	invitationAcceptRoute = "/invitations/"
+)
+
+type Role string
+
+const (
+	RoleOwner   Role = "owner"
+	RoleManager Role = "manager"
+	RoleStaff   Role = "staff"
+)
+
+type Permission string
+
+const (
+	PermissionViewBusiness    Permission = "business:view"
+	PermissionEditBusiness    Permission = "business:edit"
+	PermissionEditCatalog     Permission = "catalog:edit"
+	PermissionEditHours       Permission = "hours:edit"
+	PermissionManageCalendars Permission = "calendars:manage"
+	PermissionViewAgenda      Permission = "agenda:view"
+	PermissionViewOwnAgenda   Permission = "agenda:view-own"
+	PermissionManageBookings  Permission = "booking:manage"
+	PermissionMarkNoShow      Permission = "booking:no-show"
+	PermissionManageUsers     Permission = "users:manage"
+	PermissionManageChats     Permission = "chats:manage"
+)
+
+var permissions = map[Role][]Permission{
+	RoleOwner: {
+		PermissionViewBusiness,
+		PermissionEditBusiness,
+		PermissionEditCatalog,
+		PermissionEditHours,
+		PermissionManageCalendars,
+		PermissionViewAgenda,
+		PermissionViewOwnAgenda,
+		PermissionManageBookings,
+		PermissionMarkNoShow,
+		PermissionManageUsers,
+		PermissionManageChats,
+	},
+	RoleManager: {
+		PermissionViewBusiness,
+		PermissionEditCatalog,
+		PermissionEditHours,
+		PermissionManageCalendars,
+		PermissionViewAgenda,
+		PermissionViewOwnAgenda,
+		PermissionManageBookings,
+		PermissionMarkNoShow,
+		PermissionManageUsers,
+		PermissionManageChats,
+	},
+	RoleStaff: {
+		PermissionViewBusiness,
+		PermissionViewOwnAgenda,
+		PermissionMarkNoShow,
+	},
+}
+
+func (r Role) IsValid() bool {
+	_, ok := permissions[r]
+
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	return slices.Contains(permissions[r], permission)
+}
+
+// CanAssign tells whether a user with this role may invite or promote someone to the given role.
+// Managers only bring in staff, owners anyone.
+func (r Role) CanAssign(role Role) bool {
+	switch r {
+	case RoleOwner:
+		return role.IsValid()
+	case RoleManager:
+		return role == RoleStaff
+	default:
+		return false
+	}
+}
+
+// User is someone allowed to log in to a business. Staff users can be linked to the employee
+// whose agenda they work.
+type User struct {
+	ID         string    `json:"id"`
+	BusinessID int       `json:"businessId"`
+	EmployeeID int       `json:"employeeId,omitempty"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	Password   string    `json:"-"`
+	Role       Role      `json:"role"`
+	DateAdd    time.Time `json:"createdAt"`
+	DateUpd    time.Time `json:"updatedAt"`
+}
+
+func NewUser(businessID int, employeeID int, name string, email string, passwordHash string, role Role) *User {
+	now := time.Now().UTC()
+
+	return &User{
+		ID:         helper.Uuid().String(),
+		BusinessID: businessID,
+		EmployeeID: employeeID,
+		Name:       name,
+		Email:      strings.ToLower(email),
+		Password:   passwordHash,
+		Role:       role,
+		DateAdd:    now,
+		DateUpd:    now,
+	}
+}
+
+// Invitation lets someone join a business with the given role. Only the hash of the token is
+// stored, the raw token travels in the link sent by email.
+type Invitation struct {
+	ID         string     `json:"id"`
+	BusinessID int        `json:"businessId"`
+	EmployeeID int        `json:"employeeId,omitempty"`
+	Email      string     `json:"email"`
+	Role       Role       `json:"role"`
+	TokenHash  string     `json:"-"`
+	InvitedBy  string     `json:"invitedBy"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt"`
+	DateAdd    time.Time  `json:"createdAt"`
+}
+
+func NewInvitation(businessID int, employeeID int, email string, role Role, invitedBy string) (*Invitation, string, error) {
+	random := make([]byte, invitationTokenBytes)
+
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", eris.Wrap(err, "Error generating the invitation token")
+	}
+
+	raw := base64.RawURLEncoding.EncodeToString(random)
+	now := time.Now().UTC()
+
+	return &Invitation{
+		ID:         helper.Uuid().String(),
+		BusinessID: businessID,
+		EmployeeID: employeeID,
+		Email:      strings.ToLower(email),
+		Role:       role,
+		TokenHash:  HashToken(raw),
+		InvitedBy:  invitedBy,
+		ExpiresAt:  now.Add(InvitationTtl),
+		DateAdd:    now,
+	}, raw, nil
+}
+
+func (i *Invitation) IsAccepted() bool {
+	return i.AcceptedAt != nil
+}
+
+func (i *Invitation) IsExpired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
+
+// InvitationLink is the page of the dashboard where the invited user picks a name and password.
+func InvitationLink(baseUrl string, token string) string {
+	return strings.TrimSuffix(baseUrl, "/") + invitationAcceptRoute + token
+}
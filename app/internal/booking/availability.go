@@ -0,0 +1,38 @@
+package booking
+
+import (
+	"slices"
+	"time"
+)
+
+// Interval is a time range of an agenda, either open for bookings or already taken.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// FreeSlots returns the start times, every step from the start of each open interval, at which an
+// appointment of the given duration fits whole inside the interval without overlapping a busy one.
+func FreeSlots(open []Interval, busy []Interval, duration time.Duration, step time.Duration) []time.Time {
+	slots := make([]time.Time, 0)
+
+	if duration <= 0 || step <= 0 {
+		return slots
+	}
+
+	for _, window := range open {
+		for start := window.Start; !start.Add(duration).After(window.End); start = start.Add(step) {
+			candidate := Interval{Start: start, End: start.Add(duration)}
+
+			if !slices.ContainsFunc(busy, candidate.Overlaps) {
+				slots = append(slots, start)
+			}
+		}
+	}
+
+	return slots
+}
@@ -3,22 +3,537 @@ package booking
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/outbox"
+	"github.com/lib/pq"
+	"github.com/rotisserie/eris"
 )
 
 type BookingRepository interface {
-	Save(ctx context.Context, booking *Booking) error
+	Save(ctx context.Context, booking *Booking, sideEffects ...*outbox.Message) error
+	Update(ctx context.Context, booking *Booking, sideEffects ...*outbox.Message) error
+	AttachEvent(ctx context.Context, bookingID string, calendarID string, eventID string) error
+	GetByID(ctx context.Context, bookingID string) (*Booking, error)
+	GetByEventID(ctx context.Context, eventID string) (*Booking, error)
+	GetAgenda(ctx context.Context, businessID int, employeeID int, from time.Time, to time.Time) ([]*Booking, error)
 }
 
 type PgBookingRepository struct {
 	connection *sql.DB
+	outbox     outbox.OutboxRepository
 }
 
-func NewPgBookingRepository(connection *sql.DB) *PgBookingRepository {
+func NewPgBookingRepository(connection *sql.DB, outbox outbox.OutboxRepository) *PgBookingRepository {
 	return &PgBookingRepository{
 		connection: connection,
+		outbox:     outbox,
+	}
+}
+
+// Save stores the booking, its items and its side effects in the same transaction so none of them
+// is lost when the process dies or an external API fails right after the booking is registered.
+// The transaction takes a lock on the business, so two customers that book the same time at once
+// are stored one after the other and the second one gets BookingSlotTaken.
+func (r *PgBookingRepository) Save(ctx context.Context, booking *Booking, sideEffects ...*outbox.Message) error {
+	lockQuery := `SELECT pg_advisory_xact_lock($1);`
+
+	// The time of a booking is the one the agenda gives it, bookings made before they had items
+	// take a default slot.
+	overlapQuery := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM
+				booking
+			WHERE
+				business_id = $1
+				AND ($2 = 0 OR employee_id = $2)
+				AND status <> $3
+				AND booking_date::timestamptz < $5::timestamptz
+				AND booking_date::timestamptz + make_interval(mins => COALESCE(
+					(SELECT SUM(habi_duration) FROM ha_booking_item WHERE habi_booking_id = booking.id),
+					$6
+				)::int) > $4::timestamptz
+		);
+	`
+
+	query := `
+		INSERT INTO booking (
+			id,
+			session_id,
+			business_id,
+			chat_id,
+			customer_name,
+			customer_phone,
+			customer_email,
+			service_id,
+			employee_id,
+			status,
+			event_id,
+			calendar_id,
+			source,
+			locale,
+			channel,
+			booking_date,
+			created_at,
+			updated_at
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, NULLIF($9, 0), $10, NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), NULLIF($14, ''), $15, $16, $17, $18);
+	`
+
+	itemQuery := `
+		INSERT INTO ha_booking_item (
+			habi_booking_id,
+			habi_position,
+			habi_service_id,
+			habi_name,
+			habi_price,
+			habi_currency,
+			habi_duration
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctxTimeout, lockQuery, booking.BusinessID); err != nil {
+			return eris.Wrap(err, "Error locking the agenda of the business")
+		}
+
+		var taken bool
+
+		err := tx.QueryRowContext(
+			ctxTimeout,
+			overlapQuery,
+			strconv.Itoa(booking.BusinessID),
+			booking.EmployeeID,
+			StatusCancelled,
+			booking.Date.UTC().Format(time.RFC3339),
+			booking.Date.Add(booking.Duration(calendar.DefaultSlotDuration)).UTC().Format(time.RFC3339),
+			int(calendar.DefaultSlotDuration.Minutes()),
+		).Scan(&taken)
+
+		if err != nil {
+			return eris.Wrap(err, "Error checking the time of the booking is free")
+		}
+
+		if taken {
+			return BookingSlotTaken
+		}
+
+		_, err = tx.ExecContext(
+			ctxTimeout,
+			query,
+			booking.ID,
+			booking.SessionID,
+			strconv.Itoa(booking.BusinessID),
+			booking.ChatID,
+			booking.CustomerName,
+			booking.CustomerPhone,
+			booking.CustomerEmail,
+			booking.ServiceID,
+			booking.EmployeeID,
+			booking.Status,
+			booking.EventID,
+			booking.CalendarID,
+			booking.Source,
+			booking.Locale,
+			booking.Channel,
+			booking.Date.UTC().Format(time.RFC3339),
+			booking.DateAdd.UTC().Format(time.RFC3339),
+			booking.DateUpd.UTC().Format(time.RFC3339),
+		)
+
+		if err != nil {
+			return eris.Wrap(err, "Error saving booking")
+		}
+
+		for position, item := range booking.Items {
+			_, err := tx.ExecContext(
+				ctxTimeout,
+				itemQuery,
+				booking.ID,
+				position,
+				item.ServiceID,
+				item.Name,
+				item.Price,
+				item.Currency,
+				item.Duration,
+			)
+
+			if err != nil {
+				return eris.Wrap(err, "Error saving booking item")
+			}
+		}
+
+		if err := r.outbox.Add(ctxTimeout, tx, sideEffects...); err != nil {
+			return eris.Wrap(err, "Error saving booking side effects")
+		}
+
+		return nil
+	})
+}
+
+// Update stores the changes of the booking together with the side effects they produce.
+func (r *PgBookingRepository) Update(ctx context.Context, booking *Booking, sideEffects ...*outbox.Message) error {
+	query := `
+		UPDATE booking
+		SET
+			status = $2,
+			event_id = NULLIF($3, ''),
+			calendar_id = NULLIF($4, ''),
+			booking_date = $5,
+			updated_at = $6
+		WHERE
+			id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctxTimeout,
+			query,
+			booking.ID,
+			booking.Status,
+			booking.EventID,
+			booking.CalendarID,
+			booking.Date.UTC().Format(time.RFC3339),
+			booking.DateUpd.UTC().Format(time.RFC3339),
+		)
+
+		if err != nil {
+			return eris.Wrap(err, "Error updating booking")
+		}
+
+		if err := r.outbox.Add(ctxTimeout, tx, sideEffects...); err != nil {
+			return eris.Wrap(err, "Error saving booking side effects")
+		}
+
+		return nil
+	})
+}
+
+// AttachEvent only writes the calendar event of the booking, so it does not undo a cancellation
+// or a reschedule stored while the event was being created.
+func (r *PgBookingRepository) AttachEvent(ctx context.Context, bookingID string, calendarID string, eventID string) error {
+	query := `
+		UPDATE booking
+		SET
+			event_id = NULLIF($1, ''),
+			calendar_id = NULLIF($2, ''),
+			updated_at = $3
+		WHERE
+			id = $4;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	result, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		eventID,
+		calendarID,
+		time.Now().UTC().Format(time.RFC3339),
+		bookingID,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error attaching the calendar event to the booking")
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return eris.Wrap(err, "Error reading the attached bookings")
+	}
+
+	if affected == 0 {
+		return BookingNotFound
+	}
+
+	return nil
+}
+
+func (r *PgBookingRepository) GetByID(ctx context.Context, bookingID string) (*Booking, error) {
+	query := `
+		SELECT
+			id,
+			session_id,
+			business_id,
+			chat_id,
+			COALESCE(customer_name, ''),
+			COALESCE(customer_phone, ''),
+			COALESCE(customer_email, ''),
+			service_id,
+			COALESCE(employee_id, 0),
+			status,
+			COALESCE(event_id, ''),
+			COALESCE(calendar_id, ''),
+			COALESCE(source, ''),
+			COALESCE(locale, ''),
+			channel,
+			booking_date,
+			created_at,
+			updated_at
+		FROM
+			booking
+		WHERE
+			id = $1;
+	`
+
+	return r.getOne(ctx, query, bookingID)
+}
+
+func (r *PgBookingRepository) GetByEventID(ctx context.Context, eventID string) (*Booking, error) {
+	query := `
+		SELECT
+			id,
+			session_id,
+			business_id,
+			chat_id,
+			COALESCE(customer_name, ''),
+			COALESCE(customer_phone, ''),
+			COALESCE(customer_email, ''),
+			service_id,
+			COALESCE(employee_id, 0),
+			status,
+			COALESCE(event_id, ''),
+			COALESCE(calendar_id, ''),
+			COALESCE(source, ''),
+			COALESCE(locale, ''),
+			channel,
+			booking_date,
+			created_at,
+			updated_at
+		FROM
+			booking
+		WHERE
+			event_id = $1;
+	`
+
+	return r.getOne(ctx, query, eventID)
+}
+
+// GetAgenda returns the bookings of the business between from and to, only those of the employee
+// when one is given.
+func (r *PgBookingRepository) GetAgenda(
+	ctx context.Context,
+	businessID int,
+	employeeID int,
+	from time.Time,
+	to time.Time,
+) (bookings []*Booking, err error) {
+	query := `
+		SELECT
+			id,
+			session_id,
+			business_id,
+			chat_id,
+			COALESCE(customer_name, ''),
+			COALESCE(customer_phone, ''),
+			COALESCE(customer_email, ''),
+			service_id,
+			COALESCE(employee_id, 0),
+			status,
+			COALESCE(event_id, ''),
+			COALESCE(calendar_id, ''),
+			COALESCE(source, ''),
+			COALESCE(locale, ''),
+			channel,
+			booking_date,
+			created_at,
+			updated_at
+		FROM
+			booking
+		WHERE
+			business_id = $1
+			AND booking_date >= $2
+			AND booking_date < $3
+			AND ($4 = 0 OR employee_id = $4)
+		ORDER BY booking_date;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(
+		ctxTimeout,
+		query,
+		strconv.Itoa(businessID),
+		from.UTC().Format(time.RFC3339),
+		to.UTC().Format(time.RFC3339),
+		employeeID,
+	)
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "Error fetching the agenda of business %d", businessID)
 	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	bookings = make([]*Booking, 0)
+
+	for rows.Next() {
+		booking, err := scanBooking(rows)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error scanning booking")
+		}
+
+		bookings = append(bookings, booking)
+	}
+
+	if err := r.attachItems(ctx, bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
 }
 
-func (r *PgBookingRepository) Save(ctx context.Context, booking *Booking) error {
+func (r *PgBookingRepository) getOne(ctx context.Context, query string, args ...any) (*Booking, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	booking, err := scanBooking(r.connection.QueryRowContext(ctxTimeout, query, args...))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, BookingNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query booking")
+	}
+
+	if err := r.attachItems(ctx, booking); err != nil {
+		return nil, err
+	}
+
+	return booking, nil
+}
+
+// attachItems loads the items of the bookings with a single query.
+func (r *PgBookingRepository) attachItems(ctx context.Context, bookings ...*Booking) (err error) {
+	if len(bookings) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT
+			habi_booking_id,
+			habi_service_id,
+			habi_name,
+			habi_price,
+			habi_currency,
+			habi_duration
+		FROM
+			ha_booking_item
+		WHERE
+			habi_booking_id = ANY($1)
+		ORDER BY
+			habi_booking_id,
+			habi_position;
+	`
+
+	byID := make(map[string]*Booking, len(bookings))
+	ids := make([]string, len(bookings))
+
+	for i, booking := range bookings {
+		booking.Items = make([]Item, 0)
+		byID[booking.ID] = booking
+		ids[i] = booking.ID
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, pq.Array(ids))
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching booking items")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	for rows.Next() {
+		var (
+			bookingID string
+			item      Item
+		)
+
+		err := rows.Scan(&bookingID, &item.ServiceID, &item.Name, &item.Price, &item.Currency, &item.Duration)
+
+		if err != nil {
+			return eris.Wrap(err, "Error scanning booking item")
+		}
+
+		byID[bookingID].Items = append(byID[bookingID].Items, item)
+	}
+
 	return nil
 }
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBooking(row rowScanner) (*Booking, error) {
+	var (
+		booking    Booking
+		businessID string
+		date       string
+		dateAdd    string
+		dateUpd    string
+	)
+
+	err := row.Scan(
+		&booking.ID,
+		&booking.SessionID,
+		&businessID,
+		&booking.ChatID,
+		&booking.CustomerName,
+		&booking.CustomerPhone,
+		&booking.CustomerEmail,
+		&booking.ServiceID,
+		&booking.EmployeeID,
+		&booking.Status,
+		&booking.EventID,
+		&booking.CalendarID,
+		&booking.Source,
+		&booking.Locale,
+		&booking.Channel,
+		&date,
+		&dateAdd,
+		&dateUpd,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if booking.BusinessID, err = strconv.Atoi(businessID); err != nil {
+		return nil, eris.Wrap(err, "Error converting business ID to int")
+	}
+
+	if booking.Date, err = time.Parse(time.RFC3339, date); err != nil {
+		return nil, eris.Wrap(err, "Error parsing booking date")
+	}
+
+	if booking.DateAdd, err = time.Parse(time.RFC3339, dateAdd); err != nil {
+		return nil, eris.Wrap(err, "Error parsing booking creation date")
+	}
+
+	if booking.DateUpd, err = time.Parse(time.RFC3339, dateUpd); err != nil {
+		booking.DateUpd = booking.DateAdd
+	}
+
+	return &booking, nil
+}
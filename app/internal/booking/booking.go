@@ -1,21 +1,184 @@
 package booking
 
 import (
+	"slices"
 	"time"
 
 	"github.com/rotisserie/eris"
 )
 
-var BookingSessionExpired = eris.New("Booking session expired")
+var (
+	BookingSessionExpired = eris.New("Booking session expired")
+	BookingNotFound       = eris.New("Booking not found")
+	BookingNotStarted     = eris.New("Booking has not started yet")
+	BookingIsCancelled    = eris.New("Booking is cancelled")
+	BookingNotPending     = eris.New("Booking is not pending of approval")
+	BookingSlotTaken      = eris.New("Booking time is already taken")
+)
+
+// The channels a customer can book from. ChatID is the Telegram chat or the WhatsApp number, the
+// bookings of the web widget have no chat and keep the phone the customer gave instead.
+const (
+	ChannelTelegram = "telegram"
+	ChannelWhatsapp = "whatsapp"
+	ChannelWeb      = "web"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+	StatusNoShow    = "no_show"
+)
 
 type Booking struct {
-	ID         string
-	SessionID  string
-	BusinessID int
+	ID            string    `json:"id"`
+	SessionID     string    `json:"-"`
+	BusinessID    int       `json:"businessId"`
+	ChatID        int       `json:"-"`
+	CustomerName  string    `json:"customerName"`
+	CustomerPhone string    `json:"customerPhone,omitempty"`
+	CustomerEmail string    `json:"customerEmail,omitempty"`
+	ServiceID     string    `json:"serviceId"`
+	EmployeeID    int       `json:"employeeId,omitempty"`
+	Status        string    `json:"status"`
+	EventID       string    `json:"-"`
+	CalendarID    string    `json:"-"`
+	Source        string    `json:"source,omitempty"`
+	Locale        string    `json:"locale,omitempty"`
+	Channel       string    `json:"channel"`
+	Items         []Item    `json:"items"`
+	Date          time.Time `json:"date"`
+	DateAdd       time.Time `json:"createdAt"`
+	DateUpd       time.Time `json:"updatedAt"`
+}
+
+// Item is one of the services of a booking as it was in the catalog when the booking was made,
+// so later changes to the catalog do not alter it. Price is in minor units and duration in minutes.
+type Item struct {
+	ServiceID int    `json:"serviceId"`
+	Name      string `json:"name"`
+	Price     int    `json:"price"`
+	Currency  string `json:"currency"`
+	Duration  int    `json:"duration"`
+}
+
+// Duration is the time the booking takes, that of all its items together. Bookings made before
+// they had items take the fallback.
+func (b *Booking) Duration(fallback time.Duration) time.Duration {
+	if len(b.Items) == 0 {
+		return fallback
+	}
+
+	minutes := 0
+
+	for _, item := range b.Items {
+		minutes += item.Duration
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// Total is the price of all the items in minor units of the currency of the first one, the
+// services of a business share its currency.
+func (b *Booking) Total() (int, string) {
+	if len(b.Items) == 0 {
+		return 0, ""
+	}
+
+	total := 0
+
+	for _, item := range b.Items {
+		total += item.Price
+	}
+
+	return total, b.Items[0].Currency
+}
+
+func (b *Booking) ServiceNames() []string {
+	names := make([]string, len(b.Items))
+
+	for i, item := range b.Items {
+		names[i] = item.Name
+	}
+
+	return names
+}
+
+func (b *Booking) IsCancelled() bool {
+	return b.Status == StatusCancelled
+}
+
+func (b *Booking) IsPending() bool {
+	return b.Status == StatusPending
+}
+
+// Confirm accepts a booking that was waiting for the approval of the business.
+func (b *Booking) Confirm() error {
+	if !b.IsPending() {
+		return BookingNotPending
+	}
+
+	b.Status = StatusConfirmed
+	b.DateUpd = time.Now().UTC()
+
+	return nil
+}
+
+// Reject turns down a booking that was waiting for the approval of the business.
+func (b *Booking) Reject() error {
+	if !b.IsPending() {
+		return BookingNotPending
+	}
+
+	b.Cancel()
+
+	return nil
+}
+
+// MarkNoShow records that the customer did not turn up, which can only be told once the
+// booking has started.
+func (b *Booking) MarkNoShow(now time.Time) error {
+	if b.IsCancelled() {
+		return BookingIsCancelled
+	}
+
+	if now.Before(b.Date) {
+		return BookingNotStarted
+	}
+
+	b.Status = StatusNoShow
+	b.DateUpd = now.UTC()
+
+	return nil
+}
+
+func (b *Booking) Cancel() {
+	b.Status = StatusCancelled
+	b.DateUpd = time.Now().UTC()
+}
+
+func (b *Booking) Reschedule(date time.Time) {
+	b.Date = date
+	b.DateUpd = time.Now().UTC()
+}
+
+// Customer is who a booking is for, as they told the channel they booked from. Chats only know
+// the name, the web widget asks for the phone too and optionally for an email for the invite.
+type Customer struct {
+	Name  string
+	Phone string
+	Email string
+}
+
+// Origin is what the link that opened a conversation preselected and the source it is attributed
+// to, together with the language the customer uses in the chat and the channel of the chat.
+type Origin struct {
 	ServiceID  string
-	Date       time.Time
-	DateAdd    time.Time
-	DateUpd    time.Time
+	EmployeeID int
+	Source     string
+	Locale     string
+	Channel    string
 }
 
 type Session struct {
@@ -23,6 +186,11 @@ type Session struct {
 	BusinessId int
 	ChatId     int
 	ServiceId  string
+	ServiceIds []string
+	EmployeeId int
+	Source     string
+	Locale     string
+	Channel    string
 	Date       string
 	Hour       string
 	Ttl        int64
@@ -45,6 +213,26 @@ func (s *Session) Refresh() {
 	s.DateUpd = time.Now().UTC()
 }
 
+// ToggleService adds the service to the ones picked in the conversation or takes it out when it
+// was already picked. ServiceId follows the first of them.
+func (s *Session) ToggleService(serviceID string) {
+	if index := slices.Index(s.ServiceIds, serviceID); index >= 0 {
+		s.ServiceIds = slices.Delete(s.ServiceIds, index, index+1)
+	} else {
+		s.ServiceIds = append(s.ServiceIds, serviceID)
+	}
+
+	s.ServiceId = ""
+
+	if len(s.ServiceIds) > 0 {
+		s.ServiceId = s.ServiceIds[0]
+	}
+}
+
+func (s *Session) HasService(serviceID string) bool {
+	return slices.Contains(s.ServiceIds, serviceID)
+}
+
 type Slot struct {
 	Index     int
 	StartTime time.Time
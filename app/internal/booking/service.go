@@ -5,17 +5,27 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/adriein/hastypal/internal/outbox"
 	"github.com/adriein/hastypal/pkg/helper"
 	"github.com/rotisserie/eris"
 )
 
 type BookingService interface {
-	InitSession(ctx context.Context, businessID int, chatID int) (string, error)
+	InitSession(ctx context.Context, businessID int, chatID int, origin Origin) (string, error)
 	GetCurrentSession(ctx context.Context, sessionID string) (*Session, error)
 	RefreshSession(ctx context.Context, session *Session) error
 	GetSessionsOnDate(ctx context.Context, date time.Time) ([]*Session, error)
 	GetSessionOnHour(ctx context.Context, date time.Time) (*Session, error)
-	RegisterBooking(ctx context.Context, sessionID string, businessID int, serviceID string, date time.Time) (string, error)
+	RegisterBooking(ctx context.Context, session *Session, customer Customer, date time.Time, items []Item, requiresApproval bool) (*Booking, error)
+	GetBooking(ctx context.Context, bookingID string) (*Booking, error)
+	GetBookingByEvent(ctx context.Context, eventID string) (*Booking, error)
+	AttachEvent(ctx context.Context, bookingID string, calendarID string, eventID string) error
+	RescheduleBooking(ctx context.Context, booking *Booking, date time.Time) error
+	CancelBooking(ctx context.Context, booking *Booking) error
+	ConfirmBooking(ctx context.Context, booking *Booking) error
+	RejectBooking(ctx context.Context, booking *Booking) error
+	MarkNoShow(ctx context.Context, booking *Booking) error
+	GetAgenda(ctx context.Context, businessID int, employeeID int, from time.Time, to time.Time) ([]*Booking, error)
 }
 
 type Service struct {
@@ -32,14 +42,19 @@ func NewService(logger *slog.Logger, sessionRepo SessionRepository, bookingRepo
 	}
 }
 
-func (s *Service) InitSession(ctx context.Context, businessID int, chatID int) (string, error) {
+func (s *Service) InitSession(ctx context.Context, businessID int, chatID int, origin Origin) (string, error) {
 	sessionId := helper.ShortUuid()
 
 	session := &Session{
 		Id:         sessionId,
 		BusinessId: businessID,
 		ChatId:     chatID,
-		ServiceId:  "",
+		ServiceId:  origin.ServiceID,
+		ServiceIds: make([]string, 0),
+		EmployeeId: origin.EmployeeID,
+		Source:     origin.Source,
+		Locale:     origin.Locale,
+		Channel:    origin.Channel,
 		Date:       "",
 		Hour:       "",
 		DateAdd:    time.Now().UTC(),
@@ -47,6 +62,10 @@ func (s *Service) InitSession(ctx context.Context, businessID int, chatID int) (
 		Ttl:        time.Minute.Milliseconds() * 5,
 	}
 
+	if origin.ServiceID != "" {
+		session.ServiceIds = append(session.ServiceIds, origin.ServiceID)
+	}
+
 	if err := s.sessionRepo.Save(ctx, session); err != nil {
 		return "", eris.Wrap(err, "Error storing the current session")
 	}
@@ -94,18 +113,270 @@ func (s *Service) GetSessionOnHour(ctx context.Context, date time.Time) (*Sessio
 	return sessions, nil
 }
 
-func (s *Service) RegisterBooking(ctx context.Context, sessionID string, businessID int, serviceID string, date time.Time) (string, error) {
+// RegisterBooking stores a new booking with the services picked in the session as its items.
+// Bookings of businesses that require approval stay pending and only reach the calendar and the
+// reminders once they are confirmed.
+func (s *Service) RegisterBooking(
+	ctx context.Context,
+	session *Session,
+	customer Customer,
+	date time.Time,
+	items []Item,
+	requiresApproval bool,
+) (*Booking, error) {
 	booking := &Booking{
-		ID:         helper.Uuid().String(),
-		SessionID:  sessionID,
-		BusinessID: businessID,
-		ServiceID:  serviceID,
-		Date:       date,
+		ID:            helper.Uuid().String(),
+		SessionID:     session.Id,
+		BusinessID:    session.BusinessId,
+		ChatID:        session.ChatId,
+		CustomerName:  customer.Name,
+		CustomerPhone: customer.Phone,
+		CustomerEmail: customer.Email,
+		ServiceID:     session.ServiceId,
+		EmployeeID:    session.EmployeeId,
+		Source:        session.Source,
+		Locale:        session.Locale,
+		Channel:       session.Channel,
+		Items:         items,
+		Status:        StatusConfirmed,
+		Date:          date,
+		DateAdd:       time.Now().UTC(),
+		DateUpd:       time.Now().UTC(),
+	}
+
+	messageTypes := []string{outbox.CalendarEventCreate, outbox.ReminderCreate}
+
+	if requiresApproval {
+		booking.Status = StatusPending
+		messageTypes = nil
+	}
+
+	sideEffects, err := bookingSideEffects(booking, messageTypes...)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error building the booking side effects")
 	}
 
-	if err := s.bookingRepo.Save(ctx, booking); err != nil {
-		return "", eris.Wrap(err, "Error saving the booking")
+	notifications, err := bookingNotifications(booking, outbox.BookingCreated)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error building the booking notifications")
+	}
+
+	if err := s.bookingRepo.Save(ctx, booking, append(sideEffects, notifications...)...); err != nil {
+		return nil, eris.Wrap(err, "Error saving the booking")
+	}
+
+	return booking, nil
+}
+
+func (s *Service) GetBooking(ctx context.Context, bookingID string) (*Booking, error) {
+	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching booking by ID")
+	}
+
+	return booking, nil
+}
+
+func (s *Service) GetBookingByEvent(ctx context.Context, eventID string) (*Booking, error) {
+	booking, err := s.bookingRepo.GetByEventID(ctx, eventID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching booking by calendar event ID")
+	}
+
+	return booking, nil
+}
+
+func (s *Service) AttachEvent(ctx context.Context, bookingID string, calendarID string, eventID string) error {
+	if err := s.bookingRepo.AttachEvent(ctx, bookingID, calendarID, eventID); err != nil {
+		return eris.Wrap(err, "Error attaching the calendar event to the booking")
+	}
+
+	return nil
+}
+
+// RescheduleBooking moves the booking to the new date, with its calendar event, and its reminder
+// too when it is confirmed.
+func (s *Service) RescheduleBooking(ctx context.Context, booking *Booking, date time.Time) error {
+	booking.Reschedule(date)
+
+	messages, err := bookingNotifications(booking, outbox.BookingRescheduled)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the booking notifications")
+	}
+
+	sideEffectTypes := []string{outbox.CalendarEventUpdate}
+
+	if booking.Status == StatusConfirmed {
+		sideEffectTypes = append(sideEffectTypes, outbox.ReminderCreate)
+	}
+
+	sideEffects, err := bookingSideEffects(booking, sideEffectTypes...)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the booking side effects")
+	}
+
+	messages = append(messages, sideEffects...)
+
+	if err := s.bookingRepo.Update(ctx, booking, messages...); err != nil {
+		return eris.Wrap(err, "Error rescheduling the booking")
+	}
+
+	return nil
+}
+
+func (s *Service) CancelBooking(ctx context.Context, booking *Booking) error {
+	booking.Cancel()
+
+	notifications, err := bookingNotifications(booking, outbox.BookingCancelled)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the booking notifications")
+	}
+
+	sideEffects, err := bookingSideEffects(booking, outbox.CalendarEventDelete)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the booking side effects")
+	}
+
+	if err := s.bookingRepo.Update(ctx, booking, append(notifications, sideEffects...)...); err != nil {
+		return eris.Wrap(err, "Error cancelling the booking")
+	}
+
+	return nil
+}
+
+// ConfirmBooking approves a pending booking, which only then gets its calendar event and reminder.
+func (s *Service) ConfirmBooking(ctx context.Context, booking *Booking) error {
+	if err := booking.Confirm(); err != nil {
+		return err
+	}
+
+	sideEffects, err := bookingSideEffects(booking, outbox.CalendarEventCreate, outbox.ReminderCreate)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the booking side effects")
+	}
+
+	notification, err := notificationMessage(outbox.CustomerNotification, booking, outbox.BookingConfirmed)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the customer notification")
+	}
+
+	if err := s.bookingRepo.Update(ctx, booking, append(sideEffects, notification)...); err != nil {
+		return eris.Wrap(err, "Error confirming the booking")
+	}
+
+	return nil
+}
+
+func (s *Service) RejectBooking(ctx context.Context, booking *Booking) error {
+	if err := booking.Reject(); err != nil {
+		return err
+	}
+
+	notification, err := notificationMessage(outbox.CustomerNotification, booking, outbox.BookingRejected)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the customer notification")
+	}
+
+	sideEffects, err := bookingSideEffects(booking, outbox.CalendarEventDelete)
+
+	if err != nil {
+		return eris.Wrap(err, "Error building the booking side effects")
+	}
+
+	if err := s.bookingRepo.Update(ctx, booking, append(sideEffects, notification)...); err != nil {
+		return eris.Wrap(err, "Error rejecting the booking")
+	}
+
+	return nil
+}
+
+func (s *Service) MarkNoShow(ctx context.Context, booking *Booking) error {
+	if err := booking.MarkNoShow(time.Now()); err != nil {
+		return err
+	}
+
+	if err := s.bookingRepo.Update(ctx, booking); err != nil {
+		return eris.Wrap(err, "Error marking the booking as no-show")
+	}
+
+	return nil
+}
+
+func (s *Service) GetAgenda(ctx context.Context, businessID int, employeeID int, from time.Time, to time.Time) ([]*Booking, error) {
+	bookings, err := s.bookingRepo.GetAgenda(ctx, businessID, employeeID, from, to)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the agenda")
+	}
+
+	return bookings, nil
+}
+
+func bookingSideEffects(booking *Booking, messageTypes ...string) ([]*outbox.Message, error) {
+	payload := outbox.BookingPayload{
+		BookingID:  booking.ID,
+		BusinessID: booking.BusinessID,
+		EmployeeID: booking.EmployeeID,
+		Date:       booking.Date,
+	}
+
+	messages := make([]*outbox.Message, len(messageTypes))
+
+	for i, messageType := range messageTypes {
+		message, err := outbox.NewMessage(messageType, booking.ID, payload)
+
+		if err != nil {
+			return nil, eris.Wrapf(err, "Error creating %s outbox message", messageType)
+		}
+
+		messages[i] = message
+	}
+
+	return messages, nil
+}
+
+// bookingNotifications tells the chats linked to the business, the owners by email and the
+// customer what happened to the booking.
+func bookingNotifications(booking *Booking, event string) ([]*outbox.Message, error) {
+	messageTypes := []string{outbox.BusinessNotification, outbox.EmailNotification, outbox.CustomerNotification}
+	messages := make([]*outbox.Message, len(messageTypes))
+
+	for i, messageType := range messageTypes {
+		message, err := notificationMessage(messageType, booking, event)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messages[i] = message
+	}
+
+	return messages, nil
+}
+
+func notificationMessage(messageType string, booking *Booking, event string) (*outbox.Message, error) {
+	payload := outbox.NotificationPayload{
+		BookingID:  booking.ID,
+		BusinessID: booking.BusinessID,
+		Event:      event,
+	}
+
+	message, err := outbox.NewMessage(messageType, booking.ID, payload)
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "Error creating %s outbox message", messageType)
 	}
 
-	return booking.ID, nil
+	return message, nil
 }
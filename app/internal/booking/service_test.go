@@ -0,0 +1,76 @@
+package booking
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/adriein/hastypal/internal/outbox"
+)
+
+type fakeBookingRepository struct {
+	BookingRepository
+	sideEffects []*outbox.Message
+}
+
+func (f *fakeBookingRepository) Update(_ context.Context, _ *Booking, sideEffects ...*outbox.Message) error {
+	f.sideEffects = append(f.sideEffects, sideEffects...)
+
+	return nil
+}
+
+func (f *fakeBookingRepository) types() []string {
+	types := make([]string, len(f.sideEffects))
+
+	for i, message := range f.sideEffects {
+		types[i] = message.Type
+	}
+
+	return types
+}
+
+func confirmedBooking() *Booking {
+	return &Booking{
+		ID:         "b1",
+		BusinessID: 7,
+		Status:     StatusConfirmed,
+		EventID:    "hastypalb1",
+		Date:       time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCancelBookingDeletesTheCalendarEvent(t *testing.T) {
+	repo := &fakeBookingRepository{}
+	service := NewService(slog.New(slog.DiscardHandler), nil, repo)
+
+	found := confirmedBooking()
+
+	if err := service.CancelBooking(context.Background(), found); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	if !found.IsCancelled() {
+		t.Errorf("status = %s", found.Status)
+	}
+
+	if !slices.Contains(repo.types(), outbox.CalendarEventDelete) {
+		t.Errorf("side effects = %v, the calendar event should be deleted", repo.types())
+	}
+}
+
+func TestRescheduleBookingMovesTheCalendarEvent(t *testing.T) {
+	repo := &fakeBookingRepository{}
+	service := NewService(slog.New(slog.DiscardHandler), nil, repo)
+
+	if err := service.RescheduleBooking(context.Background(), confirmedBooking(), time.Date(2026, 3, 12, 10, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+
+	types := repo.types()
+
+	if !slices.Contains(types, outbox.CalendarEventUpdate) || !slices.Contains(types, outbox.ReminderCreate) {
+		t.Errorf("side effects = %v, the calendar event and the reminder should move", types)
+	}
+}
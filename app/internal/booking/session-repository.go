@@ -3,11 +3,17 @@ package booking
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/adriein/hastypal/database"
 	"github.com/rotisserie/eris"
 )
 
+var SessionNotFound = eris.New("Booking session not found")
+
 type SessionRepository interface {
 	Save(ctx context.Context, session *Session) error
 	Update(ctx context.Context, session *Session) error
@@ -26,23 +32,47 @@ func NewPgSessionRepository(connection *sql.DB) *PgSessionRepository {
 	}
 }
 
-func (r *PgSessionRepository) Save(ctx context.Context, session Session) error {
+func (r *PgSessionRepository) Save(ctx context.Context, session *Session) error {
 	query := `
-		INSERT INTO tc_currency_rates (
-			tcr_usd,
-			tcr_eur,
-			tcr_aud,
-			tcr_gbp,
-			tcr_pln,
-			tcr_brl,
-			tcr_date_upd
+		INSERT INTO booking_session (
+			id,
+			business_id,
+			chat_id,
+			service_id,
+			service_ids,
+			date,
+			hour,
+			employee_id,
+			source,
+			locale,
+			channel,
+			created_at,
+			updated_at,
+			ttl
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7);
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, NULLIF($8, 0), NULLIF($9, ''), NULLIF($10, ''), $11, $12, $13, $14);
 	`
 
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
 	_, err := r.connection.ExecContext(
-		ctx,
+		ctxTimeout,
 		query,
+		session.Id,
+		strconv.Itoa(session.BusinessId),
+		strconv.Itoa(session.ChatId),
+		session.ServiceId,
+		strings.Join(session.ServiceIds, ","),
+		session.Date,
+		session.Hour,
+		session.EmployeeId,
+		session.Source,
+		session.Locale,
+		session.Channel,
+		session.DateAdd.UTC().Format(time.RFC3339),
+		session.DateUpd.UTC().Format(time.RFC3339),
+		session.Ttl,
 	)
 
 	if err != nil {
@@ -52,18 +82,227 @@ func (r *PgSessionRepository) Save(ctx context.Context, session Session) error {
 	return nil
 }
 
-func (r *PgSessionRepository) GetByID(ctx context.Context, sessionID string) (*Session, error) {
-	return nil, nil
-}
-
 func (r *PgSessionRepository) Update(ctx context.Context, session *Session) error {
+	query := `
+		UPDATE booking_session
+		SET
+			service_id = $2,
+			service_ids = NULLIF($3, ''),
+			date = $4,
+			hour = $5,
+			updated_at = $6
+		WHERE
+			id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		session.Id,
+		session.ServiceId,
+		strings.Join(session.ServiceIds, ","),
+		session.Date,
+		session.Hour,
+		session.DateUpd.UTC().Format(time.RFC3339),
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error updating session")
+	}
+
 	return nil
 }
 
-func (r *PgSessionRepository) GetByDate(ctx context.Context, date time.Time) ([]*Session, error) {
-	return nil, nil
+func (r *PgSessionRepository) GetByID(ctx context.Context, sessionID string) (*Session, error) {
+	query := `
+		SELECT
+			id,
+			business_id,
+			chat_id,
+			service_id,
+			COALESCE(service_ids, ''),
+			date,
+			hour,
+			COALESCE(employee_id, 0),
+			COALESCE(source, ''),
+			COALESCE(locale, ''),
+			channel,
+			created_at,
+			updated_at,
+			ttl
+		FROM
+			booking_session
+		WHERE
+			id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	session, err := scanSession(r.connection.QueryRowContext(ctxTimeout, query, sessionID))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, SessionNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query session by ID")
+	}
+
+	return session, nil
+}
+
+func (r *PgSessionRepository) GetByDate(ctx context.Context, date time.Time) (sessions []*Session, err error) {
+	query := `
+		SELECT
+			id,
+			business_id,
+			chat_id,
+			service_id,
+			COALESCE(service_ids, ''),
+			date,
+			hour,
+			COALESCE(employee_id, 0),
+			COALESCE(source, ''),
+			COALESCE(locale, ''),
+			channel,
+			created_at,
+			updated_at,
+			ttl
+		FROM
+			booking_session
+		WHERE
+			date = $1 AND hour <> '';
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, date.Format(time.DateOnly))
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query sessions by date")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to scan session")
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
 }
 
 func (r *PgSessionRepository) GetByHour(ctx context.Context, date time.Time) (*Session, error) {
-	return nil, nil
-}
\ No newline at end of file
+	query := `
+		SELECT
+			id,
+			business_id,
+			chat_id,
+			service_id,
+			COALESCE(service_ids, ''),
+			date,
+			hour,
+			COALESCE(employee_id, 0),
+			COALESCE(source, ''),
+			COALESCE(locale, ''),
+			channel,
+			created_at,
+			updated_at,
+			ttl
+		FROM
+			booking_session
+		WHERE
+			date = $1 AND hour = $2
+		ORDER BY
+			updated_at DESC
+		LIMIT 1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	session, err := scanSession(r.connection.QueryRowContext(
+		ctxTimeout,
+		query,
+		date.Format(time.DateOnly),
+		date.Format("15:04"),
+	))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, eris.Wrap(err, "Failed to query session by hour")
+	}
+
+	return session, nil
+}
+
+func scanSession(row rowScanner) (*Session, error) {
+	var (
+		session    Session
+		businessID string
+		chatID     string
+		serviceIDs string
+		dateAdd    string
+		dateUpd    string
+	)
+
+	err := row.Scan(
+		&session.Id,
+		&businessID,
+		&chatID,
+		&session.ServiceId,
+		&serviceIDs,
+		&session.Date,
+		&session.Hour,
+		&session.EmployeeId,
+		&session.Source,
+		&session.Locale,
+		&session.Channel,
+		&dateAdd,
+		&dateUpd,
+		&session.Ttl,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if session.BusinessId, err = strconv.Atoi(businessID); err != nil {
+		return nil, eris.Wrap(err, "Error converting business ID to int")
+	}
+
+	if session.ChatId, err = strconv.Atoi(chatID); err != nil {
+		return nil, eris.Wrap(err, "Error converting chat ID to int")
+	}
+
+	// Sessions started before several services could be picked only have service_id.
+	switch {
+	case serviceIDs != "":
+		session.ServiceIds = strings.Split(serviceIDs, ",")
+	case session.ServiceId != "":
+		session.ServiceIds = []string{session.ServiceId}
+	}
+
+	if session.DateAdd, err = time.Parse(time.RFC3339, dateAdd); err != nil {
+		return nil, eris.Wrap(err, "Error parsing session creation date")
+	}
+
+	if session.DateUpd, err = time.Parse(time.RFC3339, dateUpd); err != nil {
+		return nil, eris.Wrap(err, "Error parsing session update date")
+	}
+
+	return &session, nil
+}
@@ -1,30 +1,126 @@
 package business
 
+import (
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+var (
+	InvalidOpeningHours = eris.New("Invalid opening hours")
+	InvalidTimeBlock    = eris.New("Invalid time block")
+)
+
+const HourLayout = "15:04"
+
 type Business struct {
-	Id             string              `json:"id"`
-	Name           string              `json:"name"`
-	ContactPhone   string              `json:"contactPhone"`
-	Email          string              `json:"email"`
-	Password       string              `json:"password"`
-	ServiceCatalog []ServiceCatalog    `json:"serviceCatalog"`
-	OpeningHours   map[string][]string `json:"openingHours"`
-	Holidays       map[string][]string `json:"holidays"`
-	ChannelName    string              `json:"channelName"`
-	Location       string              `json:"location"`
-	CreatedAt      string              `json:"createdAt"`
-	UpdatedAt      string              `json:"updatedAt"`
+	Id               int       `json:"id"`
+	Name             string    `json:"name"`
+	ContactPhone     string    `json:"contactPhone"`
+	Email            string    `json:"email"`
+	Address          string    `json:"address"`
+	Country          string    `json:"country"`
+	Lang             string    `json:"lang"`
+	ChannelID        int       `json:"channelId,omitempty"`
+	ChannelName      string    `json:"channelName"`
+	ChannelLink      string    `json:"channelLink"`
+	RequiresApproval bool      `json:"requiresApproval"`
+	DateAdd          time.Time `json:"createdAt"`
+	DateUpd          time.Time `json:"updatedAt"`
 }
 
+// ServiceCatalog is one of the services a business offers. Price is in minor units of the
+// currency and duration in minutes. Category is optional and groups the services in the bot menu.
 type ServiceCatalog struct {
-	Id         string `json:"id"`
+	Id         int       `json:"id"`
+	Name       string    `json:"name"`
+	Price      int       `json:"price"`
+	Currency   string    `json:"currency"`
+	Duration   int       `json:"duration"`
+	Category   string    `json:"category"`
+	BusinessId int       `json:"businessId"`
+	DateAdd    time.Time `json:"createdAt"`
+	DateUpd    time.Time `json:"updatedAt"`
+}
+
+// OpeningHours is a time range the business is open on a weekday. A day can have several ranges
+// to leave room for breaks.
+type OpeningHours struct {
+	Weekday time.Weekday `json:"weekday"`
+	Open    string       `json:"open"`
+	Close   string       `json:"close"`
+}
+
+type Holiday struct {
+	Id         int    `json:"id"`
+	BusinessId int    `json:"businessId"`
+	Date       string `json:"date"`
 	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Currency   string `json:"currency"`
-	Duration   string `json:"duration"`
-	BusinessId string `json:"businessId"`
+}
+
+// TimeBlock is a time range the business takes out of its agenda without closing the whole day.
+type TimeBlock struct {
+	Id         int       `json:"id"`
+	BusinessId int       `json:"businessId"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Reason     string    `json:"reason"`
+	DateAdd    time.Time `json:"createdAt"`
+}
+
+func NewTimeBlock(businessID int, start time.Time, end time.Time, reason string) (*TimeBlock, error) {
+	if !start.Before(end) {
+		return nil, InvalidTimeBlock
+	}
+
+	return &TimeBlock{
+		BusinessId: businessID,
+		Start:      start,
+		End:        end,
+		Reason:     reason,
+		DateAdd:    time.Now().UTC(),
+	}, nil
+}
+
+type Employee struct {
+	Id         int       `json:"id"`
+	Name       string    `json:"name"`
+	BusinessId int       `json:"businessId"`
+	CalendarId string    `json:"calendarId"`
+	DateAdd    time.Time `json:"createdAt"`
+	DateUpd    time.Time `json:"updatedAt"`
 }
 
 type BusinessConfig struct {
 	Step    int8   `json:"step"`
 	Content string `json:"content"`
 }
+
+// ValidateOpeningHours checks every range closes after it opens and that the ranges of a day
+// do not overlap.
+func ValidateOpeningHours(hours []*OpeningHours) error {
+	byDay := make(map[time.Weekday][][2]time.Time)
+
+	for _, hour := range hours {
+		open, openErr := time.Parse(HourLayout, hour.Open)
+		closing, closeErr := time.Parse(HourLayout, hour.Close)
+
+		if openErr != nil || closeErr != nil {
+			return eris.Wrapf(InvalidOpeningHours, "Malformed range %s-%s", hour.Open, hour.Close)
+		}
+
+		if !closing.After(open) {
+			return eris.Wrapf(InvalidOpeningHours, "Range %s-%s closes before it opens", hour.Open, hour.Close)
+		}
+
+		for _, other := range byDay[hour.Weekday] {
+			if open.Before(other[1]) && other[0].Before(closing) {
+				return eris.Wrapf(InvalidOpeningHours, "Range %s-%s overlaps on %s", hour.Open, hour.Close, hour.Weekday)
+			}
+		}
+
+		byDay[hour.Weekday] = append(byDay[hour.Weekday], [2]time.Time{open, closing})
+	}
+
+	return nil
+}
@@ -4,15 +4,47 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 	"time"
 
+	"github.com/adriein/hastypal/database"
 	"github.com/rotisserie/eris"
 )
 
-var BusinessNotFound = eris.New("Business not found")
+var (
+	BusinessNotFound        = eris.New("Business not found")
+	BusinessAlreadyExists   = eris.New("A business with this email already exists")
+	EmployeeNotFound        = eris.New("Employee not found")
+	ServiceNotFound         = eris.New("Service not found")
+	HolidayNotFound         = eris.New("Holiday not found")
+	MessageTemplateNotFound = eris.New("Message template not found")
+)
 
 type BusinessRepository interface {
+	Save(ctx context.Context, business *Business) error
+	Add(ctx context.Context, tx *sql.Tx, business *Business) error
+	Update(ctx context.Context, business *Business) error
 	GetByID(ctx context.Context, ID int) (*Business, error)
+	UpdateChannel(ctx context.Context, business *Business) error
+	ClearChannel(ctx context.Context, channelID int) error
+	GetServices(ctx context.Context, businessID int) ([]*ServiceCatalog, error)
+	GetService(ctx context.Context, businessID int, serviceID int) (*ServiceCatalog, error)
+	SaveService(ctx context.Context, service *ServiceCatalog) error
+	UpdateService(ctx context.Context, service *ServiceCatalog) error
+	DeleteService(ctx context.Context, businessID int, serviceID int) error
+	GetOpeningHours(ctx context.Context, businessID int) ([]*OpeningHours, error)
+	ReplaceOpeningHours(ctx context.Context, businessID int, hours []*OpeningHours) error
+	GetHolidays(ctx context.Context, businessID int) ([]*Holiday, error)
+	SaveHoliday(ctx context.Context, holiday *Holiday) error
+	DeleteHoliday(ctx context.Context, businessID int, holidayID int) error
+	GetTimeBlocks(ctx context.Context, businessID int, from time.Time, to time.Time) ([]*TimeBlock, error)
+	SaveTimeBlock(ctx context.Context, block *TimeBlock) error
+	GetEmployees(ctx context.Context, businessID int) ([]*Employee, error)
+	GetEmployee(ctx context.Context, businessID int, employeeID int) (*Employee, error)
+	UpdateEmployee(ctx context.Context, employee *Employee) error
+	GetMessageTemplates(ctx context.Context, businessID int) ([]*MessageTemplate, error)
+	SaveMessageTemplate(ctx context.Context, messageTemplate *MessageTemplate) error
+	DeleteMessageTemplate(ctx context.Context, businessID int, key string) error
 }
 
 type PgBusinessRepository struct {
@@ -25,58 +57,149 @@ func NewPgBusinessRepository(connection *sql.DB) *PgBusinessRepository {
 	}
 }
 
+func (r *PgBusinessRepository) Save(ctx context.Context, business *Business) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return insertBusiness(ctxTimeout, r.connection, business)
+}
+
+// Add stores the business in the transaction of the caller, for the records created with it.
+func (r *PgBusinessRepository) Add(ctx context.Context, tx *sql.Tx, business *Business) error {
+	return insertBusiness(ctx, tx, business)
+}
+
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertBusiness(ctx context.Context, connection rowQueryer, business *Business) error {
+	query := `
+		INSERT INTO ha_business (
+			hab_name,
+			hab_contact_phone,
+			hab_email,
+			hab_address,
+			hab_country,
+			hab_lang,
+			hab_requires_approval,
+			hab_date_add,
+			hab_date_upd
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING hab_id;
+	`
+
+	err := connection.QueryRowContext(
+		ctx,
+		query,
+		business.Name,
+		business.ContactPhone,
+		business.Email,
+		business.Address,
+		business.Country,
+		business.Lang,
+		business.RequiresApproval,
+		business.DateAdd,
+		business.DateUpd,
+	).Scan(&business.Id)
+
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return BusinessAlreadyExists
+		}
+
+		return eris.Wrap(err, "Error saving business")
+	}
+
+	return nil
+}
+
+func (r *PgBusinessRepository) Update(ctx context.Context, business *Business) error {
+	query := `
+		UPDATE ha_business
+		SET
+			hab_name = $2,
+			hab_contact_phone = $3,
+			hab_email = $4,
+			hab_address = $5,
+			hab_country = $6,
+			hab_lang = $7,
+			hab_requires_approval = $8,
+			hab_date_upd = $9
+		WHERE
+			hab_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		business.Id,
+		business.Name,
+		business.ContactPhone,
+		business.Email,
+		business.Address,
+		business.Country,
+		business.Lang,
+		business.RequiresApproval,
+		business.DateUpd,
+	)
+
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return BusinessAlreadyExists
+		}
+
+		return eris.Wrap(err, "Error updating business")
+	}
+
+	return nil
+}
+
 func (r *PgBusinessRepository) GetByID(ctx context.Context, ID int) (*Business, error) {
 	query := `
 		SELECT
-			a.ta_id,
-			a.ta_auction_id,
-			a.ta_tibia_auction_link,
-			a.ta_img,
-			a.ta_char_name,
-			a.ta_char_level,
-			v.*,
-			g.*,
-			w.*,
-			ts.*,
-			a.ta_world_transfer,
-			a.ta_boss_points,
-			a.ta_charm_expansion,
-			a.ta_charm_points,
-			a.ta_task_expansion,
-			a.ta_current_bid,
-			a.ta_current_bid_fiat,
-			a.ta_current_bid_currency,
-			a.ta_auction_stage,
-			a.ta_auction_start,
-			a.ta_auction_end,
-			tar.tar_status,
-			tar.tar_date_add,
-			tar.tar_date_upd
+			hab_id,
+			hab_name,
+			hab_contact_phone,
+			hab_email,
+			hab_address,
+			hab_country,
+			COALESCE(hab_lang, ''),
+			hab_requires_approval,
+			COALESCE(hab_channel_id, 0),
+			COALESCE(hab_channel_name, ''),
+			COALESCE(hab_channel_link, ''),
+			hab_date_add,
+			hab_date_upd
 		FROM
-			tc_auction a
-		INNER JOIN
-			tc_vocation v ON a.ta_char_vocation = v.tv_id
-		INNER JOIN
-			tc_gender g ON a.ta_char_gender = g.tg_id
-		INNER JOIN
-			tc_world w ON a.ta_char_world = w.tw_id
-		INNER JOIN
-			tc_auction_recording tar ON a.ta_id = tar.tar_recordable_id
-		INNER JOIN
-			tc_skills ts ON a.ta_auction_id = ts.ts_auction_id
+			ha_business
 		WHERE
-			a.ta_auction_id = $1;
+			hab_id = $1;
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
-	var (
-		business Business
-	)
+	var business Business
 
 	err := r.connection.QueryRowContext(ctxTimeout, query, ID).Scan(
 		&business.Id,
+		&business.Name,
+		&business.ContactPhone,
+		&business.Email,
+		&business.Address,
+		&business.Country,
+		&business.Lang,
+		&business.RequiresApproval,
+		&business.ChannelID,
+		&business.ChannelName,
+		&business.ChannelLink,
+		&business.DateAdd,
+		&business.DateUpd,
 	)
 
 	if err != nil {
@@ -89,3 +212,786 @@ func (r *PgBusinessRepository) GetByID(ctx context.Context, ID int) (*Business,
 
 	return &business, nil
 }
+
+func (r *PgBusinessRepository) GetEmployees(ctx context.Context, businessID int) (employees []*Employee, err error) {
+	query := `
+		SELECT
+			hae_id,
+			hae_name,
+			hae_business_id,
+			COALESCE(hae_calendar_id, ''),
+			hae_date_add,
+			hae_date_upd
+		FROM
+			ha_employees
+		WHERE
+			hae_business_id = $1
+		ORDER BY
+			hae_name;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query business employees")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	for rows.Next() {
+		var employee Employee
+
+		err := rows.Scan(
+			&employee.Id,
+			&employee.Name,
+			&employee.BusinessId,
+			&employee.CalendarId,
+			&employee.DateAdd,
+			&employee.DateUpd,
+		)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to scan employee")
+		}
+
+		employees = append(employees, &employee)
+	}
+
+	return employees, nil
+}
+
+func (r *PgBusinessRepository) GetEmployee(ctx context.Context, businessID int, employeeID int) (*Employee, error) {
+	query := `
+		SELECT
+			hae_id,
+			hae_name,
+			hae_business_id,
+			COALESCE(hae_calendar_id, ''),
+			hae_date_add,
+			hae_date_upd
+		FROM
+			ha_employees
+		WHERE
+			hae_business_id = $1 AND hae_id = $2;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var employee Employee
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, businessID, employeeID).Scan(
+		&employee.Id,
+		&employee.Name,
+		&employee.BusinessId,
+		&employee.CalendarId,
+		&employee.DateAdd,
+		&employee.DateUpd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, EmployeeNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query employee by ID")
+	}
+
+	return &employee, nil
+}
+
+func (r *PgBusinessRepository) UpdateEmployee(ctx context.Context, employee *Employee) error {
+	query := `
+		UPDATE ha_employees
+		SET
+			hae_name = $3,
+			hae_calendar_id = NULLIF($4, ''),
+			hae_date_upd = $5
+		WHERE
+			hae_business_id = $1 AND hae_id = $2;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		employee.BusinessId,
+		employee.Id,
+		employee.Name,
+		employee.CalendarId,
+		employee.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error updating employee")
+	}
+
+	return nil
+}
+
+/*
+================================================================================
+SERVICE CATALOG
+================================================================================
+*/
+
+// UpdateChannel points the business to its channel. A channel belongs to a single business, so
+// it is taken away from any other business that had it.
+func (r *PgBusinessRepository) UpdateChannel(ctx context.Context, business *Business) error {
+	releaseQuery := `
+		UPDATE ha_business
+		SET
+			hab_channel_id = NULL,
+			hab_channel_name = NULL,
+			hab_channel_link = NULL,
+			hab_date_upd = $2
+		WHERE
+			hab_channel_id = $1;
+	`
+
+	updateQuery := `
+		UPDATE ha_business
+		SET
+			hab_channel_id = $2,
+			hab_channel_name = NULLIF($3, ''),
+			hab_channel_link = NULLIF($4, ''),
+			hab_date_upd = $5
+		WHERE
+			hab_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctxTimeout, releaseQuery, business.ChannelID, business.DateUpd); err != nil {
+			return eris.Wrap(err, "Error releasing channel")
+		}
+
+		_, err := tx.ExecContext(
+			ctxTimeout,
+			updateQuery,
+			business.Id,
+			business.ChannelID,
+			business.ChannelName,
+			business.ChannelLink,
+			business.DateUpd,
+		)
+
+		if err != nil {
+			return eris.Wrap(err, "Error updating business channel")
+		}
+
+		return nil
+	})
+}
+
+func (r *PgBusinessRepository) ClearChannel(ctx context.Context, channelID int) error {
+	query := `
+		UPDATE ha_business
+		SET
+			hab_channel_id = NULL,
+			hab_channel_name = NULL,
+			hab_channel_link = NULL,
+			hab_date_upd = $2
+		WHERE
+			hab_channel_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := r.connection.ExecContext(ctxTimeout, query, channelID, time.Now().UTC()); err != nil {
+		return eris.Wrap(err, "Error clearing business channel")
+	}
+
+	return nil
+}
+
+func (r *PgBusinessRepository) GetServices(ctx context.Context, businessID int) (services []*ServiceCatalog, err error) {
+	query := `
+		SELECT
+			hasc_id,
+			hasc_name,
+			hasc_price,
+			hasc_currency,
+			COALESCE(hasc_duration, ''),
+			COALESCE(hasc_category, ''),
+			hasc_business_id,
+			hasc_date_add,
+			hasc_date_upd
+		FROM
+			ha_service_catalog
+		WHERE
+			hasc_business_id = $1
+		ORDER BY
+			COALESCE(hasc_category, ''),
+			hasc_name;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query business services")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	services = make([]*ServiceCatalog, 0)
+
+	for rows.Next() {
+		service, err := scanService(rows)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to scan service")
+		}
+
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+func (r *PgBusinessRepository) GetService(ctx context.Context, businessID int, serviceID int) (*ServiceCatalog, error) {
+	query := `
+		SELECT
+			hasc_id,
+			hasc_name,
+			hasc_price,
+			hasc_currency,
+			COALESCE(hasc_duration, ''),
+			COALESCE(hasc_category, ''),
+			hasc_business_id,
+			hasc_date_add,
+			hasc_date_upd
+		FROM
+			ha_service_catalog
+		WHERE
+			hasc_business_id = $1 AND hasc_id = $2;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	service, err := scanService(r.connection.QueryRowContext(ctxTimeout, query, businessID, serviceID))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ServiceNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query service by ID")
+	}
+
+	return service, nil
+}
+
+func (r *PgBusinessRepository) SaveService(ctx context.Context, service *ServiceCatalog) error {
+	query := `
+		INSERT INTO ha_service_catalog (
+			hasc_name,
+			hasc_price,
+			hasc_currency,
+			hasc_duration,
+			hasc_category,
+			hasc_business_id,
+			hasc_date_add,
+			hasc_date_upd
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		RETURNING hasc_id;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	err := r.connection.QueryRowContext(
+		ctxTimeout,
+		query,
+		service.Name,
+		service.Price,
+		service.Currency,
+		strconv.Itoa(service.Duration),
+		service.Category,
+		service.BusinessId,
+		service.DateAdd,
+		service.DateUpd,
+	).Scan(&service.Id)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving service")
+	}
+
+	return nil
+}
+
+func (r *PgBusinessRepository) UpdateService(ctx context.Context, service *ServiceCatalog) error {
+	query := `
+		UPDATE ha_service_catalog
+		SET
+			hasc_name = $3,
+			hasc_price = $4,
+			hasc_currency = $5,
+			hasc_duration = $6,
+			hasc_category = NULLIF($7, ''),
+			hasc_date_upd = $8
+		WHERE
+			hasc_business_id = $1 AND hasc_id = $2;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		service.BusinessId,
+		service.Id,
+		service.Name,
+		service.Price,
+		service.Currency,
+		strconv.Itoa(service.Duration),
+		service.Category,
+		service.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error updating service")
+	}
+
+	return nil
+}
+
+func (r *PgBusinessRepository) DeleteService(ctx context.Context, businessID int, serviceID int) error {
+	query := `DELETE FROM ha_service_catalog WHERE hasc_business_id = $1 AND hasc_id = $2;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	result, err := r.connection.ExecContext(ctxTimeout, query, businessID, serviceID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error deleting service")
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ServiceNotFound
+	}
+
+	return nil
+}
+
+func scanService(row rowScanner) (*ServiceCatalog, error) {
+	var (
+		service  ServiceCatalog
+		duration string
+	)
+
+	err := row.Scan(
+		&service.Id,
+		&service.Name,
+		&service.Price,
+		&service.Currency,
+		&duration,
+		&service.Category,
+		&service.BusinessId,
+		&service.DateAdd,
+		&service.DateUpd,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Durations written before the API existed may be empty or not numeric.
+	service.Duration, _ = strconv.Atoi(duration)
+
+	return &service, nil
+}
+
+/*
+================================================================================
+OPENING HOURS AND HOLIDAYS
+================================================================================
+*/
+
+func (r *PgBusinessRepository) GetOpeningHours(ctx context.Context, businessID int) (hours []*OpeningHours, err error) {
+	query := `
+		SELECT
+			haoh_weekday,
+			TO_CHAR(haoh_open, 'HH24:MI'),
+			TO_CHAR(haoh_close, 'HH24:MI')
+		FROM
+			ha_open_hours
+		WHERE
+			haoh_business_id = $1
+		ORDER BY
+			haoh_weekday, haoh_open;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query opening hours")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	hours = make([]*OpeningHours, 0)
+
+	for rows.Next() {
+		var hour OpeningHours
+
+		if err := rows.Scan(&hour.Weekday, &hour.Open, &hour.Close); err != nil {
+			return nil, eris.Wrap(err, "Failed to scan opening hours")
+		}
+
+		hours = append(hours, &hour)
+	}
+
+	return hours, nil
+}
+
+// ReplaceOpeningHours swaps the whole weekly schedule of the business in one transaction.
+func (r *PgBusinessRepository) ReplaceOpeningHours(ctx context.Context, businessID int, hours []*OpeningHours) error {
+	deleteQuery := `DELETE FROM ha_open_hours WHERE haoh_business_id = $1;`
+
+	insertQuery := `
+		INSERT INTO ha_open_hours (
+			haoh_business_id,
+			haoh_weekday,
+			haoh_open,
+			haoh_close,
+			haoh_date_add,
+			haoh_date_upd
+		)
+		VALUES ($1, $2, $3, $4, $5, $5);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctxTimeout, deleteQuery, businessID); err != nil {
+			return eris.Wrap(err, "Error deleting opening hours")
+		}
+
+		for _, hour := range hours {
+			if _, err := tx.ExecContext(ctxTimeout, insertQuery, businessID, hour.Weekday, hour.Open, hour.Close, now); err != nil {
+				return eris.Wrap(err, "Error saving opening hours")
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *PgBusinessRepository) GetHolidays(ctx context.Context, businessID int) (holidays []*Holiday, err error) {
+	query := `
+		SELECT
+			habh_id,
+			habh_business_id,
+			TO_CHAR(habh_date, 'YYYY-MM-DD'),
+			COALESCE(habh_name, '')
+		FROM
+			ha_business_holidays
+		WHERE
+			habh_business_id = $1
+		ORDER BY
+			habh_date;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query holidays")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	holidays = make([]*Holiday, 0)
+
+	for rows.Next() {
+		var holiday Holiday
+
+		if err := rows.Scan(&holiday.Id, &holiday.BusinessId, &holiday.Date, &holiday.Name); err != nil {
+			return nil, eris.Wrap(err, "Failed to scan holiday")
+		}
+
+		holidays = append(holidays, &holiday)
+	}
+
+	return holidays, nil
+}
+
+// SaveHoliday stores the holiday, renaming it when the business already closes that day.
+func (r *PgBusinessRepository) SaveHoliday(ctx context.Context, holiday *Holiday) error {
+	query := `
+		INSERT INTO ha_business_holidays (
+			habh_business_id,
+			habh_date,
+			habh_name,
+			habh_date_add,
+			habh_date_upd
+		)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $4)
+		ON CONFLICT (habh_business_id, habh_date) DO UPDATE SET
+			habh_name = EXCLUDED.habh_name,
+			habh_date_upd = EXCLUDED.habh_date_upd
+		RETURNING habh_id;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	err := r.connection.QueryRowContext(
+		ctxTimeout,
+		query,
+		holiday.BusinessId,
+		holiday.Date,
+		holiday.Name,
+		time.Now().UTC(),
+	).Scan(&holiday.Id)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving holiday")
+	}
+
+	return nil
+}
+
+func (r *PgBusinessRepository) DeleteHoliday(ctx context.Context, businessID int, holidayID int) error {
+	query := `DELETE FROM ha_business_holidays WHERE habh_business_id = $1 AND habh_id = $2;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	result, err := r.connection.ExecContext(ctxTimeout, query, businessID, holidayID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error deleting holiday")
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return HolidayNotFound
+	}
+
+	return nil
+}
+
+// GetTimeBlocks returns the blocks of the business that overlap the range between from and to.
+func (r *PgBusinessRepository) GetTimeBlocks(
+	ctx context.Context,
+	businessID int,
+	from time.Time,
+	to time.Time,
+) (blocks []*TimeBlock, err error) {
+	query := `
+		SELECT
+			habb_id,
+			habb_business_id,
+			habb_start,
+			habb_end,
+			COALESCE(habb_reason, ''),
+			habb_date_add
+		FROM
+			ha_business_blocks
+		WHERE
+			habb_business_id = $1
+			AND habb_start < $3
+			AND habb_end > $2
+		ORDER BY
+			habb_start;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID, from, to)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query time blocks")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	blocks = make([]*TimeBlock, 0)
+
+	for rows.Next() {
+		var block TimeBlock
+
+		err := rows.Scan(&block.Id, &block.BusinessId, &block.Start, &block.End, &block.Reason, &block.DateAdd)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to scan time block")
+		}
+
+		blocks = append(blocks, &block)
+	}
+
+	return blocks, nil
+}
+
+func (r *PgBusinessRepository) SaveTimeBlock(ctx context.Context, block *TimeBlock) error {
+	query := `
+		INSERT INTO ha_business_blocks (
+			habb_business_id,
+			habb_start,
+			habb_end,
+			habb_reason,
+			habb_date_add
+		)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING habb_id;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	err := r.connection.QueryRowContext(
+		ctxTimeout,
+		query,
+		block.BusinessId,
+		block.Start,
+		block.End,
+		block.Reason,
+		block.DateAdd,
+	).Scan(&block.Id)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving time block")
+	}
+
+	return nil
+}
+
+/*
+================================================================================
+MESSAGE TEMPLATES
+================================================================================
+*/
+
+func (r *PgBusinessRepository) GetMessageTemplates(
+	ctx context.Context,
+	businessID int,
+) (templates []*MessageTemplate, err error) {
+	query := `
+		SELECT
+			habmt_business_id,
+			habmt_key,
+			habmt_body,
+			habmt_date_upd
+		FROM
+			ha_business_message_templates
+		WHERE
+			habmt_business_id = $1
+		ORDER BY
+			habmt_key;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query message templates")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	templates = make([]*MessageTemplate, 0)
+
+	for rows.Next() {
+		var messageTemplate MessageTemplate
+
+		err := rows.Scan(
+			&messageTemplate.BusinessId,
+			&messageTemplate.Key,
+			&messageTemplate.Body,
+			&messageTemplate.DateUpd,
+		)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to scan message template")
+		}
+
+		templates = append(templates, &messageTemplate)
+	}
+
+	return templates, nil
+}
+
+// SaveMessageTemplate stores the template, replacing the one the business had for the message.
+func (r *PgBusinessRepository) SaveMessageTemplate(ctx context.Context, messageTemplate *MessageTemplate) error {
+	query := `
+		INSERT INTO ha_business_message_templates (
+			habmt_business_id,
+			habmt_key,
+			habmt_body,
+			habmt_date_add,
+			habmt_date_upd
+		)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (habmt_business_id, habmt_key) DO UPDATE SET
+			habmt_body = EXCLUDED.habmt_body,
+			habmt_date_upd = EXCLUDED.habmt_date_upd;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		messageTemplate.BusinessId,
+		messageTemplate.Key,
+		messageTemplate.Body,
+		messageTemplate.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving message template")
+	}
+
+	return nil
+}
+
+func (r *PgBusinessRepository) DeleteMessageTemplate(ctx context.Context, businessID int, key string) error {
+	query := `DELETE FROM ha_business_message_templates WHERE habmt_business_id = $1 AND habmt_key = $2;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	result, err := r.connection.ExecContext(ctxTimeout, query, businessID, key)
+
+	if err != nil {
+		return eris.Wrap(err, "Error deleting message template")
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return MessageTemplateNotFound
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
@@ -3,12 +3,35 @@ package business
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/rotisserie/eris"
 )
 
 type BusinessService interface {
+	CreateBusiness(ctx context.Context, business *Business) error
+	UpdateBusiness(ctx context.Context, business *Business) (*Business, error)
 	GetBusinessByID(ctx context.Context, ID int) (*Business, error)
+	LinkChannel(ctx context.Context, businessID int, channelID int, name string, link string) (*Business, error)
+	UnlinkChannel(ctx context.Context, channelID int) error
+	GetServices(ctx context.Context, businessID int) ([]*ServiceCatalog, error)
+	CreateService(ctx context.Context, service *ServiceCatalog) error
+	UpdateService(ctx context.Context, service *ServiceCatalog) (*ServiceCatalog, error)
+	DeleteService(ctx context.Context, businessID int, serviceID int) error
+	GetOpeningHours(ctx context.Context, businessID int) ([]*OpeningHours, error)
+	SetOpeningHours(ctx context.Context, businessID int, hours []*OpeningHours) error
+	GetHolidays(ctx context.Context, businessID int) ([]*Holiday, error)
+	AddHoliday(ctx context.Context, holiday *Holiday) error
+	DeleteHoliday(ctx context.Context, businessID int, holidayID int) error
+	GetTimeBlocks(ctx context.Context, businessID int, from time.Time, to time.Time) ([]*TimeBlock, error)
+	AddTimeBlock(ctx context.Context, block *TimeBlock) error
+	GetEmployees(ctx context.Context, businessID int) ([]*Employee, error)
+	GetEmployee(ctx context.Context, businessID int, employeeID int) (*Employee, error)
+	AssignEmployeeCalendar(ctx context.Context, businessID int, employeeID int, calendarID string) (*Employee, error)
+	GetMessageTemplates(ctx context.Context, businessID int) ([]*MessageTemplate, error)
+	SetMessageTemplate(ctx context.Context, messageTemplate *MessageTemplate) error
+	DeleteMessageTemplate(ctx context.Context, businessID int, key string) error
+	RenderMessage(ctx context.Context, businessID int, key string, data TemplateData) (string, bool, error)
 }
 
 type Service struct {
@@ -23,6 +46,43 @@ func NewService(logger *slog.Logger, repo BusinessRepository) *Service {
 	}
 }
 
+func (s *Service) CreateBusiness(ctx context.Context, business *Business) error {
+	business.DateAdd = time.Now().UTC()
+	business.DateUpd = time.Now().UTC()
+
+	if err := s.repo.Save(ctx, business); err != nil {
+		return eris.Wrap(err, "Error creating business")
+	}
+
+	s.logger.Info("Business created", "business_id", business.Id)
+
+	return nil
+}
+
+// UpdateBusiness overwrites the profile of an existing business with the given one.
+func (s *Service) UpdateBusiness(ctx context.Context, business *Business) (*Business, error) {
+	current, err := s.repo.GetByID(ctx, business.Id)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching business by ID")
+	}
+
+	current.Name = business.Name
+	current.ContactPhone = business.ContactPhone
+	current.Email = business.Email
+	current.Address = business.Address
+	current.Country = business.Country
+	current.Lang = business.Lang
+	current.RequiresApproval = business.RequiresApproval
+	current.DateUpd = time.Now().UTC()
+
+	if err := s.repo.Update(ctx, current); err != nil {
+		return nil, eris.Wrap(err, "Error updating business")
+	}
+
+	return current, nil
+}
+
 func (s *Service) GetBusinessByID(ctx context.Context, ID int) (*Business, error) {
 	business, err := s.repo.GetByID(ctx, ID)
 
@@ -32,3 +92,282 @@ func (s *Service) GetBusinessByID(ctx context.Context, ID int) (*Business, error
 
 	return business, nil
 }
+
+func (s *Service) LinkChannel(ctx context.Context, businessID int, channelID int, name string, link string) (*Business, error) {
+	business, err := s.repo.GetByID(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching business by ID")
+	}
+
+	business.ChannelID = channelID
+	business.ChannelName = name
+	business.ChannelLink = link
+	business.DateUpd = time.Now().UTC()
+
+	if err := s.repo.UpdateChannel(ctx, business); err != nil {
+		return nil, eris.Wrap(err, "Error linking the channel to the business")
+	}
+
+	return business, nil
+}
+
+func (s *Service) UnlinkChannel(ctx context.Context, channelID int) error {
+	if err := s.repo.ClearChannel(ctx, channelID); err != nil {
+		return eris.Wrap(err, "Error unlinking the channel")
+	}
+
+	return nil
+}
+
+func (s *Service) GetEmployees(ctx context.Context, businessID int) ([]*Employee, error) {
+	employees, err := s.repo.GetEmployees(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching business employees")
+	}
+
+	return employees, nil
+}
+
+func (s *Service) GetEmployee(ctx context.Context, businessID int, employeeID int) (*Employee, error) {
+	employee, err := s.repo.GetEmployee(ctx, businessID, employeeID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching employee by ID")
+	}
+
+	return employee, nil
+}
+
+func (s *Service) AssignEmployeeCalendar(ctx context.Context, businessID int, employeeID int, calendarID string) (*Employee, error) {
+	employee, err := s.repo.GetEmployee(ctx, businessID, employeeID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching employee by ID")
+	}
+
+	employee.CalendarId = calendarID
+	employee.DateUpd = time.Now().UTC()
+
+	if err := s.repo.UpdateEmployee(ctx, employee); err != nil {
+		return nil, eris.Wrap(err, "Error assigning the calendar to the employee")
+	}
+
+	return employee, nil
+}
+
+/*
+================================================================================
+SERVICE CATALOG
+================================================================================
+*/
+
+func (s *Service) GetServices(ctx context.Context, businessID int) ([]*ServiceCatalog, error) {
+	services, err := s.repo.GetServices(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching business services")
+	}
+
+	return services, nil
+}
+
+func (s *Service) CreateService(ctx context.Context, service *ServiceCatalog) error {
+	if _, err := s.repo.GetByID(ctx, service.BusinessId); err != nil {
+		return eris.Wrap(err, "Error fetching business by ID")
+	}
+
+	service.DateAdd = time.Now().UTC()
+	service.DateUpd = time.Now().UTC()
+
+	if err := s.repo.SaveService(ctx, service); err != nil {
+		return eris.Wrap(err, "Error creating service")
+	}
+
+	return nil
+}
+
+func (s *Service) UpdateService(ctx context.Context, service *ServiceCatalog) (*ServiceCatalog, error) {
+	current, err := s.repo.GetService(ctx, service.BusinessId, service.Id)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching service by ID")
+	}
+
+	current.Name = service.Name
+	current.Price = service.Price
+	current.Currency = service.Currency
+	current.Duration = service.Duration
+	current.Category = service.Category
+	current.DateUpd = time.Now().UTC()
+
+	if err := s.repo.UpdateService(ctx, current); err != nil {
+		return nil, eris.Wrap(err, "Error updating service")
+	}
+
+	return current, nil
+}
+
+func (s *Service) DeleteService(ctx context.Context, businessID int, serviceID int) error {
+	if err := s.repo.DeleteService(ctx, businessID, serviceID); err != nil {
+		return eris.Wrap(err, "Error deleting service")
+	}
+
+	return nil
+}
+
+/*
+================================================================================
+OPENING HOURS AND HOLIDAYS
+================================================================================
+*/
+
+func (s *Service) GetOpeningHours(ctx context.Context, businessID int) ([]*OpeningHours, error) {
+	hours, err := s.repo.GetOpeningHours(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching opening hours")
+	}
+
+	return hours, nil
+}
+
+// SetOpeningHours replaces the weekly schedule of the business.
+func (s *Service) SetOpeningHours(ctx context.Context, businessID int, hours []*OpeningHours) error {
+	if err := ValidateOpeningHours(hours); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetByID(ctx, businessID); err != nil {
+		return eris.Wrap(err, "Error fetching business by ID")
+	}
+
+	if err := s.repo.ReplaceOpeningHours(ctx, businessID, hours); err != nil {
+		return eris.Wrap(err, "Error storing opening hours")
+	}
+
+	return nil
+}
+
+func (s *Service) GetHolidays(ctx context.Context, businessID int) ([]*Holiday, error) {
+	holidays, err := s.repo.GetHolidays(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching holidays")
+	}
+
+	return holidays, nil
+}
+
+func (s *Service) AddHoliday(ctx context.Context, holiday *Holiday) error {
+	if _, err := s.repo.GetByID(ctx, holiday.BusinessId); err != nil {
+		return eris.Wrap(err, "Error fetching business by ID")
+	}
+
+	if err := s.repo.SaveHoliday(ctx, holiday); err != nil {
+		return eris.Wrap(err, "Error storing holiday")
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteHoliday(ctx context.Context, businessID int, holidayID int) error {
+	if err := s.repo.DeleteHoliday(ctx, businessID, holidayID); err != nil {
+		return eris.Wrap(err, "Error deleting holiday")
+	}
+
+	return nil
+}
+
+func (s *Service) GetTimeBlocks(ctx context.Context, businessID int, from time.Time, to time.Time) ([]*TimeBlock, error) {
+	blocks, err := s.repo.GetTimeBlocks(ctx, businessID, from, to)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching time blocks")
+	}
+
+	return blocks, nil
+}
+
+func (s *Service) AddTimeBlock(ctx context.Context, block *TimeBlock) error {
+	if err := s.repo.SaveTimeBlock(ctx, block); err != nil {
+		return eris.Wrap(err, "Error storing time block")
+	}
+
+	return nil
+}
+
+/*
+================================================================================
+MESSAGE TEMPLATES
+================================================================================
+*/
+
+func (s *Service) GetMessageTemplates(ctx context.Context, businessID int) ([]*MessageTemplate, error) {
+	templates, err := s.repo.GetMessageTemplates(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching message templates")
+	}
+
+	return templates, nil
+}
+
+// SetMessageTemplate stores the text the business wants for one of its messages once it renders
+// with the sample data.
+func (s *Service) SetMessageTemplate(ctx context.Context, messageTemplate *MessageTemplate) error {
+	if err := ValidateMessageTemplate(messageTemplate); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetByID(ctx, messageTemplate.BusinessId); err != nil {
+		return eris.Wrap(err, "Error fetching business by ID")
+	}
+
+	messageTemplate.DateUpd = time.Now().UTC()
+
+	if err := s.repo.SaveMessageTemplate(ctx, messageTemplate); err != nil {
+		return eris.Wrap(err, "Error storing message template")
+	}
+
+	return nil
+}
+
+// DeleteMessageTemplate brings the catalog text of the message back.
+func (s *Service) DeleteMessageTemplate(ctx context.Context, businessID int, key string) error {
+	if err := s.repo.DeleteMessageTemplate(ctx, businessID, key); err != nil {
+		return eris.Wrap(err, "Error deleting message template")
+	}
+
+	return nil
+}
+
+// RenderMessage writes the template of the business for the message, false when it has none and
+// the catalog text must be used. A template that no longer renders is logged and skipped, the
+// customer still gets the catalog text.
+func (s *Service) RenderMessage(ctx context.Context, businessID int, key string, data TemplateData) (string, bool, error) {
+	templates, err := s.repo.GetMessageTemplates(ctx, businessID)
+
+	if err != nil {
+		return "", false, eris.Wrap(err, "Error fetching message templates")
+	}
+
+	for _, messageTemplate := range templates {
+		if messageTemplate.Key != key {
+			continue
+		}
+
+		rendered, err := RenderMessageTemplate(messageTemplate.Body, data)
+
+		if err != nil {
+			s.logger.Warn("Message template does not render", "business_id", businessID, "key", key, "error", err.Error())
+
+			return "", false, nil
+		}
+
+		return rendered, true, nil
+	}
+
+	return "", false, nil
+}
@@ -0,0 +1,174 @@
+package business
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+var InvalidMessageTemplate = eris.New("Invalid message template")
+
+// The messages a business can write in its own tone, every other text comes from the catalog.
+const (
+	TemplateWelcome      = "welcome"
+	TemplateConfirmation = "confirmation"
+	TemplateReminder     = "reminder"
+)
+
+var MessageTemplateKeys = []string{TemplateWelcome, TemplateConfirmation, TemplateReminder}
+
+// A template longer than this is not a chat message any more.
+const maxTemplateLength = 1000
+
+// MessageTemplate overrides the catalog text of one of the messages of a business. Body is a Go
+// text/template restricted to the variables of TemplateData, conditionals included, e.g.
+// "Hola {{.Customer}}, te esperamos en {{.Business}}{{if .Service}} para {{.Service}}{{end}}".
+type MessageTemplate struct {
+	BusinessId int       `json:"businessId"`
+	Key        string    `json:"key"`
+	Body       string    `json:"body"`
+	DateUpd    time.Time `json:"updatedAt"`
+}
+
+// TemplateData is everything a message template can use. All the fields are written for the
+// customer already, dates and hours in their language, and any of them can be empty, e.g. there
+// is no service or date yet when the welcome is sent.
+type TemplateData struct {
+	Customer string
+	Business string
+	Service  string
+	Date     string
+	Hour     string
+}
+
+// SampleTemplateData is what a template is rendered with when it is saved, to reject it before a
+// customer ever gets a broken message.
+var SampleTemplateData = TemplateData{
+	Customer: "Ana",
+	Business: "Hastypal",
+	Service:  "Corte de pelo",
+	Date:     "lunes 5 de enero",
+	Hour:     "10:30",
+}
+
+func IsMessageTemplateKey(key string) bool {
+	for _, candidate := range MessageTemplateKeys {
+		if candidate == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateMessageTemplate checks the key is one of the customisable messages and that the body
+// parses, uses only the supported variables and renders a text with the sample data.
+func ValidateMessageTemplate(messageTemplate *MessageTemplate) error {
+	if !IsMessageTemplateKey(messageTemplate.Key) {
+		return eris.Wrapf(InvalidMessageTemplate, "Unknown message %s", messageTemplate.Key)
+	}
+
+	if len([]rune(messageTemplate.Body)) > maxTemplateLength {
+		return eris.Wrapf(InvalidMessageTemplate, "Longer than %d characters", maxTemplateLength)
+	}
+
+	rendered, err := RenderMessageTemplate(messageTemplate.Body, SampleTemplateData)
+
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(rendered) == "" {
+		return eris.Wrap(InvalidMessageTemplate, "Renders an empty message")
+	}
+
+	return nil
+}
+
+// RenderMessageTemplate writes the body with the data. A missing variable is an error instead of
+// the "<no value>" text/template would write.
+func RenderMessageTemplate(body string, data TemplateData) (string, error) {
+	parsed, err := template.New("message").Option("missingkey=error").Parse(body)
+
+	if err != nil {
+		return "", eris.Wrap(InvalidMessageTemplate, cleanTemplateError(err))
+	}
+
+	if err := checkTemplateNodes(parsed.Root); err != nil {
+		return "", err
+	}
+
+	var rendered bytes.Buffer
+
+	if err := parsed.Execute(&rendered, data); err != nil {
+		return "", eris.Wrap(InvalidMessageTemplate, cleanTemplateError(err))
+	}
+
+	return rendered.String(), nil
+}
+
+// checkTemplateNodes only lets through text, variables and if/else on variables. Loops, nested
+// templates, functions and method calls have no use in a message and could be abused to keep
+// the bot busy, so they are rejected.
+func checkTemplateNodes(node parse.Node) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+
+		for _, child := range node.Nodes {
+			if err := checkTemplateNodes(child); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case *parse.TextNode, *parse.CommentNode:
+		return nil
+	case *parse.ActionNode:
+		return checkTemplatePipe(node.Pipe)
+	case *parse.IfNode:
+		if err := checkTemplatePipe(node.Pipe); err != nil {
+			return err
+		}
+
+		if err := checkTemplateNodes(node.List); err != nil {
+			return err
+		}
+
+		return checkTemplateNodes(node.ElseList)
+	default:
+		return eris.Wrapf(InvalidMessageTemplate, "Unsupported action %s", node)
+	}
+}
+
+// checkTemplatePipe accepts a single variable of TemplateData, e.g. {{.Customer}}.
+func checkTemplatePipe(pipe *parse.PipeNode) error {
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return eris.Wrapf(InvalidMessageTemplate, "Unsupported action {{%s}}", pipe)
+	}
+
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+
+	if !ok || len(field.Ident) != 1 {
+		return eris.Wrapf(InvalidMessageTemplate, "Unsupported action {{%s}}", pipe)
+	}
+
+	// Checked here and not only when rendering, the sample data never reaches both sides of an if.
+	if _, known := reflect.TypeOf(TemplateData{}).FieldByName(field.Ident[0]); !known {
+		return eris.Wrapf(InvalidMessageTemplate, "Unknown variable %s", field)
+	}
+
+	return nil
+}
+
+// cleanTemplateError drops the "template: message:" prefix text/template puts in its errors.
+func cleanTemplateError(err error) string {
+	return strings.TrimSpace(strings.TrimPrefix(err.Error(), "template: message:"))
+}
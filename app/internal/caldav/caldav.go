@@ -0,0 +1,62 @@
+package caldav
+
+import (
+	"encoding/xml"
+
+	"github.com/rotisserie/eris"
+)
+
+var NotACalendar = eris.New("The URL is not a CalDAV calendar collection")
+
+const (
+	icsContentType  = "text/calendar; charset=utf-8"
+	xmlContentType  = "application/xml; charset=utf-8"
+	timeRangeLayout = "20060102T150405Z"
+)
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8" ?>
+<D:propfind xmlns:D="DAV:">
+  <D:prop>
+    <D:resourcetype/>
+  </D:prop>
+</D:propfind>`
+
+// calendarQueryBody asks the server for the events overlapping the time range, with the
+// recurring ones already expanded into single instances.
+const calendarQueryBody = `<?xml version="1.0" encoding="utf-8" ?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data>
+      <C:expand start="%[1]s" end="%[2]s"/>
+    </C:calendar-data>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="%[1]s" end="%[2]s"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+// multistatus is the 207 answer of PROPFIND and REPORT requests. Elements are matched by local
+// name, servers are free to pick their namespace prefixes.
+type multistatus struct {
+	XMLName   xml.Name   `xml:"multistatus"`
+	Responses []response `xml:"response"`
+}
+
+type response struct {
+	Href      string     `xml:"href"`
+	Propstats []propstat `xml:"propstat"`
+}
+
+type propstat struct {
+	Status string `xml:"status"`
+	Prop   struct {
+		CalendarData string `xml:"calendar-data"`
+		ResourceType struct {
+			Calendar *struct{} `xml:"calendar"`
+		} `xml:"resourcetype"`
+	} `xml:"prop"`
+}
@@ -0,0 +1,335 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/pkg/ics"
+	"github.com/rotisserie/eris"
+)
+
+// Client is the CalDAV implementation of calendar.Provider. Each booking is stored as one
+// {uid}.ics resource inside the calendar collection of the connection.
+type Client struct {
+	logger      *slog.Logger
+	http        *http.Client
+	connections calendar.ConnectionRepository
+}
+
+func NewClient(logger *slog.Logger, connections calendar.ConnectionRepository) *Client {
+	return &Client{
+		logger:      logger,
+		http:        &http.Client{Timeout: 10 * time.Second},
+		connections: connections,
+	}
+}
+
+// Verify checks that the URL of the connection is a calendar collection the credentials can read.
+func (c *Client) Verify(ctx context.Context, connection *calendar.Connection) error {
+	collection, err := url.Parse(withTrailingSlash(connection.Url))
+
+	if err != nil || (collection.Scheme != "https" && collection.Scheme != "http") {
+		return eris.Wrapf(NotACalendar, "Invalid URL %s", connection.Url)
+	}
+
+	status, body, err := c.do(ctx, connection, "PROPFIND", collection, xmlContentType, "0", propfindBody, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusMultiStatus {
+		return eris.Wrapf(NotACalendar, "PROPFIND answered %d", status)
+	}
+
+	var result multistatus
+
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return eris.Wrap(err, "Error decoding the PROPFIND response")
+	}
+
+	for _, response := range result.Responses {
+		for _, propstat := range response.Propstats {
+			if propstat.Prop.ResourceType.Calendar != nil {
+				return nil
+			}
+		}
+	}
+
+	return NotACalendar
+}
+
+// CreateEvent stores the event under the booking ID. An already existing resource means a
+// previous attempt got through, so it is not considered an error.
+func (c *Client) CreateEvent(ctx context.Context, businessID int, event *calendar.Event) (string, error) {
+	connection, collection, err := c.collection(ctx, businessID, event.CalendarID)
+
+	if err != nil {
+		return "", err
+	}
+
+	eventID := event.BookingID
+	headers := map[string]string{"If-None-Match": "*"}
+
+	status, _, err := c.do(ctx, connection, http.MethodPut, eventURL(collection, eventID), icsContentType, "", toIcs(eventID, event), headers)
+
+	if err != nil {
+		return "", err
+	}
+
+	if status == http.StatusPreconditionFailed {
+		c.logger.Info("CalDAV event already exists", "business_id", businessID, "event_id", eventID)
+
+		return eventID, nil
+	}
+
+	if status != http.StatusCreated && status != http.StatusNoContent {
+		return "", eris.Errorf("Error creating the event %s, CalDAV server answered %d", eventID, status)
+	}
+
+	return eventID, nil
+}
+
+func (c *Client) UpdateEvent(ctx context.Context, businessID int, event *calendar.Event) error {
+	connection, collection, err := c.collection(ctx, businessID, event.CalendarID)
+
+	if err != nil {
+		return err
+	}
+
+	status, _, err := c.do(ctx, connection, http.MethodPut, eventURL(collection, event.ID), icsContentType, "", toIcs(event.ID, event), nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusCreated && status != http.StatusNoContent && status != http.StatusOK {
+		return eris.Errorf("Error updating the event %s, CalDAV server answered %d", event.ID, status)
+	}
+
+	return nil
+}
+
+// DeleteEvent removes the event from the collection. Events already deleted by the owner are ignored.
+func (c *Client) DeleteEvent(ctx context.Context, businessID int, calendarID string, eventID string) error {
+	connection, collection, err := c.collection(ctx, businessID, calendarID)
+
+	if err != nil {
+		return err
+	}
+
+	status, _, err := c.do(ctx, connection, http.MethodDelete, eventURL(collection, eventID), "", "", "", nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status >= http.StatusBadRequest && status != http.StatusNotFound && status != http.StatusGone {
+		return eris.Errorf("Error deleting the event %s, CalDAV server answered %d", eventID, status)
+	}
+
+	return nil
+}
+
+// FreeBusy returns the busy periods of every calendar between from and to, keyed by calendar ID.
+// Cancelled and transparent events do not block time.
+func (c *Client) FreeBusy(
+	ctx context.Context,
+	businessID int,
+	calendarIDs []string,
+	from time.Time,
+	to time.Time,
+) (map[string][]calendar.BusyPeriod, error) {
+	location, err := time.LoadLocation(calendar.DefaultTimeZone)
+
+	if err != nil {
+		location = time.UTC
+	}
+
+	query := fmt.Sprintf(calendarQueryBody, from.UTC().Format(timeRangeLayout), to.UTC().Format(timeRangeLayout))
+	busy := make(map[string][]calendar.BusyPeriod, len(calendarIDs))
+
+	for _, calendarID := range calendarIDs {
+		connection, collection, err := c.collection(ctx, businessID, calendarID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		status, body, err := c.do(ctx, connection, "REPORT", collection, xmlContentType, "1", query, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if status != http.StatusMultiStatus {
+			return nil, eris.Errorf("Error querying the calendar %s, CalDAV server answered %d", calendarID, status)
+		}
+
+		var result multistatus
+
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, eris.Wrap(err, "Error decoding the calendar-query response")
+		}
+
+		periods := make([]calendar.BusyPeriod, 0)
+
+		for _, response := range result.Responses {
+			for _, propstat := range response.Propstats {
+				if propstat.Prop.CalendarData == "" {
+					continue
+				}
+
+				events, err := ics.Parse(propstat.Prop.CalendarData, location)
+
+				if err != nil {
+					return nil, eris.Wrapf(err, "Error parsing the event %s", response.Href)
+				}
+
+				for _, event := range events {
+					if event.Status == ics.StatusCancelled || event.Transparent {
+						continue
+					}
+
+					if !event.End.After(from) || !event.Start.Before(to) {
+						continue
+					}
+
+					periods = append(periods, calendar.BusyPeriod{Start: event.Start, End: event.End})
+				}
+			}
+		}
+
+		busy[calendarID] = periods
+	}
+
+	return busy, nil
+}
+
+// collection resolves the calendar ID against the URL of the connection. An empty ID is the
+// collection of the connection itself and IDs pointing to another host are rejected so the
+// credentials are never sent elsewhere.
+func (c *Client) collection(ctx context.Context, businessID int, calendarID string) (*calendar.Connection, *url.URL, error) {
+	connection, err := c.connections.GetByBusinessID(ctx, businessID)
+
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "Error fetching the calendar connection")
+	}
+
+	base, err := url.Parse(withTrailingSlash(connection.Url))
+
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "Error parsing the calendar URL of business %d", businessID)
+	}
+
+	if calendarID == "" {
+		return connection, base, nil
+	}
+
+	reference, err := url.Parse(withTrailingSlash(calendarID))
+
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "Error parsing the calendar ID %s", calendarID)
+	}
+
+	collection := base.ResolveReference(reference)
+
+	if collection.Scheme != base.Scheme || collection.Host != base.Host {
+		return nil, nil, eris.Errorf("Calendar %s is not on the server of the connection", calendarID)
+	}
+
+	return connection, collection, nil
+}
+
+func (c *Client) do(
+	ctx context.Context,
+	connection *calendar.Connection,
+	method string,
+	target *url.URL,
+	contentType string,
+	depth string,
+	body string,
+	headers map[string]string,
+) (int, []byte, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctxTimeout, method, target.String(), strings.NewReader(body))
+
+	if err != nil {
+		return 0, nil, eris.Wrapf(err, "Error building the %s request", method)
+	}
+
+	req.SetBasicAuth(connection.Username, connection.Password)
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if depth != "" {
+		req.Header.Set("Depth", depth)
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := c.http.Do(req)
+
+	if err != nil {
+		return 0, nil, eris.Wrapf(err, "Error sending the %s request to the CalDAV server", method)
+	}
+
+	defer res.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(res.Body, 10<<20))
+
+	if err != nil {
+		return 0, nil, eris.Wrapf(err, "Error reading the %s response", method)
+	}
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return 0, nil, eris.Errorf("CalDAV server rejected the credentials of business %d", connection.BusinessID)
+	}
+
+	return res.StatusCode, responseBody, nil
+}
+
+func toIcs(uid string, event *calendar.Event) string {
+	feed := &ics.Calendar{
+		Events: []*ics.Event{
+			{
+				UID:          uid,
+				Summary:      event.Summary,
+				Description:  event.Description,
+				Location:     event.Location,
+				Status:       ics.StatusConfirmed,
+				Start:        event.Start,
+				End:          event.End,
+				LastModified: time.Now(),
+			},
+		},
+	}
+
+	return feed.Write()
+}
+
+func eventURL(collection *url.URL, eventID string) *url.URL {
+	return collection.JoinPath(eventID + ".ics")
+}
+
+func withTrailingSlash(value string) string {
+	if strings.HasSuffix(value, "/") {
+		return value
+	}
+
+	return value + "/"
+}
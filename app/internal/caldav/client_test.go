@@ -0,0 +1,262 @@
+package caldav
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adriein/hastypal/internal/calendar"
+)
+
+// caldavServer answers every request with the status the test picks for its method and records
+// what it received.
+type caldavServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   map[string]int
+	body     map[string]string
+	requests []*recordedRequest
+}
+
+type recordedRequest struct {
+	method  string
+	path    string
+	header  http.Header
+	body    string
+	user    string
+	pass    string
+	hasAuth bool
+}
+
+func newCaldavServer(t *testing.T) *caldavServer {
+	server := &caldavServer{status: make(map[string]int), body: make(map[string]string)}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		user, pass, hasAuth := r.BasicAuth()
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		server.requests = append(server.requests, &recordedRequest{
+			method:  r.Method,
+			path:    r.URL.Path,
+			header:  r.Header.Clone(),
+			body:    string(body),
+			user:    user,
+			pass:    pass,
+			hasAuth: hasAuth,
+		})
+
+		status, ok := server.status[r.Method]
+
+		if !ok {
+			status = http.StatusNoContent
+		}
+
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, server.body[r.Method])
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *caldavServer) last() *recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[len(s.requests)-1]
+}
+
+type fakeConnections struct {
+	calendar.ConnectionRepository
+	connection *calendar.Connection
+}
+
+func (f *fakeConnections) GetByBusinessID(_ context.Context, businessID int) (*calendar.Connection, error) {
+	if f.connection == nil || f.connection.BusinessID != businessID {
+		return nil, calendar.ConnectionNotFound
+	}
+
+	return f.connection, nil
+}
+
+func caldavClient(server *caldavServer) *Client {
+	return NewClient(slog.New(slog.DiscardHandler), &fakeConnections{connection: &calendar.Connection{
+		BusinessID: 7,
+		Provider:   calendar.ProviderCaldav,
+		Url:        server.URL + "/calendars/marta/citas",
+		Username:   "marta",
+		Password:   "secret",
+	}})
+}
+
+func bookingEvent() *calendar.Event {
+	return &calendar.Event{
+		BookingID: "b1",
+		Summary:   "Reserva Hastypal",
+		Start:     time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC),
+		End:       time.Date(2026, 3, 5, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestCreateEventPutsTheBookingResource(t *testing.T) {
+	server := newCaldavServer(t)
+	server.status[http.MethodPut] = http.StatusCreated
+
+	eventID, err := caldavClient(server).CreateEvent(context.Background(), 7, bookingEvent())
+
+	if err != nil || eventID != "b1" {
+		t.Fatalf("create = %q, %v", eventID, err)
+	}
+
+	request := server.last()
+
+	if request.method != http.MethodPut || request.path != "/calendars/marta/citas/b1.ics" {
+		t.Errorf("request = %s %s", request.method, request.path)
+	}
+
+	if request.header.Get("If-None-Match") != "*" {
+		t.Errorf("If-None-Match = %q, creating must not overwrite an event", request.header.Get("If-None-Match"))
+	}
+
+	if !request.hasAuth || request.user != "marta" || request.pass != "secret" {
+		t.Errorf("credentials = %q:%q", request.user, request.pass)
+	}
+
+	if !strings.Contains(request.body, "UID:b1\r\n") || !strings.Contains(request.body, "DTSTART:20260305T090000Z\r\n") {
+		t.Errorf("event = %s", request.body)
+	}
+}
+
+func TestCreateEventTakesAnExistingResourceAsCreated(t *testing.T) {
+	server := newCaldavServer(t)
+	server.status[http.MethodPut] = http.StatusPreconditionFailed
+
+	eventID, err := caldavClient(server).CreateEvent(context.Background(), 7, bookingEvent())
+
+	if err != nil || eventID != "b1" {
+		t.Errorf("create = %q, %v, a retried creation should find the event there", eventID, err)
+	}
+}
+
+func TestUpdateEventReplacesTheResource(t *testing.T) {
+	server := newCaldavServer(t)
+
+	event := bookingEvent()
+	event.ID = "b1"
+
+	if err := caldavClient(server).UpdateEvent(context.Background(), 7, event); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	request := server.last()
+
+	if request.method != http.MethodPut || request.header.Get("If-None-Match") != "" {
+		t.Errorf("request = %s with If-None-Match %q", request.method, request.header.Get("If-None-Match"))
+	}
+}
+
+func TestDeleteEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "deleted", status: http.StatusNoContent},
+		{name: "already deleted", status: http.StatusNotFound},
+		{name: "gone", status: http.StatusGone},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newCaldavServer(t)
+			server.status[http.MethodDelete] = test.status
+
+			err := caldavClient(server).DeleteEvent(context.Background(), 7, "", "b1")
+
+			if (err != nil) != test.wantErr {
+				t.Errorf("delete = %v, want error %t", err, test.wantErr)
+			}
+
+			if request := server.last(); request.method != http.MethodDelete || request.path != "/calendars/marta/citas/b1.ics" {
+				t.Errorf("request = %s %s", request.method, request.path)
+			}
+		})
+	}
+}
+
+func TestFreeBusyReadsTheBusyEvents(t *testing.T) {
+	server := newCaldavServer(t)
+	server.status["REPORT"] = http.StatusMultiStatus
+	server.body["REPORT"] = `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/calendars/marta/citas/a.ics</d:href>
+    <d:propstat>
+      <d:prop><cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART:20260305T090000Z
+DTEND:20260305T100000Z
+END:VEVENT
+END:VCALENDAR
+</cal:calendar-data></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/calendars/marta/citas/b.ics</d:href>
+    <d:propstat>
+      <d:prop><cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:b
+STATUS:CANCELLED
+DTSTART:20260305T110000Z
+DTEND:20260305T120000Z
+END:VEVENT
+END:VCALENDAR
+</cal:calendar-data></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+
+	from := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	busy, err := caldavClient(server).FreeBusy(context.Background(), 7, []string{""}, from, from.Add(24*time.Hour))
+
+	if err != nil {
+		t.Fatalf("free busy: %v", err)
+	}
+
+	periods := busy[""]
+
+	if len(periods) != 1 || !periods[0].Start.Equal(time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("busy = %v, the cancelled event does not block time", periods)
+	}
+
+	request := server.last()
+
+	if request.method != "REPORT" || request.header.Get("Depth") != "1" || !strings.Contains(request.body, `start="20260305T000000Z"`) {
+		t.Errorf("request = %s depth %q: %s", request.method, request.header.Get("Depth"), request.body)
+	}
+}
+
+func TestRejectedCredentialsAreAnError(t *testing.T) {
+	server := newCaldavServer(t)
+	server.status[http.MethodDelete] = http.StatusUnauthorized
+
+	if err := caldavClient(server).DeleteEvent(context.Background(), 7, "", "b1"); err == nil {
+		t.Error("a 401 should not pass for a deleted event")
+	}
+}
@@ -0,0 +1,67 @@
+package calendar
+
+import (
+	"context"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+var (
+	ConnectionNotFound   = eris.New("Calendar connection not found")
+	ProviderNotSupported = eris.New("Calendar provider not supported")
+)
+
+const (
+	ProviderGoogle = "google"
+	ProviderCaldav = "caldav"
+)
+
+const (
+	DefaultTimeZone     = "Europe/Madrid"
+	DefaultSlotDuration = 30 * time.Minute
+)
+
+// Event is a booking as seen by any calendar provider.
+type Event struct {
+	ID          string
+	BookingID   string
+	CalendarID  string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	TimeZone    string
+}
+
+type BusyPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Connection is the calendar account a business keeps its bookings in. The URL and credentials
+// are only used by the providers that are not connected through OAuth.
+type Connection struct {
+	BusinessID int
+	Provider   string
+	Url        string
+	Username   string
+	Password   string
+	DateAdd    time.Time
+	DateUpd    time.Time
+}
+
+// Provider is implemented by every calendar backend a business can connect. An empty calendar ID
+// targets the default calendar of the connection.
+type Provider interface {
+	CreateEvent(ctx context.Context, businessID int, event *Event) (string, error)
+	UpdateEvent(ctx context.Context, businessID int, event *Event) error
+	DeleteEvent(ctx context.Context, businessID int, calendarID string, eventID string) error
+	FreeBusy(ctx context.Context, businessID int, calendarIDs []string, from time.Time, to time.Time) (map[string][]BusyPeriod, error)
+}
+
+// ConnectionVerifier is implemented by the providers that can check a connection before it is stored.
+type ConnectionVerifier interface {
+	Verify(ctx context.Context, connection *Connection) error
+}
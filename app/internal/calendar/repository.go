@@ -0,0 +1,126 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adriein/hastypal/pkg/helper"
+	"github.com/rotisserie/eris"
+)
+
+type ConnectionRepository interface {
+	Save(ctx context.Context, connection *Connection) error
+	GetByBusinessID(ctx context.Context, businessID int) (*Connection, error)
+}
+
+// PgConnectionRepository stores the passwords of the connections sealed with the app key.
+type PgConnectionRepository struct {
+	connection *sql.DB
+	cipher     *helper.Cipher
+}
+
+func NewPgConnectionRepository(connection *sql.DB, cipher *helper.Cipher) *PgConnectionRepository {
+	return &PgConnectionRepository{
+		connection: connection,
+		cipher:     cipher,
+	}
+}
+
+// Save stores the connection of the business, replacing the one it had before.
+func (r *PgConnectionRepository) Save(ctx context.Context, connection *Connection) error {
+	password, err := r.cipher.Seal(connection.Password)
+
+	if err != nil {
+		return eris.Wrapf(err, "Error sealing the calendar password of business %d", connection.BusinessID)
+	}
+
+	query := `
+		INSERT INTO ha_calendar_connection (
+			hacc_business_id,
+			hacc_provider,
+			hacc_url,
+			hacc_username,
+			hacc_password,
+			hacc_date_add,
+			hacc_date_upd
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (hacc_business_id) DO UPDATE SET
+			hacc_provider = EXCLUDED.hacc_provider,
+			hacc_url = EXCLUDED.hacc_url,
+			hacc_username = EXCLUDED.hacc_username,
+			hacc_password = EXCLUDED.hacc_password,
+			hacc_date_upd = EXCLUDED.hacc_date_upd;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err = r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		connection.BusinessID,
+		connection.Provider,
+		connection.Url,
+		connection.Username,
+		password,
+		connection.DateAdd,
+		connection.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrapf(err, "Error saving the calendar connection of business %d", connection.BusinessID)
+	}
+
+	return nil
+}
+
+func (r *PgConnectionRepository) GetByBusinessID(ctx context.Context, businessID int) (*Connection, error) {
+	query := `
+		SELECT
+			hacc_business_id,
+			hacc_provider,
+			COALESCE(hacc_url, ''),
+			COALESCE(hacc_username, ''),
+			COALESCE(hacc_password, ''),
+			hacc_date_add,
+			hacc_date_upd
+		FROM ha_calendar_connection
+		WHERE hacc_business_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var connection Connection
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, businessID).Scan(
+		&connection.BusinessID,
+		&connection.Provider,
+		&connection.Url,
+		&connection.Username,
+		&connection.Password,
+		&connection.DateAdd,
+		&connection.DateUpd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ConnectionNotFound
+		}
+
+		return nil, eris.Wrapf(err, "Error fetching the calendar connection of business %d", businessID)
+	}
+
+	password, err := r.cipher.Open(connection.Password)
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "Error opening the calendar password of business %d", businessID)
+	}
+
+	connection.Password = password
+
+	return &connection, nil
+}
@@ -0,0 +1,134 @@
+package calendar
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+type CalendarService interface {
+	Connect(ctx context.Context, connection *Connection) error
+	GetConnection(ctx context.Context, businessID int) (*Connection, error)
+	CreateEvent(ctx context.Context, businessID int, event *Event) (string, error)
+	UpdateEvent(ctx context.Context, businessID int, event *Event) error
+	DeleteEvent(ctx context.Context, businessID int, calendarID string, eventID string) error
+	FreeBusy(ctx context.Context, businessID int, calendarIDs []string, from time.Time, to time.Time) (map[string][]BusyPeriod, error)
+}
+
+// Service routes every calendar operation to the provider the business is connected to.
+type Service struct {
+	logger    *slog.Logger
+	repo      ConnectionRepository
+	providers map[string]Provider
+}
+
+func NewService(logger *slog.Logger, repo ConnectionRepository, providers map[string]Provider) *Service {
+	return &Service{
+		logger:    logger,
+		repo:      repo,
+		providers: providers,
+	}
+}
+
+// Connect verifies the connection against the provider, when it supports it, and stores it as
+// the calendar of the business.
+func (s *Service) Connect(ctx context.Context, connection *Connection) error {
+	provider, ok := s.providers[connection.Provider]
+
+	if !ok {
+		return eris.Wrapf(ProviderNotSupported, "Provider %s", connection.Provider)
+	}
+
+	if verifier, ok := provider.(ConnectionVerifier); ok {
+		if err := verifier.Verify(ctx, connection); err != nil {
+			return eris.Wrap(err, "Error verifying the calendar connection")
+		}
+	}
+
+	now := time.Now().UTC()
+
+	connection.DateAdd = now
+	connection.DateUpd = now
+
+	if err := s.repo.Save(ctx, connection); err != nil {
+		return eris.Wrap(err, "Error storing the calendar connection")
+	}
+
+	s.logger.Info("Calendar connected", "business_id", connection.BusinessID, "provider", connection.Provider)
+
+	return nil
+}
+
+func (s *Service) GetConnection(ctx context.Context, businessID int) (*Connection, error) {
+	connection, err := s.repo.GetByBusinessID(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the calendar connection")
+	}
+
+	return connection, nil
+}
+
+func (s *Service) CreateEvent(ctx context.Context, businessID int, event *Event) (string, error) {
+	provider, err := s.provider(ctx, businessID)
+
+	if err != nil {
+		return "", err
+	}
+
+	return provider.CreateEvent(ctx, businessID, event)
+}
+
+func (s *Service) UpdateEvent(ctx context.Context, businessID int, event *Event) error {
+	provider, err := s.provider(ctx, businessID)
+
+	if err != nil {
+		return err
+	}
+
+	return provider.UpdateEvent(ctx, businessID, event)
+}
+
+func (s *Service) DeleteEvent(ctx context.Context, businessID int, calendarID string, eventID string) error {
+	provider, err := s.provider(ctx, businessID)
+
+	if err != nil {
+		return err
+	}
+
+	return provider.DeleteEvent(ctx, businessID, calendarID, eventID)
+}
+
+func (s *Service) FreeBusy(
+	ctx context.Context,
+	businessID int,
+	calendarIDs []string,
+	from time.Time,
+	to time.Time,
+) (map[string][]BusyPeriod, error) {
+	provider, err := s.provider(ctx, businessID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.FreeBusy(ctx, businessID, calendarIDs, from, to)
+}
+
+func (s *Service) provider(ctx context.Context, businessID int) (Provider, error) {
+	connection, err := s.repo.GetByBusinessID(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the calendar connection")
+	}
+
+	provider, ok := s.providers[connection.Provider]
+
+	if !ok {
+		return nil, eris.Wrapf(ProviderNotSupported, "Provider %s", connection.Provider)
+	}
+
+	return provider, nil
+}
@@ -0,0 +1,137 @@
+package calendarsync
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/google"
+	"github.com/rotisserie/eris"
+)
+
+type CalendarSyncService interface {
+	HandleNotification(ctx context.Context, channelID string, channelToken string, resourceID string) error
+}
+
+// Service reflects the changes made by the business owner in Google Calendar back into the
+// bookings. The affected customers hear of them from the notifications of the bookings.
+type Service struct {
+	logger  *slog.Logger
+	google  google.GoogleService
+	booking booking.BookingService
+}
+
+func NewService(logger *slog.Logger, google google.GoogleService, booking booking.BookingService) *Service {
+	return &Service{
+		logger:  logger,
+		google:  google,
+		booking: booking,
+	}
+}
+
+// HandleNotification applies the changes of the calendar and only then moves the channel past
+// them. A change the booking refuses is logged and skipped, any other failure leaves the sync
+// token as it was so the notification Google sends again applies the whole batch once more.
+// Applying a change twice is harmless, the booking already matches the event the second time.
+func (s *Service) HandleNotification(ctx context.Context, channelID string, channelToken string, resourceID string) error {
+	changes, syncToken, err := s.google.SyncChanges(ctx, channelID, channelToken, resourceID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error syncing the calendar changes")
+	}
+
+	var applyErr error
+
+	for _, change := range changes {
+		err := s.applyChange(ctx, change)
+
+		if err == nil {
+			continue
+		}
+
+		if isPermanent(err) {
+			s.logger.Warn(
+				"Skipping google calendar change the booking refuses",
+				"event_id", change.EventID,
+				"booking_id", change.BookingID,
+				"error", eris.ToString(err, true),
+			)
+
+			continue
+		}
+
+		s.logger.Error(
+			"Error applying google calendar change",
+			"event_id", change.EventID,
+			"booking_id", change.BookingID,
+			"error", eris.ToString(err, true),
+		)
+
+		applyErr = eris.Wrapf(err, "Error applying the change of event %s", change.EventID)
+	}
+
+	if applyErr != nil {
+		return applyErr
+	}
+
+	if err := s.google.SaveSyncToken(ctx, channelID, syncToken); err != nil {
+		return eris.Wrap(err, "Error saving the sync token")
+	}
+
+	return nil
+}
+
+// isPermanent tells the failures of a change that applying it again would repeat.
+func isPermanent(err error) bool {
+	return eris.Is(err, booking.BookingNotFound) ||
+		eris.Is(err, booking.BookingIsCancelled) ||
+		eris.Is(err, booking.BookingNotPending) ||
+		eris.Is(err, booking.BookingNotStarted)
+}
+
+func (s *Service) applyChange(ctx context.Context, change *google.EventChange) error {
+	bookingToUpdate, err := s.findBooking(ctx, change)
+
+	if err != nil {
+		if errors.Is(err, booking.BookingNotFound) {
+			return nil
+		}
+
+		return eris.Wrap(err, "Error fetching the booking of the event")
+	}
+
+	if bookingToUpdate.IsCancelled() {
+		return nil
+	}
+
+	if change.Cancelled {
+		if err := s.booking.CancelBooking(ctx, bookingToUpdate); err != nil {
+			return eris.Wrap(err, "Error cancelling the booking")
+		}
+
+		s.logger.Info("Booking cancelled from google calendar", "booking_id", bookingToUpdate.ID)
+
+		return nil
+	}
+
+	if change.Start.Equal(bookingToUpdate.Date) {
+		return nil
+	}
+
+	if err := s.booking.RescheduleBooking(ctx, bookingToUpdate, change.Start); err != nil {
+		return eris.Wrap(err, "Error rescheduling the booking")
+	}
+
+	s.logger.Info("Booking rescheduled from google calendar", "booking_id", bookingToUpdate.ID)
+
+	return nil
+}
+
+func (s *Service) findBooking(ctx context.Context, change *google.EventChange) (*booking.Booking, error) {
+	if change.BookingID != "" {
+		return s.booking.GetBooking(ctx, change.BookingID)
+	}
+
+	return s.booking.GetBookingByEvent(ctx, change.EventID)
+}
@@ -0,0 +1,250 @@
+package conversation
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/rotisserie/eris"
+)
+
+// Hours the bot offers on the days of a business that has not set its opening hours yet.
+const (
+	defaultOpen  = "08:00"
+	defaultClose = "20:00"
+)
+
+// Layouts of the day and hour picked in a conversation as the session stores them.
+const (
+	hourLayout = business.HourLayout
+	slotLayout = time.DateOnly + " " + hourLayout
+)
+
+// agenda is what the free slots of a range of days depend on: the opening hours and holidays of
+// the business and the time already taken by bookings and blocks.
+type agenda struct {
+	hours    []*business.OpeningHours
+	holidays map[string]bool
+	busy     []booking.Interval
+	now      time.Time
+}
+
+func (e *Engine) loadAgenda(ctx context.Context, session *booking.Session, from time.Time, to time.Time) (*agenda, error) {
+	hours, err := e.business.GetOpeningHours(ctx, session.BusinessId)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the opening hours of the business")
+	}
+
+	holidays, err := e.business.GetHolidays(ctx, session.BusinessId)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the holidays of the business")
+	}
+
+	services, err := e.business.GetServices(ctx, session.BusinessId)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the services of the business")
+	}
+
+	bookings, err := e.booking.GetAgenda(ctx, session.BusinessId, session.EmployeeId, from, to)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the agenda of the business")
+	}
+
+	blocks, err := e.business.GetTimeBlocks(ctx, session.BusinessId, from, to)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the time blocks of the business")
+	}
+
+	employeeBusy, err := e.employeeBusy(ctx, session, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &agenda{
+		hours:    hours,
+		holidays: make(map[string]bool, len(holidays)),
+		busy:     make([]booking.Interval, 0, len(bookings)+len(blocks)+len(employeeBusy)),
+		now:      time.Now(),
+	}
+
+	for _, holiday := range holidays {
+		result.holidays[holiday.Date] = true
+	}
+
+	for _, found := range bookings {
+		if found.IsCancelled() {
+			continue
+		}
+
+		// Bookings made before they had items take the duration of their service.
+		fallback := calendar.DefaultSlotDuration
+
+		if service := findService(services, found.ServiceID); service != nil && service.Duration > 0 {
+			fallback = time.Duration(service.Duration) * time.Minute
+		}
+
+		result.busy = append(result.busy, booking.Interval{
+			Start: found.Date,
+			End:   found.Date.Add(found.Duration(fallback)),
+		})
+	}
+
+	for _, block := range blocks {
+		result.busy = append(result.busy, booking.Interval{Start: block.Start, End: block.End})
+	}
+
+	result.busy = append(result.busy, employeeBusy...)
+
+	return result, nil
+}
+
+// employeeBusy is the time taken in the own calendar of the employee of the session, by the
+// events the business keeps outside of Hastypal. The calendar is an outside service: when it
+// cannot be read the failure is logged and the slots come from the bookings and blocks alone, so
+// a provider that is down does not stop the business from taking bookings.
+func (e *Engine) employeeBusy(
+	ctx context.Context,
+	session *booking.Session,
+	from time.Time,
+	to time.Time,
+) ([]booking.Interval, error) {
+	if session.EmployeeId == 0 {
+		return nil, nil
+	}
+
+	employee, err := e.business.GetEmployee(ctx, session.BusinessId, session.EmployeeId)
+
+	if err != nil {
+		if eris.Is(err, business.EmployeeNotFound) {
+			return nil, nil
+		}
+
+		return nil, eris.Wrap(err, "Error fetching the employee of the session")
+	}
+
+	// Employees without a calendar of their own share the one of the business, its events are
+	// not theirs.
+	if employee.CalendarId == "" {
+		return nil, nil
+	}
+
+	periods, err := e.calendar.FreeBusy(ctx, session.BusinessId, []string{employee.CalendarId}, from, to)
+
+	if err != nil {
+		if !eris.Is(err, calendar.ConnectionNotFound) {
+			e.logger.Warn(
+				"Error reading the calendar of the employee, offering the slots without it",
+				"business_id", session.BusinessId,
+				"employee_id", session.EmployeeId,
+				"error", eris.ToString(err, true),
+			)
+		}
+
+		return nil, nil
+	}
+
+	busy := make([]booking.Interval, 0, len(periods[employee.CalendarId]))
+
+	for _, period := range periods[employee.CalendarId] {
+		busy = append(busy, booking.Interval{Start: period.Start, End: period.End})
+	}
+
+	return busy, nil
+}
+
+// freeSlots returns the times of the day an appointment of the given duration can start at. day
+// is the start of the day in the location of the business.
+func (a *agenda) freeSlots(day time.Time, duration time.Duration) ([]time.Time, error) {
+	if a.holidays[day.Format(time.DateOnly)] {
+		return nil, nil
+	}
+
+	ranges := make([][2]string, 0)
+
+	for _, hours := range a.hours {
+		if hours.Weekday == day.Weekday() {
+			ranges = append(ranges, [2]string{hours.Open, hours.Close})
+		}
+	}
+
+	if len(a.hours) == 0 {
+		ranges = append(ranges, [2]string{defaultOpen, defaultClose})
+	}
+
+	open := make([]booking.Interval, 0, len(ranges))
+
+	for _, hourRange := range ranges {
+		start, err := atHour(day, hourRange[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := atHour(day, hourRange[1])
+
+		if err != nil {
+			return nil, err
+		}
+
+		open = append(open, booking.Interval{Start: start, End: end})
+	}
+
+	slots := make([]time.Time, 0)
+
+	for _, slot := range booking.FreeSlots(open, a.busy, duration, calendar.DefaultSlotDuration) {
+		if slot.After(a.now) {
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots, nil
+}
+
+// slotIsFree tells whether the services can still start at date. The hour comes from the action
+// of a choice, which can be an old one or a made up one.
+func (e *Engine) slotIsFree(
+	ctx context.Context,
+	session *booking.Session,
+	services []*business.ServiceCatalog,
+	date time.Time,
+) (bool, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	agenda, err := e.loadAgenda(ctx, session, day, day.AddDate(0, 0, 1))
+
+	if err != nil {
+		return false, err
+	}
+
+	slots, err := agenda.freeSlots(day, servicesDuration(services))
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, slot := range slots {
+		if slot.Equal(date) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func atHour(day time.Time, hour string) (time.Time, error) {
+	parsed, err := time.Parse(business.HourLayout, hour)
+
+	if err != nil {
+		return time.Time{}, eris.Wrapf(err, "Error parsing the hour %s", hour)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, day.Location()), nil
+}
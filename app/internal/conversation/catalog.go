@@ -0,0 +1,144 @@
+package conversation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/translation"
+)
+
+// serviceCategories returns the categories of the catalog in the order they are listed, the
+// services without a category are grouped last under an empty name.
+func serviceCategories(services []*business.ServiceCatalog) []string {
+	categories := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, service := range services {
+		if service.Category == "" || seen[service.Category] {
+			continue
+		}
+
+		seen[service.Category] = true
+		categories = append(categories, service.Category)
+	}
+
+	for _, service := range services {
+		if service.Category == "" {
+			return append(categories, "")
+		}
+	}
+
+	return categories
+}
+
+func servicesInCategory(services []*business.ServiceCatalog, category string) []*business.ServiceCatalog {
+	filtered := make([]*business.ServiceCatalog, 0)
+
+	for _, service := range services {
+		if service.Category == category {
+			filtered = append(filtered, service)
+		}
+	}
+
+	return filtered
+}
+
+func findService(services []*business.ServiceCatalog, serviceID string) *business.ServiceCatalog {
+	for _, service := range services {
+		if strconv.Itoa(service.Id) == serviceID {
+			return service
+		}
+	}
+
+	return nil
+}
+
+// categoryLabel names a category of the menu, the services without one are listed as others.
+func categoryLabel(l translation.Localizer, category string) string {
+	if category == "" {
+		return l.T("conversation.uncategorized", nil)
+	}
+
+	return category
+}
+
+// serviceLabel is how the bots name a service to the customer, e.g. Corte de pelo · 18,00 € · 30 min.
+func serviceLabel(l translation.Localizer, service *business.ServiceCatalog) string {
+	return service.Name + " · " + serviceDetails(l, service)
+}
+
+// serviceDetails is the price and duration of a service, what the channels show next to its name.
+func serviceDetails(l translation.Localizer, service *business.ServiceCatalog) string {
+	return l.Money(service.Price, service.Currency) + " · " + formatDuration(service.Duration)
+}
+
+// serviceNames joins the names of the services of an appointment, e.g. Corte de pelo + Barba.
+func serviceNames(services []*business.ServiceCatalog) string {
+	names := make([]string, len(services))
+
+	for i, service := range services {
+		names[i] = service.Name
+	}
+
+	return strings.Join(names, " + ")
+}
+
+// selectionLabel names the services picked for an appointment together with their total price
+// and duration, e.g. Corte de pelo + Barba · 25,00 € · 45 min.
+func selectionLabel(l translation.Localizer, services []*business.ServiceCatalog) string {
+	price := 0
+
+	for _, service := range services {
+		price += service.Price
+	}
+
+	return fmt.Sprintf(
+		"%s · %s · %s",
+		serviceNames(services),
+		l.Money(price, services[0].Currency),
+		formatDuration(int(servicesDuration(services).Minutes())),
+	)
+}
+
+// servicesDuration is the time an appointment for all the services takes.
+func servicesDuration(services []*business.ServiceCatalog) time.Duration {
+	minutes := 0
+
+	for _, service := range services {
+		minutes += service.Duration
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+func bookingItems(services []*business.ServiceCatalog) []booking.Item {
+	items := make([]booking.Item, len(services))
+
+	for i, service := range services {
+		items[i] = booking.Item{
+			ServiceID: service.Id,
+			Name:      service.Name,
+			Price:     service.Price,
+			Currency:  service.Currency,
+			Duration:  service.Duration,
+		}
+	}
+
+	return items
+}
+
+func formatDuration(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
@@ -0,0 +1,170 @@
+package conversation
+
+import (
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/customer"
+	"github.com/adriein/hastypal/internal/translation"
+)
+
+// Step is where the booking dialogue is. Each step is a prompt the channel shows to the customer
+// and the choices they have there.
+type Step string
+
+const (
+	StepCategories   Step = "categories"
+	StepServices     Step = "services"
+	StepDates        Step = "dates"
+	StepHours        Step = "hours"
+	StepConfirmation Step = "confirmation"
+	StepBooked       Step = "booked"
+	StepPending      Step = "pending"
+	StepExpired      Step = "expired"
+	StepInvalidLink  Step = "invalid_link"
+)
+
+// ChoiceKind tells the channels apart the choices they may lay out differently.
+type ChoiceKind string
+
+const (
+	// ChoiceOption is a service, a category, a day or a time the customer picks.
+	ChoiceOption ChoiceKind = "option"
+	// ChoiceNavigation moves between pages or back to the previous step.
+	ChoiceNavigation ChoiceKind = "navigation"
+	// ChoiceNext takes the booking to the next step, e.g. to the dates once a service is picked.
+	ChoiceNext ChoiceKind = "next"
+)
+
+// Choice is something the customer can pick. Action is what the channel sends back to the engine
+// when it is picked, the callback data of a Telegram button or the id of a WhatsApp row.
+type Choice struct {
+	Kind     ChoiceKind
+	Label    string
+	Detail   string
+	Action   string
+	Selected bool
+}
+
+// Line is a paragraph of a prompt: an emoji, a bold label and the text, or the whole text in bold
+// when it is Strong. Listing lines repeat the details of the choices for the channels whose
+// buttons are too short for them, the channels that show the details next to the choice skip them.
+type Line struct {
+	Emoji   string
+	Label   string
+	Text    string
+	Strong  bool
+	Listing bool
+}
+
+// Prompt is what the engine says at a step, for the channel to render in its own format.
+// Business and Session are nil when the conversation could not start, Profile is the customer of
+// the chat once the booking is made.
+type Prompt struct {
+	Step      Step
+	ChatID    int
+	Business  *business.Business
+	Session   *booking.Session
+	Profile   *customer.Customer
+	Localizer translation.Localizer
+	Lines     []Line
+	Choices   []Choice
+}
+
+// Customer is who talks to the bot, as the channel knows them. Only the web widget asks for the
+// phone and the email, the chats know how to reach the customer already.
+type Customer struct {
+	Name   string
+	Phone  string
+	Email  string
+	Locale string
+}
+
+// Channel is how much a chat channel fits in a prompt, the engine pages the choices to it.
+type Channel struct {
+	Name string
+	// MaxServices is the size of the catalog listed at once, above it the customer picks a
+	// category first.
+	MaxServices int
+	DaysPerPage int
+	// HoursPerPage is zero for the channels that show all the free times of a day at once.
+	HoursPerPage int
+}
+
+func (p *Prompt) line(emoji string, text string) {
+	p.Lines = append(p.Lines, Line{Emoji: emoji, Text: text})
+}
+
+func (p *Prompt) strong(emoji string, text string) {
+	p.Lines = append(p.Lines, Line{Emoji: emoji, Text: text, Strong: true})
+}
+
+func (p *Prompt) labelled(emoji string, label string, text string) {
+	p.Lines = append(p.Lines, Line{Emoji: emoji, Label: label, Text: text})
+}
+
+func (p *Prompt) choice(kind ChoiceKind, label string, action string) {
+	p.Choices = append(p.Choices, Choice{Kind: kind, Label: label, Action: action})
+}
+
+// Options returns the choices of the kind in the order the engine gave them.
+func (p *Prompt) Options(kinds ...ChoiceKind) []Choice {
+	filtered := make([]Choice, 0, len(p.Choices))
+
+	for _, choice := range p.Choices {
+		for _, kind := range kinds {
+			if choice.Kind == kind {
+				filtered = append(filtered, choice)
+			}
+		}
+	}
+
+	return filtered
+}
+
+/*
+================================================================================
+CLOSING PROMPTS
+================================================================================
+*/
+
+// InvalidLink answers a start link the engine cannot read.
+func InvalidLink(l translation.Localizer, chatID int) *Prompt {
+	prompt := &Prompt{Step: StepInvalidLink, ChatID: chatID, Localizer: l}
+
+	prompt.line("🙂‍↕️", l.T("conversation.invalid_link", nil))
+	prompt.strong("", l.T("conversation.invalid_link_instructions", nil))
+
+	return prompt
+}
+
+// SessionExpired tells the customer to start again, the channel offers them the way to do it.
+func SessionExpired(l translation.Localizer, chatID int) *Prompt {
+	prompt := &Prompt{Step: StepExpired, ChatID: chatID, Localizer: l}
+
+	prompt.line("🙂‍↕️", l.T("conversation.session_expired", nil))
+	prompt.strong("ℹ️", l.T("conversation.start_again_instructions", nil))
+
+	return prompt
+}
+
+// Booked closes the conversation of a booking that needs no approval, the confirmation being the
+// catalog text or the one the business wrote.
+func Booked(l translation.Localizer, chatID int, confirmation string) *Prompt {
+	prompt := &Prompt{Step: StepBooked, ChatID: chatID, Localizer: l}
+
+	prompt.strong("🎉", confirmation)
+	prompt.line("📅", l.T("conversation.reminder_notice", nil))
+	prompt.line("💙", l.T("conversation.thanks", nil))
+
+	return prompt
+}
+
+func Pending(l translation.Localizer, chatID int) *Prompt {
+	prompt := &Prompt{Step: StepPending, ChatID: chatID, Localizer: l}
+
+	prompt.strong("⏳", l.T("booking.pending", nil))
+	prompt.line("🔔", l.T("booking.pending_instructions", nil))
+	prompt.line("💙", l.T("conversation.thanks", nil))
+
+	return prompt
+}
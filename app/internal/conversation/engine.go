@@ -0,0 +1,928 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/customer"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/adriein/hastypal/pkg/constants"
+	"github.com/rotisserie/eris"
+)
+
+// bookingHorizon is how many days ahead a customer can book, the same whatever the channel pages.
+const bookingHorizon = constants.DaysPerPage * (constants.MaxAllowedDatePage + 1)
+
+// templateCatalogKeys is the catalog text of each message a business can write in its own tone.
+var templateCatalogKeys = map[string]string{
+	business.TemplateWelcome:      "conversation.welcome",
+	business.TemplateConfirmation: "conversation.booking_confirmed",
+	business.TemplateReminder:     "reminder.message",
+}
+
+// Engine runs the booking dialogue the same way on every channel: it keeps the session, decides
+// the next step from the choice the customer picked and returns the prompt of that step for the
+// channel to render. The bookings from a chat are kept in the profile of its customer.
+type Engine struct {
+	logger    *slog.Logger
+	business  business.BusinessService
+	booking   booking.BookingService
+	calendar  calendar.CalendarService
+	customers customer.CustomerService
+	lang      translation.TranslationService
+	channel   Channel
+}
+
+func NewEngine(
+	logger *slog.Logger,
+	business business.BusinessService,
+	booking booking.BookingService,
+	calendar calendar.CalendarService,
+	customers customer.CustomerService,
+	lang translation.TranslationService,
+	channel Channel,
+) *Engine {
+	return &Engine{
+		logger:    logger,
+		business:  business,
+		booking:   booking,
+		calendar:  calendar,
+		customers: customers,
+		lang:      lang,
+		channel:   channel,
+	}
+}
+
+/*
+================================================================================
+CONVERSATION START
+================================================================================
+*/
+
+// Start opens a booking conversation from the payload of a start link. Links with a service skip
+// the catalog and go straight to the dates.
+func (e *Engine) Start(ctx context.Context, chatID int, customer Customer, rawPayload string) (*Prompt, error) {
+	payload, err := ParseStartPayload(rawPayload)
+
+	if err != nil {
+		return InvalidLink(e.localizer(customer.Locale), chatID), nil
+	}
+
+	owner, err := e.business.GetBusinessByID(ctx, payload.BusinessID)
+
+	if err != nil {
+		if eris.Is(err, business.BusinessNotFound) {
+			return InvalidLink(e.localizer(customer.Locale), chatID), nil
+		}
+
+		return nil, eris.Wrap(err, "Error fetching business")
+	}
+
+	origin, err := e.resolveOrigin(ctx, payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	origin.Locale = customer.Locale
+	origin.Channel = e.channel.Name
+
+	sessionID, err := e.booking.InitSession(ctx, payload.BusinessID, chatID, origin)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error creating a session for this conversation")
+	}
+
+	session, err := e.booking.GetCurrentSession(ctx, sessionID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching current booking session")
+	}
+
+	if origin.ServiceID != "" {
+		return e.dates(ctx, chatID, session, owner, constants.MinAllowedDatePage)
+	}
+
+	// The web widget does not know who the customer is until they leave their contact details.
+	if customer.Name == "" {
+		return e.services(ctx, chatID, session, owner, "")
+	}
+
+	l := e.localizer(session.Locale, owner.Lang)
+
+	greeting, err := e.welcomeText(ctx, l, owner, customer.Name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return e.services(ctx, chatID, session, owner, "", Line{Emoji: "👋", Text: greeting})
+}
+
+// CheckStartLink makes sure a start link opens a conversation with all it preselects, before a
+// channel hands the link out.
+func (e *Engine) CheckStartLink(ctx context.Context, payload StartPayload) error {
+	if err := payload.Validate(); err != nil {
+		return err
+	}
+
+	origin, err := e.resolveOrigin(ctx, payload)
+
+	if err != nil {
+		return err
+	}
+
+	if payload.ServiceID != 0 && origin.ServiceID == "" {
+		return business.ServiceNotFound
+	}
+
+	if payload.EmployeeID != 0 && origin.EmployeeID == 0 {
+		return business.EmployeeNotFound
+	}
+
+	return nil
+}
+
+// resolveOrigin keeps the service and employee of the link only when they belong to the business,
+// a link pointing to a removed service still opens the conversation from the catalog.
+func (e *Engine) resolveOrigin(ctx context.Context, payload StartPayload) (booking.Origin, error) {
+	origin := booking.Origin{Source: payload.Source}
+
+	if payload.ServiceID != 0 {
+		services, err := e.business.GetServices(ctx, payload.BusinessID)
+
+		if err != nil {
+			return origin, eris.Wrap(err, "Error fetching the services of the business")
+		}
+
+		if service := findService(services, strconv.Itoa(payload.ServiceID)); service != nil {
+			origin.ServiceID = strconv.Itoa(service.Id)
+		}
+	}
+
+	if payload.EmployeeID != 0 {
+		employee, err := e.business.GetEmployee(ctx, payload.BusinessID, payload.EmployeeID)
+
+		if err != nil && !eris.Is(err, business.EmployeeNotFound) {
+			return origin, eris.Wrap(err, "Error fetching the employee of the link")
+		}
+
+		if err == nil {
+			origin.EmployeeID = employee.Id
+		}
+	}
+
+	return origin, nil
+}
+
+/*
+================================================================================
+CONVERSATION STEPS
+================================================================================
+*/
+
+// Handle moves the conversation to the step of the choice the customer picked, action being the
+// one the engine gave the choice. Actions that are not part of the booking dialogue get no prompt.
+func (e *Engine) Handle(ctx context.Context, chatID int, customer Customer, action string) (*Prompt, error) {
+	parsedUrl, err := url.Parse(action)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error parsing the action")
+	}
+
+	switch parsedUrl.Path {
+	case constants.ServiceCommand,
+		constants.DatesCommand,
+		constants.HoursCommand,
+		constants.ConfirmationCommand,
+		constants.FinishCommand:
+	default:
+		return nil, nil
+	}
+
+	queryParams := parsedUrl.Query()
+
+	session, err := e.booking.GetCurrentSession(ctx, queryParams.Get("session"))
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching current booking session")
+	}
+
+	owner, err := e.business.GetBusinessByID(ctx, session.BusinessId)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching business")
+	}
+
+	if err := session.EnsureIsValid(); err != nil {
+		prompt := SessionExpired(e.localizer(session.Locale, owner.Lang), chatID)
+		prompt.Business = owner
+		prompt.Session = session
+
+		return prompt, nil
+	}
+
+	switch parsedUrl.Path {
+	case constants.ServiceCommand:
+		if toggle := queryParams.Get("toggle"); toggle != "" {
+			session.ToggleService(toggle)
+		}
+
+		if err := e.booking.RefreshSession(ctx, session); err != nil {
+			return nil, eris.Wrap(err, "Error refreshing the current session")
+		}
+
+		return e.services(ctx, chatID, session, owner, queryParams.Get("category"))
+	case constants.DatesCommand:
+		currentPage, err := strconv.Atoi(queryParams.Get("page"))
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error converting string to int")
+		}
+
+		// Buttons sent before several services could be picked carry the service.
+		if serviceID := queryParams.Get("service"); serviceID != "" && !session.HasService(serviceID) {
+			session.ToggleService(serviceID)
+		}
+
+		if err := e.booking.RefreshSession(ctx, session); err != nil {
+			return nil, eris.Wrap(err, "Error refreshing the current session")
+		}
+
+		return e.dates(ctx, chatID, session, owner, currentPage)
+	case constants.HoursCommand:
+		location, err := time.LoadLocation("Europe/Madrid")
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error loading time location")
+		}
+
+		selectedDate, err := time.ParseInLocation(time.DateOnly, queryParams.Get("date"), location)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error parsing time")
+		}
+
+		// Only the channels that page the hours send the page.
+		currentPage, _ := strconv.Atoi(queryParams.Get("page"))
+
+		session.Date = selectedDate.Format(time.DateOnly)
+
+		if err := e.booking.RefreshSession(ctx, session); err != nil {
+			return nil, eris.Wrap(err, "Error refreshing the current session")
+		}
+
+		return e.hours(ctx, chatID, session, owner, selectedDate, currentPage)
+	case constants.ConfirmationCommand:
+		if _, err := time.Parse(hourLayout, queryParams.Get("hour")); err != nil {
+			return nil, eris.Wrap(err, "Error parsing the hour")
+		}
+
+		session.Hour = queryParams.Get("hour")
+
+		if err := e.booking.RefreshSession(ctx, session); err != nil {
+			return nil, eris.Wrap(err, "Error refreshing the current session")
+		}
+
+		return e.confirmation(ctx, chatID, session, owner)
+	}
+
+	return e.book(ctx, chatID, customer, session, owner)
+}
+
+// services shows the catalog of the business so the customer picks one or several services for
+// the same appointment. When the catalog does not fit the channel and has several categories the
+// customer picks a category first, category is the position of the picked one in the menu so the
+// actions stay short.
+func (e *Engine) services(
+	ctx context.Context,
+	chatID int,
+	session *booking.Session,
+	owner *business.Business,
+	category string,
+	intro ...Line,
+) (*Prompt, error) {
+	services, err := e.business.GetServices(ctx, owner.Id)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the services of the business")
+	}
+
+	categories := serviceCategories(services)
+
+	l := e.localizer(session.Locale, owner.Lang)
+
+	prompt := newPrompt(StepServices, chatID, session, owner, l)
+	prompt.Lines = append(prompt.Lines, intro...)
+
+	switch {
+	case len(services) == 0:
+		prompt.strong("", l.T("conversation.no_services", nil))
+	case category == "" && len(services) > e.channel.MaxServices && len(categories) > 1:
+		prompt.Step = StepCategories
+		prompt.strong("", l.T("conversation.choose_category", nil))
+
+		for i, name := range categories {
+			prompt.choice(
+				ChoiceOption,
+				categoryLabel(l, name),
+				fmt.Sprintf("%s?session=%s&category=%d", constants.ServiceCommand, session.Id, i),
+			)
+		}
+	default:
+		if category != "" {
+			index, err := strconv.Atoi(category)
+
+			if err != nil || index < 0 || index >= len(categories) {
+				return nil, eris.Errorf("Unknown service category %s", category)
+			}
+
+			services = servicesInCategory(services, categories[index])
+
+			prompt.strong("", categoryLabel(l, categories[index]))
+		}
+
+		prompt.strong("", l.T("conversation.choose_services", nil))
+
+		for _, service := range services {
+			serviceID := strconv.Itoa(service.Id)
+
+			prompt.Lines = append(prompt.Lines, Line{Emoji: "🔸", Text: serviceLabel(l, service), Listing: true})
+
+			prompt.Choices = append(prompt.Choices, Choice{
+				Kind:   ChoiceOption,
+				Label:  service.Name,
+				Detail: serviceDetails(l, service),
+				Action: fmt.Sprintf(
+					"%s?session=%s&category=%s&toggle=%s",
+					constants.ServiceCommand,
+					session.Id,
+					category,
+					serviceID,
+				),
+				Selected: session.HasService(serviceID),
+			})
+		}
+
+		if category != "" {
+			prompt.choice(
+				ChoiceNavigation,
+				l.T("button.back", nil),
+				fmt.Sprintf("%s?session=%s", constants.ServiceCommand, session.Id),
+			)
+		}
+	}
+
+	selected, err := e.sessionServices(ctx, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(selected) > 0 {
+		prompt.labelled("🟢", l.T("conversation.selection", nil), selectionLabel(l, selected))
+
+		prompt.choice(
+			ChoiceNext,
+			l.Plural("button.choose_date", len(selected), nil),
+			fmt.Sprintf("%s?session=%s&page=%d", constants.DatesCommand, session.Id, constants.MinAllowedDatePage),
+		)
+	}
+
+	return prompt, nil
+}
+
+// dates shows a page of the days with room for all the services of the session.
+func (e *Engine) dates(
+	ctx context.Context,
+	chatID int,
+	session *booking.Session,
+	owner *business.Business,
+	currentPage int,
+) (*Prompt, error) {
+	services, err := e.sessionServices(ctx, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(services) == 0 {
+		return e.services(ctx, chatID, session, owner, "")
+	}
+
+	l := e.localizer(session.Locale, owner.Lang)
+
+	prompt := newPrompt(StepDates, chatID, session, owner, l)
+	prompt.line("📅", l.T("conversation.dates_intro", translation.Params{"business": owner.Name}))
+	prompt.line("🔸", selectionLabel(l, services))
+	prompt.strong("", l.T("conversation.choose_date", nil))
+
+	location, err := time.LoadLocation("Europe/Madrid")
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error loading time location")
+	}
+
+	daysPerPage := e.channel.DaysPerPage
+	maxPage := bookingHorizon/daysPerPage - 1
+	currentPage = max(constants.MinAllowedDatePage, min(currentPage, maxPage))
+
+	today := time.Now().In(location)
+	startDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, location)
+	startDate = startDate.AddDate(0, 0, daysPerPage*currentPage)
+
+	agenda, err := e.loadAgenda(ctx, session, startDate, startDate.AddDate(0, 0, daysPerPage))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < daysPerPage; i++ {
+		day := startDate.AddDate(0, 0, i)
+
+		slots, err := agenda.freeSlots(day, servicesDuration(services))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(slots) == 0 {
+			continue
+		}
+
+		prompt.Choices = append(prompt.Choices, Choice{
+			Kind:   ChoiceOption,
+			Label:  l.DayButton(day, today),
+			Detail: l.Date(day),
+			Action: fmt.Sprintf("%s?session=%s&date=%s", constants.HoursCommand, session.Id, day.Format(time.DateOnly)),
+		})
+	}
+
+	if currentPage > constants.MinAllowedDatePage {
+		prompt.choice(
+			ChoiceNavigation,
+			l.T("button.less_dates", nil),
+			fmt.Sprintf("%s?session=%s&page=%d", constants.DatesCommand, session.Id, currentPage-1),
+		)
+	}
+
+	if currentPage < maxPage {
+		prompt.choice(
+			ChoiceNavigation,
+			l.T("button.more_dates", nil),
+			fmt.Sprintf("%s?session=%s&page=%d", constants.DatesCommand, session.Id, currentPage+1),
+		)
+	}
+
+	prompt.choice(
+		ChoiceNavigation,
+		l.T("button.back", nil),
+		fmt.Sprintf("%s?session=%s", constants.ServiceCommand, session.Id),
+	)
+
+	return prompt, nil
+}
+
+// hours shows the free times of the day for all the services of the session, a page of them on
+// the channels that page the hours.
+func (e *Engine) hours(
+	ctx context.Context,
+	chatID int,
+	session *booking.Session,
+	owner *business.Business,
+	selectedDate time.Time,
+	currentPage int,
+	intro ...Line,
+) (*Prompt, error) {
+	services, err := e.sessionServices(ctx, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(services) == 0 {
+		return e.services(ctx, chatID, session, owner, "")
+	}
+
+	agenda, err := e.loadAgenda(ctx, session, selectedDate, selectedDate.AddDate(0, 0, 1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := agenda.freeSlots(selectedDate, servicesDuration(services))
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := e.localizer(session.Locale, owner.Lang)
+
+	prompt := newPrompt(StepHours, chatID, session, owner, l)
+	prompt.Lines = append(prompt.Lines, intro...)
+	prompt.line("⌚️", l.T("conversation.hours_intro", nil))
+	prompt.line("🔸", selectionLabel(l, services))
+	prompt.line("📅", l.RelativeDate(selectedDate, time.Now()))
+	prompt.strong("", l.T("conversation.choose_hour", nil))
+
+	start, end := 0, len(slots)
+
+	if perPage := e.channel.HoursPerPage; perPage > 0 {
+		start = min(max(currentPage, 0)*perPage, len(slots))
+		end = min(start+perPage, len(slots))
+	}
+
+	for _, slot := range slots[start:end] {
+		prompt.choice(
+			ChoiceOption,
+			l.Time(slot),
+			fmt.Sprintf("%s?session=%s&hour=%s", constants.ConfirmationCommand, session.Id, slot.Format(hourLayout)),
+		)
+	}
+
+	day := selectedDate.Format(time.DateOnly)
+
+	if start > 0 {
+		prompt.choice(
+			ChoiceNavigation,
+			l.T("button.earlier_hours", nil),
+			fmt.Sprintf("%s?session=%s&date=%s&page=%d", constants.HoursCommand, session.Id, day, currentPage-1),
+		)
+	}
+
+	if end < len(slots) {
+		prompt.choice(
+			ChoiceNavigation,
+			l.T("button.later_hours", nil),
+			fmt.Sprintf("%s?session=%s&date=%s&page=%d", constants.HoursCommand, session.Id, day, currentPage+1),
+		)
+	}
+
+	prompt.choice(
+		ChoiceNavigation,
+		l.T("button.back", nil),
+		fmt.Sprintf("%s?session=%s&page=%d", constants.DatesCommand, session.Id, constants.MinAllowedDatePage),
+	)
+
+	return prompt, nil
+}
+
+// confirmation sums the appointment up before the customer books it.
+func (e *Engine) confirmation(
+	ctx context.Context,
+	chatID int,
+	session *booking.Session,
+	owner *business.Business,
+) (*Prompt, error) {
+	services, err := e.sessionServices(ctx, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(services) == 0 {
+		return e.services(ctx, chatID, session, owner, "")
+	}
+
+	selectedDate, err := sessionDate(session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	free, err := e.slotIsFree(ctx, session, services, selectedDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !free {
+		return e.slotTaken(ctx, chatID, session, owner, selectedDate)
+	}
+
+	l := e.localizer(session.Locale, owner.Lang)
+
+	prompt := newPrompt(StepConfirmation, chatID, session, owner, l)
+	prompt.line("🙂", l.T("conversation.confirm_intro", nil))
+
+	for _, service := range services {
+		prompt.line("🟢", serviceLabel(l, service))
+	}
+
+	if len(services) > 1 {
+		total := 0
+
+		for _, service := range services {
+			total += service.Price
+		}
+
+		prompt.labelled(
+			"💶",
+			l.T("conversation.total", nil),
+			l.Money(total, services[0].Currency)+" · "+formatDuration(int(servicesDuration(services).Minutes())),
+		)
+	}
+
+	prompt.line("📅", l.RelativeDate(selectedDate, time.Now()))
+	prompt.line("⌚️", l.Hour(l.Time(selectedDate)))
+	prompt.strong("", l.T("conversation.confirm_instructions", nil))
+
+	prompt.choice(ChoiceNext, l.T("button.confirm", nil), fmt.Sprintf("%s?session=%s", constants.FinishCommand, session.Id))
+	prompt.choice(
+		ChoiceNavigation,
+		l.T("button.back", nil),
+		fmt.Sprintf("%s?session=%s&date=%s", constants.HoursCommand, session.Id, selectedDate.Format(time.DateOnly)),
+	)
+
+	return prompt, nil
+}
+
+// book registers the booking of the session and closes the conversation.
+func (e *Engine) book(
+	ctx context.Context,
+	chatID int,
+	customer Customer,
+	session *booking.Session,
+	owner *business.Business,
+) (*Prompt, error) {
+	selectedDate, err := sessionDate(session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := e.sessionServices(ctx, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(services) == 0 {
+		return e.services(ctx, chatID, session, owner, "")
+	}
+
+	// A booking has to say who it is for, the web widget asks for the name with the confirmation.
+	if customer.Name == "" {
+		return e.confirmation(ctx, chatID, session, owner)
+	}
+
+	// A returning customer of a chat is not asked again for the phone they shared.
+	if customer.Phone == "" && chatID != 0 {
+		phone, err := e.customerPhone(ctx, owner.Id, chatID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		customer.Phone = phone
+	}
+
+	// The time was free when the customer confirmed, it may have been taken since.
+	free, err := e.slotIsFree(ctx, session, services, selectedDate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !free {
+		return e.slotTaken(ctx, chatID, session, owner, selectedDate)
+	}
+
+	registered, err := e.booking.RegisterBooking(
+		ctx,
+		session,
+		booking.Customer{Name: customer.Name, Phone: customer.Phone, Email: customer.Email},
+		selectedDate,
+		bookingItems(services),
+		owner.RequiresApproval,
+	)
+
+	if err != nil {
+		if eris.Is(err, booking.BookingSlotTaken) {
+			return e.slotTaken(ctx, chatID, session, owner, selectedDate)
+		}
+
+		return nil, eris.Wrap(err, "Error creating and saving the booking")
+	}
+
+	profile := e.recordBooking(ctx, registered)
+
+	l := e.localizer(session.Locale, owner.Lang)
+
+	prompt := Pending(l, chatID)
+
+	if !registered.IsPending() {
+		confirmation, err := e.confirmationText(ctx, l, owner, customer.Name, services, selectedDate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prompt = Booked(l, chatID, confirmation)
+	}
+
+	prompt.Business = owner
+	prompt.Session = session
+	prompt.Profile = profile
+
+	return prompt, nil
+}
+
+// slotTaken sends the customer back to the free hours of the day when the one they picked is no
+// longer free.
+func (e *Engine) slotTaken(
+	ctx context.Context,
+	chatID int,
+	session *booking.Session,
+	owner *business.Business,
+	selectedDate time.Time,
+) (*Prompt, error) {
+	l := e.localizer(session.Locale, owner.Lang)
+	day := time.Date(selectedDate.Year(), selectedDate.Month(), selectedDate.Day(), 0, 0, 0, 0, selectedDate.Location())
+
+	notice := Line{Emoji: "⚠️", Text: l.T("conversation.slot_taken", nil), Strong: true}
+
+	return e.hours(ctx, chatID, session, owner, day, 0, notice)
+}
+
+// customerPhone is the phone in the profile of the customer of the chat, empty when they did not
+// share one or never booked with the business.
+func (e *Engine) customerPhone(ctx context.Context, businessID int, chatID int) (string, error) {
+	profile, err := e.customers.GetChatCustomer(ctx, businessID, e.channel.Name, chatID)
+
+	if err != nil {
+		if eris.Is(err, customer.CustomerNotFound) {
+			return "", nil
+		}
+
+		return "", eris.Wrap(err, "Error fetching the customer profile")
+	}
+
+	return profile.Phone, nil
+}
+
+// recordBooking counts the booking in the profile of the customer. The booking is already made,
+// a profile that could not be stored is logged and the conversation ends as usual without it.
+func (e *Engine) recordBooking(ctx context.Context, registered *booking.Booking) *customer.Customer {
+	if registered.ChatID == 0 {
+		return nil
+	}
+
+	profile, err := e.customers.RecordBooking(ctx, registered)
+
+	if err != nil {
+		e.logger.Warn(
+			"Error recording the booking in the customer profile",
+			"booking_id", registered.ID,
+			"error", eris.ToString(err, true),
+		)
+
+		return nil
+	}
+
+	return profile
+}
+
+/*
+================================================================================
+CONVERSATION HELPERS
+================================================================================
+*/
+
+func newPrompt(
+	step Step,
+	chatID int,
+	session *booking.Session,
+	owner *business.Business,
+	l translation.Localizer,
+) *Prompt {
+	return &Prompt{
+		Step:      step,
+		ChatID:    chatID,
+		Business:  owner,
+		Session:   session,
+		Localizer: l,
+		Lines:     make([]Line, 0),
+		Choices:   make([]Choice, 0),
+	}
+}
+
+// sessionServices returns the services picked in the session in the order they were picked,
+// leaving out those that left the catalog while the customer was booking.
+func (e *Engine) sessionServices(ctx context.Context, session *booking.Session) ([]*business.ServiceCatalog, error) {
+	services, err := e.business.GetServices(ctx, session.BusinessId)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the services of the business")
+	}
+
+	selected := make([]*business.ServiceCatalog, 0, len(session.ServiceIds))
+
+	for _, serviceID := range session.ServiceIds {
+		if service := findService(services, serviceID); service != nil {
+			selected = append(selected, service)
+		}
+	}
+
+	return selected, nil
+}
+
+// localizer speaks the first supported language of the candidates, from the most specific one.
+func (e *Engine) localizer(candidates ...string) translation.Localizer {
+	return translation.NewLocalizer(e.lang, candidates...)
+}
+
+// BusinessLocalizer speaks the first supported language of the candidates and the language of the
+// business after them, for the texts said outside of a session. A business that cannot be read
+// leaves the candidates alone.
+func (e *Engine) BusinessLocalizer(ctx context.Context, businessID int, candidates ...string) translation.Localizer {
+	owner, err := e.business.GetBusinessByID(ctx, businessID)
+
+	if err != nil {
+		return e.localizer(candidates...)
+	}
+
+	return e.localizer(append(candidates, owner.Lang)...)
+}
+
+// BusinessText writes the message with the template of the business when it has one and with the
+// catalog text in the language of the reader otherwise.
+func (e *Engine) BusinessText(
+	ctx context.Context,
+	l translation.Localizer,
+	businessID int,
+	key string,
+	data business.TemplateData,
+) (string, error) {
+	text, ok, err := e.business.RenderMessage(ctx, businessID, key, data)
+
+	if err != nil {
+		return "", eris.Wrap(err, "Error rendering the template of the business")
+	}
+
+	if ok {
+		return text, nil
+	}
+
+	return l.T(templateCatalogKeys[key], translation.Params{
+		"name":     data.Customer,
+		"business": data.Business,
+		"service":  data.Service,
+		"date":     data.Date,
+		"hour":     data.Hour,
+	}), nil
+}
+
+func (e *Engine) welcomeText(
+	ctx context.Context,
+	l translation.Localizer,
+	owner *business.Business,
+	customer string,
+) (string, error) {
+	data := business.TemplateData{Customer: customer, Business: owner.Name}
+
+	return e.BusinessText(ctx, l, owner.Id, business.TemplateWelcome, data)
+}
+
+func (e *Engine) confirmationText(
+	ctx context.Context,
+	l translation.Localizer,
+	owner *business.Business,
+	customer string,
+	services []*business.ServiceCatalog,
+	date time.Time,
+) (string, error) {
+	data := business.TemplateData{
+		Customer: customer,
+		Business: owner.Name,
+		Service:  serviceNames(services),
+		Date:     l.Date(date),
+		Hour:     l.Time(date),
+	}
+
+	return e.BusinessText(ctx, l, owner.Id, business.TemplateConfirmation, data)
+}
+
+// sessionDate is the day and hour picked in the session in the time of the business.
+func sessionDate(session *booking.Session) (time.Time, error) {
+	location, err := time.LoadLocation("Europe/Madrid")
+
+	if err != nil {
+		return time.Time{}, eris.Wrap(err, "Error loading time location")
+	}
+
+	date, err := time.ParseInLocation(slotLayout, session.Date+" "+session.Hour, location)
+
+	if err != nil {
+		return time.Time{}, eris.Wrap(err, "Error merging date and hour")
+	}
+
+	return date, nil
+}
@@ -0,0 +1,110 @@
+package conversation
+
+import (
+	"encoding/base64"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/rotisserie/eris"
+)
+
+var (
+	InvalidStartPayload = eris.New("Invalid start payload")
+	StartPayloadTooLong = eris.New("Start payload too long")
+)
+
+// Telegram only accepts start parameters of up to 64 characters from A-Z, a-z, 0-9, _ and -.
+const maxStartParameterLength = 64
+
+var sourcePattern = regexp.MustCompile(`^[a-z0-9_-]{1,24}$`)
+
+// StartPayload is what a link that opens a booking conversation carries: the business, and
+// optionally the service and employee to preselect and the marketing source of the link. It
+// travels as a base64url encoded query string so it fits the characters Telegram allows in the
+// start parameter, the links of the other channels carry it the same way.
+type StartPayload struct {
+	BusinessID int    `json:"businessId"`
+	ServiceID  int    `json:"serviceId,omitempty"`
+	EmployeeID int    `json:"employeeId,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+func (p StartPayload) Encode() string {
+	values := url.Values{}
+	values.Set("b", strconv.Itoa(p.BusinessID))
+
+	if p.ServiceID != 0 {
+		values.Set("s", strconv.Itoa(p.ServiceID))
+	}
+
+	if p.EmployeeID != 0 {
+		values.Set("e", strconv.Itoa(p.EmployeeID))
+	}
+
+	if p.Source != "" {
+		values.Set("c", p.Source)
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(values.Encode()))
+}
+
+func (p StartPayload) Validate() error {
+	if p.BusinessID <= 0 || p.ServiceID < 0 || p.EmployeeID < 0 {
+		return InvalidStartPayload
+	}
+
+	if p.Source != "" && !sourcePattern.MatchString(p.Source) {
+		return InvalidStartPayload
+	}
+
+	if len(p.Encode()) > maxStartParameterLength {
+		return StartPayloadTooLong
+	}
+
+	return nil
+}
+
+// ParseStartPayload reads the parameter of /start or of the start text of a channel. Links shared before the payload was encoded
+// carry the business ID alone and keep working.
+func ParseStartPayload(raw string) (StartPayload, error) {
+	if businessID, err := strconv.Atoi(raw); err == nil {
+		payload := StartPayload{BusinessID: businessID}
+
+		return payload, payload.Validate()
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+
+	if err != nil {
+		return StartPayload{}, InvalidStartPayload
+	}
+
+	values, err := url.ParseQuery(string(decoded))
+
+	if err != nil {
+		return StartPayload{}, InvalidStartPayload
+	}
+
+	var payload StartPayload
+
+	fields := map[string]*int{"b": &payload.BusinessID, "s": &payload.ServiceID, "e": &payload.EmployeeID}
+
+	for key, field := range fields {
+		if !values.Has(key) {
+			continue
+		}
+
+		if *field, err = strconv.Atoi(values.Get(key)); err != nil {
+			return StartPayload{}, InvalidStartPayload
+		}
+	}
+
+	payload.Source = values.Get("c")
+
+	if err := payload.Validate(); err != nil {
+		return StartPayload{}, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,130 @@
+package customer
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/pkg/helper"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	CustomerNotFound = eris.New("Customer not found")
+	InvalidCustomer  = eris.New("Invalid customer")
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 40
+)
+
+// Customer is who books with a business from a chat, the same person in all their bookings with
+// it. It is created with their first booking, from then on the chats know their name and phone
+// and the business keeps its notes and tags about them.
+type Customer struct {
+	ID           string    `json:"id"`
+	BusinessID   int       `json:"businessId"`
+	Channel      string    `json:"channel"`
+	ChatID       int       `json:"chatId"`
+	Name         string    `json:"name"`
+	Phone        string    `json:"phone,omitempty"`
+	Notes        string    `json:"notes"`
+	Tags         []string  `json:"tags"`
+	FirstVisit   time.Time `json:"firstVisit"`
+	LastVisit    time.Time `json:"lastVisit"`
+	BookingCount int       `json:"bookingCount"`
+	LastBooking  string    `json:"-"`
+	DateAdd      time.Time `json:"createdAt"`
+	DateUpd      time.Time `json:"updatedAt"`
+}
+
+// NewCustomer is the profile of the customer of a first booking, the booking is not counted yet.
+func NewCustomer(found *booking.Booking) *Customer {
+	now := time.Now().UTC()
+
+	customer := &Customer{
+		ID:         helper.Uuid().String(),
+		BusinessID: found.BusinessID,
+		Channel:    found.Channel,
+		ChatID:     found.ChatID,
+		Name:       found.CustomerName,
+		Phone:      found.CustomerPhone,
+		Tags:       make([]string, 0),
+		FirstVisit: found.Date,
+		LastVisit:  found.Date,
+		DateAdd:    now,
+		DateUpd:    now,
+	}
+
+	// The WhatsApp chat is the phone number of the customer.
+	if customer.Phone == "" && found.Channel == booking.ChannelWhatsapp {
+		customer.Phone = "+" + strconv.Itoa(found.ChatID)
+	}
+
+	return customer
+}
+
+// RecordBooking counts the booking in the visits of the customer. Counting the same booking again
+// changes nothing, so a retried booking is not counted twice.
+func (c *Customer) RecordBooking(found *booking.Booking) {
+	if c.LastBooking == found.ID {
+		return
+	}
+
+	if found.CustomerName != "" {
+		c.Name = found.CustomerName
+	}
+
+	if c.Phone == "" && found.CustomerPhone != "" {
+		c.Phone = found.CustomerPhone
+	}
+
+	if c.BookingCount == 0 || found.Date.Before(c.FirstVisit) {
+		c.FirstVisit = found.Date
+	}
+
+	if found.Date.After(c.LastVisit) {
+		c.LastVisit = found.Date
+	}
+
+	c.BookingCount++
+	c.LastBooking = found.ID
+	c.DateUpd = time.Now().UTC()
+}
+
+// IsReturning tells a customer who booked before, the chats do not ask them again what they
+// already answered.
+func (c *Customer) IsReturning() bool {
+	return c.BookingCount > 1
+}
+
+// Annotate replaces the notes and tags of the business about the customer. Tags are trimmed,
+// lower cased and kept once.
+func (c *Customer) Annotate(notes string, tags []string) error {
+	if len(tags) > maxTags {
+		return eris.Wrapf(InvalidCustomer, "A customer takes up to %d tags", maxTags)
+	}
+
+	cleaned := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if tag == "" || len(tag) > maxTagLength {
+			return eris.Wrapf(InvalidCustomer, "Tags take from 1 to %d characters", maxTagLength)
+		}
+
+		if !slices.Contains(cleaned, tag) {
+			cleaned = append(cleaned, tag)
+		}
+	}
+
+	c.Notes = strings.TrimSpace(notes)
+	c.Tags = cleaned
+	c.DateUpd = time.Now().UTC()
+
+	return nil
+}
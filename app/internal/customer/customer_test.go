@@ -0,0 +1,77 @@
+package customer
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/rotisserie/eris"
+)
+
+func TestRecordBooking(t *testing.T) {
+	first := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+
+	found := &booking.Booking{
+		ID:           "b1",
+		BusinessID:   7,
+		ChatID:       42,
+		Channel:      booking.ChannelTelegram,
+		CustomerName: "Ana",
+		Date:         first,
+	}
+
+	customer := NewCustomer(found)
+	customer.RecordBooking(found)
+	customer.RecordBooking(found)
+
+	if customer.BookingCount != 1 {
+		t.Fatalf("booking count = %d, the same booking counts once", customer.BookingCount)
+	}
+
+	if customer.IsReturning() {
+		t.Error("a customer with one booking is not returning")
+	}
+
+	earlier := &booking.Booking{ID: "b2", CustomerName: "Ana García", CustomerPhone: "+34600111222", Date: first.AddDate(0, 0, -3)}
+	later := &booking.Booking{ID: "b3", Date: first.AddDate(0, 1, 0)}
+
+	customer.RecordBooking(earlier)
+	customer.RecordBooking(later)
+
+	if customer.BookingCount != 3 || !customer.IsReturning() {
+		t.Errorf("booking count = %d, want 3", customer.BookingCount)
+	}
+
+	if !customer.FirstVisit.Equal(earlier.Date) || !customer.LastVisit.Equal(later.Date) {
+		t.Errorf("visits = %s to %s", customer.FirstVisit, customer.LastVisit)
+	}
+
+	if customer.Name != "Ana García" || customer.Phone != "+34600111222" {
+		t.Errorf("name = %q, phone = %q", customer.Name, customer.Phone)
+	}
+}
+
+func TestNewCustomerTakesTheWhatsappNumber(t *testing.T) {
+	customer := NewCustomer(&booking.Booking{Channel: booking.ChannelWhatsapp, ChatID: 34600111222})
+
+	if customer.Phone != "+34600111222" {
+		t.Errorf("phone = %q", customer.Phone)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	customer := &Customer{}
+
+	if err := customer.Annotate("  Prefers mornings ", []string{"VIP", " vip", "regular"}); err != nil {
+		t.Fatalf("annotate: %v", err)
+	}
+
+	if customer.Notes != "Prefers mornings" || !slices.Equal(customer.Tags, []string{"vip", "regular"}) {
+		t.Errorf("notes = %q, tags = %v", customer.Notes, customer.Tags)
+	}
+
+	if err := customer.Annotate("", []string{" "}); !eris.Is(err, InvalidCustomer) {
+		t.Errorf("an empty tag should be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rotisserie/eris"
+)
+
+type CustomerRepository interface {
+	GetByChat(ctx context.Context, businessID int, channel string, chatID int) (*Customer, error)
+	GetLatestByChat(ctx context.Context, channel string, chatID int) (*Customer, error)
+	GetByID(ctx context.Context, businessID int, customerID string) (*Customer, error)
+	GetByBusiness(ctx context.Context, businessID int) ([]*Customer, error)
+	Save(ctx context.Context, customer *Customer) error
+}
+
+type PgCustomerRepository struct {
+	connection *sql.DB
+}
+
+func NewPgCustomerRepository(connection *sql.DB) *PgCustomerRepository {
+	return &PgCustomerRepository{
+		connection: connection,
+	}
+}
+
+const customerColumns = `
+	hac_id,
+	hac_business_id,
+	hac_channel,
+	hac_chat_id,
+	hac_name,
+	COALESCE(hac_phone, ''),
+	hac_notes,
+	hac_tags,
+	hac_first_visit,
+	hac_last_visit,
+	hac_booking_count,
+	COALESCE(hac_last_booking_id, ''),
+	hac_date_add,
+	hac_date_upd
+`
+
+func (r *PgCustomerRepository) GetByChat(ctx context.Context, businessID int, channel string, chatID int) (*Customer, error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM
+			ha_customer
+		WHERE
+			hac_business_id = $1 AND hac_channel = $2 AND hac_chat_id = $3;
+	`
+
+	return r.getOne(ctx, query, businessID, channel, chatID)
+}
+
+// GetLatestByChat returns the profile of the chat with the business it booked with last.
+func (r *PgCustomerRepository) GetLatestByChat(ctx context.Context, channel string, chatID int) (*Customer, error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM
+			ha_customer
+		WHERE
+			hac_channel = $1 AND hac_chat_id = $2
+		ORDER BY hac_date_upd DESC
+		LIMIT 1;
+	`
+
+	return r.getOne(ctx, query, channel, chatID)
+}
+
+func (r *PgCustomerRepository) GetByID(ctx context.Context, businessID int, customerID string) (*Customer, error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM
+			ha_customer
+		WHERE
+			hac_business_id = $1 AND hac_id = $2;
+	`
+
+	return r.getOne(ctx, query, businessID, customerID)
+}
+
+// GetByBusiness lists the customers of the business, the ones who visit last first.
+func (r *PgCustomerRepository) GetByBusiness(ctx context.Context, businessID int) (customers []*Customer, err error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM
+			ha_customer
+		WHERE
+			hac_business_id = $1
+		ORDER BY hac_last_visit DESC, hac_name;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching customers")
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = eris.Wrap(closeErr, "Error closing customer rows")
+		}
+	}()
+
+	customers = make([]*Customer, 0)
+
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		customers = append(customers, customer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "Error iterating customers")
+	}
+
+	return customers, nil
+}
+
+// Save stores the profile, the one of the same chat with the business when it was created
+// meanwhile by another booking.
+func (r *PgCustomerRepository) Save(ctx context.Context, customer *Customer) error {
+	query := `
+		INSERT INTO ha_customer (
+			hac_id,
+			hac_business_id,
+			hac_channel,
+			hac_chat_id,
+			hac_name,
+			hac_phone,
+			hac_notes,
+			hac_tags,
+			hac_first_visit,
+			hac_last_visit,
+			hac_booking_count,
+			hac_last_booking_id,
+			hac_date_add,
+			hac_date_upd
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, NULLIF($12, ''), $13, $14)
+		ON CONFLICT (hac_business_id, hac_channel, hac_chat_id) DO UPDATE SET
+			hac_name = EXCLUDED.hac_name,
+			hac_phone = EXCLUDED.hac_phone,
+			hac_notes = EXCLUDED.hac_notes,
+			hac_tags = EXCLUDED.hac_tags,
+			hac_first_visit = EXCLUDED.hac_first_visit,
+			hac_last_visit = EXCLUDED.hac_last_visit,
+			hac_booking_count = EXCLUDED.hac_booking_count,
+			hac_last_booking_id = EXCLUDED.hac_last_booking_id,
+			hac_date_upd = EXCLUDED.hac_date_upd;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		customer.ID,
+		customer.BusinessID,
+		customer.Channel,
+		customer.ChatID,
+		customer.Name,
+		customer.Phone,
+		customer.Notes,
+		pq.Array(customer.Tags),
+		customer.FirstVisit,
+		customer.LastVisit,
+		customer.BookingCount,
+		customer.LastBooking,
+		customer.DateAdd,
+		customer.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving customer")
+	}
+
+	return nil
+}
+
+func (r *PgCustomerRepository) getOne(ctx context.Context, query string, args ...any) (*Customer, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	customer, err := scanCustomer(r.connection.QueryRowContext(ctxTimeout, query, args...))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, CustomerNotFound
+		}
+
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCustomer(row rowScanner) (*Customer, error) {
+	var customer Customer
+
+	err := row.Scan(
+		&customer.ID,
+		&customer.BusinessID,
+		&customer.Channel,
+		&customer.ChatID,
+		&customer.Name,
+		&customer.Phone,
+		&customer.Notes,
+		pq.Array(&customer.Tags),
+		&customer.FirstVisit,
+		&customer.LastVisit,
+		&customer.BookingCount,
+		&customer.LastBooking,
+		&customer.DateAdd,
+		&customer.DateUpd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		return nil, eris.Wrap(err, "Error scanning customer")
+	}
+
+	if customer.Tags == nil {
+		customer.Tags = make([]string, 0)
+	}
+
+	return &customer, nil
+}
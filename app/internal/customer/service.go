@@ -0,0 +1,157 @@
+package customer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/rotisserie/eris"
+)
+
+type CustomerService interface {
+	GetChatCustomer(ctx context.Context, businessID int, channel string, chatID int) (*Customer, error)
+	RecordBooking(ctx context.Context, found *booking.Booking) (*Customer, error)
+	SaveChatPhone(ctx context.Context, channel string, chatID int, phone string) (*Customer, error)
+	GetCustomers(ctx context.Context, businessID int) ([]*Customer, error)
+	GetCustomer(ctx context.Context, businessID int, customerID string) (*Customer, error)
+	AnnotateCustomer(ctx context.Context, businessID int, customerID string, notes string, tags []string) (*Customer, error)
+}
+
+type Service struct {
+	logger *slog.Logger
+	repo   CustomerRepository
+}
+
+func NewService(logger *slog.Logger, repo CustomerRepository) *Service {
+	return &Service{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+/*
+================================================================================
+CHAT CUSTOMERS
+================================================================================
+*/
+
+// GetChatCustomer returns the profile of the customer of the chat with the business, CustomerNotFound
+// before their first booking.
+func (s *Service) GetChatCustomer(ctx context.Context, businessID int, channel string, chatID int) (*Customer, error) {
+	found, err := s.repo.GetByChat(ctx, businessID, channel, chatID)
+
+	if err != nil {
+		if eris.Is(err, CustomerNotFound) {
+			return nil, CustomerNotFound
+		}
+
+		return nil, eris.Wrap(err, "Error fetching the customer of the chat")
+	}
+
+	return found, nil
+}
+
+// RecordBooking counts the booking in the profile of its customer, creating it on their first
+// booking. The bookings of the web widget come from no chat and have no profile.
+func (s *Service) RecordBooking(ctx context.Context, found *booking.Booking) (*Customer, error) {
+	if found.ChatID == 0 {
+		return nil, CustomerNotFound
+	}
+
+	customer, err := s.GetChatCustomer(ctx, found.BusinessID, found.Channel, found.ChatID)
+
+	if err != nil && !eris.Is(err, CustomerNotFound) {
+		return nil, err
+	}
+
+	if customer == nil {
+		customer = NewCustomer(found)
+	}
+
+	customer.RecordBooking(found)
+
+	if err := s.repo.Save(ctx, customer); err != nil {
+		return nil, eris.Wrap(err, "Error storing the customer")
+	}
+
+	return customer, nil
+}
+
+// SaveChatPhone keeps the phone the customer shared from the chat in their profile with the
+// business they booked with last, the one that asked for it.
+func (s *Service) SaveChatPhone(ctx context.Context, channel string, chatID int, phone string) (*Customer, error) {
+	customer, err := s.repo.GetLatestByChat(ctx, channel, chatID)
+
+	if err != nil {
+		if eris.Is(err, CustomerNotFound) {
+			return nil, CustomerNotFound
+		}
+
+		return nil, eris.Wrap(err, "Error fetching the customer of the chat")
+	}
+
+	customer.Phone = phone
+	customer.DateUpd = time.Now().UTC()
+
+	if err := s.repo.Save(ctx, customer); err != nil {
+		return nil, eris.Wrap(err, "Error storing the phone of the customer")
+	}
+
+	return customer, nil
+}
+
+/*
+================================================================================
+BUSINESS CUSTOMERS
+================================================================================
+*/
+
+func (s *Service) GetCustomers(ctx context.Context, businessID int) ([]*Customer, error) {
+	customers, err := s.repo.GetByBusiness(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the customers of the business")
+	}
+
+	return customers, nil
+}
+
+func (s *Service) GetCustomer(ctx context.Context, businessID int, customerID string) (*Customer, error) {
+	customer, err := s.repo.GetByID(ctx, businessID, customerID)
+
+	if err != nil {
+		if eris.Is(err, CustomerNotFound) {
+			return nil, CustomerNotFound
+		}
+
+		return nil, eris.Wrap(err, "Error fetching the customer")
+	}
+
+	return customer, nil
+}
+
+// AnnotateCustomer replaces the notes and tags the business keeps about the customer.
+func (s *Service) AnnotateCustomer(
+	ctx context.Context,
+	businessID int,
+	customerID string,
+	notes string,
+	tags []string,
+) (*Customer, error) {
+	customer, err := s.GetCustomer(ctx, businessID, customerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := customer.Annotate(notes, tags); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(ctx, customer); err != nil {
+		return nil, eris.Wrap(err, "Error storing the customer")
+	}
+
+	return customer, nil
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/rotisserie/eris"
+)
+
+var FeedNotFound = eris.New("Booking feed not found")
+
+const (
+	tokenBytes = 32
+	PastWindow = 24 * time.Hour
+)
+
+// Feed is the secret URL a business subscribes to from its calendar app. Regenerating the token
+// revokes every URL handed out before.
+type Feed struct {
+	BusinessID   int
+	BusinessName string
+	BusinessLang string
+	Token        string
+	DateAdd      time.Time
+	DateUpd      time.Time
+}
+
+func NewFeed(businessID int) (*Feed, error) {
+	feed := &Feed{
+		BusinessID: businessID,
+		DateAdd:    time.Now().UTC(),
+	}
+
+	if err := feed.RegenerateToken(); err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func (f *Feed) RegenerateToken() error {
+	random := make([]byte, tokenBytes)
+
+	if _, err := rand.Read(random); err != nil {
+		return eris.Wrap(err, "Error generating the feed token")
+	}
+
+	f.Token = base64.RawURLEncoding.EncodeToString(random)
+	f.DateUpd = time.Now().UTC()
+
+	return nil
+}
+
+// Entry is a booking with the service details the feed shows.
+type Entry struct {
+	BookingID       string
+	CustomerName    string
+	ServiceName     string
+	ServiceDuration string
+	Status          string
+	Date            time.Time
+	DateUpd         time.Time
+}
+
+// Duration reads the service duration, stored either in minutes or as a Go duration, falling
+// back to one slot.
+func (e *Entry) Duration() time.Duration {
+	if minutes, err := strconv.Atoi(e.ServiceDuration); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+
+	if duration, err := time.ParseDuration(e.ServiceDuration); err == nil && duration > 0 {
+		return duration
+	}
+
+	return calendar.DefaultSlotDuration
+}
+
+// Document is a rendered feed along with the validators of its HTTP caching.
+type Document struct {
+	Body         string
+	ETag         string
+	LastModified time.Time
+}
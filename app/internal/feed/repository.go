@@ -0,0 +1,195 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/rotisserie/eris"
+)
+
+type FeedRepository interface {
+	Save(ctx context.Context, feed *Feed) error
+	GetByBusinessID(ctx context.Context, businessID int) (*Feed, error)
+	GetByToken(ctx context.Context, token string) (*Feed, error)
+	GetEntries(ctx context.Context, businessID int, from time.Time) ([]*Entry, error)
+}
+
+type PgFeedRepository struct {
+	connection *sql.DB
+}
+
+func NewPgFeedRepository(connection *sql.DB) *PgFeedRepository {
+	return &PgFeedRepository{
+		connection: connection,
+	}
+}
+
+func (r *PgFeedRepository) Save(ctx context.Context, feed *Feed) error {
+	query := `
+		INSERT INTO ha_booking_feed (
+			habf_business_id,
+			habf_token,
+			habf_date_add,
+			habf_date_upd
+		)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (habf_business_id) DO UPDATE SET
+			habf_token = EXCLUDED.habf_token,
+			habf_date_upd = EXCLUDED.habf_date_upd;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(ctxTimeout, query, feed.BusinessID, feed.Token, feed.DateAdd, feed.DateUpd)
+
+	if err != nil {
+		return eris.Wrapf(err, "Error saving the booking feed of business %d", feed.BusinessID)
+	}
+
+	return nil
+}
+
+func (r *PgFeedRepository) GetByBusinessID(ctx context.Context, businessID int) (*Feed, error) {
+	query := `
+		SELECT
+			habf_business_id,
+			hab_name,
+			COALESCE(hab_lang, ''),
+			habf_token,
+			habf_date_add,
+			habf_date_upd
+		FROM ha_booking_feed
+		JOIN ha_business ON hab_id = habf_business_id
+		WHERE habf_business_id = $1;
+	`
+
+	return r.getOne(ctx, query, businessID)
+}
+
+func (r *PgFeedRepository) GetByToken(ctx context.Context, token string) (*Feed, error) {
+	query := `
+		SELECT
+			habf_business_id,
+			hab_name,
+			COALESCE(hab_lang, ''),
+			habf_token,
+			habf_date_add,
+			habf_date_upd
+		FROM ha_booking_feed
+		JOIN ha_business ON hab_id = habf_business_id
+		WHERE habf_token = $1;
+	`
+
+	return r.getOne(ctx, query, token)
+}
+
+// GetEntries returns the bookings of the business from the given date on, cancelled ones
+// included so subscribed calendars drop them.
+func (r *PgFeedRepository) GetEntries(ctx context.Context, businessID int, from time.Time) (entries []*Entry, err error) {
+	query := `
+		SELECT
+			booking.id,
+			COALESCE(booking.customer_name, ''),
+			COALESCE(items.names, hasc_name, ''),
+			COALESCE(items.duration, hasc_duration, ''),
+			booking.status,
+			booking.booking_date,
+			booking.updated_at
+		FROM booking
+		LEFT JOIN ha_service_catalog ON CAST(hasc_id AS VARCHAR) = booking.service_id
+		LEFT JOIN (
+			SELECT
+				habi_booking_id,
+				STRING_AGG(habi_name, ' + ' ORDER BY habi_position) AS names,
+				CAST(SUM(habi_duration) AS VARCHAR) AS duration
+			FROM ha_booking_item
+			GROUP BY habi_booking_id
+		) items ON items.habi_booking_id = booking.id
+		WHERE
+			booking.business_id = $1
+			AND booking.booking_date >= $2
+		ORDER BY booking.booking_date;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, strconv.Itoa(businessID), from.UTC().Format(time.RFC3339))
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "Error fetching the feed entries of business %d", businessID)
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	entries = make([]*Entry, 0)
+
+	for rows.Next() {
+		var (
+			entry   Entry
+			date    string
+			dateUpd string
+		)
+
+		err := rows.Scan(
+			&entry.BookingID,
+			&entry.CustomerName,
+			&entry.ServiceName,
+			&entry.ServiceDuration,
+			&entry.Status,
+			&date,
+			&dateUpd,
+		)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error scanning feed entry")
+		}
+
+		if entry.Date, err = time.Parse(time.RFC3339, date); err != nil {
+			return nil, eris.Wrap(err, "Error parsing booking date")
+		}
+
+		if entry.DateUpd, err = time.Parse(time.RFC3339, dateUpd); err != nil {
+			return nil, eris.Wrap(err, "Error parsing booking update date")
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "Error iterating feed entries")
+	}
+
+	return entries, nil
+}
+
+func (r *PgFeedRepository) getOne(ctx context.Context, query string, arg any) (*Feed, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var feed Feed
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, arg).Scan(
+		&feed.BusinessID,
+		&feed.BusinessName,
+		&feed.BusinessLang,
+		&feed.Token,
+		&feed.DateAdd,
+		&feed.DateUpd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, FeedNotFound
+		}
+
+		return nil, eris.Wrap(err, "Error fetching booking feed")
+	}
+
+	return &feed, nil
+}
@@ -0,0 +1,149 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/adriein/hastypal/pkg/ics"
+	"github.com/rotisserie/eris"
+)
+
+type FeedService interface {
+	GetFeed(ctx context.Context, businessID int) (*Feed, error)
+	RegenerateFeed(ctx context.Context, businessID int) (*Feed, error)
+	Render(ctx context.Context, token string) (*Document, error)
+}
+
+type Service struct {
+	logger *slog.Logger
+	repo   FeedRepository
+	lang   translation.TranslationService
+}
+
+func NewService(logger *slog.Logger, repo FeedRepository, lang translation.TranslationService) *Service {
+	return &Service{
+		logger: logger,
+		repo:   repo,
+		lang:   lang,
+	}
+}
+
+// GetFeed returns the feed of the business, creating it the first time it is asked for.
+func (s *Service) GetFeed(ctx context.Context, businessID int) (*Feed, error) {
+	feed, err := s.repo.GetByBusinessID(ctx, businessID)
+
+	if err == nil {
+		return feed, nil
+	}
+
+	if !errors.Is(err, FeedNotFound) {
+		return nil, eris.Wrap(err, "Error fetching the booking feed")
+	}
+
+	return s.RegenerateFeed(ctx, businessID)
+}
+
+// RegenerateFeed issues a new token for the feed, the URLs with the old one stop working.
+func (s *Service) RegenerateFeed(ctx context.Context, businessID int) (*Feed, error) {
+	feed, err := NewFeed(businessID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(ctx, feed); err != nil {
+		return nil, eris.Wrap(err, "Error storing the booking feed")
+	}
+
+	s.logger.Info("Booking feed token regenerated", "business_id", businessID)
+
+	return feed, nil
+}
+
+func (s *Service) Render(ctx context.Context, token string) (*Document, error) {
+	feed, err := s.repo.GetByToken(ctx, token)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the booking feed")
+	}
+
+	entries, err := s.repo.GetEntries(ctx, feed.BusinessID, time.Now().Add(-PastWindow))
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the booking feed entries")
+	}
+
+	lastModified := feed.DateUpd
+	l := translation.NewLocalizer(s.lang, feed.BusinessLang)
+
+	stream := &ics.Calendar{
+		Name:   feed.BusinessName,
+		Method: ics.MethodPublish,
+		Events: make([]*ics.Event, len(entries)),
+	}
+
+	for i, entry := range entries {
+		stream.Events[i] = toIcsEvent(l, entry)
+
+		if entry.DateUpd.After(lastModified) {
+			lastModified = entry.DateUpd
+		}
+	}
+
+	body := stream.Write()
+	sum := sha256.Sum256([]byte(body))
+
+	return &Document{
+		Body:         body,
+		ETag:         fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16])),
+		LastModified: lastModified.UTC().Truncate(time.Second),
+	}, nil
+}
+
+// toIcsEvent writes the booking as an event, its description in the language of the business.
+func toIcsEvent(l translation.Localizer, entry *Entry) *ics.Event {
+	serviceName := entry.ServiceName
+
+	if serviceName == "" {
+		serviceName = l.T("feed.booking", nil)
+	}
+
+	summary := serviceName
+
+	if entry.CustomerName != "" {
+		summary = fmt.Sprintf("%s · %s", serviceName, entry.CustomerName)
+	}
+
+	status := ics.StatusConfirmed
+
+	if entry.Status == booking.StatusCancelled {
+		status = ics.StatusCancelled
+	}
+
+	var description strings.Builder
+
+	if entry.CustomerName != "" {
+		description.WriteString(l.T("feed.customer", translation.Params{"name": entry.CustomerName}) + "\n")
+	}
+
+	description.WriteString(l.T("feed.service", translation.Params{"service": serviceName}) + "\n")
+	description.WriteString(l.T("feed.duration", translation.Params{"minutes": int(entry.Duration().Minutes())}))
+
+	return &ics.Event{
+		UID:          entry.BookingID + "@hastypal",
+		Summary:      summary,
+		Description:  description.String(),
+		Status:       status,
+		Start:        entry.Date,
+		End:          entry.Date.Add(entry.Duration()),
+		LastModified: entry.DateUpd,
+	}
+}
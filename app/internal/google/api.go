@@ -2,7 +2,9 @@ package google
 
 import (
 	"context"
+	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/adriein/hastypal/pkg/constants"
@@ -10,13 +12,14 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
-	"google.golang.org/api/option"
 )
 
+// CalendarApi is the OAuth side of Google: it hands out the consent URL, trades the code for
+// the tokens and authenticates the HTTP requests of a business. The calendar SDK stays in Service.
 type CalendarApi interface {
-	GetAuthenticationURL(businessID string) string
-	ExchangeToken(ctx context.Context, state string, code string) (*GoogleToken, error)
-	Client(ctx context.Context, businessToken *GoogleToken) (*calendar.Service, error)
+	GetAuthenticationURL(state string, verifier string) string
+	ExchangeToken(ctx context.Context, code string, verifier string) (*GoogleToken, error)
+	Client(ctx context.Context, businessToken *GoogleToken, refreshed func(*GoogleToken)) (*http.Client, error)
 }
 
 type GoogleCalendarApi struct{}
@@ -29,32 +32,34 @@ func (g *GoogleCalendarApi) getOauth2Config() *oauth2.Config {
 	return &oauth2.Config{
 		ClientID:     os.Getenv(constants.GoogleClientId),
 		ClientSecret: os.Getenv(constants.GoogleClientSecret),
-		RedirectURL:  "http://localhost:4000/api/v1/business/google-auth-callback",
+		RedirectURL:  os.Getenv(constants.GoogleRedirectUrl),
 		Endpoint:     google.Endpoint,
-		Scopes:       []string{calendar.CalendarEventsScope},
+		Scopes:       []string{calendar.CalendarEventsScope, calendar.CalendarReadonlyScope},
 	}
 }
 
-func (g *GoogleCalendarApi) GetAuthenticationURL(businessID string) string {
+func (g *GoogleCalendarApi) GetAuthenticationURL(state string, verifier string) string {
 	config := g.getOauth2Config()
 
-	return config.AuthCodeURL(businessID, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(businessID))
+	return config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
 }
 
-func (g *GoogleCalendarApi) ExchangeToken(ctx context.Context, state string, code string) (*GoogleToken, error) {
+// ExchangeToken trades the authorization code for the tokens of the account, the caller sets the
+// business they belong to.
+func (g *GoogleCalendarApi) ExchangeToken(ctx context.Context, code string, verifier string) (*GoogleToken, error) {
 	config := g.getOauth2Config()
 
-	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(state))
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 
 	if err != nil {
 		return nil, eris.Wrap(err, "Error exchanging token")
 	}
 
 	googleToken := &GoogleToken{
-		BusinessID:   state,
 		AccessToken:  token.AccessToken,
 		TokenType:    token.TokenType,
 		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
 		DateAdd:      time.Now(),
 		DateUpd:      time.Now(),
 	}
@@ -62,20 +67,72 @@ func (g *GoogleCalendarApi) ExchangeToken(ctx context.Context, state string, cod
 	return googleToken, nil
 }
 
-func (g *GoogleCalendarApi) Client(ctx context.Context, businessToken *GoogleToken) (*calendar.Service, error) {
+// Client returns an HTTP client that authenticates as the business, refreshing the access
+// token when it expires. refreshed gets every new token so the caller can store it.
+func (g *GoogleCalendarApi) Client(
+	ctx context.Context,
+	businessToken *GoogleToken,
+	refreshed func(*GoogleToken),
+) (*http.Client, error) {
 	config := g.getOauth2Config()
 
 	token := &oauth2.Token{
 		AccessToken:  businessToken.AccessToken,
 		TokenType:    businessToken.TokenType,
 		RefreshToken: businessToken.RefreshToken,
+		Expiry:       businessToken.Expiry,
 	}
 
-	client, err := calendar.NewService(ctx, option.WithTokenSource(config.TokenSource(ctx, token)))
+	// Tokens stored before their expiry was kept would pass for never expiring, they are
+	// refreshed once to learn it.
+	if token.Expiry.IsZero() {
+		token.Expiry = businessToken.DateUpd
+	}
+
+	source := &refreshingTokenSource{
+		source:    config.TokenSource(ctx, token),
+		current:   businessToken,
+		refreshed: refreshed,
+	}
+
+	return oauth2.NewClient(ctx, source), nil
+}
+
+// refreshingTokenSource hands out the tokens of source and tells refreshed about the ones that
+// are not the last it saw.
+type refreshingTokenSource struct {
+	mu        sync.Mutex
+	source    oauth2.TokenSource
+	current   *GoogleToken
+	refreshed func(*GoogleToken)
+}
+
+func (s *refreshingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
 
 	if err != nil {
-		return nil, eris.Wrap(err, "Error creating the calendar service")
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token.AccessToken == s.current.AccessToken {
+		return token, nil
+	}
+
+	next := *s.current
+	next.AccessToken = token.AccessToken
+	next.TokenType = token.TokenType
+	next.RefreshToken = token.RefreshToken
+	next.Expiry = token.Expiry
+	next.DateUpd = time.Now()
+
+	s.current = &next
+
+	if s.refreshed != nil {
+		s.refreshed(&next)
 	}
 
-	return client, nil
+	return token, nil
 }
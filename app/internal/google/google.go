@@ -1,12 +1,108 @@
 package google
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+var (
+	TokenNotFound     = eris.New("Google token not found")
+	ChannelNotFound   = eris.New("Google watch channel not found")
+	InvalidOAuthState = eris.New("Invalid google authorization state")
+)
+
+const (
+	PrimaryCalendar          = "primary"
+	BookingIDEventProperty   = "hastypalBookingId"
+	ChannelTtl               = 7 * 24 * time.Hour
+	ChannelRenewalWindow     = 24 * time.Hour
+	ResourceStateSync        = "sync"
+	EventStatusCancelledName = "cancelled"
+	OAuthStateTtl            = 10 * time.Minute
+	oauthStateBytes          = 32
+)
 
 type GoogleToken struct {
 	BusinessID   string
 	AccessToken  string
 	TokenType    string
 	RefreshToken string
+	Expiry       time.Time
 	DateAdd      time.Time
 	DateUpd      time.Time
 }
+
+// OAuthState is an authorization started by a member of the business. Google gets the random
+// State back on the callback, and only the session that started it can finish it with the PKCE
+// Verifier kept here.
+type OAuthState struct {
+	State      string
+	BusinessID int
+	SessionID  string
+	Verifier   string
+	Expiration time.Time
+	DateAdd    time.Time
+}
+
+func NewOAuthState(businessID int, sessionID string, verifier string) (*OAuthState, error) {
+	random := make([]byte, oauthStateBytes)
+
+	if _, err := rand.Read(random); err != nil {
+		return nil, eris.Wrap(err, "Error generating the google authorization state")
+	}
+
+	now := time.Now().UTC()
+
+	return &OAuthState{
+		State:      base64.RawURLEncoding.EncodeToString(random),
+		BusinessID: businessID,
+		SessionID:  sessionID,
+		Verifier:   verifier,
+		Expiration: now.Add(OAuthStateTtl),
+		DateAdd:    now,
+	}, nil
+}
+
+// Belongs tells whether the callback comes from the session of the business that started the
+// authorization, in time.
+func (s *OAuthState) Belongs(businessID int, sessionID string, now time.Time) bool {
+	return s.BusinessID == businessID && s.SessionID == sessionID && now.Before(s.Expiration)
+}
+
+// WatchChannel is an events.watch subscription registered for the calendar of a business.
+type WatchChannel struct {
+	ID         string
+	BusinessID int
+	ResourceID string
+	CalendarID string
+	Token      string
+	SyncToken  string
+	Expiration time.Time
+	DateAdd    time.Time
+	DateUpd    time.Time
+}
+
+func (c *WatchChannel) NeedsRenewal(now time.Time) bool {
+	return c.Expiration.Before(now.Add(ChannelRenewalWindow))
+}
+
+// Calendar is one of the calendars the connected Google account can write events to.
+type Calendar struct {
+	Id       string `json:"id"`
+	Summary  string `json:"summary"`
+	TimeZone string `json:"timeZone"`
+	Primary  bool   `json:"primary"`
+}
+
+// EventChange is a Hastypal created event that changed in Google Calendar since the last sync.
+type EventChange struct {
+	EventID    string
+	BookingID  string
+	BusinessID int
+	CalendarID string
+	Cancelled  bool
+	Start      time.Time
+}
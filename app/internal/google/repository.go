@@ -3,11 +3,25 @@ package google
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/rotisserie/eris"
 )
 
 type GoogleRepository interface {
 	Save(ctx context.Context, token *GoogleToken) error
 	GetByBusinessID(ctx context.Context, ID int) (*GoogleToken, error)
+	SaveChannel(ctx context.Context, channel *WatchChannel) error
+	UpdateChannel(ctx context.Context, channel *WatchChannel) error
+	DeleteChannel(ctx context.Context, channelID string) error
+	GetChannelByID(ctx context.Context, channelID string) (*WatchChannel, error)
+	GetChannelByCalendar(ctx context.Context, businessID int, calendarID string) (*WatchChannel, error)
+	GetChannelsExpiringBefore(ctx context.Context, date time.Time) ([]*WatchChannel, error)
+	SaveOAuthState(ctx context.Context, state *OAuthState) error
+	TakeOAuthState(ctx context.Context, state string) (*OAuthState, error)
 }
 
 type PgGoogleRepository struct {
@@ -21,9 +35,387 @@ func NewPgGoogleRepository(connection *sql.DB) *PgGoogleRepository {
 }
 
 func (r *PgGoogleRepository) Save(ctx context.Context, token *GoogleToken) error {
+	query := `
+		INSERT INTO google_token (
+			business_id,
+			access_token,
+			token_type,
+			refresh_token,
+			expiry,
+			created_at,
+			updated_at
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		ON CONFLICT (business_id) DO UPDATE SET
+			access_token = EXCLUDED.access_token,
+			token_type = EXCLUDED.token_type,
+			refresh_token = COALESCE(NULLIF(EXCLUDED.refresh_token, ''), google_token.refresh_token),
+			expiry = EXCLUDED.expiry,
+			updated_at = EXCLUDED.updated_at;
+	`
+
+	expiry := ""
+
+	if !token.Expiry.IsZero() {
+		expiry = token.Expiry.UTC().Format(time.DateTime)
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		token.BusinessID,
+		token.AccessToken,
+		token.TokenType,
+		token.RefreshToken,
+		expiry,
+		token.DateAdd.UTC().Format(time.DateTime),
+		token.DateUpd.UTC().Format(time.DateTime),
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving google token")
+	}
+
 	return nil
 }
 
 func (r *PgGoogleRepository) GetByBusinessID(ctx context.Context, ID int) (*GoogleToken, error) {
-	return nil, nil
+	query := `
+		SELECT
+			business_id,
+			access_token,
+			token_type,
+			refresh_token,
+			COALESCE(expiry, ''),
+			created_at,
+			updated_at
+		FROM
+			google_token
+		WHERE
+			business_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var (
+		token   GoogleToken
+		expiry  string
+		dateAdd string
+		dateUpd string
+	)
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, strconv.Itoa(ID)).Scan(
+		&token.BusinessID,
+		&token.AccessToken,
+		&token.TokenType,
+		&token.RefreshToken,
+		&expiry,
+		&dateAdd,
+		&dateUpd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, TokenNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query google token by business ID")
+	}
+
+	token.Expiry, _ = time.Parse(time.DateTime, expiry)
+	token.DateAdd, _ = time.Parse(time.DateTime, dateAdd)
+	token.DateUpd, _ = time.Parse(time.DateTime, dateUpd)
+
+	return &token, nil
+}
+
+func (r *PgGoogleRepository) SaveChannel(ctx context.Context, channel *WatchChannel) error {
+	query := `
+		INSERT INTO ha_google_channel (
+			hagc_id,
+			hagc_business_id,
+			hagc_resource_id,
+			hagc_calendar_id,
+			hagc_token,
+			hagc_sync_token,
+			hagc_expiration,
+			hagc_date_add,
+			hagc_date_upd
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		channel.ID,
+		channel.BusinessID,
+		channel.ResourceID,
+		channel.CalendarID,
+		channel.Token,
+		channel.SyncToken,
+		channel.Expiration,
+		channel.DateAdd,
+		channel.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving google watch channel")
+	}
+
+	return nil
+}
+
+func (r *PgGoogleRepository) UpdateChannel(ctx context.Context, channel *WatchChannel) error {
+	query := `
+		UPDATE ha_google_channel
+		SET
+			hagc_sync_token = NULLIF($2, ''),
+			hagc_expiration = $3,
+			hagc_date_upd = $4
+		WHERE
+			hagc_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		channel.ID,
+		channel.SyncToken,
+		channel.Expiration,
+		channel.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error updating google watch channel")
+	}
+
+	return nil
+}
+
+func (r *PgGoogleRepository) DeleteChannel(ctx context.Context, channelID string) error {
+	query := `DELETE FROM ha_google_channel WHERE hagc_id = $1;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := r.connection.ExecContext(ctxTimeout, query, channelID); err != nil {
+		return eris.Wrap(err, "Error deleting google watch channel")
+	}
+
+	return nil
+}
+
+func (r *PgGoogleRepository) GetChannelByID(ctx context.Context, channelID string) (*WatchChannel, error) {
+	query := `
+		SELECT
+			hagc_id,
+			hagc_business_id,
+			hagc_resource_id,
+			hagc_calendar_id,
+			hagc_token,
+			COALESCE(hagc_sync_token, ''),
+			hagc_expiration,
+			hagc_date_add,
+			hagc_date_upd
+		FROM
+			ha_google_channel
+		WHERE
+			hagc_id = $1;
+	`
+
+	return r.getChannel(ctx, query, channelID)
+}
+
+func (r *PgGoogleRepository) GetChannelByCalendar(ctx context.Context, businessID int, calendarID string) (*WatchChannel, error) {
+	query := `
+		SELECT
+			hagc_id,
+			hagc_business_id,
+			hagc_resource_id,
+			hagc_calendar_id,
+			hagc_token,
+			COALESCE(hagc_sync_token, ''),
+			hagc_expiration,
+			hagc_date_add,
+			hagc_date_upd
+		FROM
+			ha_google_channel
+		WHERE
+			hagc_business_id = $1 AND hagc_calendar_id = $2
+		ORDER BY
+			hagc_expiration DESC
+		LIMIT 1;
+	`
+
+	return r.getChannel(ctx, query, businessID, calendarID)
+}
+
+func (r *PgGoogleRepository) GetChannelsExpiringBefore(ctx context.Context, date time.Time) (channels []*WatchChannel, err error) {
+	query := `
+		SELECT
+			hagc_id,
+			hagc_business_id,
+			hagc_resource_id,
+			hagc_calendar_id,
+			hagc_token,
+			COALESCE(hagc_sync_token, ''),
+			hagc_expiration,
+			hagc_date_add,
+			hagc_date_upd
+		FROM
+			ha_google_channel
+		WHERE
+			hagc_expiration < $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, date)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to query expiring google watch channels")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	for rows.Next() {
+		channel, err := scanChannel(rows)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to scan google watch channel")
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+func (r *PgGoogleRepository) SaveOAuthState(ctx context.Context, state *OAuthState) error {
+	query := `
+		INSERT INTO ha_google_oauth_state (
+			hagos_state,
+			hagos_business_id,
+			hagos_session_id,
+			hagos_verifier,
+			hagos_expiration,
+			hagos_date_add
+		)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		state.State,
+		state.BusinessID,
+		state.SessionID,
+		state.Verifier,
+		state.Expiration,
+		state.DateAdd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving google authorization state")
+	}
+
+	return nil
+}
+
+// TakeOAuthState returns the authorization state and deletes it, so a callback cannot be replayed.
+func (r *PgGoogleRepository) TakeOAuthState(ctx context.Context, state string) (*OAuthState, error) {
+	query := `
+		DELETE FROM ha_google_oauth_state
+		WHERE
+			hagos_state = $1
+		RETURNING
+			hagos_state,
+			hagos_business_id,
+			hagos_session_id,
+			hagos_verifier,
+			hagos_expiration,
+			hagos_date_add;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var found OAuthState
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, state).Scan(
+		&found.State,
+		&found.BusinessID,
+		&found.SessionID,
+		&found.Verifier,
+		&found.Expiration,
+		&found.DateAdd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, InvalidOAuthState
+		}
+
+		return nil, eris.Wrap(err, "Failed to take google authorization state")
+	}
+
+	return &found, nil
+}
+
+func (r *PgGoogleRepository) getChannel(ctx context.Context, query string, args ...any) (*WatchChannel, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	channel, err := scanChannel(r.connection.QueryRowContext(ctxTimeout, query, args...))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ChannelNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query google watch channel")
+	}
+
+	return channel, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanChannel(row rowScanner) (*WatchChannel, error) {
+	var channel WatchChannel
+
+	err := row.Scan(
+		&channel.ID,
+		&channel.BusinessID,
+		&channel.ResourceID,
+		&channel.CalendarID,
+		&channel.Token,
+		&channel.SyncToken,
+		&channel.Expiration,
+		&channel.DateAdd,
+		&channel.DateUpd,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &channel, nil
 }
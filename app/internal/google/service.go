@@ -2,105 +2,641 @@ package google
 
 import (
 	"context"
+	"errors"
 	"log/slog"
-	"net/url"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/pkg/constants"
+	"github.com/adriein/hastypal/pkg/helper"
 	"github.com/rotisserie/eris"
-	"google.golang.org/api/calendar/v3"
+	"golang.org/x/oauth2"
+	gcalendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 )
 
 type GoogleService interface {
-	Authenticate(ctx context.Context, bussinesID string) (string, error)
-	Authorize() error
-	CalendarEvent(ctx context.Context, businessID int, date time.Time) error
+	Authenticate(ctx context.Context, businessID int, sessionID string) (string, error)
+	Authorize(ctx context.Context, businessID int, sessionID string, state string, code string) error
+	ListCalendars(ctx context.Context, businessID int) ([]*Calendar, error)
+	WatchCalendar(ctx context.Context, businessID int, calendarID string) error
+	RenewChannels(ctx context.Context) error
+	SyncChanges(ctx context.Context, channelID string, channelToken string, resourceID string) ([]*EventChange, string, error)
+	SaveSyncToken(ctx context.Context, channelID string, syncToken string) error
 }
 
+// Service is the Google implementation of calendar.Provider. On top of it, it handles the OAuth
+// flow and the push notifications that keep the bookings in sync with Google Calendar.
 type Service struct {
-	logger *slog.Logger
-	api    CalendarApi
-	repo   GoogleRepository
+	logger      *slog.Logger
+	api         CalendarApi
+	repo        GoogleRepository
+	connections calendar.ConnectionRepository
 }
 
 func NewService(
 	logger *slog.Logger,
 	api CalendarApi,
 	repo GoogleRepository,
+	connections calendar.ConnectionRepository,
 ) *Service {
 	return &Service{
-		logger: logger,
-		api:    api,
-		repo:   repo,
+		logger:      logger,
+		api:         api,
+		repo:        repo,
+		connections: connections,
 	}
 }
 
-func (s *Service) Authenticate(ctx context.Context, bussinesID string) (string, error) {
-	url := s.api.GetAuthenticationURL(bussinesID)
+// Authenticate starts the authorization of the business in Google and returns the consent URL. The
+// random state and the PKCE verifier are kept on the server for the session that started it.
+func (s *Service) Authenticate(ctx context.Context, businessID int, sessionID string) (string, error) {
+	state, err := NewOAuthState(businessID, sessionID, oauth2.GenerateVerifier())
 
-	return url, nil
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.SaveOAuthState(ctx, state); err != nil {
+		return "", eris.Wrap(err, "Error storing the google authorization state")
+	}
+
+	return s.api.GetAuthenticationURL(state.State, state.Verifier), nil
 }
 
-func (s *Service) Authorize(ctx context.Context, req string) error {
-	parsedUrl, err := url.Parse(req)
+// Authorize finishes the authorization started by Authenticate. The state is used once, and only
+// by the session of the business that started it before it expires.
+func (s *Service) Authorize(ctx context.Context, businessID int, sessionID string, state string, code string) error {
+	started, err := s.repo.TakeOAuthState(ctx, state)
 
 	if err != nil {
-		return eris.Wrap(err, "Error parsing the URL")
+		if eris.Is(err, InvalidOAuthState) {
+			return InvalidOAuthState
+		}
+
+		return eris.Wrap(err, "Error fetching the google authorization state")
 	}
 
-	code := parsedUrl.Query().Get("code")
-	state := parsedUrl.Query().Get("state")
+	if !started.Belongs(businessID, sessionID, time.Now().UTC()) {
+		return InvalidOAuthState
+	}
 
-	token, err := s.api.ExchangeToken(ctx, state, code)
+	token, err := s.api.ExchangeToken(ctx, code, started.Verifier)
 
 	if err != nil {
 		return eris.Wrap(err, "Error exchanging token")
 	}
 
+	token.BusinessID = strconv.Itoa(businessID)
+
 	if err := s.repo.Save(ctx, token); err != nil {
 		return eris.Wrap(err, "Error storing the exchanged google token")
 	}
 
+	now := time.Now().UTC()
+
+	connection := &calendar.Connection{
+		BusinessID: businessID,
+		Provider:   calendar.ProviderGoogle,
+		DateAdd:    now,
+		DateUpd:    now,
+	}
+
+	if err := s.connections.Save(ctx, connection); err != nil {
+		return eris.Wrap(err, "Error storing the google calendar connection")
+	}
+
+	if err := s.WatchCalendar(ctx, businessID, PrimaryCalendar); err != nil {
+		return eris.Wrap(err, "Error watching the business calendar")
+	}
+
 	return nil
 }
 
-func (s *Service) CalendarEvent(ctx context.Context, businessID int, date time.Time) error {
+/*
+================================================================================
+CALENDAR PROVIDER
+================================================================================
+*/
+
+// CreateEvent creates the event of a booking and returns its ID. The event ID is derived from the
+// booking so a retried creation finds the event already there instead of duplicating it.
+func (s *Service) CreateEvent(ctx context.Context, businessID int, event *calendar.Event) (string, error) {
+	client, err := s.client(ctx, businessID)
+
+	if err != nil {
+		return "", err
+	}
+
+	googleEvent := toGoogleEvent(event)
+	googleEvent.Id = EventIDFor(event.BookingID)
+	googleEvent.Status = "confirmed"
+	googleEvent.ExtendedProperties = &gcalendar.EventExtendedProperties{
+		Private: map[string]string{BookingIDEventProperty: event.BookingID},
+	}
+
+	created, err := client.Events.Insert(calendarOrPrimary(event.CalendarID), googleEvent).Context(ctx).Do()
+
+	if err != nil {
+		if hasStatus(err, http.StatusConflict) {
+			return googleEvent.Id, nil
+		}
+
+		return "", eris.Wrap(err, "Error creating the event to the calendar")
+	}
+
+	return created.Id, nil
+}
+
+// UpdateEvent moves the event of the booking. Events deleted by the owner meanwhile are ignored,
+// the sync of the calendar cancels their booking.
+func (s *Service) UpdateEvent(ctx context.Context, businessID int, event *calendar.Event) error {
+	client, err := s.client(ctx, businessID)
+
+	if err != nil {
+		return err
+	}
+
+	patch := toGoogleEvent(event)
+
+	_, err = client.Events.Patch(calendarOrPrimary(event.CalendarID), event.ID, patch).Context(ctx).Do()
+
+	if err != nil && !hasStatus(err, http.StatusGone) && !hasStatus(err, http.StatusNotFound) {
+		return eris.Wrap(err, "Error updating the event of the calendar")
+	}
+
+	return nil
+}
+
+// DeleteEvent removes the event from the calendar. Events already deleted by the owner are ignored.
+func (s *Service) DeleteEvent(ctx context.Context, businessID int, calendarID string, eventID string) error {
+	client, err := s.client(ctx, businessID)
+
+	if err != nil {
+		return err
+	}
+
+	err = client.Events.Delete(calendarOrPrimary(calendarID), eventID).Context(ctx).Do()
+
+	if err != nil && !hasStatus(err, http.StatusGone) && !hasStatus(err, http.StatusNotFound) {
+		return eris.Wrap(err, "Error deleting the event of the calendar")
+	}
+
+	return nil
+}
+
+// FreeBusy returns the busy periods of every calendar between from and to, keyed by calendar ID.
+func (s *Service) FreeBusy(
+	ctx context.Context,
+	businessID int,
+	calendarIDs []string,
+	from time.Time,
+	to time.Time,
+) (map[string][]calendar.BusyPeriod, error) {
+	client, err := s.client(ctx, businessID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*gcalendar.FreeBusyRequestItem, len(calendarIDs))
+
+	for i, calendarID := range calendarIDs {
+		items[i] = &gcalendar.FreeBusyRequestItem{Id: calendarOrPrimary(calendarID)}
+	}
+
+	response, err := client.Freebusy.Query(&gcalendar.FreeBusyRequest{
+		TimeMin: from.Format(time.RFC3339),
+		TimeMax: to.Format(time.RFC3339),
+		Items:   items,
+	}).Context(ctx).Do()
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error querying the free/busy information")
+	}
+
+	busy := make(map[string][]calendar.BusyPeriod, len(response.Calendars))
+
+	for calendarID, freeBusy := range response.Calendars {
+		if len(freeBusy.Errors) > 0 {
+			return nil, eris.Errorf("Error in the free/busy of calendar %s: %s", calendarID, freeBusy.Errors[0].Reason)
+		}
+
+		periods := make([]calendar.BusyPeriod, 0, len(freeBusy.Busy))
+
+		for _, period := range freeBusy.Busy {
+			start, err := time.Parse(time.RFC3339, period.Start)
+
+			if err != nil {
+				return nil, eris.Wrap(err, "Error parsing the busy period start")
+			}
+
+			end, err := time.Parse(time.RFC3339, period.End)
+
+			if err != nil {
+				return nil, eris.Wrap(err, "Error parsing the busy period end")
+			}
+
+			periods = append(periods, calendar.BusyPeriod{Start: start, End: end})
+		}
+
+		busy[calendarID] = periods
+	}
+
+	return busy, nil
+}
+
+// ListCalendars returns the calendars of the connected account where Hastypal can write events.
+func (s *Service) ListCalendars(ctx context.Context, businessID int) ([]*Calendar, error) {
+	client, err := s.client(ctx, businessID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	calendars := make([]*Calendar, 0)
+	pageToken := ""
+
+	for {
+		call := client.CalendarList.List().MinAccessRole("writer").Context(ctx)
+
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		list, err := call.Do()
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error listing the calendars")
+		}
+
+		for _, entry := range list.Items {
+			calendars = append(calendars, &Calendar{
+				Id:       entry.Id,
+				Summary:  entry.Summary,
+				TimeZone: entry.TimeZone,
+				Primary:  entry.Primary,
+			})
+		}
+
+		if list.NextPageToken == "" {
+			return calendars, nil
+		}
+
+		pageToken = list.NextPageToken
+	}
+}
+
+func (s *Service) client(ctx context.Context, businessID int) (*gcalendar.Service, error) {
 	token, err := s.repo.GetByBusinessID(ctx, businessID)
 
 	if err != nil {
-		return eris.Wrap(err, "Error retrieving the token")
+		return nil, eris.Wrap(err, "Error retrieving the token")
 	}
 
-	client, err := s.api.Client(ctx, token)
+	// The refreshed token is stored so the next client does not refresh it again, a token that
+	// could not be stored is still good for this one.
+	httpClient, err := s.api.Client(ctx, token, func(refreshed *GoogleToken) {
+		if err := s.repo.Save(context.WithoutCancel(ctx), refreshed); err != nil {
+			s.logger.Warn(
+				"Error storing the refreshed google token",
+				"business_id", businessID,
+				"error", eris.ToString(err, true),
+			)
+		}
+	})
 
 	if err != nil {
-		return eris.Wrap(err, "Error getting the google calendar client")
+		return nil, eris.Wrap(err, "Error getting the google calendar client")
 	}
 
-	//TODO: the end date and start date is the same I need to figure out how to do that
-	event := &calendar.Event{
-		Summary:     "Google I/O 2015",
-		Location:    "800 Howard St., San Francisco, CA 94103",
-		Description: "A chance to hear more about Google's developer products.",
-		Start: &calendar.EventDateTime{
-			DateTime: date.Format(time.RFC3339),
-			TimeZone: "Europe/Madrid",
-		},
-		End: &calendar.EventDateTime{
-			DateTime: date.Format(time.RFC3339),
-			TimeZone: "Europe/Madrid",
+	client, err := gcalendar.NewService(ctx, option.WithHTTPClient(httpClient))
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error creating the calendar service")
+	}
+
+	return client, nil
+}
+
+func toGoogleEvent(event *calendar.Event) *gcalendar.Event {
+	timeZone := event.TimeZone
+
+	if timeZone == "" {
+		timeZone = calendar.DefaultTimeZone
+	}
+
+	return &gcalendar.Event{
+		Summary:     event.Summary,
+		Description: event.Description,
+		Location:    event.Location,
+		Start: &gcalendar.EventDateTime{
+			DateTime: event.Start.Format(time.RFC3339),
+			TimeZone: timeZone,
 		},
-		Organizer: &calendar.EventOrganizer{
-			Email: "adria.claret@gmail.com",
-			Self:  true,
+		End: &gcalendar.EventDateTime{
+			DateTime: event.End.Format(time.RFC3339),
+			TimeZone: timeZone,
 		},
-		Status: "confirmed",
 	}
+}
+
+func calendarOrPrimary(calendarID string) string {
+	if calendarID == "" {
+		return PrimaryCalendar
+	}
+
+	return calendarID
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *googleapi.Error
+
+	return errors.As(err, &apiErr) && apiErr.Code == status
+}
+
+// EventIDFor derives the calendar event ID from the booking ID so that retrying the creation of
+// an event never duplicates it. Google accepts base32hex characters, which covers a hex UUID.
+func EventIDFor(bookingID string) string {
+	return strings.ReplaceAll(bookingID, "-", "")
+}
+
+/*
+================================================================================
+PUSH NOTIFICATIONS
+================================================================================
+*/
+
+// WatchCalendar registers an events.watch channel for a calendar of the business, replacing the
+// previous one, and stores the sync token used for the incremental syncs.
+func (s *Service) WatchCalendar(ctx context.Context, businessID int, calendarID string) error {
+	client, err := s.client(ctx, businessID)
+
+	if err != nil {
+		return err
+	}
+
+	previous, err := s.repo.GetChannelByCalendar(ctx, businessID, calendarOrPrimary(calendarID))
+
+	if err != nil && !errors.Is(err, ChannelNotFound) {
+		return eris.Wrap(err, "Error retrieving the current watch channel")
+	}
+
+	channel := &WatchChannel{
+		ID:         helper.Uuid().String(),
+		BusinessID: businessID,
+		CalendarID: calendarOrPrimary(calendarID),
+		Token:      helper.Uuid().String(),
+		DateAdd:    time.Now().UTC(),
+		DateUpd:    time.Now().UTC(),
+	}
+
+	if previous != nil {
+		channel.SyncToken = previous.SyncToken
+	}
+
+	if channel.SyncToken == "" {
+		// The bookings already match the calendar they are watched from, only the token counts.
+		_, syncToken, err := s.listChanges(ctx, client, channel, "")
+
+		if err != nil {
+			return eris.Wrap(err, "Error fetching the initial sync token")
+		}
+
+		channel.SyncToken = syncToken
+	}
+
+	watched, err := client.Events.Watch(channel.CalendarID, &gcalendar.Channel{
+		Id:         channel.ID,
+		Type:       "web_hook",
+		Address:    os.Getenv(constants.GoogleCalendarWebhookUrl),
+		Token:      channel.Token,
+		Expiration: time.Now().Add(ChannelTtl).UnixMilli(),
+	}).Context(ctx).Do()
+
+	if err != nil {
+		return eris.Wrap(err, "Error registering the calendar watch channel")
+	}
+
+	channel.ResourceID = watched.ResourceId
+	channel.Expiration = time.UnixMilli(watched.Expiration).UTC()
+
+	if err := s.repo.SaveChannel(ctx, channel); err != nil {
+		return eris.Wrap(err, "Error storing the calendar watch channel")
+	}
+
+	if previous != nil {
+		s.stopChannel(ctx, client, previous)
+	}
+
+	s.logger.Info(
+		"Google calendar watch channel registered",
+		"business_id", businessID,
+		"calendar_id", channel.CalendarID,
+		"channel_id", channel.ID,
+		"expiration", channel.Expiration,
+	)
+
+	return nil
+}
+
+// RenewChannels re-registers every watch channel that expires inside the renewal window.
+// A failing business does not prevent the renewal of the rest.
+func (s *Service) RenewChannels(ctx context.Context) error {
+	channels, err := s.repo.GetChannelsExpiringBefore(ctx, time.Now().UTC().Add(ChannelRenewalWindow))
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the channels about to expire")
+	}
+
+	var renewErr error
+
+	for _, channel := range channels {
+		if err := s.WatchCalendar(ctx, channel.BusinessID, channel.CalendarID); err != nil {
+			s.logger.Error(
+				"Error renewing google calendar watch channel",
+				"business_id", channel.BusinessID,
+				"channel_id", channel.ID,
+				"error", eris.ToString(err, true),
+			)
+
+			renewErr = eris.Wrapf(err, "Error renewing channel %s", channel.ID)
+		}
+	}
+
+	return renewErr
+}
+
+// SyncChanges runs an incremental sync for the channel that received a push notification and
+// returns the Hastypal events that were moved or deleted since the last sync, with the sync token
+// that comes after them. The token is not stored here: the caller saves it with SaveSyncToken once
+// every change is applied, so a failed notification is synced again from the same point. When
+// Google no longer accepts the sync token the changes are every Hastypal event of the calendar, so
+// the bookings are reconciled with all of them before the new token is saved.
+func (s *Service) SyncChanges(
+	ctx context.Context,
+	channelID string,
+	channelToken string,
+	resourceID string,
+) ([]*EventChange, string, error) {
+	channel, err := s.repo.GetChannelByID(ctx, channelID)
+
+	if err != nil {
+		return nil, "", eris.Wrap(err, "Error retrieving the watch channel")
+	}
+
+	if channel.Token != channelToken || channel.ResourceID != resourceID {
+		return nil, "", eris.New("Push notification does not match the registered channel")
+	}
+
+	client, err := s.client(ctx, channel.BusinessID)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	changes, syncToken, err := s.listChanges(ctx, client, channel, channel.SyncToken)
+
+	if err != nil && hasStatus(err, http.StatusGone) {
+		s.logger.Warn("Google sync token invalidated, running a full sync", "channel_id", channel.ID)
+
+		changes, syncToken, err = s.listChanges(ctx, client, channel, "")
+	}
+
+	if err != nil {
+		return nil, "", eris.Wrap(err, "Error listing the changed events")
+	}
+
+	return changes, syncToken, nil
+}
+
+// listChanges lists the events of the channel calendar changed since syncToken, all of them when
+// it is empty, and returns those of Hastypal with the sync token that comes after them.
+func (s *Service) listChanges(
+	ctx context.Context,
+	client *gcalendar.Service,
+	channel *WatchChannel,
+	syncToken string,
+) ([]*EventChange, string, error) {
+	changes := make([]*EventChange, 0)
+	pageToken := ""
+
+	for {
+		call := client.Events.List(channel.CalendarID).
+			ShowDeleted(true).
+			SingleEvents(true).
+			MaxResults(2500).
+			Context(ctx)
+
+		if syncToken != "" {
+			call = call.SyncToken(syncToken)
+		}
+
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		events, err := call.Do()
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, event := range events.Items {
+			change, err := toEventChange(channel, event)
+
+			// An event that cannot be read will not read better on the next notification.
+			if err != nil {
+				s.logger.Warn(
+					"Skipping unreadable google calendar event",
+					"channel_id", channel.ID,
+					"event_id", event.Id,
+					"error", eris.ToString(err, true),
+				)
+
+				continue
+			}
+
+			if change != nil {
+				changes = append(changes, change)
+			}
+		}
+
+		if events.NextPageToken == "" {
+			return changes, events.NextSyncToken, nil
+		}
+
+		pageToken = events.NextPageToken
+	}
+}
 
-	_, err = client.Events.Insert("primary", event).Do()
+// SaveSyncToken moves the channel past the changes of a sync once they are all applied.
+func (s *Service) SaveSyncToken(ctx context.Context, channelID string, syncToken string) error {
+	channel, err := s.repo.GetChannelByID(ctx, channelID)
 
 	if err != nil {
-		return eris.Wrap(err, "Error creating the event to the calendar")
+		return eris.Wrap(err, "Error retrieving the watch channel")
+	}
+
+	channel.SyncToken = syncToken
+	channel.DateUpd = time.Now().UTC()
+
+	if err := s.repo.UpdateChannel(ctx, channel); err != nil {
+		return eris.Wrap(err, "Error storing the new sync token")
 	}
 
 	return nil
 }
+
+func (s *Service) stopChannel(ctx context.Context, client *gcalendar.Service, channel *WatchChannel) {
+	err := client.Channels.Stop(&gcalendar.Channel{
+		Id:         channel.ID,
+		ResourceId: channel.ResourceID,
+	}).Context(ctx).Do()
+
+	if err != nil {
+		s.logger.Warn("Error stopping google calendar watch channel", "channel_id", channel.ID, "error", err)
+	}
+
+	if err := s.repo.DeleteChannel(ctx, channel.ID); err != nil {
+		s.logger.Warn("Error deleting google calendar watch channel", "channel_id", channel.ID, "error", err)
+	}
+}
+
+// toEventChange maps a changed event to a booking change. Deleted events come back from an
+// incremental sync without their extended properties, so those are matched by event ID later on.
+func toEventChange(channel *WatchChannel, event *gcalendar.Event) (*EventChange, error) {
+	change := &EventChange{
+		EventID:    event.Id,
+		BusinessID: channel.BusinessID,
+		CalendarID: channel.CalendarID,
+		Cancelled:  event.Status == EventStatusCancelledName,
+	}
+
+	if event.ExtendedProperties != nil {
+		change.BookingID = event.ExtendedProperties.Private[BookingIDEventProperty]
+	}
+
+	if change.Cancelled {
+		return change, nil
+	}
+
+	if change.BookingID == "" || event.Start == nil {
+		return nil, nil
+	}
+
+	start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error parsing the event start date")
+	}
+
+	change.Start = start
+
+	return change, nil
+}
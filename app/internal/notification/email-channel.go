@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/adriein/hastypal/pkg/ics"
+	"github.com/rotisserie/eris"
+)
+
+// EmailChannel emails the notifications to the customers who left an email. The ones that put the
+// booking in the calendar or take it out carry the invite that does it.
+type EmailChannel struct {
+	sender EmailSender
+}
+
+func NewEmailChannel(sender EmailSender) *EmailChannel {
+	return &EmailChannel{
+		sender: sender,
+	}
+}
+
+func (c *EmailChannel) NotificationAddress(recipient Recipient) string {
+	return recipient.Email
+}
+
+func (c *EmailChannel) SendNotification(ctx context.Context, message *Message) error {
+	l := message.Localizer
+
+	params := translation.Params{
+		"name":     message.Booking.CustomerName,
+		"business": message.Business.Name,
+		"date":     message.Date(),
+		"hour":     message.Hour(),
+	}
+
+	content := emailContent{
+		Lang:       l.Locale,
+		Title:      l.T(fmt.Sprintf("email.%s_subject", message.Notification.Type), params),
+		Paragraphs: []string{message.Text},
+		Details:    bookingDetails(l, message.Booking, message.LocalDate),
+		Footer:     l.T("email.footer", params),
+	}
+
+	attachments := make([]Attachment, 0, 1)
+
+	switch message.Notification.Type {
+	case TypeBooked, TypeApproved, TypeRescheduled:
+		content.Paragraphs = append(content.Paragraphs, l.T("email.invite_notice", nil))
+		attachments = append(attachments, inviteAttachment(bookingInvite(message, InviteRequest)))
+	case TypeCancelled:
+		attachments = append(attachments, inviteAttachment(bookingInvite(message, InviteCancel)))
+	}
+
+	email, err := content.email([]string{message.Address}, attachments...)
+
+	if err != nil {
+		return err
+	}
+
+	if err := c.sender.Send(ctx, email); err != nil {
+		return eris.Wrap(err, "Error emailing the customer")
+	}
+
+	return nil
+}
+
+// bookingInvite is the calendar event of the booking. The UID is the same for all the invites of
+// the booking and the sequence the time of its last change, so calendars update the event.
+func bookingInvite(message *Message, method string) *ics.Calendar {
+	found := message.Booking
+
+	status := ics.StatusConfirmed
+
+	if method == InviteCancel {
+		status = ics.StatusCancelled
+	}
+
+	return &ics.Calendar{
+		Method: method,
+		Events: []*ics.Event{{
+			UID:         fmt.Sprintf("%s@hastypal", found.ID),
+			Sequence:    int(found.DateUpd.Unix()),
+			Summary:     message.Business.Name,
+			Description: strings.Join(found.ServiceNames(), ", "),
+			Location:    message.Business.Address,
+			Status:      status,
+			Start:       found.Date,
+			End:         found.Date.Add(found.Duration(calendar.DefaultSlotDuration)),
+			Organizer:   &ics.Participant{Name: message.Business.Name, Email: message.Business.Email},
+			Attendees:   []ics.Participant{{Name: found.CustomerName, Email: message.Address}},
+		}},
+	}
+}
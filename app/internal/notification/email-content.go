@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/rotisserie/eris"
+)
+
+type emailDetail struct {
+	Label string
+	Value string
+}
+
+// emailContent is what an email says, written once as plain text and once as HTML.
+type emailContent struct {
+	Lang       string
+	Title      string
+	Paragraphs []string
+	Details    []emailDetail
+	Link       string
+	LinkLabel  string
+	Footer     string
+}
+
+var emailLayout = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="margin:0;padding:24px;background:#f5f7fa;font-family:system-ui,-apple-system,'Segoe UI',sans-serif;color:#1f2933">
+<table role="presentation" width="100%" style="max-width:560px;margin:0 auto;background:#ffffff;border:1px solid #e4e7eb">
+<tr><td style="padding:24px">
+<h1 style="margin:0 0 16px;font-size:20px">{{.Title}}</h1>
+{{range .Paragraphs}}<p style="margin:0 0 12px">{{.}}</p>
+{{end}}{{if .Details}}<table role="presentation" style="margin:8px 0 16px">
+{{range .Details}}<tr><td style="padding:2px 16px 2px 0;color:#616e7c">{{.Label}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Link}}<p><a href="{{.Link}}" style="color:#2563eb">{{.LinkLabel}}</a></p>
+{{end}}<p style="margin:16px 0 0;font-size:12px;color:#616e7c">{{.Footer}}</p>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+func (c emailContent) email(to []string, attachments ...Attachment) (*Email, error) {
+	var html bytes.Buffer
+
+	if err := emailLayout.Execute(&html, c); err != nil {
+		return nil, eris.Wrap(err, "Error rendering the email")
+	}
+
+	return &Email{
+		To:          to,
+		Subject:     c.Title,
+		Text:        c.text(),
+		Html:        html.String(),
+		Attachments: attachments,
+	}, nil
+}
+
+func (c emailContent) text() string {
+	var text strings.Builder
+
+	text.WriteString(c.Title + "\n\n")
+
+	for _, paragraph := range c.Paragraphs {
+		text.WriteString(paragraph + "\n\n")
+	}
+
+	for _, detail := range c.Details {
+		text.WriteString(fmt.Sprintf("%s: %s\n", detail.Label, detail.Value))
+	}
+
+	if len(c.Details) > 0 {
+		text.WriteString("\n")
+	}
+
+	if c.Link != "" {
+		text.WriteString(fmt.Sprintf("%s: %s\n\n", c.LinkLabel, c.Link))
+	}
+
+	text.WriteString(c.Footer + "\n")
+
+	return text.String()
+}
+
+// bookingDetails lists who booked, what and when, with the total when the services have a price.
+func bookingDetails(l translation.Localizer, found *booking.Booking, localDate time.Time) []emailDetail {
+	details := make([]emailDetail, 0)
+
+	if found.CustomerName != "" {
+		details = append(details, emailDetail{Label: l.T("email.customer", nil), Value: found.CustomerName})
+	}
+
+	if services := found.ServiceNames(); len(services) > 0 {
+		details = append(details, emailDetail{Label: l.T("email.service", nil), Value: strings.Join(services, " + ")})
+	}
+
+	details = append(details, emailDetail{
+		Label: l.T("email.date", nil),
+		Value: fmt.Sprintf("%s, %s", l.Date(localDate), l.Hour(l.Time(localDate))),
+	})
+
+	if total, currency := found.Total(); total > 0 {
+		details = append(details, emailDetail{Label: l.T("email.total", nil), Value: l.Money(total, currency)})
+	}
+
+	return details
+}
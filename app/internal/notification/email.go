@@ -0,0 +1,263 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/pkg/helper"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	InvalidSender      = eris.New("Invalid email sender address")
+	StartTlsNotOffered = eris.New("The SMTP server does not offer STARTTLS")
+)
+
+const (
+	smtpTimeout = 30 * time.Second
+	// base64LineLength keeps the attachments within the line length limit of RFC 5322.
+	base64LineLength = 76
+)
+
+// Email is a message with a plain text and an HTML version of the same content, the mail client
+// shows the one it can.
+type Email struct {
+	To          []string
+	Subject     string
+	Text        string
+	Html        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+type EmailSender interface {
+	Send(ctx context.Context, email *Email) error
+}
+
+// SmtpConfig is where the emails are sent from. RequireTls refuses servers that do not offer
+// STARTTLS, local sinks like MailHog do not so it is turned off for them.
+type SmtpConfig struct {
+	Host       string
+	Port       string
+	Username   string
+	Password   string
+	From       string
+	RequireTls bool
+}
+
+// SmtpSender delivers the emails to an SMTP server, upgrading the connection with STARTTLS when
+// the server offers it. Credentials only go over an encrypted connection.
+type SmtpSender struct {
+	config SmtpConfig
+	from   *mail.Address
+}
+
+func NewSmtpSender(config SmtpConfig) (*SmtpSender, error) {
+	from, err := mail.ParseAddress(config.From)
+
+	if err != nil {
+		return nil, eris.Wrap(InvalidSender, err.Error())
+	}
+
+	return &SmtpSender{
+		config: config,
+		from:   from,
+	}, nil
+}
+
+func (s *SmtpSender) Send(ctx context.Context, email *Email) error {
+	message, err := compose(s.from, email, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: smtpTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, s.config.Port))
+
+	if err != nil {
+		return eris.Wrap(err, "Error connecting to the SMTP server")
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+
+		return eris.Wrap(err, "Error setting the SMTP deadline")
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+
+	if err != nil {
+		conn.Close()
+
+		return eris.Wrap(err, "Error greeting the SMTP server")
+	}
+
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return eris.Wrap(err, "Error starting TLS with the SMTP server")
+		}
+	} else if s.config.RequireTls {
+		return StartTlsNotOffered
+	}
+
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return eris.Wrap(err, "Error authenticating with the SMTP server")
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return eris.Wrap(err, "Error setting the sender of the email")
+	}
+
+	for _, recipient := range email.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return eris.Wrapf(err, "Error adding the recipient %s", recipient)
+		}
+	}
+
+	writer, err := client.Data()
+
+	if err != nil {
+		return eris.Wrap(err, "Error starting the email data")
+	}
+
+	if _, err := writer.Write(message); err != nil {
+		return eris.Wrap(err, "Error writing the email")
+	}
+
+	if err := writer.Close(); err != nil {
+		return eris.Wrap(err, "Error sending the email")
+	}
+
+	return client.Quit()
+}
+
+// compose writes the email as a multipart/mixed message: the text and HTML versions go together
+// in a multipart/alternative part, followed by the attachments.
+func compose(from *mail.Address, email *Email, now time.Time) ([]byte, error) {
+	var message bytes.Buffer
+
+	mixed := multipart.NewWriter(&message)
+
+	_, domain, _ := strings.Cut(from.Address, "@")
+
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + strings.Join(email.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", email.Subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%s@%s>", helper.Uuid().String(), domain),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", mixed.Boundary()),
+	}
+
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	var body bytes.Buffer
+
+	alternative := multipart.NewWriter(&body)
+
+	versions := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.Html},
+	}
+
+	for _, version := range versions {
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {version.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error creating the email body")
+		}
+
+		encoder := quotedprintable.NewWriter(part)
+
+		if _, err := encoder.Write([]byte(version.content)); err != nil {
+			return nil, eris.Wrap(err, "Error encoding the email body")
+		}
+
+		if err := encoder.Close(); err != nil {
+			return nil, eris.Wrap(err, "Error encoding the email body")
+		}
+	}
+
+	if err := alternative.Close(); err != nil {
+		return nil, eris.Wrap(err, "Error closing the email body")
+	}
+
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", alternative.Boundary())},
+	})
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error creating the email body")
+	}
+
+	if _, err := part.Write(body.Bytes()); err != nil {
+		return nil, eris.Wrap(err, "Error writing the email body")
+	}
+
+	for _, attachment := range email.Attachments {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+
+		if err != nil {
+			return nil, eris.Wrapf(err, "Error creating the attachment %s", attachment.Name)
+		}
+
+		if _, err := part.Write(wrapBase64(attachment.Content)); err != nil {
+			return nil, eris.Wrapf(err, "Error writing the attachment %s", attachment.Name)
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, eris.Wrap(err, "Error closing the email")
+	}
+
+	return message.Bytes(), nil
+}
+
+func wrapBase64(content []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(content)
+
+	var wrapped bytes.Buffer
+
+	for len(encoded) > base64LineLength {
+		wrapped.WriteString(encoded[:base64LineLength] + "\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+
+	wrapped.WriteString(encoded + "\r\n")
+
+	return wrapped.Bytes()
+}
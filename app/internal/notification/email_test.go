@@ -0,0 +1,272 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adriein/hastypal/pkg/ics"
+)
+
+// smtpSink is a bare SMTP server in the spirit of MailHog: it takes any message without TLS nor
+// authentication and keeps the envelope and the data of the last one.
+type smtpSink struct {
+	listener   net.Listener
+	from       string
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newSmtpSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	sink := &smtpSink{listener: listener, done: make(chan struct{})}
+
+	go sink.serve()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return sink
+}
+
+func (s *smtpSink) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 sink ready")
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			return
+		}
+
+		command := strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 sink")
+		case "MAIL":
+			s.from = command
+			reply("250 ok")
+		case "RCPT":
+			s.recipients = append(s.recipients, command)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+
+			var data strings.Builder
+
+			for {
+				line, err := reader.ReadString('\n')
+
+				if err != nil {
+					return
+				}
+
+				if line == ".\r\n" {
+					break
+				}
+
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+
+			s.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpSink) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+
+	return port
+}
+
+func TestSmtpSenderDeliversTheMultipartEmail(t *testing.T) {
+	sink := newSmtpSink(t)
+
+	sender, err := NewSmtpSender(SmtpConfig{
+		Host: "127.0.0.1",
+		Port: sink.port(),
+		From: "Hastypal <reservas@hastypal.com>",
+	})
+
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+
+	invite := &ics.Calendar{
+		Method: InviteRequest,
+		Events: []*ics.Event{{
+			UID:     "b1@hastypal",
+			Summary: "Peluquería Marta",
+			Start:   time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC),
+			End:     time.Date(2026, 3, 5, 9, 30, 0, 0, time.UTC),
+		}},
+	}
+
+	email := &Email{
+		To:          []string{"ana@example.com"},
+		Subject:     "Tu cita en Peluquería Marta está confirmada",
+		Text:        "Hola Ana, tu cita está confirmada.",
+		Html:        "<p>Hola Ana, tu cita está confirmada.</p>",
+		Attachments: []Attachment{inviteAttachment(invite)},
+	}
+
+	if err := sender.Send(context.Background(), email); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	<-sink.done
+
+	if !strings.Contains(sink.from, "<reservas@hastypal.com>") {
+		t.Errorf("sender = %q", sink.from)
+	}
+
+	if len(sink.recipients) != 1 || !strings.Contains(sink.recipients[0], "<ana@example.com>") {
+		t.Errorf("recipients = %q", sink.recipients)
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(sink.data))
+
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+
+	if err != nil || subject != email.Subject {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("content type = %q, %v", mediaType, err)
+	}
+
+	parts := multipart.NewReader(message.Body, params["boundary"])
+
+	body, err := parts.NextPart()
+
+	if err != nil {
+		t.Fatalf("body part: %v", err)
+	}
+
+	bodyType, bodyParams, _ := mime.ParseMediaType(body.Header.Get("Content-Type"))
+
+	if bodyType != "multipart/alternative" {
+		t.Fatalf("body content type = %q", bodyType)
+	}
+
+	versions := multipart.NewReader(body, bodyParams["boundary"])
+
+	for _, want := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain", email.Text},
+		{"text/html", email.Html},
+	} {
+		// The reader decodes the quoted-printable parts itself.
+		version, err := versions.NextPart()
+
+		if err != nil {
+			t.Fatalf("%s part: %v", want.contentType, err)
+		}
+
+		if contentType, _, _ := mime.ParseMediaType(version.Header.Get("Content-Type")); contentType != want.contentType {
+			t.Errorf("content type = %q, want %q", contentType, want.contentType)
+		}
+
+		content, _ := io.ReadAll(version)
+
+		if string(content) != want.content {
+			t.Errorf("%s = %q, want %q", want.contentType, content, want.content)
+		}
+	}
+
+	attachment, err := parts.NextPart()
+
+	if err != nil {
+		t.Fatalf("attachment part: %v", err)
+	}
+
+	if attachment.FileName() != "invite.ics" {
+		t.Errorf("attachment name = %q", attachment.FileName())
+	}
+
+	if contentType := attachment.Header.Get("Content-Type"); contentType != "text/calendar; charset=utf-8; method=REQUEST" {
+		t.Errorf("attachment content type = %q", contentType)
+	}
+
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("more parts than the body and the invite: %v", err)
+	}
+}
+
+func TestSmtpSenderRequiresStartTls(t *testing.T) {
+	sink := newSmtpSink(t)
+
+	sender, err := NewSmtpSender(SmtpConfig{
+		Host:       "127.0.0.1",
+		Port:       sink.port(),
+		From:       "reservas@hastypal.com",
+		RequireTls: true,
+	})
+
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+
+	err = sender.Send(context.Background(), &Email{To: []string{"ana@example.com"}, Subject: "Hola"})
+
+	if err != StartTlsNotOffered {
+		t.Fatalf("send = %v, want %v", err, StartTlsNotOffered)
+	}
+
+	<-sink.done
+
+	if sink.data != "" {
+		t.Errorf("the email went through a plain connection")
+	}
+}
+
+func TestNewSmtpSenderRejectsAnInvalidSender(t *testing.T) {
+	if _, err := NewSmtpSender(SmtpConfig{From: "reservas"}); err == nil {
+		t.Fatal("expected an error for a sender without a domain")
+	}
+}
@@ -0,0 +1,23 @@
+package notification
+
+import (
+	"fmt"
+
+	"github.com/adriein/hastypal/pkg/ics"
+)
+
+// Methods of an invite.
+const (
+	InviteRequest = ics.MethodRequest
+	InviteCancel  = ics.MethodCancel
+)
+
+// inviteAttachment attaches the calendar as an invite, which mail clients offer to add to the
+// calendar of the recipient.
+func inviteAttachment(invite *ics.Calendar) Attachment {
+	return Attachment{
+		Name:        "invite.ics",
+		ContentType: fmt.Sprintf("text/calendar; charset=utf-8; method=%s", invite.Method),
+		Content:     []byte(invite.Write()),
+	}
+}
@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/rotisserie/eris"
+)
+
+// EmailInvitationSender emails the invitations to join a business, in the language of the business.
+type EmailInvitationSender struct {
+	sender   EmailSender
+	business business.BusinessService
+	lang     translation.TranslationService
+}
+
+func NewEmailInvitationSender(
+	sender EmailSender,
+	business business.BusinessService,
+	lang translation.TranslationService,
+) *EmailInvitationSender {
+	return &EmailInvitationSender{
+		sender:   sender,
+		business: business,
+		lang:     lang,
+	}
+}
+
+func (s *EmailInvitationSender) SendInvitation(ctx context.Context, invitation *auth.Invitation, businessName string, link string) error {
+	owner, err := s.business.GetBusinessByID(ctx, invitation.BusinessID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the business of the invitation")
+	}
+
+	l := translation.NewLocalizer(s.lang, owner.Lang)
+
+	params := translation.Params{
+		"business": businessName,
+		"date":     l.Date(invitation.ExpiresAt),
+	}
+
+	content := emailContent{
+		Lang:       l.Locale,
+		Title:      l.T("email.invitation_subject", params),
+		Paragraphs: []string{l.T("email.invitation_text", params)},
+		Link:       link,
+		LinkLabel:  l.T("email.invitation_accept", nil),
+		Footer:     l.T("email.footer", params),
+	}
+
+	email, err := content.email([]string{invitation.Email})
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.sender.Send(ctx, email); err != nil {
+		return eris.Wrap(err, "Error emailing the invitation")
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	// Undeliverable is a failure retrying does not fix, e.g. WhatsApp refusing free form messages
+	// outside the customer window. The attempt is recorded and the channel given up.
+	Undeliverable  = eris.New("Notification cannot be delivered through the channel")
+	InvalidChannel = eris.New("Invalid notification channel")
+)
+
+// What a customer is told about their booking.
+const (
+	TypeReminder    = "reminder"
+	TypeBooked      = "booked"
+	TypeApproved    = "approved"
+	TypeRejected    = "rejected"
+	TypeRescheduled = "rescheduled"
+	TypeCancelled   = "cancelled"
+)
+
+// The channels a notification goes through. Telegram and WhatsApp reach the customers who booked
+// from them, email and SMS those who left an address or a phone.
+const (
+	ChannelTelegram = "telegram"
+	ChannelWhatsapp = "whatsapp"
+	ChannelEmail    = "email"
+	ChannelSms      = "sms"
+)
+
+var Channels = []string{ChannelTelegram, ChannelWhatsapp, ChannelEmail, ChannelSms}
+
+const (
+	DeliverySent          = "sent"
+	DeliveryFailed        = "failed"
+	DeliveryUndeliverable = "undeliverable"
+)
+
+// Notification is something to tell the customer of a booking. The ID comes from what triggered
+// it, the outbox message or the reminder, so a retry is the same notification and does not send
+// again through the channels that already delivered it.
+type Notification struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	BusinessID int               `json:"businessId"`
+	BookingID  string            `json:"bookingId"`
+	Recipient  Recipient         `json:"recipient"`
+	Payload    map[string]string `json:"payload"`
+	DateAdd    time.Time         `json:"createdAt"`
+}
+
+// Recipient is the customer of the booking with every address the channels can reach them at.
+// Key identifies the customer within the business, their preferences are stored under it.
+type Recipient struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Locale  string `json:"locale,omitempty"`
+	Channel string `json:"channel"`
+	ChatID  int    `json:"chatId,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
+
+func NewRecipient(found *booking.Booking) Recipient {
+	recipient := Recipient{
+		Key:     CustomerKey(found),
+		Name:    found.CustomerName,
+		Locale:  found.Locale,
+		Channel: found.Channel,
+		ChatID:  found.ChatID,
+		Phone:   found.CustomerPhone,
+		Email:   found.CustomerEmail,
+	}
+
+	// The WhatsApp chat is the phone number of the customer.
+	if recipient.Phone == "" && found.Channel == booking.ChannelWhatsapp {
+		recipient.Phone = "+" + strconv.Itoa(found.ChatID)
+	}
+
+	return recipient
+}
+
+// CustomerKey names the customer of a booking the same way in all their bookings: by their chat
+// when they booked from one and by their phone when they booked from the web.
+func CustomerKey(found *booking.Booking) string {
+	if found.Channel == booking.ChannelTelegram || found.Channel == booking.ChannelWhatsapp {
+		return fmt.Sprintf("%s:%d", found.Channel, found.ChatID)
+	}
+
+	return "phone:" + normalizePhone(found.CustomerPhone)
+}
+
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '+' || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return -1
+	}, phone)
+}
+
+// Delivery is one attempt to send a notification through a channel. A channel is tried again on
+// the next retry of the notification until an attempt is sent or fails as undeliverable.
+type Delivery struct {
+	ID             string    `json:"id"`
+	NotificationID string    `json:"notificationId"`
+	BookingID      string    `json:"bookingId"`
+	Type           string    `json:"type"`
+	Channel        string    `json:"channel"`
+	Address        string    `json:"address"`
+	Attempt        int       `json:"attempt"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	DateAdd        time.Time `json:"createdAt"`
+}
+
+// Preference is the channels a customer wants to be told through, in place of the defaults.
+type Preference struct {
+	BusinessID  int       `json:"businessId"`
+	CustomerKey string    `json:"customer"`
+	Channels    []string  `json:"channels"`
+	DateUpd     time.Time `json:"updatedAt"`
+}
+
+// Settings are the channels a business lets its notifications go through, e.g. to leave out SMS
+// for what it costs. A business that never set them uses all of them.
+type Settings struct {
+	BusinessID int       `json:"businessId"`
+	Channels   []string  `json:"channels"`
+	DateUpd    time.Time `json:"updatedAt"`
+}
+
+func ValidateChannels(channels []string) error {
+	for _, channel := range channels {
+		if !slices.Contains(Channels, channel) {
+			return eris.Wrapf(InvalidChannel, "Unknown channel %s", channel)
+		}
+	}
+
+	return nil
+}
+
+// Message is a notification ready for a channel: the booking and the business it is about, the
+// language of the customer and the text of the notification already written in it.
+type Message struct {
+	Notification *Notification
+	Address      string
+	Booking      *booking.Booking
+	Business     *business.Business
+	Localizer    translation.Localizer
+	// LocalDate is the date of the booking in the time of the business.
+	LocalDate time.Time
+	Text      string
+}
+
+func (m *Message) Date() string {
+	return m.Localizer.Date(m.LocalDate)
+}
+
+func (m *Message) Hour() string {
+	return m.Localizer.Time(m.LocalDate)
+}
+
+// ChannelSender delivers the notifications through one channel.
+type ChannelSender interface {
+	// NotificationAddress is where the channel reaches the recipient, empty when it cannot.
+	NotificationAddress(recipient Recipient) string
+	SendNotification(ctx context.Context, message *Message) error
+}
@@ -0,0 +1,260 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/rotisserie/eris"
+)
+
+// fakeRepository keeps the preferences and settings the routing reads, the rest is not used.
+type fakeRepository struct {
+	NotificationRepository
+	preference *Preference
+	settings   *Settings
+}
+
+func (r *fakeRepository) GetPreference(ctx context.Context, businessID int, customerKey string) (*Preference, error) {
+	if r.preference == nil || r.preference.CustomerKey != customerKey {
+		return nil, PreferenceNotFound
+	}
+
+	return r.preference, nil
+}
+
+func (r *fakeRepository) GetSettings(ctx context.Context, businessID int) (*Settings, error) {
+	if r.settings == nil {
+		return &Settings{BusinessID: businessID, Channels: Channels}, nil
+	}
+
+	return r.settings, nil
+}
+
+// fakeSender reaches the recipient through the address the test picks for it.
+type fakeSender struct {
+	address func(recipient Recipient) string
+}
+
+func (s *fakeSender) NotificationAddress(recipient Recipient) string {
+	return s.address(recipient)
+}
+
+func (s *fakeSender) SendNotification(ctx context.Context, message *Message) error {
+	return nil
+}
+
+func routingService(repo *fakeRepository) *Service {
+	return &Service{
+		repo: repo,
+		senders: map[string]ChannelSender{
+			ChannelTelegram: &fakeSender{address: func(r Recipient) string {
+				if r.Channel != booking.ChannelTelegram {
+					return ""
+				}
+
+				return "chat"
+			}},
+			ChannelEmail: &fakeSender{address: func(r Recipient) string { return r.Email }},
+			ChannelSms:   &fakeSender{address: func(r Recipient) string { return r.Phone }},
+		},
+	}
+}
+
+func TestRoute(t *testing.T) {
+	telegramCustomer := Recipient{Key: "telegram:42", Channel: booking.ChannelTelegram, ChatID: 42, Email: "ana@example.com"}
+	webCustomer := Recipient{Key: "phone:+34600111222", Channel: booking.ChannelWeb, Phone: "+34600111222"}
+
+	tests := []struct {
+		name      string
+		repo      *fakeRepository
+		typ       string
+		recipient Recipient
+		want      []string
+	}{
+		{
+			name:      "chat and email by default",
+			repo:      &fakeRepository{},
+			typ:       TypeReminder,
+			recipient: telegramCustomer,
+			want:      []string{ChannelTelegram, ChannelEmail},
+		},
+		{
+			name:      "the chat they booked from is skipped when booking",
+			repo:      &fakeRepository{},
+			typ:       TypeBooked,
+			recipient: telegramCustomer,
+			want:      []string{ChannelEmail},
+		},
+		{
+			name:      "sms takes the place of the chat for the web without an email",
+			repo:      &fakeRepository{},
+			typ:       TypeBooked,
+			recipient: webCustomer,
+			want:      []string{ChannelSms},
+		},
+		{
+			name: "the preference of the customer replaces the defaults",
+			repo: &fakeRepository{
+				preference: &Preference{CustomerKey: "telegram:42", Channels: []string{ChannelEmail}},
+			},
+			typ:       TypeReminder,
+			recipient: telegramCustomer,
+			want:      []string{ChannelEmail},
+		},
+		{
+			name:      "channels the business left out are dropped",
+			repo:      &fakeRepository{settings: &Settings{Channels: []string{ChannelTelegram, ChannelWhatsapp}}},
+			typ:       TypeReminder,
+			recipient: telegramCustomer,
+			want:      []string{ChannelTelegram},
+		},
+		{
+			name: "channels without a sender are dropped",
+			repo: &fakeRepository{
+				preference: &Preference{CustomerKey: "telegram:42", Channels: []string{ChannelWhatsapp, ChannelTelegram}},
+			},
+			typ:       TypeReminder,
+			recipient: telegramCustomer,
+			want:      []string{ChannelTelegram},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := routingService(test.repo)
+
+			got, err := service.route(context.Background(), &Notification{
+				Type:       test.typ,
+				BusinessID: 1,
+				Recipient:  test.recipient,
+			})
+
+			if err != nil {
+				t.Fatalf("route: %v", err)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("channels = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNextAttempt(t *testing.T) {
+	deliveries := []*Delivery{
+		{Channel: ChannelEmail, Status: DeliveryFailed},
+		{Channel: ChannelEmail, Status: DeliveryFailed},
+		{Channel: ChannelTelegram, Status: DeliverySent},
+		{Channel: ChannelWhatsapp, Status: DeliveryUndeliverable},
+	}
+
+	if attempt, done := nextAttempt(deliveries, ChannelEmail); done || attempt != 3 {
+		t.Errorf("email = %d, %v, want 3, false", attempt, done)
+	}
+
+	if _, done := nextAttempt(deliveries, ChannelTelegram); !done {
+		t.Error("a sent channel should be done")
+	}
+
+	if _, done := nextAttempt(deliveries, ChannelWhatsapp); !done {
+		t.Error("an undeliverable channel should be done")
+	}
+
+	if attempt, done := nextAttempt(deliveries, ChannelSms); done || attempt != 1 {
+		t.Errorf("sms = %d, %v, want 1, false", attempt, done)
+	}
+}
+
+func TestStillHolds(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	confirmed := &booking.Booking{Status: booking.StatusConfirmed, Date: now.Add(time.Hour)}
+	past := &booking.Booking{Status: booking.StatusConfirmed, Date: now.Add(-time.Hour)}
+	cancelled := &booking.Booking{Status: booking.StatusCancelled, Date: now.Add(time.Hour)}
+
+	tests := []struct {
+		typ   string
+		found *booking.Booking
+		want  bool
+	}{
+		{TypeReminder, confirmed, true},
+		{TypeReminder, past, false},
+		{TypeReminder, cancelled, false},
+		{TypeApproved, confirmed, true},
+		{TypeApproved, cancelled, false},
+		{TypeCancelled, cancelled, true},
+		{TypeRejected, confirmed, false},
+	}
+
+	for _, test := range tests {
+		if got := stillHolds(test.typ, test.found, now); got != test.want {
+			t.Errorf("stillHolds(%s, %s) = %v, want %v", test.typ, test.found.Status, got, test.want)
+		}
+	}
+}
+
+func TestSmsChannel(t *testing.T) {
+	var received smsRequest
+	var authorization string
+
+	status := http.StatusOK
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+
+		json.NewDecoder(r.Body).Decode(&received)
+
+		w.WriteHeader(status)
+	}))
+
+	defer gateway.Close()
+
+	channel := NewSmsChannel(gateway.URL, "secret", "Hastypal")
+
+	address := channel.NotificationAddress(Recipient{Phone: "+34 600 11 12 22"})
+
+	if address != "+34600111222" {
+		t.Fatalf("address = %q", address)
+	}
+
+	if channel.NotificationAddress(Recipient{Phone: "123"}) != "" {
+		t.Error("a short phone should not be reachable")
+	}
+
+	message := &Message{
+		Address:  address,
+		Business: &business.Business{Name: "Barberia"},
+		Text:     "See you tomorrow",
+	}
+
+	if err := channel.SendNotification(context.Background(), message); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if received.To != "+34600111222" || received.From != "Hastypal" || received.Text != "Barberia: See you tomorrow" {
+		t.Errorf("request = %+v", received)
+	}
+
+	if authorization != "Bearer secret" {
+		t.Errorf("authorization = %q", authorization)
+	}
+
+	status = http.StatusBadRequest
+
+	if err := channel.SendNotification(context.Background(), message); !eris.Is(err, Undeliverable) {
+		t.Errorf("a refused message should be undeliverable, got %v", err)
+	}
+
+	status = http.StatusBadGateway
+
+	if err := channel.SendNotification(context.Background(), message); err == nil || eris.Is(err, Undeliverable) {
+		t.Errorf("a failing gateway should be retried, got %v", err)
+	}
+}
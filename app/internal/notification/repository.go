@@ -0,0 +1,327 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rotisserie/eris"
+)
+
+var PreferenceNotFound = eris.New("Notification preference not found")
+
+type NotificationRepository interface {
+	Save(ctx context.Context, notification *Notification) error
+	SaveDelivery(ctx context.Context, delivery *Delivery) error
+	GetDeliveries(ctx context.Context, notificationID string) ([]*Delivery, error)
+	GetBookingDeliveries(ctx context.Context, bookingID string) ([]*Delivery, error)
+	GetPreference(ctx context.Context, businessID int, customerKey string) (*Preference, error)
+	SavePreference(ctx context.Context, preference *Preference) error
+	GetSettings(ctx context.Context, businessID int) (*Settings, error)
+	SaveSettings(ctx context.Context, settings *Settings) error
+}
+
+type PgNotificationRepository struct {
+	connection *sql.DB
+}
+
+func NewPgNotificationRepository(connection *sql.DB) *PgNotificationRepository {
+	return &PgNotificationRepository{
+		connection: connection,
+	}
+}
+
+// Save stores the notification the first time it is dispatched, its retries keep the first one.
+func (r *PgNotificationRepository) Save(ctx context.Context, notification *Notification) error {
+	query := `
+		INSERT INTO ha_notification (
+			han_id,
+			han_type,
+			han_business_id,
+			han_booking_id,
+			han_recipient,
+			han_payload,
+			han_date_add
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (han_id) DO NOTHING;
+	`
+
+	recipient, err := json.Marshal(notification.Recipient)
+
+	if err != nil {
+		return eris.Wrap(err, "Error marshaling the notification recipient")
+	}
+
+	payload, err := json.Marshal(notification.Payload)
+
+	if err != nil {
+		return eris.Wrap(err, "Error marshaling the notification payload")
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err = r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		notification.ID,
+		notification.Type,
+		notification.BusinessID,
+		notification.BookingID,
+		recipient,
+		payload,
+		notification.DateAdd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving notification")
+	}
+
+	return nil
+}
+
+func (r *PgNotificationRepository) SaveDelivery(ctx context.Context, delivery *Delivery) error {
+	query := `
+		INSERT INTO ha_notification_delivery (
+			hand_id,
+			hand_notification_id,
+			hand_channel,
+			hand_address,
+			hand_attempt,
+			hand_status,
+			hand_error,
+			hand_date_add
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		delivery.ID,
+		delivery.NotificationID,
+		delivery.Channel,
+		delivery.Address,
+		delivery.Attempt,
+		delivery.Status,
+		delivery.Error,
+		delivery.DateAdd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving notification delivery")
+	}
+
+	return nil
+}
+
+func (r *PgNotificationRepository) GetDeliveries(ctx context.Context, notificationID string) ([]*Delivery, error) {
+	return r.getDeliveries(ctx, "han_id = $1", notificationID)
+}
+
+// GetBookingDeliveries lists every attempt to tell the customer about the booking, the latest first.
+func (r *PgNotificationRepository) GetBookingDeliveries(ctx context.Context, bookingID string) ([]*Delivery, error) {
+	return r.getDeliveries(ctx, "han_booking_id = $1", bookingID)
+}
+
+func (r *PgNotificationRepository) getDeliveries(ctx context.Context, condition string, arg any) (deliveries []*Delivery, err error) {
+	query := `
+		SELECT
+			hand_id,
+			han_id,
+			han_booking_id,
+			han_type,
+			hand_channel,
+			hand_address,
+			hand_attempt,
+			hand_status,
+			COALESCE(hand_error, ''),
+			hand_date_add
+		FROM
+			ha_notification_delivery
+		JOIN ha_notification ON han_id = hand_notification_id
+		WHERE
+			` + condition + `
+		ORDER BY hand_date_add DESC, hand_attempt DESC;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, arg)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching notification deliveries")
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = eris.Wrap(closeErr, "Error closing notification delivery rows")
+		}
+	}()
+
+	deliveries = make([]*Delivery, 0)
+
+	for rows.Next() {
+		var delivery Delivery
+
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.NotificationID,
+			&delivery.BookingID,
+			&delivery.Type,
+			&delivery.Channel,
+			&delivery.Address,
+			&delivery.Attempt,
+			&delivery.Status,
+			&delivery.Error,
+			&delivery.DateAdd,
+		); err != nil {
+			return nil, eris.Wrap(err, "Error scanning notification delivery")
+		}
+
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "Error iterating notification deliveries")
+	}
+
+	return deliveries, nil
+}
+
+func (r *PgNotificationRepository) GetPreference(ctx context.Context, businessID int, customerKey string) (*Preference, error) {
+	query := `
+		SELECT
+			hanp_business_id,
+			hanp_customer_key,
+			hanp_channels,
+			hanp_date_upd
+		FROM
+			ha_notification_preference
+		WHERE
+			hanp_business_id = $1 AND hanp_customer_key = $2;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var preference Preference
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, businessID, customerKey).Scan(
+		&preference.BusinessID,
+		&preference.CustomerKey,
+		pq.Array(&preference.Channels),
+		&preference.DateUpd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, PreferenceNotFound
+		}
+
+		return nil, eris.Wrap(err, "Error fetching notification preference")
+	}
+
+	return &preference, nil
+}
+
+func (r *PgNotificationRepository) SavePreference(ctx context.Context, preference *Preference) error {
+	query := `
+		INSERT INTO ha_notification_preference (
+			hanp_business_id,
+			hanp_customer_key,
+			hanp_channels,
+			hanp_date_upd
+		)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (hanp_business_id, hanp_customer_key) DO UPDATE SET
+			hanp_channels = EXCLUDED.hanp_channels,
+			hanp_date_upd = EXCLUDED.hanp_date_upd;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		preference.BusinessID,
+		preference.CustomerKey,
+		pq.Array(preference.Channels),
+		preference.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving notification preference")
+	}
+
+	return nil
+}
+
+// GetSettings returns the settings of the business, all the channels when it never set them.
+func (r *PgNotificationRepository) GetSettings(ctx context.Context, businessID int) (*Settings, error) {
+	query := `
+		SELECT
+			hans_business_id,
+			hans_channels,
+			hans_date_upd
+		FROM
+			ha_notification_settings
+		WHERE
+			hans_business_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var settings Settings
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, businessID).Scan(
+		&settings.BusinessID,
+		pq.Array(&settings.Channels),
+		&settings.DateUpd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Settings{BusinessID: businessID, Channels: Channels}, nil
+		}
+
+		return nil, eris.Wrap(err, "Error fetching notification settings")
+	}
+
+	return &settings, nil
+}
+
+func (r *PgNotificationRepository) SaveSettings(ctx context.Context, settings *Settings) error {
+	query := `
+		INSERT INTO ha_notification_settings (
+			hans_business_id,
+			hans_channels,
+			hans_date_upd
+		)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (hans_business_id) DO UPDATE SET
+			hans_channels = EXCLUDED.hans_channels,
+			hans_date_upd = EXCLUDED.hans_date_upd;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(ctxTimeout, query, settings.BusinessID, pq.Array(settings.Channels), settings.DateUpd)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving notification settings")
+	}
+
+	return nil
+}
@@ -0,0 +1,552 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/outbox"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/adriein/hastypal/pkg/helper"
+	"github.com/rotisserie/eris"
+)
+
+type NotificationService interface {
+	NotifyCustomer(ctx context.Context, notificationID string, notificationType string, bookingID string) error
+	AlertOwners(ctx context.Context, bookingID string, event string) error
+	GetSettings(ctx context.Context, businessID int) (*Settings, error)
+	SetSettings(ctx context.Context, settings *Settings) error
+	SetPreference(ctx context.Context, preference *Preference) error
+	GetBookingDeliveries(ctx context.Context, businessID int, bookingID string) ([]*Delivery, error)
+}
+
+type Service struct {
+	logger   *slog.Logger
+	repo     NotificationRepository
+	booking  booking.BookingService
+	business business.BusinessService
+	users    auth.AuthService
+	lang     translation.TranslationService
+	email    EmailSender
+	senders  map[string]ChannelSender
+	appUrl   string
+}
+
+func NewService(
+	logger *slog.Logger,
+	repo NotificationRepository,
+	booking booking.BookingService,
+	business business.BusinessService,
+	users auth.AuthService,
+	lang translation.TranslationService,
+	email EmailSender,
+	senders map[string]ChannelSender,
+	appUrl string,
+) *Service {
+	return &Service{
+		logger:   logger,
+		repo:     repo,
+		booking:  booking,
+		business: business,
+		users:    users,
+		lang:     lang,
+		email:    email,
+		senders:  senders,
+		appUrl:   appUrl,
+	}
+}
+
+// TypeOfEvent is what the customer is told about an event of their booking, nothing when the
+// event is not theirs to know.
+func TypeOfEvent(event string) (string, bool) {
+	types := map[string]string{
+		outbox.BookingCreated:     TypeBooked,
+		outbox.BookingConfirmed:   TypeApproved,
+		outbox.BookingRejected:    TypeRejected,
+		outbox.BookingRescheduled: TypeRescheduled,
+		outbox.BookingCancelled:   TypeCancelled,
+	}
+
+	notificationType, ok := types[event]
+
+	return notificationType, ok
+}
+
+/*
+================================================================================
+CUSTOMER NOTIFICATIONS
+================================================================================
+*/
+
+// NotifyCustomer sends a notification to the customer of the booking through the channels routed
+// for them. Each attempt is recorded as a delivery, the channels that already delivered it or
+// cannot deliver it are not tried again and the rest make it fail to be retried.
+func (s *Service) NotifyCustomer(ctx context.Context, notificationID string, notificationType string, bookingID string) error {
+	found, err := s.booking.GetBooking(ctx, bookingID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the booking")
+	}
+
+	// The booking may have changed since, only what still holds is told.
+	if !stillHolds(notificationType, found, time.Now()) {
+		return nil
+	}
+
+	owner, err := s.business.GetBusinessByID(ctx, found.BusinessID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching business")
+	}
+
+	notification := &Notification{
+		ID:         notificationID,
+		Type:       notificationType,
+		BusinessID: found.BusinessID,
+		BookingID:  found.ID,
+		Recipient:  NewRecipient(found),
+		Payload:    map[string]string{"date": found.Date.UTC().Format(time.RFC3339)},
+		DateAdd:    time.Now().UTC(),
+	}
+
+	channels, err := s.route(ctx, notification)
+
+	if err != nil {
+		return err
+	}
+
+	if len(channels) == 0 {
+		return nil
+	}
+
+	if err := s.repo.Save(ctx, notification); err != nil {
+		return eris.Wrap(err, "Error storing the notification")
+	}
+
+	deliveries, err := s.repo.GetDeliveries(ctx, notification.ID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the deliveries of the notification")
+	}
+
+	message, err := s.message(ctx, notification, found, owner)
+
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+
+	failed := make([]string, 0)
+
+	for _, channel := range channels {
+		attempt, done := nextAttempt(deliveries, channel)
+
+		if done {
+			continue
+		}
+
+		sender := s.senders[channel]
+
+		channelMessage := *message
+		channelMessage.Address = sender.NotificationAddress(notification.Recipient)
+
+		delivery := &Delivery{
+			ID:             helper.Uuid().String(),
+			NotificationID: notification.ID,
+			BookingID:      notification.BookingID,
+			Type:           notification.Type,
+			Channel:        channel,
+			Address:        channelMessage.Address,
+			Attempt:        attempt,
+			Status:         DeliverySent,
+			DateAdd:        time.Now().UTC(),
+		}
+
+		sendErr := sender.SendNotification(ctx, &channelMessage)
+
+		if sendErr != nil {
+			delivery.Status = DeliveryFailed
+			delivery.Error = sendErr.Error()
+
+			if eris.Is(sendErr, Undeliverable) {
+				delivery.Status = DeliveryUndeliverable
+			}
+
+			s.logger.Warn(
+				"Error notifying the customer",
+				"notification_id", notification.ID,
+				"booking_id", notification.BookingID,
+				"channel", channel,
+				"attempt", attempt,
+				"error", eris.ToString(sendErr, true),
+			)
+		}
+
+		if err := s.repo.SaveDelivery(ctx, delivery); err != nil {
+			return eris.Wrap(err, "Error storing the notification delivery")
+		}
+
+		if delivery.Status == DeliveryFailed {
+			failed = append(failed, channel)
+			lastErr = sendErr
+		}
+	}
+
+	if len(failed) > 0 {
+		return eris.Wrapf(lastErr, "Error notifying the customer through %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// route picks the channels of a notification: those the customer asked for or, when they did not,
+// the chat they booked from and the email they left. A customer of the web has no chat, their
+// phone takes its place. Only the channels the business allows and that reach the customer stay.
+func (s *Service) route(ctx context.Context, notification *Notification) ([]string, error) {
+	recipient := notification.Recipient
+
+	candidates := defaultChannels(recipient)
+
+	preference, err := s.repo.GetPreference(ctx, notification.BusinessID, recipient.Key)
+
+	if err != nil && !eris.Is(err, PreferenceNotFound) {
+		return nil, eris.Wrap(err, "Error fetching the notification preference of the customer")
+	}
+
+	if preference != nil {
+		candidates = preference.Channels
+	}
+
+	settings, err := s.repo.GetSettings(ctx, notification.BusinessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the notification settings of the business")
+	}
+
+	channels := make([]string, 0, len(candidates))
+
+	for _, channel := range candidates {
+		// The chat they booked from already answered them with the confirmation.
+		if notification.Type == TypeBooked && channel == recipient.Channel {
+			continue
+		}
+
+		if !slices.Contains(settings.Channels, channel) || slices.Contains(channels, channel) {
+			continue
+		}
+
+		sender, ok := s.senders[channel]
+
+		if !ok || sender.NotificationAddress(recipient) == "" {
+			continue
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+func defaultChannels(recipient Recipient) []string {
+	channels := make([]string, 0, 2)
+
+	switch recipient.Channel {
+	case booking.ChannelTelegram:
+		channels = append(channels, ChannelTelegram)
+	case booking.ChannelWhatsapp:
+		channels = append(channels, ChannelWhatsapp)
+	default:
+		channels = append(channels, ChannelSms)
+	}
+
+	return append(channels, ChannelEmail)
+}
+
+// message writes the notification in the language of the customer, the reminder with the text
+// of the business when it has its own.
+func (s *Service) message(
+	ctx context.Context,
+	notification *Notification,
+	found *booking.Booking,
+	owner *business.Business,
+) (*Message, error) {
+	location, err := time.LoadLocation(calendar.DefaultTimeZone)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error loading time location")
+	}
+
+	message := &Message{
+		Notification: notification,
+		Booking:      found,
+		Business:     owner,
+		Localizer:    s.localizer(found.Locale, owner.Lang),
+		LocalDate:    found.Date.In(location),
+	}
+
+	data := business.TemplateData{
+		Customer: found.CustomerName,
+		Business: owner.Name,
+		Service:  strings.Join(found.ServiceNames(), " + "),
+		Date:     message.Date(),
+		Hour:     message.Hour(),
+	}
+
+	if notification.Type == TypeReminder {
+		text, ok, err := s.business.RenderMessage(ctx, owner.Id, business.TemplateReminder, data)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error rendering the template of the business")
+		}
+
+		if ok {
+			message.Text = text
+
+			return message, nil
+		}
+	}
+
+	message.Text = message.Localizer.T(fmt.Sprintf("notification.%s", notification.Type), translation.Params{
+		"name":     data.Customer,
+		"business": data.Business,
+		"date":     data.Date,
+		"hour":     data.Hour,
+	})
+
+	return message, nil
+}
+
+// stillHolds tells whether the booking is still as the notification says, e.g. a reminder of a
+// booking cancelled since is not sent.
+func stillHolds(notificationType string, found *booking.Booking, now time.Time) bool {
+	switch notificationType {
+	case TypeReminder:
+		return found.Status == booking.StatusConfirmed && found.Date.After(now)
+	case TypeBooked, TypeApproved, TypeRescheduled:
+		return found.Status == booking.StatusConfirmed
+	case TypeRejected, TypeCancelled:
+		return found.IsCancelled()
+	}
+
+	return false
+}
+
+// nextAttempt is the number of the next attempt through the channel, done when an attempt was
+// already sent or found the channel cannot deliver the notification.
+func nextAttempt(deliveries []*Delivery, channel string) (int, bool) {
+	attempts := 0
+
+	for _, delivery := range deliveries {
+		if delivery.Channel != channel {
+			continue
+		}
+
+		if delivery.Status != DeliveryFailed {
+			return 0, true
+		}
+
+		attempts++
+	}
+
+	return attempts + 1, false
+}
+
+/*
+================================================================================
+NOTIFICATION SETTINGS
+================================================================================
+*/
+
+func (s *Service) GetSettings(ctx context.Context, businessID int) (*Settings, error) {
+	settings, err := s.repo.GetSettings(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the notification settings")
+	}
+
+	return settings, nil
+}
+
+func (s *Service) SetSettings(ctx context.Context, settings *Settings) error {
+	if err := ValidateChannels(settings.Channels); err != nil {
+		return err
+	}
+
+	settings.DateUpd = time.Now().UTC()
+
+	if err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return eris.Wrap(err, "Error storing the notification settings")
+	}
+
+	return nil
+}
+
+// SetPreference stores the channels a customer asked to be told through. An empty list keeps
+// them from being notified at all.
+func (s *Service) SetPreference(ctx context.Context, preference *Preference) error {
+	if err := ValidateChannels(preference.Channels); err != nil {
+		return err
+	}
+
+	preference.DateUpd = time.Now().UTC()
+
+	if err := s.repo.SavePreference(ctx, preference); err != nil {
+		return eris.Wrap(err, "Error storing the notification preference")
+	}
+
+	return nil
+}
+
+// GetBookingDeliveries lists the attempts to notify the customer of a booking of the business, a
+// booking of another business is not found.
+func (s *Service) GetBookingDeliveries(ctx context.Context, businessID int, bookingID string) ([]*Delivery, error) {
+	found, err := s.booking.GetBooking(ctx, bookingID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the booking")
+	}
+
+	if found.BusinessID != businessID {
+		return nil, booking.BookingNotFound
+	}
+
+	deliveries, err := s.repo.GetBookingDeliveries(ctx, bookingID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the notification deliveries")
+	}
+
+	return deliveries, nil
+}
+
+/*
+================================================================================
+OWNER ALERTS
+================================================================================
+*/
+
+// AlertOwners emails the business and its owners about new, cancelled and rescheduled bookings,
+// with the same details they get in the linked chats. Confirmations come from the owners
+// themselves, they are not told about them.
+func (s *Service) AlertOwners(ctx context.Context, bookingID string, event string) error {
+	if event == outbox.BookingConfirmed || event == outbox.BookingRejected {
+		return nil
+	}
+
+	found, err := s.booking.GetBooking(ctx, bookingID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the booking")
+	}
+
+	owner, err := s.business.GetBusinessByID(ctx, found.BusinessID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching business")
+	}
+
+	location, err := time.LoadLocation(calendar.DefaultTimeZone)
+
+	if err != nil {
+		return eris.Wrap(err, "Error loading time location")
+	}
+
+	recipients, err := s.ownerEmails(ctx, owner)
+
+	if err != nil {
+		return err
+	}
+
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	l := s.localizer(owner.Lang)
+
+	noticeKey := "notice." + strings.TrimPrefix(event, "booking.")
+
+	if event == outbox.BookingCreated && found.IsPending() {
+		noticeKey = "notice.pending"
+	}
+
+	title := l.T(noticeKey, nil)
+
+	if found.CustomerName != "" {
+		title = fmt.Sprintf("%s: %s", title, found.CustomerName)
+	}
+
+	content := emailContent{
+		Lang:      l.Locale,
+		Title:     title,
+		Details:   bookingDetails(l, found, found.Date.In(location)),
+		Link:      fmt.Sprintf("%s/dashboard/bookings/%s", s.appUrl, found.ID),
+		LinkLabel: l.T("email.open_booking", nil),
+		Footer:    l.T("email.footer", translation.Params{"business": owner.Name}),
+	}
+
+	if found.CustomerPhone != "" {
+		content.Details = append(content.Details, emailDetail{Label: l.T("email.phone", nil), Value: found.CustomerPhone})
+	}
+
+	if found.CustomerEmail != "" {
+		content.Details = append(content.Details, emailDetail{Label: l.T("email.email", nil), Value: found.CustomerEmail})
+	}
+
+	email, err := content.email(recipients)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.email.Send(ctx, email); err != nil {
+		return eris.Wrap(err, "Error emailing the owners of the business")
+	}
+
+	return nil
+}
+
+// ownerEmails is the email of the business along with those of the users who own it, each once.
+func (s *Service) ownerEmails(ctx context.Context, owner *business.Business) ([]string, error) {
+	users, err := s.users.GetUsers(ctx, owner.Id)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the users of the business")
+	}
+
+	candidates := []string{owner.Email}
+
+	for _, user := range users {
+		if user.Role == auth.RoleOwner {
+			candidates = append(candidates, user.Email)
+		}
+	}
+
+	seen := make(map[string]bool)
+	recipients := make([]string, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		key := strings.ToLower(strings.TrimSpace(candidate))
+
+		if key == "" || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		recipients = append(recipients, strings.TrimSpace(candidate))
+	}
+
+	return recipients, nil
+}
+
+// localizer speaks the first supported language of the candidates, from the most specific one.
+func (s *Service) localizer(candidates ...string) translation.Localizer {
+	return translation.NewLocalizer(s.lang, candidates...)
+}
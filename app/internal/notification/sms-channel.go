@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// A phone with fewer digits than this is not one a text message can reach.
+const minPhoneLength = 7
+
+// SmsChannel sends text messages through a generic HTTP gateway. It posts the message as JSON,
+// {"from": "...", "to": "+34600111222", "text": "..."}, to the gateway url with the token as a
+// bearer, which most providers take as it is or through a small proxy in front of them.
+type SmsChannel struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+func NewSmsChannel(url string, token string, from string) *SmsChannel {
+	return &SmsChannel{
+		url:    url,
+		token:  token,
+		from:   from,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+type smsRequest struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+func (c *SmsChannel) NotificationAddress(recipient Recipient) string {
+	phone := normalizePhone(recipient.Phone)
+
+	if len(phone) < minPhoneLength {
+		return ""
+	}
+
+	return phone
+}
+
+func (c *SmsChannel) SendNotification(ctx context.Context, message *Message) error {
+	body, err := json.Marshal(smsRequest{
+		From: c.from,
+		To:   message.Address,
+		Text: fmt.Sprintf("%s: %s", message.Business.Name, message.Text),
+	})
+
+	if err != nil {
+		return eris.Wrap(err, "Error marshaling struct")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewBuffer(body))
+
+	if err != nil {
+		return eris.Wrap(err, "Error creating http request")
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+
+	if c.token != "" {
+		request.Header.Add("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.client.Do(request)
+
+	if err != nil {
+		return eris.Wrap(err, "Error performing http request")
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+
+	// The gateway refusing the message, a wrong number say, will refuse it again. Throttling and
+	// its own failures are worth retrying.
+	if response.StatusCode < http.StatusInternalServerError && response.StatusCode != http.StatusTooManyRequests {
+		return eris.Wrapf(Undeliverable, "SMS gateway refused the message, status: %d, body: %s", response.StatusCode, responseBody)
+	}
+
+	return eris.Errorf("Error sending the SMS, status: %d, body: %s", response.StatusCode, responseBody)
+}
@@ -0,0 +1,136 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+const (
+	PollInterval = 2 * time.Second
+	BatchSize    = 20
+	LeaseTime    = 5 * time.Minute
+)
+
+var HandlerNotFound = eris.New("No handler registered for the outbox message type")
+
+type Handler func(ctx context.Context, message *Message) error
+
+// Dispatcher executes the side effects stored in the outbox. Every message is delivered at least
+// once, so handlers must tolerate being run again for a message they already processed.
+type Dispatcher struct {
+	logger   *slog.Logger
+	repo     OutboxRepository
+	handlers map[string]Handler
+}
+
+func NewDispatcher(logger *slog.Logger, repo OutboxRepository) *Dispatcher {
+	return &Dispatcher{
+		logger:   logger,
+		repo:     repo,
+		handlers: make(map[string]Handler),
+	}
+}
+
+func (d *Dispatcher) Register(messageType string, handler Handler) {
+	d.handlers[messageType] = handler
+}
+
+// Run polls the outbox until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.logger.Info("Outbox dispatcher started", "poll_interval", PollInterval, "batch_size", BatchSize)
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DispatchBatch(ctx); err != nil {
+			d.logger.Error("Error dispatching outbox batch", "error", eris.ToString(err, true))
+		}
+
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Outbox dispatcher stopped")
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) DispatchBatch(ctx context.Context) error {
+	messages, err := d.repo.Claim(ctx, BatchSize, LeaseTime)
+
+	if err != nil {
+		return eris.Wrap(err, "Error claiming outbox messages")
+	}
+
+	for _, message := range messages {
+		d.dispatch(ctx, message)
+
+		if err := d.repo.Update(ctx, message); err != nil {
+			return eris.Wrapf(err, "Error storing the result of outbox message %s", message.ID)
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, message *Message) {
+	handler, ok := d.handlers[message.Type]
+
+	if !ok {
+		message.MarkDead(HandlerNotFound)
+
+		d.logger.Error(
+			"Outbox message dead-lettered",
+			"message_id", message.ID,
+			"type", message.Type,
+			"aggregate_id", message.AggregateID,
+			"error", HandlerNotFound.Error(),
+		)
+
+		return
+	}
+
+	if err := handler(ctx, message); err != nil {
+		isDead := message.MarkFailed(err)
+
+		if isDead {
+			d.logger.Error(
+				"Outbox message dead-lettered",
+				"message_id", message.ID,
+				"type", message.Type,
+				"aggregate_id", message.AggregateID,
+				"attempts", message.Attempts,
+				"error", eris.ToString(err, true),
+			)
+
+			return
+		}
+
+		d.logger.Warn(
+			"Outbox message failed, retry scheduled",
+			"message_id", message.ID,
+			"type", message.Type,
+			"aggregate_id", message.AggregateID,
+			"attempts", message.Attempts,
+			"next_attempt_at", message.NextAttemptAt,
+			"error", err.Error(),
+		)
+
+		return
+	}
+
+	message.MarkDone()
+
+	d.logger.Info(
+		"Outbox message dispatched",
+		"message_id", message.ID,
+		"type", message.Type,
+		"aggregate_id", message.AggregateID,
+		"attempts", message.Attempts+1,
+	)
+}
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/adriein/hastypal/pkg/helper"
+	"github.com/rotisserie/eris"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusDone       = "done"
+	StatusDead       = "dead"
+)
+
+// Side effect types
+const (
+	CalendarEventCreate  = "calendar.event.create"
+	CalendarEventUpdate  = "calendar.event.update"
+	CalendarEventDelete  = "calendar.event.delete"
+	ReminderCreate       = "reminder.create"
+	BusinessNotification = "business.notification"
+	EmailNotification    = "email.notification"
+	CustomerNotification = "customer.notification"
+)
+
+// Notification events. The chats of the business are not told of the review of a pending
+// booking, they are where it is reviewed.
+const (
+	BookingCreated     = "booking.created"
+	BookingConfirmed   = "booking.confirmed"
+	BookingRejected    = "booking.rejected"
+	BookingCancelled   = "booking.cancelled"
+	BookingRescheduled = "booking.rescheduled"
+)
+
+const (
+	MaxAttempts    = 8
+	BaseRetryDelay = 5 * time.Second
+	MaxRetryDelay  = time.Hour
+)
+
+type Message struct {
+	ID            string
+	Type          string
+	AggregateID   string
+	Payload       json.RawMessage
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	DateAdd       time.Time
+	DateUpd       time.Time
+}
+
+func NewMessage(messageType string, aggregateID string, payload any) (*Message, error) {
+	encoded, err := json.Marshal(payload)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error marshaling outbox payload")
+	}
+
+	now := time.Now().UTC()
+
+	return &Message{
+		ID:            helper.Uuid().String(),
+		Type:          messageType,
+		AggregateID:   aggregateID,
+		Payload:       encoded,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		DateAdd:       now,
+		DateUpd:       now,
+	}, nil
+}
+
+func (m *Message) Decode(v any) error {
+	if err := json.Unmarshal(m.Payload, v); err != nil {
+		return eris.Wrapf(err, "Error unmarshaling payload of outbox message %s", m.ID)
+	}
+
+	return nil
+}
+
+func (m *Message) MarkDone() {
+	m.Status = StatusDone
+	m.LastError = ""
+	m.DateUpd = time.Now().UTC()
+}
+
+func (m *Message) MarkDead(err error) {
+	m.Status = StatusDead
+	m.LastError = err.Error()
+	m.DateUpd = time.Now().UTC()
+}
+
+// MarkFailed schedules the next attempt with an exponential backoff or dead-letters the
+// message once it has run out of attempts. It reports whether the message is now dead.
+func (m *Message) MarkFailed(err error) bool {
+	m.Attempts++
+	m.LastError = err.Error()
+	m.DateUpd = time.Now().UTC()
+
+	if m.Attempts >= MaxAttempts {
+		m.MarkDead(err)
+
+		return true
+	}
+
+	delay := BaseRetryDelay << (m.Attempts - 1)
+
+	if delay > MaxRetryDelay {
+		delay = MaxRetryDelay
+	}
+
+	m.Status = StatusPending
+	m.NextAttemptAt = m.DateUpd.Add(delay)
+
+	return false
+}
+
+// BookingPayload is carried by the calendar and reminder side effects of a booking.
+type BookingPayload struct {
+	BookingID  string    `json:"bookingId"`
+	BusinessID int       `json:"businessId"`
+	EmployeeID int       `json:"employeeId"`
+	Date       time.Time `json:"date"`
+}
+
+// NotificationPayload tells the chats linked to a business, its owners by email or the customer
+// what happened to one of its bookings.
+type NotificationPayload struct {
+	BookingID  string `json:"bookingId"`
+	BusinessID int    `json:"businessId"`
+	Event      string `json:"event"`
+}
@@ -0,0 +1,173 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/rotisserie/eris"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, tx *sql.Tx, messages ...*Message) error
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*Message, error)
+	Update(ctx context.Context, message *Message) error
+}
+
+type PgOutboxRepository struct {
+	connection *sql.DB
+}
+
+func NewPgOutboxRepository(connection *sql.DB) *PgOutboxRepository {
+	return &PgOutboxRepository{
+		connection: connection,
+	}
+}
+
+// Add stores the messages using the transaction of the aggregate that produced them.
+func (r *PgOutboxRepository) Add(ctx context.Context, tx *sql.Tx, messages ...*Message) error {
+	query := `
+		INSERT INTO ha_outbox (
+			hao_id,
+			hao_type,
+			hao_aggregate_id,
+			hao_payload,
+			hao_status,
+			hao_attempts,
+			hao_next_attempt_at,
+			hao_date_add,
+			hao_date_upd
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`
+
+	for _, message := range messages {
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			message.ID,
+			message.Type,
+			message.AggregateID,
+			[]byte(message.Payload),
+			message.Status,
+			message.Attempts,
+			message.NextAttemptAt,
+			message.DateAdd,
+			message.DateUpd,
+		)
+
+		if err != nil {
+			return eris.Wrapf(err, "Error saving outbox message %s", message.Type)
+		}
+	}
+
+	return nil
+}
+
+// Claim leases the next due messages so concurrent dispatchers never pick the same row. Messages
+// left in processing by a crashed dispatcher become claimable again once their lease expires.
+func (r *PgOutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) (messages []*Message, err error) {
+	query := `
+		UPDATE ha_outbox
+		SET
+			hao_status = 'processing',
+			hao_locked_until = NOW() + $2 * INTERVAL '1 second'
+		WHERE
+			hao_id IN (
+				SELECT
+					hao_id
+				FROM
+					ha_outbox
+				WHERE
+					(hao_status = 'pending' AND hao_next_attempt_at <= NOW())
+					OR (hao_status = 'processing' AND hao_locked_until < NOW())
+				ORDER BY
+					hao_next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING
+			hao_id,
+			hao_type,
+			hao_aggregate_id,
+			hao_payload,
+			hao_status,
+			hao_attempts,
+			COALESCE(hao_last_error, ''),
+			hao_next_attempt_at,
+			hao_date_add,
+			hao_date_upd;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, limit, int(lease.Seconds()))
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Failed to claim outbox messages")
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	for rows.Next() {
+		var message Message
+
+		err := rows.Scan(
+			&message.ID,
+			&message.Type,
+			&message.AggregateID,
+			&message.Payload,
+			&message.Status,
+			&message.Attempts,
+			&message.LastError,
+			&message.NextAttemptAt,
+			&message.DateAdd,
+			&message.DateUpd,
+		)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Failed to scan outbox message")
+		}
+
+		messages = append(messages, &message)
+	}
+
+	return messages, nil
+}
+
+func (r *PgOutboxRepository) Update(ctx context.Context, message *Message) error {
+	query := `
+		UPDATE ha_outbox
+		SET
+			hao_status = $2,
+			hao_attempts = $3,
+			hao_last_error = NULLIF($4, ''),
+			hao_next_attempt_at = $5,
+			hao_locked_until = NULL,
+			hao_date_upd = $6
+		WHERE
+			hao_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		message.ID,
+		message.Status,
+		message.Attempts,
+		message.LastError,
+		message.NextAttemptAt,
+		message.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error updating outbox message")
+	}
+
+	return nil
+}
@@ -2,12 +2,20 @@ package reminder
 
 import "time"
 
+// Reminder is when the customer of a booking is reminded of it, the day before. A booking has one
+// reminder, rescheduling the booking schedules it again.
 type Reminder struct {
 	ID          string
 	BookingID   string
 	ScheduledAt time.Time
 	Sent        bool
-	SentAt      string
+	SentAt      time.Time
 	DateAdd     time.Time
 	DateUpd     time.Time
 }
+
+func (r *Reminder) MarkAsSent(now time.Time) {
+	r.Sent = true
+	r.SentAt = now.UTC()
+	r.DateUpd = now.UTC()
+}
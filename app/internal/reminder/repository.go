@@ -3,10 +3,15 @@ package reminder
 import (
 	"context"
 	"database/sql"
+	"time"
+
+	"github.com/rotisserie/eris"
 )
 
 type ReminderRepository interface {
 	Save(ctx context.Context, reminder *Reminder) error
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*Reminder, error)
+	Update(ctx context.Context, reminder *Reminder) error
 }
 
 type PgReminderRepository struct {
@@ -19,6 +24,130 @@ func NewPgReminderRepository(connection *sql.DB) *PgReminderRepository {
 	}
 }
 
+// Save schedules the reminder of the booking. A booking that already had one gets it scheduled
+// again under the new id, so the reminder of the new date is a notification of its own. The same
+// id again leaves the reminder as it is, sent or not.
 func (r *PgReminderRepository) Save(ctx context.Context, reminder *Reminder) error {
+	query := `
+		INSERT INTO ha_reminder (
+			har_id,
+			har_booking_id,
+			har_scheduled_at,
+			har_sent,
+			har_sent_at,
+			har_date_add,
+			har_date_upd
+		)
+		VALUES ($1, $2, $3, FALSE, NULL, $4, $5)
+		ON CONFLICT (har_booking_id) DO UPDATE SET
+			har_id = EXCLUDED.har_id,
+			har_scheduled_at = EXCLUDED.har_scheduled_at,
+			har_sent = FALSE,
+			har_sent_at = NULL,
+			har_date_upd = EXCLUDED.har_date_upd
+		WHERE
+			ha_reminder.har_id <> EXCLUDED.har_id;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		reminder.ID,
+		reminder.BookingID,
+		reminder.ScheduledAt,
+		reminder.DateAdd,
+		reminder.DateUpd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving reminder")
+	}
+
+	return nil
+}
+
+// GetDue returns the reminders not sent yet whose time has come, the oldest first.
+func (r *PgReminderRepository) GetDue(ctx context.Context, now time.Time, limit int) (reminders []*Reminder, err error) {
+	query := `
+		SELECT
+			har_id,
+			har_booking_id,
+			har_scheduled_at,
+			har_sent,
+			har_date_add,
+			har_date_upd
+		FROM
+			ha_reminder
+		WHERE
+			har_sent = FALSE AND har_scheduled_at <= $1
+		ORDER BY har_scheduled_at
+		LIMIT $2;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, now, limit)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching due reminders")
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = eris.Wrap(closeErr, "Error closing reminder rows")
+		}
+	}()
+
+	reminders = make([]*Reminder, 0)
+
+	for rows.Next() {
+		var reminder Reminder
+
+		if err := rows.Scan(
+			&reminder.ID,
+			&reminder.BookingID,
+			&reminder.ScheduledAt,
+			&reminder.Sent,
+			&reminder.DateAdd,
+			&reminder.DateUpd,
+		); err != nil {
+			return nil, eris.Wrap(err, "Error scanning reminder")
+		}
+
+		reminders = append(reminders, &reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "Error iterating reminders")
+	}
+
+	return reminders, nil
+}
+
+// Update marks the reminder as sent unless the booking was rescheduled meanwhile, which gave the
+// reminder a new id to be sent at the new date.
+func (r *PgReminderRepository) Update(ctx context.Context, reminder *Reminder) error {
+	query := `
+		UPDATE ha_reminder SET
+			har_sent = $2,
+			har_sent_at = $3,
+			har_date_upd = $4
+		WHERE
+			har_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(ctxTimeout, query, reminder.ID, reminder.Sent, reminder.SentAt, reminder.DateUpd)
+
+	if err != nil {
+		return eris.Wrap(err, "Error updating reminder")
+	}
+
 	return nil
 }
@@ -5,29 +5,41 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/adriein/hastypal/internal/notification"
 	"github.com/rotisserie/eris"
 )
 
+// dueBatchSize is how many reminders a run sends at most, the next run takes the rest.
+const dueBatchSize = 200
+
 type ReminderService interface {
-	NewReminder(ctx context.Context, bookingID string, scheduledAt time.Time) error
+	NewReminder(ctx context.Context, reminderID string, bookingID string, scheduledAt time.Time) error
+	SendDue(ctx context.Context) error
 }
 
 type Service struct {
-	logger *slog.Logger
-	repo   ReminderRepository
+	logger       *slog.Logger
+	repo         ReminderRepository
+	notification notification.NotificationService
 }
 
-func NewService(logger *slog.Logger, repo ReminderRepository) *Service {
+func NewService(logger *slog.Logger, repo ReminderRepository, notification notification.NotificationService) *Service {
 	return &Service{
-		logger: logger,
-		repo:   repo,
+		logger:       logger,
+		repo:         repo,
+		notification: notification,
 	}
 }
 
-func (s *Service) NewReminder(ctx context.Context, bookingID string, scheduledAt time.Time) error {
+// NewReminder schedules the reminder of the booking under the given ID. Scheduling it again under
+// the same ID changes nothing, so the side effect that creates it can be delivered twice.
+func (s *Service) NewReminder(ctx context.Context, reminderID string, bookingID string, scheduledAt time.Time) error {
 	reminder := &Reminder{
+		ID:          reminderID,
 		BookingID:   bookingID,
 		ScheduledAt: scheduledAt,
+		DateAdd:     time.Now().UTC(),
+		DateUpd:     time.Now().UTC(),
 	}
 
 	if err := s.repo.Save(ctx, reminder); err != nil {
@@ -36,3 +48,40 @@ func (s *Service) NewReminder(ctx context.Context, bookingID string, scheduledAt
 
 	return nil
 }
+
+// SendDue reminds the customers whose reminder is due through the channels routed for them. A
+// reminder that fails stays due for the next run, which only tries the channels that failed.
+func (s *Service) SendDue(ctx context.Context) error {
+	reminders, err := s.repo.GetDue(ctx, time.Now(), dueBatchSize)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the due reminders")
+	}
+
+	failed := 0
+
+	for _, reminder := range reminders {
+		if err := s.notification.NotifyCustomer(ctx, reminder.ID, notification.TypeReminder, reminder.BookingID); err != nil {
+			s.logger.Warn(
+				"Error sending reminder",
+				"reminder_id", reminder.ID,
+				"booking_id", reminder.BookingID,
+				"error", eris.ToString(err, true),
+			)
+
+			failed++
+
+			continue
+		}
+
+		reminder.MarkAsSent(time.Now())
+
+		if err := s.repo.Update(ctx, reminder); err != nil {
+			return eris.Wrap(err, "Error marking the reminder as sent")
+		}
+	}
+
+	s.logger.Info("Due reminders sent", "due", len(reminders), "failed", failed)
+
+	return nil
+}
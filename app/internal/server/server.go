@@ -2,9 +2,13 @@ package server
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"reflect"
+	"strings"
 
 	"github.com/adriein/hastypal/internal"
+	"github.com/adriein/hastypal/internal/auth"
 	"github.com/adriein/hastypal/internal/web"
 	"github.com/adriein/hastypal/pkg/constants"
 	"github.com/adriein/hastypal/pkg/middleware"
@@ -37,7 +41,7 @@ func New(app *internal.App) *Server {
 
 	server := &Server{
 		gin:       engine,
-		validator: validator.New(),
+		validator: newValidator(),
 	}
 
 	server.routeSetup(app)
@@ -64,6 +68,154 @@ func (s *Server) routeSetup(app *internal.App) {
 
 	s.gin.POST("/telegram-webhook", s.webhookController(app).Post())
 
+	//WHATSAPP WEBHOOK
+
+	whatsapp := s.whatsappController(app)
+
+	s.gin.GET("/whatsapp-webhook", whatsapp.Verify())
+	s.gin.POST("/whatsapp-webhook", whatsapp.Post())
+
+	//WEB WIDGET
+	widget := s.widgetController(app)
+	s.gin.GET("/book/:businessId", widget.Start())
+	s.gin.GET("/book/:businessId/step", widget.Step())
+	s.gin.POST("/book/:businessId/step", widget.Book())
+
+	//GOOGLE CALENDAR
+
+	google := s.googleController(app)
+
+	s.gin.POST("/google/calendar-notification", google.Notification())
+
+	//BOOKING FEED
+
+	feed := s.feedController(app)
+
+	s.gin.GET("/feeds/:file", feed.Subscribe())
+
+	api := s.gin.Group("/api/v1")
+
+	//AUTH
+
+	authentication := s.authController(app)
+
+	api.POST("/business", authentication.Register())
+	api.POST("/business/login", authentication.Login())
+	api.POST("/business/refresh", authentication.Refresh())
+	api.POST("/business/logout", authentication.Logout())
+	api.POST("/invitations/accept", authentication.AcceptInvitation())
+
+	member := api.Group("/business/:id", web.Authenticated(app.Modules.Auth), web.BusinessMember())
+
+	//BUSINESS
+
+	business := s.businessController(app)
+
+	member.GET("", web.Allow(auth.PermissionViewBusiness), business.Get())
+	member.PUT("", web.Allow(auth.PermissionEditBusiness), business.Update())
+	member.GET("/services", web.Allow(auth.PermissionViewBusiness), business.Services())
+	member.POST("/services", web.Allow(auth.PermissionEditCatalog), business.CreateService())
+	member.PUT("/services/:serviceId", web.Allow(auth.PermissionEditCatalog), business.UpdateService())
+	member.DELETE("/services/:serviceId", web.Allow(auth.PermissionEditCatalog), business.DeleteService())
+	member.GET("/hours", web.Allow(auth.PermissionViewBusiness), business.OpeningHours())
+	member.PUT("/hours", web.Allow(auth.PermissionEditHours), business.SetOpeningHours())
+	member.GET("/holidays", web.Allow(auth.PermissionViewBusiness), business.Holidays())
+	member.POST("/holidays", web.Allow(auth.PermissionEditHours), business.AddHoliday())
+	member.DELETE("/holidays/:holidayId", web.Allow(auth.PermissionEditHours), business.DeleteHoliday())
+	member.GET("/templates", web.Allow(auth.PermissionViewBusiness), business.MessageTemplates())
+	member.PUT("/templates/:key", web.Allow(auth.PermissionEditBusiness), business.SetMessageTemplate())
+	member.DELETE("/templates/:key", web.Allow(auth.PermissionEditBusiness), business.DeleteMessageTemplate())
+
+	//CUSTOMERS
+
+	customers := s.customerController(app)
+
+	member.GET("/customers", web.Allow(auth.PermissionViewAgenda), customers.Get())
+	member.GET("/customers/:customerId", web.Allow(auth.PermissionViewAgenda), customers.Profile())
+	member.PUT("/customers/:customerId", web.Allow(auth.PermissionManageBookings), customers.Annotate())
+
+	//NOTIFICATIONS
+
+	notifications := s.notificationController(app)
+
+	member.GET("/notifications/settings", web.Allow(auth.PermissionViewBusiness), notifications.Settings())
+	member.PUT("/notifications/settings", web.Allow(auth.PermissionEditBusiness), notifications.SetSettings())
+	member.PUT("/notifications/preferences", web.Allow(auth.PermissionManageBookings), notifications.SetPreference())
+	member.GET("/bookings/:bookingId/deliveries", web.Allow(auth.PermissionViewAgenda), notifications.Deliveries())
+
+	//USERS
+
+	users := s.userController(app)
+
+	member.GET("/users", web.Allow(auth.PermissionManageUsers), users.Get())
+	member.PUT("/users/:userId", web.Allow(auth.PermissionManageUsers), users.ChangeRole())
+	member.DELETE("/users/:userId", web.Allow(auth.PermissionManageUsers), users.Remove())
+	member.GET("/invitations", web.Allow(auth.PermissionManageUsers), users.Invitations())
+	member.POST("/invitations", web.Allow(auth.PermissionManageUsers), users.Invite())
+
+	//AGENDA
+
+	bookings := s.bookingController(app)
+
+	member.GET("/agenda", web.Allow(auth.PermissionViewOwnAgenda), bookings.Agenda())
+	member.POST("/bookings/:bookingId/no-show", web.Allow(auth.PermissionMarkNoShow), bookings.NoShow())
+
+	//EMPLOYEES
+
+	employee := s.employeeController(app)
+
+	member.GET("/google-auth", web.Allow(auth.PermissionManageCalendars), google.Auth())
+	member.GET("/google-auth-callback", web.Allow(auth.PermissionManageCalendars), google.AuthCallback())
+	member.GET("/google/calendars", web.Allow(auth.PermissionManageCalendars), employee.Calendars())
+	member.GET("/employees", web.Allow(auth.PermissionViewBusiness), employee.Get())
+	member.PUT("/employees/:employeeId/calendar", web.Allow(auth.PermissionManageCalendars), employee.AssignCalendar())
+	member.GET("/employees/:employeeId/free-busy", web.Allow(auth.PermissionViewAgenda), employee.FreeBusy())
+
+	//CALENDAR
+
+	member.POST("/calendar/caldav", web.Allow(auth.PermissionManageCalendars), s.calendarController(app).ConnectCaldav())
+	member.GET("/calendar/feed", web.Allow(auth.PermissionManageCalendars), feed.Get())
+	member.POST("/calendar/feed", web.Allow(auth.PermissionManageCalendars), feed.Regenerate())
+
+	//TELEGRAM
+
+	telegramChats := s.webhookController(app)
+
+	member.GET("/telegram/chats", web.Allow(auth.PermissionManageChats), telegramChats.Chats())
+	member.POST("/telegram/link-code", web.Allow(auth.PermissionManageChats), telegramChats.LinkCode())
+	member.DELETE("/telegram/chats/:chatId", web.Allow(auth.PermissionManageChats), telegramChats.Unlink())
+	member.POST("/telegram/start-link", web.Allow(auth.PermissionViewBusiness), telegramChats.StartLink())
+
+	//WHATSAPP
+
+	member.POST("/whatsapp/start-link", web.Allow(auth.PermissionViewBusiness), whatsapp.StartLink())
+
+	//DASHBOARD
+
+	dashboard := s.dashboardController(app)
+
+	s.gin.GET("/dashboard/login", dashboard.LoginPage())
+	s.gin.POST("/dashboard/login", dashboard.Login())
+	s.gin.POST("/dashboard/logout", dashboard.Logout())
+	s.gin.GET("/invitations/:token", dashboard.InvitationPage())
+	s.gin.POST("/invitations/:token", dashboard.AcceptInvitation())
+
+	panel := s.gin.Group("/dashboard", web.DashboardSession(app.Modules.Auth))
+
+	panel.GET("", func(ctx *gin.Context) { ctx.Redirect(http.StatusSeeOther, "/dashboard/agenda") })
+	panel.GET("/agenda", dashboard.Allow(auth.PermissionViewOwnAgenda), dashboard.Agenda())
+	panel.GET("/week", dashboard.Allow(auth.PermissionViewOwnAgenda), dashboard.Week())
+	panel.GET("/bookings/:bookingId", dashboard.Allow(auth.PermissionViewOwnAgenda), dashboard.Booking())
+	panel.POST("/bookings/:bookingId/cancel", dashboard.Allow(auth.PermissionManageBookings), dashboard.CancelBooking())
+	panel.POST("/bookings/:bookingId/no-show", dashboard.Allow(auth.PermissionMarkNoShow), dashboard.NoShow())
+	panel.GET("/services", dashboard.Allow(auth.PermissionViewBusiness), dashboard.Services())
+	panel.POST("/services", dashboard.Allow(auth.PermissionEditCatalog), dashboard.CreateService())
+	panel.POST("/services/:serviceId", dashboard.Allow(auth.PermissionEditCatalog), dashboard.UpdateService())
+	panel.POST("/services/:serviceId/delete", dashboard.Allow(auth.PermissionEditCatalog), dashboard.DeleteService())
+	panel.GET("/hours", dashboard.Allow(auth.PermissionViewBusiness), dashboard.Hours())
+	panel.POST("/hours", dashboard.Allow(auth.PermissionEditHours), dashboard.SetHours())
+	panel.GET("/widget", dashboard.Allow(auth.PermissionViewBusiness), dashboard.Widget())
+
 	cwd, _ := os.Getwd()
 
 	//STATIC
@@ -72,11 +224,6 @@ func (s *Server) routeSetup(app *internal.App) {
 	//TODO: setup the routes again
 
 	/*
-		api.Route("GET /business/google-auth", constructGoogleAuthHandler(api))
-		api.Route("GET /business/google-auth-callback", constructGoogleAuthCallbackHandler(api, database))
-		api.Route("POST /business", constructCreateBusinessHandler(api, database))
-		api.Route("POST /business/login", constructLoginBusinessHandler(api, database))
-
 		api.Route("GET /notification/send", constructSendNotificationHandler(api, database))
 	*/
 }
@@ -85,5 +232,124 @@ func (s *Server) webhookController(app *internal.App) *web.TelegramController {
 	logger := app.Modules.Logger
 	service := app.Modules.Telegram
 
-	return web.NewTelegramController(logger, service)
+	return web.NewTelegramController(logger, s.validator, service, os.Getenv(constants.TelegramWebhookSecret))
+}
+
+func (s *Server) whatsappController(app *internal.App) *web.WhatsappController {
+	logger := app.Modules.Logger
+	service := app.Modules.Whatsapp
+
+	return web.NewWhatsappController(
+		logger,
+		s.validator,
+		service,
+		os.Getenv(constants.WhatsappAppSecret),
+		os.Getenv(constants.WhatsappVerifyToken),
+	)
+}
+
+func (s *Server) widgetController(app *internal.App) *web.WidgetController {
+	logger := app.Modules.Logger
+	engine := app.Modules.Widget
+
+	return web.NewWidgetController(logger, engine)
+}
+
+func (s *Server) googleController(app *internal.App) *web.GoogleController {
+	logger := app.Modules.Logger
+	service := app.Modules.Google
+	sync := app.Modules.CalendarSync
+
+	return web.NewGoogleController(logger, service, sync)
+}
+
+func (s *Server) authController(app *internal.App) *web.AuthController {
+	logger := app.Modules.Logger
+	service := app.Modules.Auth
+
+	return web.NewAuthController(logger, s.validator, service)
+}
+
+func (s *Server) userController(app *internal.App) *web.UserController {
+	logger := app.Modules.Logger
+	service := app.Modules.Auth
+
+	return web.NewUserController(logger, s.validator, service)
+}
+
+func (s *Server) bookingController(app *internal.App) *web.BookingController {
+	logger := app.Modules.Logger
+	service := app.Modules.Booking
+
+	return web.NewBookingController(logger, service)
+}
+
+func (s *Server) dashboardController(app *internal.App) *web.DashboardController {
+	logger := app.Modules.Logger
+	authentication := app.Modules.Auth
+	business := app.Modules.Business
+	booking := app.Modules.Booking
+
+	return web.NewDashboardController(logger, s.validator, authentication, business, booking, os.Getenv(constants.AppUrl))
+}
+
+func (s *Server) businessController(app *internal.App) *web.BusinessController {
+	logger := app.Modules.Logger
+	service := app.Modules.Business
+
+	return web.NewBusinessController(logger, s.validator, service)
+}
+
+func (s *Server) customerController(app *internal.App) *web.CustomerController {
+	logger := app.Modules.Logger
+	service := app.Modules.Customer
+
+	return web.NewCustomerController(logger, s.validator, service)
+}
+
+func (s *Server) notificationController(app *internal.App) *web.NotificationController {
+	logger := app.Modules.Logger
+	service := app.Modules.Notification
+
+	return web.NewNotificationController(logger, s.validator, service)
+}
+
+func (s *Server) employeeController(app *internal.App) *web.EmployeeController {
+	logger := app.Modules.Logger
+	business := app.Modules.Business
+	calendar := app.Modules.Calendar
+	google := app.Modules.Google
+
+	return web.NewEmployeeController(logger, s.validator, business, calendar, google)
+}
+
+func (s *Server) calendarController(app *internal.App) *web.CalendarController {
+	logger := app.Modules.Logger
+	service := app.Modules.Calendar
+
+	return web.NewCalendarController(logger, s.validator, service)
+}
+
+func (s *Server) feedController(app *internal.App) *web.FeedController {
+	logger := app.Modules.Logger
+	service := app.Modules.Feed
+
+	return web.NewFeedController(logger, service)
+}
+
+// newValidator reports the invalid fields by their JSON name so they match the request body.
+func newValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	return validate
 }
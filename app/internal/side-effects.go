@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"context"
+	"strings"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/notification"
+	"github.com/adriein/hastypal/internal/outbox"
+	"github.com/adriein/hastypal/internal/reminder"
+	"github.com/adriein/hastypal/internal/telegram"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/rotisserie/eris"
+)
+
+func calendarEventHandler(
+	bookingService booking.BookingService,
+	businessService business.BusinessService,
+	calendarService calendar.CalendarService,
+	lang translation.TranslationService,
+) outbox.Handler {
+	return func(ctx context.Context, message *outbox.Message) error {
+		var payload outbox.BookingPayload
+
+		if err := message.Decode(&payload); err != nil {
+			return err
+		}
+
+		current, err := bookingService.GetBooking(ctx, payload.BookingID)
+
+		if err != nil {
+			return eris.Wrap(err, "Error fetching the booking")
+		}
+
+		if current.EventID != "" || current.IsCancelled() {
+			return nil
+		}
+
+		calendarID := ""
+
+		if payload.EmployeeID != 0 {
+			employee, err := businessService.GetEmployee(ctx, payload.BusinessID, payload.EmployeeID)
+
+			if err != nil {
+				return eris.Wrap(err, "Error fetching the employee of the booking")
+			}
+
+			if employee.CalendarId != "" {
+				calendarID = employee.CalendarId
+			}
+		}
+
+		l, err := businessLocalizer(ctx, businessService, lang, payload.BusinessID)
+
+		if err != nil {
+			return err
+		}
+
+		eventID, err := calendarService.CreateEvent(ctx, payload.BusinessID, bookingEvent(l, current, calendarID))
+
+		if err != nil {
+			return eris.Wrap(err, "Error creating the event in the business calendar")
+		}
+
+		if err := bookingService.AttachEvent(ctx, payload.BookingID, calendarID, eventID); err != nil {
+			return eris.Wrap(err, "Error linking the calendar event to the booking")
+		}
+
+		return nil
+	}
+}
+
+// calendarEventUpdateHandler moves the event of the booking to its current date. A booking whose
+// event is not created yet gets it with the current date from calendarEventHandler.
+func calendarEventUpdateHandler(
+	bookingService booking.BookingService,
+	businessService business.BusinessService,
+	calendarService calendar.CalendarService,
+	lang translation.TranslationService,
+) outbox.Handler {
+	return func(ctx context.Context, message *outbox.Message) error {
+		var payload outbox.BookingPayload
+
+		if err := message.Decode(&payload); err != nil {
+			return err
+		}
+
+		current, err := bookingService.GetBooking(ctx, payload.BookingID)
+
+		if err != nil {
+			return eris.Wrap(err, "Error fetching the booking")
+		}
+
+		if current.EventID == "" || current.IsCancelled() {
+			return nil
+		}
+
+		l, err := businessLocalizer(ctx, businessService, lang, payload.BusinessID)
+
+		if err != nil {
+			return err
+		}
+
+		event := bookingEvent(l, current, current.CalendarID)
+		event.ID = current.EventID
+
+		if err := calendarService.UpdateEvent(ctx, payload.BusinessID, event); err != nil {
+			return eris.Wrap(err, "Error updating the event in the business calendar")
+		}
+
+		return nil
+	}
+}
+
+// calendarEventDeleteHandler removes the event of a cancelled or rejected booking. The providers
+// take an event that is already gone as deleted, so running it again changes nothing.
+func calendarEventDeleteHandler(
+	bookingService booking.BookingService,
+	calendarService calendar.CalendarService,
+) outbox.Handler {
+	return func(ctx context.Context, message *outbox.Message) error {
+		var payload outbox.BookingPayload
+
+		if err := message.Decode(&payload); err != nil {
+			return err
+		}
+
+		current, err := bookingService.GetBooking(ctx, payload.BookingID)
+
+		if err != nil {
+			return eris.Wrap(err, "Error fetching the booking")
+		}
+
+		if current.EventID == "" {
+			return nil
+		}
+
+		if err := calendarService.DeleteEvent(ctx, payload.BusinessID, current.CalendarID, current.EventID); err != nil {
+			return eris.Wrap(err, "Error deleting the event from the business calendar")
+		}
+
+		return nil
+	}
+}
+
+// bookingEvent is the calendar event of the booking, written in the language of the business
+// that reads the calendar.
+func bookingEvent(l translation.Localizer, current *booking.Booking, calendarID string) *calendar.Event {
+	return &calendar.Event{
+		BookingID:   current.ID,
+		CalendarID:  calendarID,
+		Summary:     l.T("calendar.event_title", nil),
+		Description: bookingDescription(l, current),
+		Start:       current.Date,
+		End:         current.Date.Add(current.Duration(calendar.DefaultSlotDuration)),
+		TimeZone:    calendar.DefaultTimeZone,
+	}
+}
+
+// bookingDescription lists the services of the booking with their total for the calendar event.
+func bookingDescription(l translation.Localizer, current *booking.Booking) string {
+	if len(current.Items) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(current.Items)+2)
+
+	if current.CustomerName != "" {
+		lines = append(lines, l.T("calendar.customer", translation.Params{"name": current.CustomerName}))
+	}
+
+	for _, item := range current.Items {
+		lines = append(lines, l.T("calendar.item", translation.Params{"service": item.Name, "minutes": item.Duration}))
+	}
+
+	total, currency := current.Total()
+
+	lines = append(lines, l.T("calendar.total", translation.Params{"amount": l.Money(total, currency)}))
+
+	return strings.Join(lines, "\n")
+}
+
+// businessLocalizer speaks the language of the business, for the texts the business reads.
+func businessLocalizer(
+	ctx context.Context,
+	businessService business.BusinessService,
+	lang translation.TranslationService,
+	businessID int,
+) (translation.Localizer, error) {
+	owner, err := businessService.GetBusinessByID(ctx, businessID)
+
+	if err != nil {
+		return translation.Localizer{}, eris.Wrap(err, "Error fetching the business of the booking")
+	}
+
+	return translation.NewLocalizer(lang, owner.Lang), nil
+}
+
+func reminderHandler(reminderService reminder.ReminderService) outbox.Handler {
+	return func(ctx context.Context, message *outbox.Message) error {
+		var payload outbox.BookingPayload
+
+		if err := message.Decode(&payload); err != nil {
+			return err
+		}
+
+		// The reminder takes the ID of the message, a redelivery finds it already scheduled.
+		if err := reminderService.NewReminder(ctx, message.ID, payload.BookingID, payload.Date.AddDate(0, 0, -1)); err != nil {
+			return eris.Wrap(err, "Error storing a new reminder")
+		}
+
+		return nil
+	}
+}
+
+func businessNotificationHandler(telegramService telegram.TelegramService) outbox.Handler {
+	return func(ctx context.Context, message *outbox.Message) error {
+		var payload outbox.NotificationPayload
+
+		if err := message.Decode(&payload); err != nil {
+			return err
+		}
+
+		if err := telegramService.NotifyBusiness(ctx, payload.BookingID, payload.Event); err != nil {
+			return eris.Wrap(err, "Error notifying the business")
+		}
+
+		return nil
+	}
+}
+
+func emailNotificationHandler(notificationService notification.NotificationService) outbox.Handler {
+	return func(ctx context.Context, message *outbox.Message) error {
+		var payload outbox.NotificationPayload
+
+		if err := message.Decode(&payload); err != nil {
+			return err
+		}
+
+		if err := notificationService.AlertOwners(ctx, payload.BookingID, payload.Event); err != nil {
+			return eris.Wrap(err, "Error emailing the owners of the business")
+		}
+
+		return nil
+	}
+}
+
+// customerNotificationHandler tells the customer about their booking. The outbox message is the
+// notification, its retries only go through the channels that did not deliver it yet.
+func customerNotificationHandler(notificationService notification.NotificationService) outbox.Handler {
+	return func(ctx context.Context, message *outbox.Message) error {
+		var payload outbox.NotificationPayload
+
+		if err := message.Decode(&payload); err != nil {
+			return err
+		}
+
+		notificationType, ok := notification.TypeOfEvent(payload.Event)
+
+		if !ok {
+			return nil
+		}
+
+		if err := notificationService.NotifyCustomer(ctx, message.ID, notificationType, payload.BookingID); err != nil {
+			return eris.Wrap(err, "Error notifying the customer")
+		}
+
+		return nil
+	}
+}
@@ -6,37 +6,385 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"sort"
 
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/outbox"
+	"github.com/adriein/hastypal/internal/translation"
 	"github.com/adriein/hastypal/pkg/constants"
 	"github.com/rotisserie/eris"
 )
 
-func (stm *TelegramMessage) SessionExpired() TelegramMessage {
-	var markdownText strings.Builder
+func (stm *TelegramMessage) BookingRescheduled(l translation.Localizer, date string, hour string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("🔄").Bold(l.T("booking.rescheduled", nil)).Paragraph().
+		Emoji("📅").Text(date).Paragraph().
+		Emoji("⌚️").Text(l.Hour(hour)).Paragraph().
+		Emoji("💙").Text(l.T("conversation.reminder_notice", nil))
 
-	expiredSession := "![🙂‍↕️](tg://emoji?id=5368324170671202286) Lo sentimos, la sesión ha caducado\\!\n\n"
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) BookingCancelled(l translation.Localizer, date string, hour string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("❌").Bold(l.T("booking.cancelled", nil)).Paragraph().
+		Emoji("📅").Text(date).Paragraph().
+		Emoji("⌚️").Text(l.Hour(hour)).Paragraph().
+		Bold(l.T("booking.book_again", nil))
+
+	return stm.plain(text)
+}
+
+// BusinessBookingNotice is sent to the chats linked to a business when one of its bookings is
+// created, cancelled or rescheduled. New bookings waiting for approval carry the buttons to
+// confirm or reject them.
+func (stm *TelegramMessage) BusinessBookingNotice(l translation.Localizer, businessName string, event string, notice BookingNotice) TelegramMessage {
+	titles := map[string][2]string{
+		outbox.BookingCreated:     {"🆕", "notice.created"},
+		outbox.BookingCancelled:   {"❌", "notice.cancelled"},
+		outbox.BookingRescheduled: {"🔄", "notice.rescheduled"},
+	}
+
+	title := titles[event]
+	pending := event == outbox.BookingCreated && notice.Pending
+
+	if pending {
+		title = [2]string{"⏳", "notice.pending"}
+	}
+
+	text := NewMarkdown().
+		Bold(businessName).Paragraph().
+		Emoji(title[0]).Bold(l.T(title[1], nil)).Paragraph().
+		Emoji("👤").Text(notice.Customer).Paragraph()
+
+	if notice.Service != "" {
+		text.Emoji("🔸").Text(notice.Service).Paragraph()
+	}
+
+	text.Emoji("📅").Text(notice.Date).Paragraph().
+		Emoji("⌚️").Text(l.Hour(notice.Hour))
+
+	keyboard := NewKeyboard()
+
+	if pending {
+		keyboard.Row(
+			CallbackButton(l.T("button.confirm", nil), fmt.Sprintf("%s?booking=%s", constants.ApproveCommand, notice.BookingID)),
+			CallbackButton(l.T("button.reject", nil), fmt.Sprintf("%s?booking=%s", constants.RejectCommand, notice.BookingID)),
+		)
+	}
+
+	return stm.compose(text, keyboard)
+}
+
+// BookingReviewed tells a linked chat who confirmed or rejected a pending booking.
+func (stm *TelegramMessage) BookingReviewed(l translation.Localizer, approved bool, reviewer string, notice BookingNotice) TelegramMessage {
+	icon, key := "✅", "notice.approved"
+
+	if !approved {
+		icon, key = "❌", "notice.rejected"
+	}
+
+	text := NewMarkdown().Emoji(icon).Text(l.T(key, translation.Params{
+		"reviewer": reviewer,
+		"customer": notice.Customer,
+		"date":     notice.Date,
+		"hour":     l.Hour(notice.Hour),
+	}))
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) BookingApproved(l translation.Localizer, date string, hour string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("🎉").Bold(l.T("booking.approved", nil)).Paragraph().
+		Emoji("📅").Text(date).Paragraph().
+		Emoji("⌚️").Text(l.Hour(hour)).Paragraph().
+		Emoji("💙").Text(l.T("conversation.reminder_notice", nil))
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) BookingRejected(l translation.Localizer, date string, hour string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("❌").Bold(l.T("booking.rejected", nil)).Paragraph().
+		Emoji("📅").Text(date).Paragraph().
+		Emoji("⌚️").Text(l.Hour(hour)).Paragraph().
+		Bold(l.T("booking.book_another", nil))
+
+	return stm.plain(text)
+}
+
+// Reminder reminds the customer of their booking with the reminder text of the business.
+func (stm *TelegramMessage) Reminder(text string) TelegramMessage {
+	return stm.plain(NewMarkdown().Emoji("⏰").Text(text))
+}
+
+// CustomerNotice tells the customer something about their booking in a text written already.
+func (stm *TelegramMessage) CustomerNotice(text string) TelegramMessage {
+	return stm.plain(NewMarkdown().Emoji("🔔").Text(text))
+}
+
+// AskPhone offers the customer of a first booking to share their phone with the business, the
+// buttons take the place of their keyboard until they answer.
+func (stm *TelegramMessage) AskPhone(l translation.Localizer, businessName string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("📱").Text(l.T("customer.ask_phone", translation.Params{"business": businessName}))
+
+	message := stm.plain(text)
+	message.ReplyMarkup = ReplyKeyboard{
+		Keyboard: [][]KeyboardButton{
+			{{Text: l.T("button.share_phone", nil), RequestContact: true}},
+			{{Text: l.T("button.skip_phone", nil)}},
+		},
+		OneTimeKeyboard: true,
+		ResizeKeyboard:  true,
+	}
+
+	return message
+}
+
+// PhoneSaved thanks the customer for their phone and gives them their keyboard back.
+func (stm *TelegramMessage) PhoneSaved(l translation.Localizer, businessName string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("💙").Text(l.T("customer.phone_saved", translation.Params{"business": businessName}))
+
+	message := stm.plain(text)
+	message.ReplyMarkup = RemoveKeyboard{RemoveKeyboard: true}
+
+	return message
+}
+
+func (stm *TelegramMessage) PhoneSkipped(l translation.Localizer) TelegramMessage {
+	message := stm.plain(NewMarkdown().Emoji("👌").Text(l.T("customer.phone_skipped", nil)))
+	message.ReplyMarkup = RemoveKeyboard{RemoveKeyboard: true}
+
+	return message
+}
+
+func (stm *TelegramMessage) ChatLinked(l translation.Localizer, businessName string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("🔗").Bold(l.T("chat.linked", translation.Params{"business": businessName})).Paragraph().
+		Text(l.T("chat.linked_details", nil))
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) ChatLinkFailed(l translation.Localizer) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("🙂‍↕️").Text(l.T("chat.link_failed", nil)).Paragraph().
+		Bold(l.T("chat.link_failed_instructions", nil))
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) OwnerOnly(l translation.Localizer) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("🔒").Text(l.T("owner.only", nil)).Paragraph().
+		Bold(l.T("owner.only_instructions", nil))
+
+	return stm.plain(text)
+}
+
+// OwnerCommandUsage shows how to write a command, usageKey names the arguments it takes.
+func (stm *TelegramMessage) OwnerCommandUsage(l translation.Localizer, command string, usageKey string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("ℹ️").Text(l.T("owner.usage", nil)).Space().
+		Code(command + " " + l.T(usageKey, nil))
+
+	return stm.plain(text)
+}
+
+// OwnerAgenda lists the bookings and the blocked ranges of a day for the /agenda command.
+func (stm *TelegramMessage) OwnerAgenda(l translation.Localizer, date string, bookings []BookingNotice, blocks []string) TelegramMessage {
+	text := NewMarkdown().Emoji("📅").Bold(l.T("owner.agenda", translation.Params{"date": date})).Paragraph()
+
+	if len(bookings) == 0 {
+		text.Text(l.T("owner.no_bookings", nil)).Paragraph()
+	}
+
+	for _, notice := range bookings {
+		icon := "🔸"
+
+		if notice.Pending {
+			icon = "⏳"
+		}
+
+		text.Emoji(icon).Bold(notice.Hour).Space().Text(notice.Customer)
+
+		if notice.Service != "" {
+			text.Text(" · " + notice.Service)
+		}
+
+		text.Line()
+	}
+
+	for _, block := range blocks {
+		text.Line().Emoji("🚫").Text(block)
+	}
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) OwnerTimeBlocked(l translation.Localizer, date string, from string, to string, affected int) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("🚫").Bold(l.T("owner.time_blocked", translation.Params{"date": date, "from": from, "to": to}))
+
+	if affected > 0 {
+		text.Paragraph().Emoji("⚠️").Text(l.Plural("owner.affected", affected, nil))
+	}
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) OwnerHolidayAdded(l translation.Localizer, date string, name string) TelegramMessage {
+	text := NewMarkdown().Emoji("🏖").Bold(l.T("owner.holiday_added", translation.Params{"date": date}))
+
+	if name != "" {
+		text.Paragraph().Text(name)
+	}
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) OwnerStats(l translation.Localizer, stats BookingStats) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("📊").Bold(l.T("stats.title", translation.Params{"period": stats.Period})).Paragraph().
+		Text(l.T("stats.total", translation.Params{"count": stats.Total})).Line().
+		Text(l.T("stats.confirmed", translation.Params{"count": stats.Confirmed, "upcoming": stats.Upcoming})).Line().
+		Text(l.T("stats.pending", translation.Params{"count": stats.Pending})).Line().
+		Text(l.T("stats.cancelled", translation.Params{"count": stats.Cancelled})).Line().
+		Text(l.T("stats.no_show", translation.Params{"count": stats.NoShow}))
+
+	if len(stats.Sources) > 0 {
+		text.Paragraph().Bold(l.T("stats.by_source", nil)).Line()
+
+		sources := make([]string, 0, len(stats.Sources))
+
+		for source := range stats.Sources {
+			sources = append(sources, source)
+		}
+
+		sort.Strings(sources)
+
+		for _, source := range sources {
+			text.Line().Text(fmt.Sprintf("%s: %d", source, stats.Sources[source]))
+		}
+	}
 
-	processInstructionsIcon := "![‍ℹ️️](tg://emoji?id=5368324170671202286)"
-	processInstructions := " *Pulsa Volver a empezar y te redirigiremos al canal de donde vienes*"
+	return stm.plain(text)
+}
+
+// BookNow is the message pinned to the channel of the business, with the button that opens a
+// booking conversation with the bot.
+func (stm *TelegramMessage) BookNow(l translation.Localizer, businessName string, startUrl string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("📅").Bold(l.T("channel.book_now", translation.Params{"business": businessName})).Paragraph().
+		Text(l.T("channel.book_now_details", nil))
+
+	keyboard := NewKeyboard().Row(UrlButton(l.T("button.book_now", nil), startUrl))
+
+	message := stm.compose(text, keyboard)
+
+	// Anyone in the channel can forward the link to the business.
+	message.ProtectContent = false
+
+	return message
+}
+
+func (stm *TelegramMessage) ChannelLinked(l translation.Localizer, channelName string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("📣").Bold(l.T("channel.linked", translation.Params{"channel": channelName})).Paragraph().
+		Text(l.T("channel.linked_details", nil))
+
+	return stm.plain(text)
+}
+
+func (stm *TelegramMessage) ChannelMissingRights(l translation.Localizer, channelName string) TelegramMessage {
+	text := NewMarkdown().
+		Emoji("⚠️").Bold(l.T("channel.missing_rights", translation.Params{"channel": channelName})).Paragraph().
+		Text(l.T("channel.missing_rights_details", nil))
+
+	return stm.plain(text)
+}
+
+// Prompt renders a step of the booking conversation: its lines as paragraphs and its choices as
+// buttons, the days and times in rows of three so a page of them fits the screen. The services
+// show their price and duration on the button, the other steps keep the details in the text.
+func (stm *TelegramMessage) Prompt(prompt *conversation.Prompt, startAgainUrl string) TelegramMessage {
+	text := NewMarkdown()
+
+	for i, line := range prompt.Lines {
+		if i > 0 {
+			text.Paragraph()
+		}
+
+		if line.Emoji != "" {
+			text.Emoji(line.Emoji)
+		}
+
+		switch {
+		case line.Strong:
+			text.Bold(line.Text)
+		case line.Label != "":
+			text.Bold(line.Label).Space().Text(line.Text)
+		default:
+			text.Text(line.Text)
+		}
+	}
+
+	keyboard := NewKeyboard()
+
+	switch prompt.Step {
+	case conversation.StepExpired:
+		keyboard.Row(UrlButton(prompt.Localizer.T("button.start_again", nil), startAgainUrl))
+	case conversation.StepDates, conversation.StepHours:
+		keyboard.Grid(3, promptButtons(prompt, prompt.Options(conversation.ChoiceOption))...)
 
-	markdownText.WriteString(expiredSession)
-	markdownText.WriteString(processInstructionsIcon)
-	markdownText.WriteString(processInstructions)
+		for _, button := range promptButtons(prompt, prompt.Options(conversation.ChoiceNavigation, conversation.ChoiceNext)) {
+			keyboard.Row(button)
+		}
+	default:
+		for _, button := range promptButtons(prompt, prompt.Choices) {
+			keyboard.Row(button)
+		}
+	}
+
+	return stm.compose(text, keyboard)
+}
+
+func promptButtons(prompt *conversation.Prompt, choices []conversation.Choice) []KeyboardButton {
+	buttons := make([]KeyboardButton, len(choices))
 
-	startAgainButton := KeyboardButton{
-		Text: "Volver a empezar",
-		Url:  "t.me/+0djgKpMfYY5lY2I8",
+	for i, choice := range choices {
+		label := choice.Label
+
+		if prompt.Step == conversation.StepServices && choice.Detail != "" {
+			label += " · " + choice.Detail
+		}
+
+		if choice.Selected {
+			label = "✅ " + label
+		}
+
+		buttons[i] = CallbackButton(label, choice.Action)
 	}
 
-	chunked := [][]KeyboardButton{{startAgainButton}}
+	return buttons
+}
 
+// plain builds a message without buttons for the chats that are not in a booking conversation.
+func (stm *TelegramMessage) plain(text *Markdown) TelegramMessage {
+	return stm.compose(text, NewKeyboard())
+}
+
+// compose builds a message of a booking conversation, which the customer cannot forward.
+func (stm *TelegramMessage) compose(text *Markdown, keyboard *Keyboard) TelegramMessage {
 	return TelegramMessage{
 		ChatId:         stm.ChatId,
-		Text:           markdownText.String(),
+		Text:           text.String(),
 		ParseMode:      constants.TelegramMarkdown,
 		ProtectContent: true,
-		ReplyMarkup:    ReplyMarkup{InlineKeyboard: chunked},
+		ReplyMarkup:    keyboard.Markup(),
 	}
 }
 
@@ -45,9 +393,28 @@ type AnswerCallbackQuery struct {
 	Text            string `json:"text"`
 }
 
+type PinChatMessage struct {
+	ChatId              int  `json:"chat_id"`
+	MessageId           int  `json:"message_id"`
+	DisableNotification bool `json:"disable_notification"`
+}
+
+type ExportChatInviteLink struct {
+	ChatId int `json:"chat_id"`
+}
+
+type SetWebhook struct {
+	Url         string `json:"url"`
+	SecretToken string `json:"secret_token"`
+}
+
 type TelegramBot interface {
 	SendMsg(dto BookingTelegramMessage) error
+	Send(message TelegramMessage) error
+	SendPinned(message TelegramMessage) error
+	ExportInviteLink(chatID int) (string, error)
 	AnswerCallbackQuery(msg AnswerCallbackQuery) error
+	SetWebhook(url string, secret string) error
 }
 
 type Bot struct {
@@ -62,6 +429,7 @@ func NewTelegramBot(url string, token string) *Bot {
 	}
 }
 
+// SendMsg sends a message of a booking conversation, headed by the business and the session.
 func (tb *Bot) SendMsg(dto BookingTelegramMessage) error {
 	telegramMessage := dto.Message
 
@@ -80,58 +448,55 @@ func (tb *Bot) SendMsg(dto BookingTelegramMessage) error {
 		ReplyMarkup:    telegramMessage.ReplyMarkup,
 	}
 
-	byteEncodedBody, err := json.Marshal(updatedTelegramMessage)
+	return tb.Send(updatedTelegramMessage)
+}
 
-	if err != nil {
-		return eris.Wrap(err, "Error marshaling struct")
-	}
+// Send sends a message as it is, for the chats that are not in a booking conversation.
+func (tb *Bot) Send(message TelegramMessage) error {
+	return tb.call("sendMessage", message, nil)
+}
 
-	request, err := http.NewRequest(
-		http.MethodPost,
-		fmt.Sprintf(
-			"%s/bot%s/sendMessage",
-			tb.url,
-			tb.token,
-		),
-		bytes.NewBuffer(byteEncodedBody),
-	)
+// SendPinned sends the message and pins it to the top of the chat.
+func (tb *Bot) SendPinned(message TelegramMessage) error {
+	var sent TelegramMessageUpdate
 
-	if err != nil {
-		return eris.Wrap(err, "Error creating new http request")
+	if err := tb.call("sendMessage", message, &sent); err != nil {
+		return err
 	}
 
-	request.Header.Add("Content-Type", "application/json")
+	pin := PinChatMessage{ChatId: message.ChatId, MessageId: sent.MessageId, DisableNotification: true}
 
-	client := &http.Client{}
-	response, err := client.Do(request)
-
-	if err != nil {
-		return eris.Wrap(err, "Error performing http request")
+	if err := tb.call("pinChatMessage", pin, nil); err != nil {
+		return eris.Wrap(err, "Error pinning message")
 	}
 
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(response.Body)
-
-		if err != nil {
-			return eris.Wrap(err, "Error reading http body buffer")
-		}
+	return nil
+}
 
-		var data TelegramHttpResponse
+// ExportInviteLink creates the primary invite link of a chat the bot administers.
+func (tb *Bot) ExportInviteLink(chatID int) (string, error) {
+	var link string
 
-		if err := json.Unmarshal(body, &data); err != nil {
-			return eris.Wrap(err, "Error unmarshaling http response")
-		}
-
-		return eris.Wrapf(err, "Error code: %d, Description: %s", data.ErrorCode, data.Description)
+	if err := tb.call("exportChatInviteLink", ExportChatInviteLink{ChatId: chatID}, &link); err != nil {
+		return "", err
 	}
 
-	return nil
+	return link, nil
 }
 
 func (tb *Bot) AnswerCallbackQuery(msg AnswerCallbackQuery) error {
-	byteEncodedBody, err := json.Marshal(msg)
+	return tb.call("answerCallbackQuery", msg, nil)
+}
+
+// SetWebhook points the bot updates to url, Telegram sends the secret back in the
+// X-Telegram-Bot-Api-Secret-Token header of every update.
+func (tb *Bot) SetWebhook(url string, secret string) error {
+	return tb.call("setWebhook", SetWebhook{Url: url, SecretToken: secret}, nil)
+}
+
+// call performs a method of the bot API and decodes its result into result when it is not nil.
+func (tb *Bot) call(method string, body any, result any) error {
+	byteEncodedBody, err := json.Marshal(body)
 
 	if err != nil {
 		return eris.Wrap(err, "Error marshaling struct")
@@ -140,9 +505,10 @@ func (tb *Bot) AnswerCallbackQuery(msg AnswerCallbackQuery) error {
 	request, err := http.NewRequest(
 		http.MethodPost,
 		fmt.Sprintf(
-			"%s/bot%s/answerCallbackQuery",
+			"%s/bot%s/%s",
 			tb.url,
 			tb.token,
+			method,
 		),
 		bytes.NewBuffer(byteEncodedBody),
 	)
@@ -175,7 +541,23 @@ func (tb *Bot) AnswerCallbackQuery(msg AnswerCallbackQuery) error {
 			return eris.Wrap(err, "Error unmarshaling http response")
 		}
 
-		return eris.Wrapf(err, "Error code: %d, Description: %s", data.ErrorCode, data.Description)
+		return eris.Errorf("Error calling %s, code: %d, Description: %s", method, data.ErrorCode, data.Description)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	var data struct {
+		Result json.RawMessage `json:"result"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&data); err != nil {
+		return eris.Wrap(err, "Error decoding http response")
+	}
+
+	if err := json.Unmarshal(data.Result, result); err != nil {
+		return eris.Wrapf(err, "Error unmarshaling the result of %s", method)
 	}
 
 	return nil
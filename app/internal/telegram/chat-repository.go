@@ -0,0 +1,279 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adriein/hastypal/database"
+	"github.com/rotisserie/eris"
+)
+
+type ChatRepository interface {
+	SaveCode(ctx context.Context, code *ChatLinkCode) error
+	GetCode(ctx context.Context, code string) (*ChatLinkCode, error)
+	Link(ctx context.Context, code *ChatLinkCode, chat *BusinessChat) error
+	Unlink(ctx context.Context, businessID int, chatID int) error
+	GetByID(ctx context.Context, chatID int) (*BusinessChat, error)
+	GetByBusinessID(ctx context.Context, businessID int) ([]*BusinessChat, error)
+}
+
+type PgChatRepository struct {
+	connection *sql.DB
+}
+
+func NewPgChatRepository(connection *sql.DB) *PgChatRepository {
+	return &PgChatRepository{
+		connection: connection,
+	}
+}
+
+const selectChatQuery = `
+	SELECT
+		habc_chat_id,
+		habc_business_id,
+		habc_title,
+		COALESCE(habc_linked_by, ''),
+		habc_date_add
+	FROM
+		ha_business_chat
+`
+
+func (r *PgChatRepository) SaveCode(ctx context.Context, code *ChatLinkCode) error {
+	query := `
+		INSERT INTO ha_chat_link_code (
+			hacl_code,
+			hacl_business_id,
+			hacl_user_id,
+			hacl_expires_at,
+			hacl_date_add
+		)
+		VALUES ($1, $2, $3, $4, $5);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		code.Code,
+		code.BusinessID,
+		code.UserID,
+		code.ExpiresAt,
+		code.DateAdd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving chat link code")
+	}
+
+	return nil
+}
+
+func (r *PgChatRepository) GetCode(ctx context.Context, code string) (*ChatLinkCode, error) {
+	query := `
+		SELECT
+			hacl_code,
+			hacl_business_id,
+			hacl_user_id,
+			hacl_expires_at,
+			hacl_used_at,
+			hacl_date_add
+		FROM
+			ha_chat_link_code
+		WHERE
+			hacl_code = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var (
+		linkCode ChatLinkCode
+		usedAt   sql.NullTime
+	)
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, code).Scan(
+		&linkCode.Code,
+		&linkCode.BusinessID,
+		&linkCode.UserID,
+		&linkCode.ExpiresAt,
+		&usedAt,
+		&linkCode.DateAdd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ChatLinkCodeNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query chat link code")
+	}
+
+	if usedAt.Valid {
+		linkCode.UsedAt = &usedAt.Time
+	}
+
+	return &linkCode, nil
+}
+
+// Link spends the code and links the chat in one transaction. A chat already linked to a business
+// moves to the business of the code, and a spent code cannot link a second chat.
+func (r *PgChatRepository) Link(ctx context.Context, code *ChatLinkCode, chat *BusinessChat) error {
+	useQuery := `
+		UPDATE ha_chat_link_code
+		SET hacl_used_at = $2
+		WHERE hacl_code = $1 AND hacl_used_at IS NULL;
+	`
+
+	linkQuery := `
+		INSERT INTO ha_business_chat (
+			habc_chat_id,
+			habc_business_id,
+			habc_title,
+			habc_linked_by,
+			habc_date_add
+		)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		ON CONFLICT (habc_chat_id) DO UPDATE
+		SET
+			habc_business_id = EXCLUDED.habc_business_id,
+			habc_title = EXCLUDED.habc_title,
+			habc_linked_by = EXCLUDED.habc_linked_by,
+			habc_date_add = EXCLUDED.habc_date_add;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return database.WithTransaction(ctxTimeout, r.connection, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctxTimeout, useQuery, code.Code, chat.DateAdd)
+
+		if err != nil {
+			return eris.Wrap(err, "Error spending chat link code")
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return eris.Wrap(err, "Error spending chat link code")
+		}
+
+		if affected == 0 {
+			return ChatLinkCodeNotFound
+		}
+
+		_, err = tx.ExecContext(
+			ctxTimeout,
+			linkQuery,
+			chat.ChatID,
+			chat.BusinessID,
+			chat.Title,
+			chat.LinkedBy,
+			chat.DateAdd,
+		)
+
+		if err != nil {
+			return eris.Wrap(err, "Error linking chat")
+		}
+
+		return nil
+	})
+}
+
+func (r *PgChatRepository) Unlink(ctx context.Context, businessID int, chatID int) error {
+	query := `DELETE FROM ha_business_chat WHERE habc_business_id = $1 AND habc_chat_id = $2;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	result, err := r.connection.ExecContext(ctxTimeout, query, businessID, chatID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error unlinking chat")
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return eris.Wrap(err, "Error unlinking chat")
+	}
+
+	if affected == 0 {
+		return ChatNotLinked
+	}
+
+	return nil
+}
+
+func (r *PgChatRepository) GetByID(ctx context.Context, chatID int) (*BusinessChat, error) {
+	query := selectChatQuery + `WHERE habc_chat_id = $1;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	chat, err := scanChat(r.connection.QueryRowContext(ctxTimeout, query, chatID))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ChatNotLinked
+		}
+
+		return nil, eris.Wrap(err, "Failed to query business chat")
+	}
+
+	return chat, nil
+}
+
+func (r *PgChatRepository) GetByBusinessID(ctx context.Context, businessID int) (chats []*BusinessChat, err error) {
+	query := selectChatQuery + `WHERE habc_business_id = $1 ORDER BY habc_date_add;`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	rows, err := r.connection.QueryContext(ctxTimeout, query, businessID)
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "Error fetching the chats of business %d", businessID)
+	}
+
+	defer database.CloseRowsSafely(rows, &err)
+
+	chats = make([]*BusinessChat, 0)
+
+	for rows.Next() {
+		chat, err := scanChat(rows)
+
+		if err != nil {
+			return nil, eris.Wrap(err, "Error scanning business chat")
+		}
+
+		chats = append(chats, chat)
+	}
+
+	return chats, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanChat(row rowScanner) (*BusinessChat, error) {
+	var chat BusinessChat
+
+	err := row.Scan(
+		&chat.ChatID,
+		&chat.BusinessID,
+		&chat.Title,
+		&chat.LinkedBy,
+		&chat.DateAdd,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &chat, nil
+}
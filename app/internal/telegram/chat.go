@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+var (
+	ChatLinkCodeNotFound = eris.New("Chat link code not found")
+	ChatLinkCodeExpired  = eris.New("Chat link code expired")
+	ChatNotLinked        = eris.New("Chat not linked to any business")
+)
+
+const (
+	ChatLinkCodeTtl    = 15 * time.Minute
+	chatLinkCodeLength = 8
+	// Letters and digits that cannot be mistaken for one another when typed from a screen.
+	chatLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// ChatLinkCode is the one-time code a business user sends to the bot with /link from the chat
+// that has to receive the notifications of the business.
+type ChatLinkCode struct {
+	Code       string     `json:"code"`
+	BusinessID int        `json:"businessId"`
+	UserID     string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	UsedAt     *time.Time `json:"-"`
+	DateAdd    time.Time  `json:"createdAt"`
+}
+
+func NewChatLinkCode(businessID int, userID string) (*ChatLinkCode, error) {
+	random := make([]byte, chatLinkCodeLength)
+
+	if _, err := rand.Read(random); err != nil {
+		return nil, eris.Wrap(err, "Error generating the chat link code")
+	}
+
+	code := make([]byte, chatLinkCodeLength)
+
+	for i, b := range random {
+		code[i] = chatLinkCodeAlphabet[int(b)%len(chatLinkCodeAlphabet)]
+	}
+
+	now := time.Now().UTC()
+
+	return &ChatLinkCode{
+		Code:       string(code),
+		BusinessID: businessID,
+		UserID:     userID,
+		ExpiresAt:  now.Add(ChatLinkCodeTtl),
+		DateAdd:    now,
+	}, nil
+}
+
+func (c *ChatLinkCode) IsUsed() bool {
+	return c.UsedAt != nil
+}
+
+func (c *ChatLinkCode) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// BusinessChat is a private chat or a group of the business staff that receives its notifications.
+type BusinessChat struct {
+	ChatID     int       `json:"chatId"`
+	BusinessID int       `json:"businessId"`
+	Title      string    `json:"title"`
+	LinkedBy   string    `json:"linkedBy,omitempty"`
+	DateAdd    time.Time `json:"createdAt"`
+}
+
+func NewBusinessChat(chat TelegramChat, code *ChatLinkCode) *BusinessChat {
+	return &BusinessChat{
+		ChatID:     chat.Id,
+		BusinessID: code.BusinessID,
+		Title:      chat.DisplayName(),
+		LinkedBy:   code.UserID,
+		DateAdd:    time.Now().UTC(),
+	}
+}
+
+// DisplayName is the title of a group or the name of the person of a private chat.
+func (c TelegramChat) DisplayName() string {
+	if c.Title != "" {
+		return c.Title
+	}
+
+	return strings.TrimSpace(c.FirstName + " " + c.LastName)
+}
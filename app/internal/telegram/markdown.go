@@ -0,0 +1,160 @@
+package telegram
+
+import (
+	"strings"
+)
+
+// Every custom emoji of the bot is drawn from the same sticker, the emoji itself is what clients
+// without custom emoji support show.
+const customEmojiID = "5368324170671202286"
+
+var markdownReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"_", "\\_",
+	"*", "\\*",
+	"[", "\\[",
+	"]", "\\]",
+	"(", "\\(",
+	")", "\\)",
+	"~", "\\~",
+	"`", "\\`",
+	">", "\\>",
+	"#", "\\#",
+	"+", "\\+",
+	"-", "\\-",
+	"=", "\\=",
+	"|", "\\|",
+	"{", "\\{",
+	"}", "\\}",
+	".", "\\.",
+	"!", "\\!",
+)
+
+// Inside the url of a link and inside code only these two characters are special.
+var (
+	linkReplacer = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+	codeReplacer = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+)
+
+// EscapeMarkdown escapes the characters MarkdownV2 reserves, for text that comes from the users.
+func EscapeMarkdown(text string) string {
+	return markdownReplacer.Replace(text)
+}
+
+// Markdown writes the text of a message in MarkdownV2. Every method takes plain text and escapes
+// it, so names with dots, dashes or brackets never make Telegram reject the message.
+type Markdown struct {
+	text strings.Builder
+}
+
+func NewMarkdown() *Markdown {
+	return &Markdown{}
+}
+
+func (m *Markdown) Text(text string) *Markdown {
+	m.text.WriteString(EscapeMarkdown(text))
+
+	return m
+}
+
+func (m *Markdown) Bold(text string) *Markdown {
+	return m.wrap("*", text)
+}
+
+func (m *Markdown) Italic(text string) *Markdown {
+	return m.wrap("_", text)
+}
+
+func (m *Markdown) Code(text string) *Markdown {
+	m.text.WriteString("`" + codeReplacer.Replace(text) + "`")
+
+	return m
+}
+
+func (m *Markdown) Link(text string, url string) *Markdown {
+	m.text.WriteString("[" + EscapeMarkdown(text) + "](" + linkReplacer.Replace(url) + ")")
+
+	return m
+}
+
+// Emoji writes a custom emoji followed by a space, as the lines of the bot start.
+func (m *Markdown) Emoji(emoji string) *Markdown {
+	m.text.WriteString("![" + emoji + "](tg://emoji?id=" + customEmojiID + ") ")
+
+	return m
+}
+
+func (m *Markdown) Space() *Markdown {
+	m.text.WriteString(" ")
+
+	return m
+}
+
+// Line ends the current line.
+func (m *Markdown) Line() *Markdown {
+	m.text.WriteString("\n")
+
+	return m
+}
+
+// Paragraph ends the current line leaving a blank one after it.
+func (m *Markdown) Paragraph() *Markdown {
+	m.text.WriteString("\n\n")
+
+	return m
+}
+
+func (m *Markdown) String() string {
+	return m.text.String()
+}
+
+func (m *Markdown) wrap(marker string, text string) *Markdown {
+	m.text.WriteString(marker + EscapeMarkdown(text) + marker)
+
+	return m
+}
+
+// Keyboard lays out the inline buttons of a message in rows.
+type Keyboard struct {
+	rows [][]KeyboardButton
+}
+
+func NewKeyboard() *Keyboard {
+	return &Keyboard{rows: make([][]KeyboardButton, 0)}
+}
+
+func CallbackButton(text string, data string) KeyboardButton {
+	return KeyboardButton{Text: text, CallbackData: data}
+}
+
+func UrlButton(text string, url string) KeyboardButton {
+	return KeyboardButton{Text: text, Url: url}
+}
+
+// Row adds the buttons side by side in a row of their own.
+func (k *Keyboard) Row(buttons ...KeyboardButton) *Keyboard {
+	if len(buttons) > 0 {
+		k.rows = append(k.rows, buttons)
+	}
+
+	return k
+}
+
+// Grid adds the buttons in rows of the given number of columns, the last one holding the rest.
+func (k *Keyboard) Grid(columns int, buttons ...KeyboardButton) *Keyboard {
+	for start := 0; start < len(buttons); start += columns {
+		k.Row(buttons[start:min(start+columns, len(buttons))]...)
+	}
+
+	return k
+}
+
+// Column adds every button in a row of its own.
+func (k *Keyboard) Column(buttons ...KeyboardButton) *Keyboard {
+	return k.Grid(1, buttons...)
+}
+
+// Markup is the keyboard as Telegram takes it, an empty one when it has no buttons.
+func (k *Keyboard) Markup() ReplyMarkup {
+	return ReplyMarkup{InlineKeyboard: k.rows}
+}
@@ -0,0 +1,142 @@
+package telegram
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/outbox"
+	"github.com/adriein/hastypal/internal/translation"
+)
+
+// Run with -update to write the golden files again after changing a message on purpose.
+var update = flag.Bool("update", false, "update the golden files")
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatalf("reading %s, run the tests with -update to create it: %v", path, err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("%s does not match the golden file\n got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestMarkdownEscapesEveryPart(t *testing.T) {
+	text := NewMarkdown().
+		Emoji("📅").Bold("Barbería Dr. Pérez (centro)").Paragraph().
+		Text("Corte + barba - 18,50 € [promo] #1 _ahora_ ~ya~ > = | {} !").Line().
+		Italic("Sr. López-García").Space().
+		Link("Reserva (aquí)", "https://t.me/bot?start=a_b(c)").Line().
+		Code("/block 25/12 10:00-12:00 [motivo] `x`")
+
+	assertGolden(t, "markdown", []byte(text.String()))
+}
+
+func TestKeyboardLayout(t *testing.T) {
+	days := make([]KeyboardButton, 0, 7)
+
+	for _, day := range []string{"Lun 1", "Mar 2", "Mié 3", "Jue 4", "Vie 5", "Sáb 6", "Dom 7"} {
+		days = append(days, CallbackButton(day, "/hours?date="+day))
+	}
+
+	keyboard := NewKeyboard().
+		Grid(3, days...).
+		Row(CallbackButton("Confirmar", "/approve"), CallbackButton("Rechazar", "/reject")).
+		Row().
+		Column(UrlButton("Volver a empezar", "https://t.me/channel"))
+
+	marshalled, err := json.MarshalIndent(keyboard.Markup(), "", "  ")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, "keyboard", marshalled)
+
+	empty, err := json.Marshal(NewKeyboard().Markup())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(empty) != `{"inline_keyboard":[]}` {
+		t.Errorf("empty keyboard = %s, Telegram rejects a null inline_keyboard", empty)
+	}
+}
+
+// The messages carry names typed by businesses and customers, which must reach Telegram escaped.
+func TestBotMessages(t *testing.T) {
+	lang := translation.NewService()
+
+	notice := BookingNotice{
+		BookingID: "3f2a",
+		Customer:  "Ana (VIP)",
+		Service:   "Corte + barba",
+		Date:      "lunes 5 de enero",
+		Hour:      "10:30",
+		Pending:   true,
+	}
+
+	stats := BookingStats{
+		Period:    "Enero",
+		Total:     12,
+		Confirmed: 8,
+		Upcoming:  3,
+		Pending:   1,
+		Cancelled: 2,
+		NoShow:    1,
+		Sources:   map[string]int{"instagram": 5, "flyer-2025": 2},
+	}
+
+	for _, locale := range []string{"es", "en"} {
+		l := translation.NewLocalizer(lang, locale)
+		message := TelegramMessage{ChatId: 42}
+
+		messages := map[string]TelegramMessage{
+			"session_expired":   message.Prompt(conversation.SessionExpired(l, 42), "https://t.me/barberia_centro"),
+			"business_notice":   message.BusinessBookingNotice(l, "Barbería Dr. Pérez", outbox.BookingCreated, notice),
+			"booking_reviewed":  message.BookingReviewed(l, true, "José-Luis", notice),
+			"booking_confirmed": message.Prompt(conversation.Booked(l, 42, l.T("conversation.booking_confirmed", nil)), ""),
+			"booking_cancelled": message.BookingCancelled(l, notice.Date, notice.Hour),
+			"owner_usage":       message.OwnerCommandUsage(l, "/block", "owner.block_usage"),
+			"owner_agenda":      message.OwnerAgenda(l, notice.Date, []BookingNotice{notice}, []string{"13:00-14:00 comida."}),
+			"owner_blocked":     message.OwnerTimeBlocked(l, notice.Date, "10:00", "12:00", 1),
+			"owner_stats":       message.OwnerStats(l, stats),
+			"book_now":          message.BookNow(l, "Barbería Dr. Pérez", "https://t.me/HastypalBot?start=MQ"),
+			"ask_phone":         message.AskPhone(l, "Barbería Dr. Pérez"),
+			"phone_saved":       message.PhoneSaved(l, "Barbería Dr. Pérez"),
+		}
+
+		for name, built := range messages {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				marshalled, err := json.MarshalIndent(built, "", "  ")
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				assertGolden(t, locale+"_"+name, marshalled)
+			})
+		}
+	}
+}
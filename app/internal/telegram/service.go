@@ -3,50 +3,71 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/adriein/hastypal/internal/auth"
 	"github.com/adriein/hastypal/internal/booking"
 	"github.com/adriein/hastypal/internal/business"
-	"github.com/adriein/hastypal/internal/google"
-	"github.com/adriein/hastypal/internal/reminder"
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/customer"
+	"github.com/adriein/hastypal/internal/notification"
 	"github.com/adriein/hastypal/internal/translation"
 	"github.com/adriein/hastypal/pkg/constants"
-	"github.com/adriein/hastypal/pkg/helper/array"
 	"github.com/adriein/hastypal/pkg/helper/reflection"
 	"github.com/rotisserie/eris"
 )
 
 type TelegramService interface {
 	HandleMessage(ctx context.Context, update TelegramUpdate) error
+	CreateLinkCode(ctx context.Context, businessID int, userID string) (*ChatLinkCode, error)
+	StartLink(ctx context.Context, payload conversation.StartPayload) (string, error)
+	GetChats(ctx context.Context, businessID int) ([]*BusinessChat, error)
+	UnlinkChat(ctx context.Context, businessID int, chatID int) error
+	NotifyBusiness(ctx context.Context, bookingID string, event string) error
+	RegisterWebhook(url string, secret string) error
+	notification.ChannelSender
 }
 
 type Service struct {
-	business business.BusinessService
-	booking  booking.BookingService
-	lang     translation.TranslationService
-	reminder reminder.ReminderService
-	google   google.GoogleService
-	bot      TelegramBot
+	logger    *slog.Logger
+	business  business.BusinessService
+	booking   booking.BookingService
+	users     auth.AuthService
+	customers customer.CustomerService
+	lang      translation.TranslationService
+	chats     ChatRepository
+	bot       TelegramBot
+	engine    *conversation.Engine
+	botName   string
 }
 
 func NewService(
+	logger *slog.Logger,
 	business business.BusinessService,
 	booking booking.BookingService,
+	users auth.AuthService,
+	customers customer.CustomerService,
 	lang translation.TranslationService,
-	reminder reminder.ReminderService,
-	google google.GoogleService,
+	chats ChatRepository,
 	bot TelegramBot,
+	engine *conversation.Engine,
+	botName string,
 ) *Service {
 	return &Service{
-		business: business,
-		booking:  booking,
-		lang:     lang,
-		reminder: reminder,
-		google:   google,
-		bot:      bot,
+		logger:    logger,
+		business:  business,
+		booking:   booking,
+		users:     users,
+		customers: customers,
+		lang:      lang,
+		chats:     chats,
+		bot:       bot,
+		engine:    engine,
+		botName:   botName,
 	}
 }
 
@@ -56,6 +77,16 @@ TELEGRAM UPDATE HANLDER
 ================================================================================
 */
 
+// RegisterWebhook tells Telegram where to post the bot updates and the secret token to post
+// them with.
+func (s *Service) RegisterWebhook(url string, secret string) error {
+	if err := s.bot.SetWebhook(url, secret); err != nil {
+		return eris.Wrap(err, "Error registering the telegram webhook")
+	}
+
+	return nil
+}
+
 func (s *Service) HandleMessage(ctx context.Context, update TelegramUpdate) error {
 	if reflection.HasField(update, constants.TelegramMessageField) {
 		if err := s.resolveBotCommand(ctx, update); err != nil {
@@ -65,6 +96,14 @@ func (s *Service) HandleMessage(ctx context.Context, update TelegramUpdate) erro
 		return nil
 	}
 
+	if reflection.HasField(update, constants.TelegramMyChatMemberField) {
+		if err := s.resolveChatMemberUpdate(ctx, update); err != nil {
+			return eris.Wrap(err, "Error resolving chat member update")
+		}
+
+		return nil
+	}
+
 	if err := s.resolveCallbackQueryCommand(ctx, update); err != nil {
 		return eris.Wrap(err, "Error resolving callback query command")
 	}
@@ -77,12 +116,28 @@ func (s *Service) resolveBotCommand(ctx context.Context, update TelegramUpdate)
 		return nil
 	}
 
+	if update.Message.Contact != nil {
+		return s.saveCustomerPhone(ctx, update)
+	}
+
 	txtArr := strings.Split(update.Message.Text, " ")
-	command := txtArr[0]
+
+	// In groups the commands can come addressed to the bot, as in /link@HastypalBot.
+	command, _, _ := strings.Cut(txtArr[0], "@")
 
 	switch command {
 	case constants.StartCommand:
 		return s.startConversation(ctx, update)
+	case constants.LinkCommand:
+		return s.linkChat(ctx, update)
+	case constants.AgendaCommand, constants.BlockCommand, constants.HolidayCommand, constants.StatsCommand:
+		return s.resolveOwnerCommand(ctx, update, command)
+	}
+
+	if s.isPhoneSkipped(update) {
+		message := TelegramMessage{ChatId: update.Message.Chat.Id}
+
+		return s.bot.Send(message.PhoneSkipped(s.localizer(update.Message.From.LanguageCode)))
 	}
 
 	return nil
@@ -100,16 +155,16 @@ func (s *Service) resolveCallbackQueryCommand(ctx context.Context, update Telegr
 	}
 
 	switch url.Path {
-	case constants.ServiceCommand:
-		return s.showServices(ctx, update)
-	case constants.DatesCommand:
-		return s.showDates(ctx, update)
-	case constants.HoursCommand:
-		return s.showHours(ctx, update)
-	case constants.ConfirmationCommand:
-		return s.showConfirmation(ctx, update)
-	case constants.FinishCommand:
-		return s.showBookingPreview(ctx, update)
+	case constants.ServiceCommand,
+		constants.DatesCommand,
+		constants.HoursCommand,
+		constants.ConfirmationCommand,
+		constants.FinishCommand:
+		return s.answerConversation(ctx, update)
+	case constants.ApproveCommand:
+		return s.reviewBooking(ctx, update, true)
+	case constants.RejectCommand:
+		return s.reviewBooking(ctx, update, false)
 	}
 
 	return nil
@@ -117,78 +172,70 @@ func (s *Service) resolveCallbackQueryCommand(ctx context.Context, update Telegr
 
 /*
 ================================================================================
-TELEGRAM START CONVERSATION COMMAND
+TELEGRAM BOOKING CONVERSATION
 ================================================================================
 */
 
 func (s *Service) startConversation(ctx context.Context, update TelegramUpdate) error {
-	var markdownText strings.Builder
-
-	businessIdRaw := strings.ReplaceAll(update.Message.Text, "/start ", "")
+	rawPayload := strings.TrimSpace(strings.TrimPrefix(update.Message.Text, constants.StartCommand))
 
-	businessID, err := strconv.Atoi(businessIdRaw)
-
-	if err != nil {
-		return eris.Wrap(err, "Error converting business ID to int")
+	customer := conversation.Customer{
+		Name:   update.Message.From.Name(),
+		Locale: update.Message.From.LanguageCode,
 	}
 
-	business, err := s.business.GetBusinessByID(ctx, businessID)
+	prompt, err := s.engine.Start(ctx, update.Message.Chat.Id, customer, rawPayload)
 
 	if err != nil {
-		return eris.Wrap(err, "Error fetching business")
+		return err
 	}
 
-	sessionID, err := s.booking.InitSession(ctx, businessID, update.Message.Chat.Id)
-
-	if err != nil {
-		return eris.Wrap(err, "Error creating a session for this conversation")
-	}
+	return s.sendPrompt(prompt)
+}
 
-	welcome := fmt.Sprintf(
-		"Hola %s ![👋](tg://emoji?id=5368324170671202286), soy HastypalBot el ayudante de %s\\.\n\n",
-		update.Message.From.FirstName,
-		"Hastypal Business Test",
-	)
+// answerConversation acks the button the customer pressed and moves the booking conversation on.
+func (s *Service) answerConversation(ctx context.Context, update TelegramUpdate) error {
+	ack := AnswerCallbackQuery{CallbackQueryId: update.CallbackQuery.Id}
 
-	services := []string{
-		"Corte de pelo y barba express 18€",
-		"Corte de pelo y barba premium 22€",
+	if err := s.bot.AnswerCallbackQuery(ack); err != nil {
+		return eris.Wrap(err, "Error acking telegram conversation")
 	}
 
-	emoji := "![🔸](tg://emoji?id=5368324170671202286)"
-
-	markdownText.WriteString(welcome)
-	markdownText.WriteString("*Te muestro a continuación los servicios que ofrecemos:*\n\n")
+	customer := conversation.Customer{
+		Name:   update.CallbackQuery.From.Name(),
+		Locale: update.CallbackQuery.From.LanguageCode,
+	}
 
-	buttons := make([]KeyboardButton, len(services))
+	prompt, err := s.engine.Handle(ctx, update.CallbackQuery.From.Id, customer, update.CallbackQuery.Data)
 
-	for i, serv := range services {
-		markdownText.WriteString(fmt.Sprintf("%s %s\n\n", emoji, serv))
+	if err != nil || prompt == nil {
+		return err
+	}
 
-		buttons[i] = KeyboardButton{
-			Text:         fmt.Sprintf("%s 📅", services[i]),
-			CallbackData: fmt.Sprintf("/dates?session=%s&service=%s&page=0", sessionID, "test-short"),
-		}
+	if err := s.sendPrompt(prompt); err != nil {
+		return err
 	}
 
-	inlineKeyboard := array.Chunk(buttons, 1)
+	return s.askCustomerPhone(prompt)
+}
 
-	message := TelegramMessage{
-		ChatId:         update.Message.Chat.Id,
-		Text:           markdownText.String(),
-		ParseMode:      constants.TelegramMarkdown,
-		ProtectContent: true,
-		ReplyMarkup:    ReplyMarkup{InlineKeyboard: inlineKeyboard},
+// sendPrompt sends a step of the booking conversation headed by the business and the session, a
+// link that opened no conversation is answered as it is.
+func (s *Service) sendPrompt(prompt *conversation.Prompt) error {
+	message := TelegramMessage{ChatId: prompt.ChatID}
+
+	if prompt.Business == nil {
+		return s.bot.Send(message.Prompt(prompt, ""))
 	}
 
 	bookingMessage := BookingTelegramMessage{
-		BusinessName:     business.Name,
-		BookingSessionId: sessionID,
-		Message:          message,
+		BusinessName:     prompt.Business.Name,
+		BookingSessionId: prompt.Session.Id,
+		Message:          message.Prompt(prompt, s.startAgainUrl(prompt.Business)),
 	}
 
 	if err := s.bot.SendMsg(bookingMessage); err != nil {
-		return eris.Wrap(err, "Error sending message to telegram")
+		return eris.Wrap(err, "Error sending telegram msg")
 	}
 
 	return nil
@@ -196,707 +243,901 @@ func (s *Service) startConversation(ctx context.Context, update TelegramUpdate)
 
 /*
 ================================================================================
-TELEGRAM SHOW SERVICES COMMAND
+TELEGRAM CUSTOMER PROFILES
 ================================================================================
 */
 
-func (s *Service) showServices(ctx context.Context, update TelegramUpdate) error {
-	ack := AnswerCallbackQuery{CallbackQueryId: update.CallbackQuery.Id}
+// askCustomerPhone offers the customer of a first booking to share their phone with the business.
+// Their profile keeps the answer, the later bookings skip the question.
+func (s *Service) askCustomerPhone(prompt *conversation.Prompt) error {
+	profile := prompt.Profile
 
-	if err := s.bot.AnswerCallbackQuery(ack); err != nil {
-		return eris.Wrap(err, "Error acking telegram conversation")
+	if profile == nil || profile.Phone != "" || profile.IsReturning() {
+		return nil
 	}
 
-	var markdownText strings.Builder
+	message := TelegramMessage{ChatId: prompt.ChatID}
 
-	parsedUrl, err := url.Parse(update.CallbackQuery.Data)
-
-	if err != nil {
-		return eris.Wrap(err, "Error parsing url")
+	if err := s.bot.Send(message.AskPhone(prompt.Localizer, prompt.Business.Name)); err != nil {
+		return eris.Wrap(err, "Error asking the customer for their phone")
 	}
 
-	queryParams := parsedUrl.Query()
-
-	sessionID := queryParams.Get("sessionId")
+	return nil
+}
 
-	session, err := s.booking.GetCurrentSession(ctx, sessionID)
+// saveCustomerPhone keeps the phone the customer shared with the button of the question. Only
+// their own phone is taken, not that of a contact they forward.
+func (s *Service) saveCustomerPhone(ctx context.Context, update TelegramUpdate) error {
+	contact := update.Message.Contact
 
-	if err != nil {
-		return eris.Wrap(err, "Error fetching current booking session")
+	if update.Message.Chat.Type != ChatTypePrivate || contact.UserId != update.Message.From.Id {
+		return nil
 	}
 
-	business, err := s.business.GetBusinessByID(ctx, session.BusinessId)
+	phone := contact.PhoneNumber
 
-	if err != nil {
-		return eris.Wrap(err, "Error fetching business")
+	if !strings.HasPrefix(phone, "+") {
+		phone = "+" + phone
 	}
 
-	if err := session.EnsureIsValid(); err != nil {
-		message := TelegramMessage{ChatId: update.CallbackQuery.From.Id}
-
-		expiredSessionMessage := message.SessionExpired()
+	profile, err := s.customers.SaveChatPhone(ctx, booking.ChannelTelegram, update.Message.Chat.Id, phone)
 
-		bookingExpiredSessionMessage := BookingTelegramMessage{
-			BusinessName:     business.Name,
-			BookingSessionId: session.Id,
-			Message:          expiredSessionMessage,
-		}
-
-		if err := s.bot.SendMsg(bookingExpiredSessionMessage); err != nil {
-			return eris.Wrap(err, "Error sending message to telegram")
+	if err != nil {
+		if eris.Is(err, customer.CustomerNotFound) {
+			return nil
 		}
 
-		return nil
+		return eris.Wrap(err, "Error saving the phone of the customer")
 	}
 
-	if err := s.booking.RefreshSession(ctx, session); err != nil {
-		return eris.Wrap(err, "Error refreshing the current session")
-	}
+	owner, err := s.business.GetBusinessByID(ctx, profile.BusinessID)
 
-	services := []string{
-		"Corte de pelo y barba express 18€",
-		"Corte de pelo y barba premium 22€",
+	if err != nil {
+		return eris.Wrap(err, "Error fetching business")
 	}
 
-	emoji := "![🔸](tg://emoji?id=5368324170671202286)"
-
-	markdownText.WriteString("*Te muestro a continuación los servicios que ofrecemos:*\n\n")
+	message := TelegramMessage{ChatId: update.Message.Chat.Id}
+	l := s.localizer(update.Message.From.LanguageCode, owner.Lang)
 
-	buttons := make([]KeyboardButton, len(services))
-
-	for i, serv := range services {
-		markdownText.WriteString(fmt.Sprintf("%s %s\n\n", emoji, serv))
-
-		buttons[i] = KeyboardButton{
-			Text:         fmt.Sprintf("%s 📅", services[i]),
-			CallbackData: fmt.Sprintf("/dates?session=%s&service=%s&page=0", session.Id, "test-short"),
-		}
+	if err := s.bot.Send(message.PhoneSaved(l, owner.Name)); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
 	}
 
-	inlineKeyboard := array.Chunk(buttons, 1)
-
-	message := TelegramMessage{
-		ChatId:         update.CallbackQuery.From.Id,
-		Text:           markdownText.String(),
-		ParseMode:      constants.TelegramMarkdown,
-		ProtectContent: true,
-		ReplyMarkup:    ReplyMarkup{InlineKeyboard: inlineKeyboard},
-	}
+	return nil
+}
 
-	bookingMessage := BookingTelegramMessage{
-		BusinessName:     business.Name,
-		BookingSessionId: session.Id,
-		Message:          message,
+// isPhoneSkipped tells the answer of the button that declines the question, which comes back as
+// its text in the language of the customer.
+func (s *Service) isPhoneSkipped(update TelegramUpdate) bool {
+	if update.Message.Chat.Type != ChatTypePrivate || update.Message.Text == "" {
+		return false
 	}
 
-	if err := s.bot.SendMsg(bookingMessage); err != nil {
-		return eris.Wrap(err, "Error sending telegram msg")
-	}
+	l := s.localizer(update.Message.From.LanguageCode)
 
-	return nil
+	return update.Message.Text == l.T("button.skip_phone", nil)
 }
 
 /*
 ================================================================================
-TELEGRAM SHOW DATES COMMAND
+TELEGRAM BUSINESS CHATS
 ================================================================================
 */
 
-func (s *Service) showDates(ctx context.Context, update TelegramUpdate) error {
-	ack := AnswerCallbackQuery{CallbackQueryId: update.CallbackQuery.Id}
+func (s *Service) CreateLinkCode(ctx context.Context, businessID int, userID string) (*ChatLinkCode, error) {
+	code, err := NewChatLinkCode(businessID, userID)
 
-	if err := s.bot.AnswerCallbackQuery(ack); err != nil {
-		return eris.Wrap(err, "Error acking telegram conversation")
+	if err != nil {
+		return nil, err
 	}
 
-	var markdownText strings.Builder
+	if err := s.chats.SaveCode(ctx, code); err != nil {
+		return nil, eris.Wrap(err, "Error storing the chat link code")
+	}
 
-	parsedUrl, err := url.Parse(update.CallbackQuery.Data)
+	return code, nil
+}
+
+func (s *Service) GetChats(ctx context.Context, businessID int) ([]*BusinessChat, error) {
+	chats, err := s.chats.GetByBusinessID(ctx, businessID)
 
 	if err != nil {
-		return eris.Wrap(err, "Error parsing url")
+		return nil, eris.Wrap(err, "Error fetching the chats of the business")
 	}
 
-	queryParams := parsedUrl.Query()
+	return chats, nil
+}
 
-	sessionID := queryParams.Get("session")
-	serviceId := queryParams.Get("service")
-	page := queryParams.Get("page")
+func (s *Service) UnlinkChat(ctx context.Context, businessID int, chatID int) error {
+	if err := s.chats.Unlink(ctx, businessID, chatID); err != nil {
+		return eris.Wrap(err, "Error unlinking the chat")
+	}
 
-	currentPage, err := strconv.Atoi(page)
+	return nil
+}
 
-	if err != nil {
-		return eris.Wrap(err, "Error converting string to int")
+// linkChat handles /link CODE, sent from the private chat or the group that has to receive the
+// notifications of the business that generated the code.
+func (s *Service) linkChat(ctx context.Context, update TelegramUpdate) error {
+	message := TelegramMessage{ChatId: update.Message.Chat.Id}
+
+	l := s.localizer(update.Message.From.LanguageCode)
+
+	fields := strings.Fields(update.Message.Text)
+
+	if len(fields) < 2 {
+		return s.bot.Send(message.ChatLinkFailed(l))
 	}
 
-	session, err := s.booking.GetCurrentSession(ctx, sessionID)
+	code, err := s.chats.GetCode(ctx, strings.ToUpper(fields[1]))
 
-	if err != nil {
-		return eris.Wrap(err, "Error fetching current booking session")
+	if err != nil && !eris.Is(err, ChatLinkCodeNotFound) {
+		return eris.Wrap(err, "Error fetching the chat link code")
+	}
+
+	if err != nil || code.IsUsed() || code.IsExpired(time.Now()) {
+		return s.bot.Send(message.ChatLinkFailed(l))
 	}
 
-	business, err := s.business.GetBusinessByID(ctx, session.BusinessId)
+	business, err := s.business.GetBusinessByID(ctx, code.BusinessID)
 
 	if err != nil {
 		return eris.Wrap(err, "Error fetching business")
 	}
 
-	if err := session.EnsureIsValid(); err != nil {
-		message := TelegramMessage{ChatId: update.CallbackQuery.From.Id}
+	if err := s.chats.Link(ctx, code, NewBusinessChat(update.Message.Chat, code)); err != nil {
+		if eris.Is(err, ChatLinkCodeNotFound) {
+			return s.bot.Send(message.ChatLinkFailed(l))
+		}
 
-		expiredSessionMessage := message.SessionExpired()
+		return eris.Wrap(err, "Error linking the chat to the business")
+	}
 
-		bookingExpiredSessionMessage := BookingTelegramMessage{
-			BusinessName:     business.Name,
-			BookingSessionId: session.Id,
-			Message:          expiredSessionMessage,
-		}
+	l = s.localizer(update.Message.From.LanguageCode, business.Lang)
 
-		if err := s.bot.SendMsg(bookingExpiredSessionMessage); err != nil {
-			return eris.Wrap(err, "Error sending message to telegram")
-		}
+	if err := s.bot.Send(message.ChatLinked(l, business.Name)); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
+	}
+
+	return nil
+}
 
+/*
+================================================================================
+TELEGRAM CHANNEL ONBOARDING
+================================================================================
+*/
+
+// resolveChatMemberUpdate reacts to the bot being made admin of a channel or removed from it. The
+// channel goes to the business of the owner who promoted the bot, which is known when the owner
+// linked their private chat with /link.
+func (s *Service) resolveChatMemberUpdate(ctx context.Context, update TelegramUpdate) error {
+	member := update.MyChatMember
+
+	if member.Chat.Type != ChatTypeChannel {
 		return nil
 	}
 
-	if err := s.booking.RefreshSession(ctx, session); err != nil {
-		return eris.Wrap(err, "Error refreshing the current session")
+	switch member.NewChatMember.Status {
+	case MemberAdministrator:
+		return s.onboardChannel(ctx, member)
+	case MemberLeft, MemberKicked:
+		if err := s.business.UnlinkChannel(ctx, member.Chat.Id); err != nil {
+			return eris.Wrap(err, "Error unlinking the channel")
+		}
 	}
 
-	commandInformation := fmt.Sprintf(
-		"%s tiene disponibles para:\n\n![🔸](tg://emoji?id=5368324170671202286) %s\n\n",
-		"Hastypal Business Test",
-		"Corte de pelo y barba express 18€",
-	)
-
-	processInstructions := "*Selecciona un día para ver las horas disponibles:*\n\n"
-
-	markdownText.WriteString("![📅](tg://emoji?id=5368324170671202286) A continuación puedes ver las fechas que ")
-	markdownText.WriteString(commandInformation)
-	markdownText.WriteString(processInstructions)
+	return nil
+}
 
-	location, err := time.LoadLocation("Europe/Madrid")
+func (s *Service) onboardChannel(ctx context.Context, member BotMemberUpdated) error {
+	owner, err := s.businessOwner(ctx, member.From)
 
 	if err != nil {
-		return eris.Wrap(err, "Error loading time location")
+		return err
 	}
 
-	startDate := time.Now().In(location)
-	startDateWithHour := time.Date(
-		startDate.Year(),
-		startDate.Month(),
-		startDate.Day(),
-		07,
-		0,
-		0,
-		0,
-		location,
-	)
+	if owner == nil {
+		s.logger.Info("Bot promoted in a channel by someone who is not a business owner", "chat_id", member.Chat.Id)
 
-	startDateWithHour = startDateWithHour.AddDate(0, 0, constants.DaysPerPage*currentPage)
+		return nil
+	}
 
-	buttons := make([]KeyboardButton, 15)
+	ownerChat := TelegramMessage{ChatId: member.From.Id}
 
-	for i := 0; i < 15; i++ {
-		newDate := startDateWithHour.AddDate(0, 0, i)
+	l := s.localizer(member.From.LanguageCode)
 
-		sessions, err := s.booking.GetSessionsOnDate(ctx, newDate)
+	if !member.NewChatMember.CanPostMessages {
+		return s.bot.Send(ownerChat.ChannelMissingRights(l, member.Chat.Title))
+	}
 
-		if err != nil {
-			return eris.Wrap(err, "Error getting all sessions for a specific date")
-		}
+	link := ""
 
-		schedule := booking.NewDaySchedule(newDate)
-		schedule.ApplyActiveSessions(sessions)
+	if member.Chat.Username != "" {
+		link = fmt.Sprintf("https://t.me/%s", member.Chat.Username)
+	}
 
-		if !schedule.HasAnyAvailableSlot() {
-			continue
+	if link == "" && member.NewChatMember.CanInviteUsers {
+		exported, err := s.bot.ExportInviteLink(member.Chat.Id)
+
+		if err != nil {
+			return eris.Wrap(err, "Error exporting the channel invite link")
 		}
 
-		dateParts := strings.Split(newDate.Format(time.RFC822), " ")
+		link = exported
+	}
 
-		day := dateParts[0]
-		month := s.lang.GetSpanishMonthShortForm(newDate.Month())
+	business, err := s.business.LinkChannel(ctx, owner.BusinessID, member.Chat.Id, member.Chat.Title, link)
 
-		buttons[i] = KeyboardButton{
-			Text:         fmt.Sprintf("%s %s", day, month),
-			CallbackData: fmt.Sprintf("/hours?session=%s&date=%s", sessionID, newDate.Format(time.DateOnly)),
-		}
+	if err != nil {
+		return eris.Wrap(err, "Error linking the channel to the business")
 	}
 
-	inlineKeyboard := array.Chunk(buttons, 3)
+	channel := TelegramMessage{ChatId: member.Chat.Id}
 
-	inlineKeyboard = s.addNavigationButtons(session.Id, serviceId, currentPage, inlineKeyboard)
+	// The customers of the channel read it in the language of the business.
+	bookNow := channel.BookNow(
+		s.localizer(business.Lang),
+		business.Name,
+		s.startUrl(conversation.StartPayload{BusinessID: business.Id}),
+	)
 
-	message := TelegramMessage{
-		ChatId:         update.CallbackQuery.From.Id,
-		Text:           markdownText.String(),
-		ParseMode:      constants.TelegramMarkdown,
-		ProtectContent: true,
-		ReplyMarkup:    ReplyMarkup{InlineKeyboard: inlineKeyboard},
+	// The channel is linked already, failing here must not make Telegram deliver the update again
+	// and publish the link twice.
+	if err := s.bot.SendPinned(bookNow); err != nil {
+		s.logger.Warn(
+			"Error publishing the booking link in the channel",
+			"chat_id", member.Chat.Id,
+			"error", eris.ToString(err, true),
+		)
 	}
 
-	bookingMessage := BookingTelegramMessage{
-		BusinessName:     business.Name,
-		BookingSessionId: session.Id,
-		Message:          message,
+	if err := s.bot.Send(ownerChat.ChannelLinked(l, member.Chat.Title)); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
 	}
 
-	if err := s.bot.SendMsg(bookingMessage); err != nil {
-		return eris.Wrap(err, "Error sending telegram msg")
+	return nil
+}
+
+// StartLink builds a deep link to share, checking that what it preselects belongs to the business.
+func (s *Service) StartLink(ctx context.Context, payload conversation.StartPayload) (string, error) {
+	if err := s.engine.CheckStartLink(ctx, payload); err != nil {
+		return "", err
 	}
 
-	return nil
+	return s.startUrl(payload), nil
 }
 
-func (s *Service) addNavigationButtons(
-	sessionID string,
-	serviceID string,
-	currentPage int,
-	inlineKeyboard [][]KeyboardButton,
-) [][]KeyboardButton {
-	navigationButtons := make([]KeyboardButton, 0, 3)
+// startUrl opens a booking conversation with the bot as the payload describes.
+func (s *Service) startUrl(payload conversation.StartPayload) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", s.botName, payload.Encode())
+}
 
-	if currentPage == constants.MinAllowedDatePage {
-		moreDaysButton := KeyboardButton{
-			Text:         "Más fechas",
-			CallbackData: fmt.Sprintf("/dates?session=%s&service=%s&page=%d", sessionID, serviceID, currentPage+1),
-		}
+// startAgainUrl is where a customer goes to book again, the channel of the business when the bot
+// knows it and a new conversation otherwise.
+func (s *Service) startAgainUrl(business *business.Business) string {
+	if business.ChannelLink != "" {
+		return business.ChannelLink
+	}
 
-		backButton := KeyboardButton{
-			Text:         "Atrás",
-			CallbackData: fmt.Sprintf("/service?session=%s", sessionID),
-		}
+	return s.startUrl(conversation.StartPayload{BusinessID: business.Id})
+}
 
-		navigationButtons = append(navigationButtons, moreDaysButton, backButton)
+/*
+================================================================================
+TELEGRAM BUSINESS NOTIFICATIONS
+================================================================================
+*/
 
-		navigationKeyboard := array.Chunk(navigationButtons, 1)
+// NotifyBusiness tells every chat linked to the business of the booking what happened to it.
+// A chat that cannot be reached does not stop the rest, the notification is only retried when
+// none of them got it.
+func (s *Service) NotifyBusiness(ctx context.Context, bookingID string, event string) error {
+	found, err := s.booking.GetBooking(ctx, bookingID)
 
-		return append(inlineKeyboard, navigationKeyboard...)
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the booking")
 	}
 
-	if currentPage == constants.MaxAllowedDatePage {
-		lessDaysButton := KeyboardButton{
-			Text:         "Menos fechas",
-			CallbackData: fmt.Sprintf("/dates?session=%s&service=%s&page=%d", sessionID, serviceID, currentPage-1),
-		}
+	chats, err := s.chats.GetByBusinessID(ctx, found.BusinessID)
 
-		backButton := KeyboardButton{
-			Text:         "Atrás",
-			CallbackData: fmt.Sprintf("/service?session=%s", sessionID),
-		}
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the chats of the business")
+	}
 
-		navigationButtons = append(navigationButtons, lessDaysButton, backButton)
+	if len(chats) == 0 {
+		return nil
+	}
 
-		navigationKeyboard := array.Chunk(navigationButtons, 1)
+	business, err := s.business.GetBusinessByID(ctx, found.BusinessID)
 
-		return append(inlineKeyboard, navigationKeyboard...)
+	if err != nil {
+		return eris.Wrap(err, "Error fetching business")
 	}
 
-	lessDaysButton := KeyboardButton{
-		Text:         "Menos fechas",
-		CallbackData: fmt.Sprintf("/dates?session=%s&service=%s&page=%d", sessionID, serviceID, currentPage-1),
-	}
+	l := s.localizer(business.Lang)
 
-	moreDaysButton := KeyboardButton{
-		Text:         "Más fechas",
-		CallbackData: fmt.Sprintf("/dates?session=%s&service=%s&page=%d", sessionID, serviceID, currentPage+1),
-	}
+	notice, err := s.bookingNotice(ctx, l, found)
 
-	backButton := KeyboardButton{
-		Text:         "Atrás",
-		CallbackData: fmt.Sprintf("/service?session=%s", sessionID),
+	if err != nil {
+		return err
 	}
 
-	navigationButtons = append(navigationButtons, lessDaysButton, moreDaysButton, backButton)
+	var lastErr error
+
+	sent := 0
+
+	for _, chat := range chats {
+		message := TelegramMessage{ChatId: chat.ChatID}
+
+		if err := s.bot.Send(message.BusinessBookingNotice(l, business.Name, event, notice)); err != nil {
+			s.logger.Warn(
+				"Error notifying business chat",
+				"business_id", found.BusinessID,
+				"chat_id", chat.ChatID,
+				"error", eris.ToString(err, true),
+			)
 
-	navigationKeyboard := array.Chunk(navigationButtons, 1)
+			lastErr = err
 
-	return append(inlineKeyboard, navigationKeyboard...)
+			continue
+		}
+
+		sent++
+	}
+
+	if sent == 0 {
+		return eris.Wrap(lastErr, "Error notifying the chats of the business")
+	}
+
+	return nil
 }
 
 /*
 ================================================================================
-TELEGRAM SHOW HOURS COMMAND
+TELEGRAM CUSTOMER NOTIFICATIONS
 ================================================================================
 */
 
-func (s *Service) showHours(ctx context.Context, update TelegramUpdate) error {
-	ack := AnswerCallbackQuery{CallbackQueryId: update.CallbackQuery.Id}
+// NotificationAddress reaches the customers who booked from Telegram, the bot cannot write first
+// to anybody else.
+func (s *Service) NotificationAddress(recipient notification.Recipient) string {
+	if recipient.Channel != booking.ChannelTelegram || recipient.ChatID == 0 {
+		return ""
+	}
 
-	if err := s.bot.AnswerCallbackQuery(ack); err != nil {
-		return eris.Wrap(err, "Error acking telegram conversation")
+	return strconv.Itoa(recipient.ChatID)
+}
+
+// SendNotification tells the customer about their booking in the chat they booked from, headed
+// by the business and the session like the rest of the conversation.
+func (s *Service) SendNotification(ctx context.Context, message *notification.Message) error {
+	l := message.Localizer
+
+	chat := TelegramMessage{ChatId: message.Booking.ChatID}
+
+	date := l.RelativeDate(message.LocalDate, time.Now())
+	hour := message.Hour()
+
+	var customerMessage TelegramMessage
+
+	switch message.Notification.Type {
+	case notification.TypeApproved:
+		customerMessage = chat.BookingApproved(l, date, hour)
+	case notification.TypeRejected:
+		customerMessage = chat.BookingRejected(l, date, hour)
+	case notification.TypeRescheduled:
+		customerMessage = chat.BookingRescheduled(l, date, hour)
+	case notification.TypeCancelled:
+		customerMessage = chat.BookingCancelled(l, date, hour)
+	case notification.TypeReminder:
+		customerMessage = chat.Reminder(message.Text)
+	default:
+		customerMessage = chat.CustomerNotice(message.Text)
+	}
+
+	bookingMessage := BookingTelegramMessage{
+		BusinessName:     message.Business.Name,
+		BookingSessionId: message.Booking.SessionID,
+		Message:          customerMessage,
 	}
 
-	var markdownText strings.Builder
+	if err := s.bot.SendMsg(bookingMessage); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
+	}
+
+	return nil
+}
 
+// reviewBooking confirms or rejects a pending booking from the buttons of the notification. Only
+// the chats linked to the business of the booking can do it.
+func (s *Service) reviewBooking(ctx context.Context, update TelegramUpdate, approve bool) error {
 	parsedUrl, err := url.Parse(update.CallbackQuery.Data)
 
 	if err != nil {
 		return eris.Wrap(err, "Error parsing URL")
 	}
 
-	queryParams := parsedUrl.Query()
+	reviewer := s.localizer(update.CallbackQuery.From.LanguageCode)
 
-	stringSelectedDate := fmt.Sprintf("%s %s", queryParams.Get("date"), "07:00:00")
-	sessionID := queryParams.Get("session")
+	answer := func(key string) error {
+		ack := AnswerCallbackQuery{CallbackQueryId: update.CallbackQuery.Id}
 
-	selectedDate, err := time.Parse(time.DateTime, stringSelectedDate)
+		if key != "" {
+			ack.Text = reviewer.T(key, nil)
+		}
 
-	if err != nil {
-		return eris.Wrap(err, "Error parsing time")
+		if err := s.bot.AnswerCallbackQuery(ack); err != nil {
+			return eris.Wrap(err, "Error acking telegram conversation")
+		}
+
+		return nil
 	}
 
-	session, err := s.booking.GetCurrentSession(ctx, sessionID)
+	found, err := s.booking.GetBooking(ctx, parsedUrl.Query().Get("booking"))
 
 	if err != nil {
-		return eris.Wrap(err, "Error fetching current booking session")
+		if eris.Is(err, booking.BookingNotFound) {
+			return answer("review.not_found")
+		}
+
+		return eris.Wrap(err, "Error fetching the booking")
 	}
 
-	business, err := s.business.GetBusinessByID(ctx, session.BusinessId)
+	chatID := update.CallbackQuery.Message.Chat.Id
 
-	if err != nil {
-		return eris.Wrap(err, "Error fetching business")
-	}
+	chat, err := s.chats.GetByID(ctx, chatID)
 
-	if err := session.EnsureIsValid(); err != nil {
-		message := TelegramMessage{ChatId: update.CallbackQuery.From.Id}
+	if err != nil && !eris.Is(err, ChatNotLinked) {
+		return eris.Wrap(err, "Error fetching the business chat")
+	}
 
-		expiredSessionMessage := message.SessionExpired()
+	if err != nil || chat.BusinessID != found.BusinessID {
+		return answer("review.chat_not_linked")
+	}
 
-		bookingExpiredSessionMessage := BookingTelegramMessage{
-			BusinessName:     business.Name,
-			BookingSessionId: session.Id,
-			Message:          expiredSessionMessage,
-		}
+	// Anyone in a linked group can press the buttons, only the team of the business can review.
+	member, err := s.linkedUser(ctx, update.CallbackQuery.From)
 
-		if err := s.bot.SendMsg(bookingExpiredSessionMessage); err != nil {
-			return eris.Wrap(err, "Error sending message to telegram")
-		}
+	if err != nil {
+		return err
+	}
 
-		return nil
+	if member == nil || member.BusinessID != found.BusinessID {
+		return answer("review.not_member")
 	}
 
-	if err := s.booking.RefreshSession(ctx, session); err != nil {
-		return eris.Wrap(err, "Error refreshing the current session")
+	review := s.booking.RejectBooking
+
+	if approve {
+		review = s.booking.ConfirmBooking
 	}
 
-	dateParts := strings.Split(selectedDate.Format(time.RFC822), " ")
+	if err := review(ctx, found); err != nil {
+		if eris.Is(err, booking.BookingNotPending) {
+			return answer("review.not_pending")
+		}
 
-	day := dateParts[0]
-	month := s.lang.GetSpanishMonthShortForm(selectedDate.Month())
+		return eris.Wrap(err, "Error reviewing the booking")
+	}
 
-	welcome := "![⌚️](tg://emoji?id=5368324170671202286) Las horas disponibles para:\n\n"
+	if err := answer(""); err != nil {
+		return err
+	}
 
-	selectedService := fmt.Sprintf(
-		"![🔸](tg://emoji?id=5368324170671202286) %s\n\n",
-		"Corte de pelo y barba express 18€",
-	)
+	business, err := s.business.GetBusinessByID(ctx, found.BusinessID)
 
-	date := fmt.Sprintf(
-		"![📅](tg://emoji?id=5368324170671202286) %s\n\n",
-		fmt.Sprintf("%s %s", day, month),
-	)
+	if err != nil {
+		return eris.Wrap(err, "Error fetching business")
+	}
 
-	processInstructions := "*Selecciona una hora y te escribiré un resumen para que puedas confirmar la reserva*\n\n"
+	// The customer hears of the review from the notification of the booking. The linked chat is
+	// told in the language of the business, the same as the notice it answers.
+	l := s.localizer(business.Lang)
 
-	markdownText.WriteString(welcome)
-	markdownText.WriteString(selectedService)
-	markdownText.WriteString(date)
-	markdownText.WriteString(processInstructions)
+	notice, err := s.bookingNotice(ctx, l, found)
 
-	buttons := make([]KeyboardButton, 12)
+	if err != nil {
+		return err
+	}
 
-	for i := 8; i <= len(buttons)+7; i++ {
-		hour := fmt.Sprintf("%02d:00", i)
+	message := TelegramMessage{ChatId: chatID}
 
-		session, err := s.booking.GetSessionOnHour(ctx, selectedDate)
+	if err := s.bot.Send(message.BookingReviewed(l, approve, update.CallbackQuery.From.FirstName, notice)); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
+	}
 
-		if err != nil {
-			return eris.Wrap(err, "Error getting all sessions for a specific date")
-		}
+	return nil
+}
 
-		if session != nil {
-			continue
-		}
+func (s *Service) bookingNotice(ctx context.Context, l translation.Localizer, found *booking.Booking) (BookingNotice, error) {
+	location, err := time.LoadLocation("Europe/Madrid")
 
-		buttons[i-8] = KeyboardButton{
-			Text: fmt.Sprintf("%s", hour),
-			CallbackData: fmt.Sprintf(
-				"/confirmation?session=%s&hour=%s",
-				sessionID,
-				hour,
-			),
-		}
+	if err != nil {
+		return BookingNotice{}, eris.Wrap(err, "Error loading time location")
 	}
 
-	backButton := KeyboardButton{
-		Text:         "Atrás",
-		CallbackData: fmt.Sprintf("/dates?session=%s&service=%s", session.Id, "test-short"),
+	services, err := s.business.GetServices(ctx, found.BusinessID)
+
+	if err != nil {
+		return BookingNotice{}, eris.Wrap(err, "Error fetching the services of the business")
 	}
 
-	buttons = append(buttons, backButton)
+	return s.noticeFor(l, found, services, location), nil
+}
 
-	inlineKeyboard := array.Chunk(buttons, 3)
+func (s *Service) noticeFor(l translation.Localizer, found *booking.Booking, services []*business.ServiceCatalog, location *time.Location) BookingNotice {
+	serviceName := strings.Join(found.ServiceNames(), " + ")
 
-	message := TelegramMessage{
-		ChatId:         update.CallbackQuery.From.Id,
-		Text:           markdownText.String(),
-		ParseMode:      constants.TelegramMarkdown,
-		ProtectContent: true,
-		ReplyMarkup:    ReplyMarkup{InlineKeyboard: inlineKeyboard},
+	for _, service := range services {
+		if strconv.Itoa(service.Id) == found.ServiceID && serviceName == "" {
+			serviceName = service.Name
+		}
 	}
 
-	bookingMessage := BookingTelegramMessage{
-		BusinessName:     business.Name,
-		BookingSessionId: session.Id,
-		Message:          message,
-	}
+	localDate := found.Date.In(location)
 
-	if err := s.bot.SendMsg(bookingMessage); err != nil {
-		return eris.Wrap(err, "Error sending telegram msg")
+	return BookingNotice{
+		BookingID: found.ID,
+		Customer:  found.CustomerName,
+		Service:   serviceName,
+		Date:      l.Date(localDate),
+		Hour:      l.Time(localDate),
+		Pending:   found.IsPending(),
 	}
+}
 
-	return nil
+// localizer speaks the first supported language of the candidates, from the most specific one.
+func (s *Service) localizer(candidates ...string) translation.Localizer {
+	return translation.NewLocalizer(s.lang, candidates...)
 }
 
 /*
 ================================================================================
-TELEGRAM SHOW CONFIRMATION COMMAND
+TELEGRAM OWNER COMMANDS
 ================================================================================
 */
 
-func (s *Service) showConfirmation(ctx context.Context, update TelegramUpdate) error {
-	ack := AnswerCallbackQuery{CallbackQueryId: update.CallbackQuery.Id}
+// resolveOwnerCommand runs the admin commands, which only the owner of a business can use from
+// the private chat they linked with /link.
+func (s *Service) resolveOwnerCommand(ctx context.Context, update TelegramUpdate, command string) error {
+	message := TelegramMessage{ChatId: update.Message.Chat.Id}
 
-	if err := s.bot.AnswerCallbackQuery(ack); err != nil {
-		return eris.Wrap(err, "Error acking telegram conversation")
+	// In a group the agenda and the stats would reach everyone in it, the commands are only
+	// answered in the private chat of the owner.
+	if update.Message.Chat.Id != update.Message.From.Id {
+		return s.bot.Send(message.OwnerOnly(s.localizer(update.Message.From.LanguageCode)))
 	}
 
-	var markdownText strings.Builder
-
-	parsedUrl, err := url.Parse(update.CallbackQuery.Data)
+	owner, err := s.businessOwner(ctx, update.Message.From)
 
 	if err != nil {
-		return eris.Wrap(err, "Error parsing URL")
+		return err
 	}
 
-	queryParams := parsedUrl.Query()
-	sessionID := queryParams.Get("session")
-	hour := queryParams.Get("hour")
+	if owner == nil {
+		return s.bot.Send(message.OwnerOnly(s.localizer(update.Message.From.LanguageCode)))
+	}
 
-	session, err := s.booking.GetCurrentSession(ctx, sessionID)
+	business, err := s.business.GetBusinessByID(ctx, owner.BusinessID)
 
 	if err != nil {
-		return eris.Wrap(err, "Error fetching current booking session")
+		return eris.Wrap(err, "Error fetching business")
 	}
 
-	business, err := s.business.GetBusinessByID(ctx, session.BusinessId)
+	l := s.localizer(update.Message.From.LanguageCode, business.Lang)
+
+	location, err := time.LoadLocation("Europe/Madrid")
 
 	if err != nil {
-		return eris.Wrap(err, "Error fetching business")
+		return eris.Wrap(err, "Error loading time location")
+	}
+
+	args := strings.Fields(update.Message.Text)[1:]
+	now := time.Now().In(location)
+
+	switch command {
+	case constants.AgendaCommand:
+		return s.showOwnerAgenda(ctx, l, message, owner, args, now)
+	case constants.BlockCommand:
+		return s.blockTime(ctx, l, message, owner, args, now)
+	case constants.HolidayCommand:
+		return s.addHoliday(ctx, l, message, owner, args, now)
+	case constants.StatsCommand:
+		return s.showStats(ctx, l, message, owner, now)
 	}
 
-	if err := session.EnsureIsValid(); err != nil {
-		message := TelegramMessage{ChatId: update.CallbackQuery.From.Id}
+	return nil
+}
 
-		expiredSessionMessage := message.SessionExpired()
+// businessOwner finds the owner behind the private chat of whoever is writing.
+func (s *Service) businessOwner(ctx context.Context, from TelegramUser) (*auth.User, error) {
+	user, err := s.linkedUser(ctx, from)
 
-		bookingExpiredSessionMessage := BookingTelegramMessage{
-			BusinessName:     business.Name,
-			BookingSessionId: session.Id,
-			Message:          expiredSessionMessage,
-		}
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	if user.Role != auth.RoleOwner {
+		return nil, nil
+	}
+
+	return user, nil
+}
+
+// linkedUser finds the business user behind the private chat of whoever is writing. Telegram
+// gives a private chat the id of the person, so the chat they linked tells which user they are.
+func (s *Service) linkedUser(ctx context.Context, from TelegramUser) (*auth.User, error) {
+	chat, err := s.chats.GetByID(ctx, from.Id)
 
-		if err := s.bot.SendMsg(bookingExpiredSessionMessage); err != nil {
-			return eris.Wrap(err, "Error sending message to telegram")
+	if err != nil {
+		if eris.Is(err, ChatNotLinked) {
+			return nil, nil
 		}
 
-		return nil
+		return nil, eris.Wrap(err, "Error fetching the business chat")
 	}
 
-	if err := s.booking.RefreshSession(ctx, session); err != nil {
-		return eris.Wrap(err, "Error refreshing the current session")
+	if chat.LinkedBy == "" {
+		return nil, nil
 	}
 
-	selectedDate, err := time.Parse(time.DateTime, session.Date)
+	user, err := s.users.GetUser(ctx, chat.BusinessID, chat.LinkedBy)
 
 	if err != nil {
-		return eris.Wrap(err, "Error parsing selected date")
+		if eris.Is(err, auth.UserNotFound) {
+			return nil, nil
+		}
+
+		return nil, eris.Wrap(err, "Error fetching the user of the chat")
 	}
 
-	dateParts := strings.Split(selectedDate.Format(time.RFC822), " ")
+	return user, nil
+}
 
-	day := dateParts[0]
-	month := s.lang.GetSpanishMonthShortForm(selectedDate.Month())
+// showOwnerAgenda handles /agenda [date], the bookings and blocks of a day, today by default.
+func (s *Service) showOwnerAgenda(
+	ctx context.Context,
+	l translation.Localizer,
+	message TelegramMessage,
+	owner *auth.User,
+	args []string,
+	now time.Time,
+) error {
+	day := now
 
-	welcome := "![🙂](tg://emoji?id=5368324170671202286) Último paso te lo prometo\\! Confirma que todo esta correcto:\n\n"
+	if len(args) > 0 {
+		parsed, err := parseCommandDate(args[0], now)
 
-	bookedService := fmt.Sprintf(
-		"![🟢](tg://emoji?id=5368324170671202286) %s\n\n",
-		"Corte de pelo y barba express 18€",
-	)
+		if err != nil {
+			return s.bot.Send(message.OwnerCommandUsage(l, constants.AgendaCommand, "owner.agenda_usage"))
+		}
 
-	date := fmt.Sprintf("![📅](tg://emoji?id=5368324170671202286) %s %s\n\n", day, month)
+		day = parsed
+	}
 
-	hourMarkdown := fmt.Sprintf(
-		"![⌚️](tg://emoji?id=5368324170671202286) %sH\n\n",
-		hour,
-	)
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 0, 1)
 
-	processInstructions := "*Pulsa confirmar si todo es correcto o cancelar de lo contrario*\n\n"
+	bookings, err := s.booking.GetAgenda(ctx, owner.BusinessID, 0, from, to)
 
-	markdownText.WriteString(welcome)
-	markdownText.WriteString(bookedService)
-	markdownText.WriteString(date)
-	markdownText.WriteString(hourMarkdown)
-	markdownText.WriteString(processInstructions)
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the agenda")
+	}
 
-	buttons := make([]KeyboardButton, 2)
+	services, err := s.business.GetServices(ctx, owner.BusinessID)
 
-	confirmButton := KeyboardButton{
-		Text:         "Confirmar",
-		CallbackData: fmt.Sprintf("/book?session=%s", sessionID),
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the services of the business")
 	}
 
-	buttons = append(buttons, confirmButton)
+	blocks, err := s.business.GetTimeBlocks(ctx, owner.BusinessID, from, to)
 
-	backButton := KeyboardButton{
-		Text:         "Atrás",
-		CallbackData: fmt.Sprintf("/hours?session=%s&date=%s", sessionID, selectedDate.Format(time.DateOnly)),
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the time blocks")
 	}
 
-	buttons = append(buttons, backButton)
+	notices := make([]BookingNotice, 0, len(bookings))
 
-	inlineKeyboard := array.Chunk(buttons, 1)
+	for _, found := range bookings {
+		if found.IsCancelled() {
+			continue
+		}
 
-	message := TelegramMessage{
-		ChatId:         update.CallbackQuery.From.Id,
-		Text:           markdownText.String(),
-		ParseMode:      constants.TelegramMarkdown,
-		ProtectContent: true,
-		ReplyMarkup:    ReplyMarkup{InlineKeyboard: inlineKeyboard},
+		notices = append(notices, s.noticeFor(l, found, services, now.Location()))
 	}
 
-	bookingMessage := BookingTelegramMessage{
-		BusinessName:     business.Name,
-		BookingSessionId: session.Id,
-		Message:          message,
+	ranges := make([]string, len(blocks))
+
+	for i, block := range blocks {
+		ranges[i] = fmt.Sprintf(
+			"%s-%s %s",
+			l.Time(block.Start.In(now.Location())),
+			l.Time(block.End.In(now.Location())),
+			block.Reason,
+		)
 	}
 
-	if err := s.bot.SendMsg(bookingMessage); err != nil {
-		return eris.Wrap(err, "Error sending telegram msg")
+	if err := s.bot.Send(message.OwnerAgenda(l, l.Date(from), notices, ranges)); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
 	}
 
 	return nil
 }
 
-/*
-================================================================================
-TELEGRAM SHOW BOOKING PREVIEW COMMAND
-================================================================================
-*/
-
-func (s *Service) showBookingPreview(ctx context.Context, update TelegramUpdate) error {
-	ack := AnswerCallbackQuery{CallbackQueryId: update.CallbackQuery.Id}
+// blockTime handles /block <date> <from>-<to> [reason]. The bookings already in the range are
+// kept, the owner is told how many there are so they can move them.
+func (s *Service) blockTime(
+	ctx context.Context,
+	l translation.Localizer,
+	message TelegramMessage,
+	owner *auth.User,
+	args []string,
+	now time.Time,
+) error {
+	usage := message.OwnerCommandUsage(l, constants.BlockCommand, "owner.block_usage")
 
-	if err := s.bot.AnswerCallbackQuery(ack); err != nil {
-		return eris.Wrap(err, "Error acking telegram conversation")
+	if len(args) < 2 {
+		return s.bot.Send(usage)
 	}
 
-	var markdownText strings.Builder
-
-	parsedUrl, err := url.Parse(update.CallbackQuery.Data)
+	day, err := parseCommandDate(args[0], now)
 
 	if err != nil {
-		return eris.Wrap(err, "Error parsing URL")
+		return s.bot.Send(usage)
 	}
 
-	queryParams := parsedUrl.Query()
-	sessionID := queryParams.Get("session")
+	rawFrom, rawTo, ok := strings.Cut(args[1], "-")
 
-	session, err := s.booking.GetCurrentSession(ctx, sessionID)
+	if !ok {
+		return s.bot.Send(usage)
+	}
 
-	if err != nil {
-		return eris.Wrap(err, "Error fetching current booking session")
+	start, startErr := time.ParseInLocation(time.DateOnly+" "+business.HourLayout, day.Format(time.DateOnly)+" "+rawFrom, now.Location())
+	end, endErr := time.ParseInLocation(time.DateOnly+" "+business.HourLayout, day.Format(time.DateOnly)+" "+rawTo, now.Location())
+
+	if startErr != nil || endErr != nil {
+		return s.bot.Send(usage)
 	}
 
-	business, err := s.business.GetBusinessByID(ctx, session.BusinessId)
+	block, err := business.NewTimeBlock(owner.BusinessID, start, end, strings.Join(args[2:], " "))
 
 	if err != nil {
-		return eris.Wrap(err, "Error fetching business")
+		return s.bot.Send(usage)
 	}
 
-	if err := session.EnsureIsValid(); err != nil {
-		message := TelegramMessage{ChatId: update.CallbackQuery.From.Id}
+	if err := s.business.AddTimeBlock(ctx, block); err != nil {
+		return eris.Wrap(err, "Error blocking time")
+	}
 
-		expiredSessionMessage := message.SessionExpired()
+	bookings, err := s.booking.GetAgenda(ctx, owner.BusinessID, 0, start, end)
 
-		bookingExpiredSessionMessage := BookingTelegramMessage{
-			BusinessName:     business.Name,
-			BookingSessionId: session.Id,
-			Message:          expiredSessionMessage,
-		}
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the agenda")
+	}
 
-		if err := s.bot.SendMsg(bookingExpiredSessionMessage); err != nil {
-			return eris.Wrap(err, "Error sending message to telegram")
+	affected := 0
+
+	for _, found := range bookings {
+		if !found.IsCancelled() {
+			affected++
 		}
+	}
 
-		return nil
+	reply := message.OwnerTimeBlocked(l, l.Date(day), l.Time(start), l.Time(end), affected)
+
+	if err := s.bot.Send(reply); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
 	}
 
-	combinedStr := fmt.Sprintf("%s %s", session.Date, session.Hour)
+	return nil
+}
 
-	loc, err := time.LoadLocation("Europe/Madrid")
+// addHoliday handles /holiday <date> [name], which closes the business the whole day.
+func (s *Service) addHoliday(
+	ctx context.Context,
+	l translation.Localizer,
+	message TelegramMessage,
+	owner *auth.User,
+	args []string,
+	now time.Time,
+) error {
+	usage := message.OwnerCommandUsage(l, constants.HolidayCommand, "owner.holiday_usage")
 
-	if err != nil {
-		return eris.Wrap(err, "Error loading location")
+	if len(args) < 1 {
+		return s.bot.Send(usage)
 	}
 
-	mergedTime, err := time.ParseInLocation(time.DateTime, combinedStr, loc)
+	day, err := parseCommandDate(args[0], now)
 
 	if err != nil {
-		eris.Wrap(err, "Error merging date and hour")
+		return s.bot.Send(usage)
 	}
 
-	bookingID, err := s.booking.RegisterBooking(ctx, sessionID, session.BusinessId, session.ServiceId, mergedTime)
-
-	if err != nil {
-		return eris.Wrap(err, "Error creating and saving the booking")
+	holiday := &business.Holiday{
+		BusinessId: owner.BusinessID,
+		Date:       day.Format(time.DateOnly),
+		Name:       strings.Join(args[1:], " "),
 	}
 
-	if err := s.reminder.NewReminder(ctx, bookingID, mergedTime); err != nil {
-		return eris.Wrap(err, "Error storing a new reminder")
+	if err := s.business.AddHoliday(ctx, holiday); err != nil {
+		return eris.Wrap(err, "Error adding the holiday")
 	}
 
-	if err := s.google.CalendarEvent(ctx, session.BusinessId, mergedTime); err != nil {
-		return eris.Wrap(err, "Error creating the event in the google calendar")
+	if err := s.bot.Send(message.OwnerHolidayAdded(l, l.Date(day), holiday.Name)); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
 	}
 
-	markdownText.WriteString("![🎉](tg://emoji?id=5368324170671202286) *¡Reserva confirmada\\!*\n\n")
-	markdownText.WriteString("![📅](tg://emoji?id=5368324170671202286) ")
-	markdownText.WriteString("Te avisaré un día antes para recordarte la cita\n\n")
-	markdownText.WriteString("![💙](tg://emoji?id=5368324170671202286) Muchas gracias por la confianza depositada")
+	return nil
+}
+
+// showStats handles /stats, the bookings of the current month by status.
+func (s *Service) showStats(ctx context.Context, l translation.Localizer, message TelegramMessage, owner *auth.User, now time.Time) error {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
 
-	buttons := make([][]KeyboardButton, 0)
+	bookings, err := s.booking.GetAgenda(ctx, owner.BusinessID, 0, from, to)
 
-	message := TelegramMessage{
-		ChatId:         update.CallbackQuery.From.Id,
-		Text:           markdownText.String(),
-		ParseMode:      constants.TelegramMarkdown,
-		ProtectContent: true,
-		ReplyMarkup:    ReplyMarkup{InlineKeyboard: buttons},
+	if err != nil {
+		return eris.Wrap(err, "Error fetching the agenda")
 	}
 
-	bookingMessage := BookingTelegramMessage{
-		BusinessName:     business.Name,
-		BookingSessionId: session.Id,
-		Message:          message,
+	stats := BookingStats{Period: l.Month(now.Month()), Sources: make(map[string]int)}
+
+	for _, found := range bookings {
+		stats.Total++
+
+		if found.Source != "" && !found.IsCancelled() {
+			stats.Sources[found.Source]++
+		}
+
+		switch found.Status {
+		case booking.StatusConfirmed:
+			stats.Confirmed++
+
+			if found.Date.After(now) {
+				stats.Upcoming++
+			}
+		case booking.StatusPending:
+			stats.Pending++
+		case booking.StatusCancelled:
+			stats.Cancelled++
+		case booking.StatusNoShow:
+			stats.NoShow++
+		}
 	}
 
-	if err := s.bot.SendMsg(bookingMessage); err != nil {
-		return eris.Wrap(err, "Error sending telegram msg")
+	if err := s.bot.Send(message.OwnerStats(l, stats)); err != nil {
+		return eris.Wrap(err, "Error sending message to telegram")
 	}
 
 	return nil
 }
+
+var commandDateLayouts = []string{time.DateOnly, "2/1/2006", "2/1"}
+
+// parseCommandDate reads the dates typed in the owner commands. A date without year is the next
+// time that day comes, so /holiday 6/1 typed in December means next January.
+func parseCommandDate(raw string, now time.Time) (time.Time, error) {
+	for _, layout := range commandDateLayouts {
+		parsed, err := time.ParseInLocation(layout, raw, now.Location())
+
+		if err != nil {
+			continue
+		}
+
+		if layout != "2/1" {
+			return parsed, nil
+		}
+
+		date := time.Date(now.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, now.Location())
+
+		if date.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())) {
+			date = date.AddDate(1, 0, 0)
+		}
+
+		return date, nil
+	}
+
+	return time.Time{}, eris.Errorf("Invalid date %s", raw)
+}
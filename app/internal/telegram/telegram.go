@@ -1,7 +1,45 @@
 package telegram
 
+import (
+	"strings"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/pkg/constants"
+)
+
+// ConversationChannel fits the booking conversation to the inline keyboards, which take the whole
+// day of free times at once.
+var ConversationChannel = conversation.Channel{
+	Name:        booking.ChannelTelegram,
+	MaxServices: constants.MaxServicesPerMenu,
+	DaysPerPage: constants.DaysPerPage,
+}
+
 //Domain objects
 
+// BookingNotice is what the chats linked to a business are told about one of its bookings.
+type BookingNotice struct {
+	BookingID string
+	Customer  string
+	Service   string
+	Date      string
+	Hour      string
+	Pending   bool
+}
+
+// BookingStats counts the bookings of a period by status for the /stats command.
+type BookingStats struct {
+	Period    string
+	Total     int
+	Confirmed int
+	Upcoming  int
+	Pending   int
+	Cancelled int
+	NoShow    int
+	Sources   map[string]int
+}
+
 type BookingTelegramMessage struct {
 	BusinessName     string          `json:"businessName"`
 	BookingSessionId string          `json:"bookingSessionId"`
@@ -17,6 +55,17 @@ type TelegramWebhook struct {
 	Url string `json:"url"`
 }
 
+// Telegram chat types and member statuses
+
+const (
+	ChatTypePrivate = "private"
+	ChatTypeChannel = "channel"
+
+	MemberAdministrator = "administrator"
+	MemberLeft          = "left"
+	MemberKicked        = "kicked"
+)
+
 type AdminTelegramBotSetup struct {
 	Commands []TelegramBotCommand `json:"commands"`
 	Webhook  TelegramWebhook      `json:"webhook"`
@@ -38,44 +87,65 @@ type TelegramUser struct {
 	LanguageCode string `json:"language_code"`
 }
 
+// Name is the full name of the user, Telegram only requires the first one.
+func (u TelegramUser) Name() string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// TelegramContact is a phone shared from the chat, UserId being the one of the user the phone
+// belongs to when it is a Telegram user.
+type TelegramContact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	UserId      int    `json:"user_id"`
+}
+
 type TelegramChat struct {
 	Id        int    `json:"id"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Type      string `json:"type"`
 }
 
 type TelegramChatMemberAdministrator struct {
-	Status            string `json:"status"`
-	CanManageChat     bool   `json:"can_manage_chat"`
-	CanChangeInfo     bool   `json:"can_change_info"`
-	CanPostMessages   bool   `json:"can_post_messages"`
-	CanEditMessages   bool   `json:"can_edit_messages"`
-	CanDeleteMessages bool   `json:"can_delete_messages"`
-	CanInviteUsers    bool   `json:"can_invite_users"`
-	CanPostStories    bool   `json:"can_post_stories"`
-	CanEditStories    bool   `json:"can_edit_stories"`
+	Status            string       `json:"status"`
+	User              TelegramUser `json:"user"`
+	CanManageChat     bool         `json:"can_manage_chat"`
+	CanChangeInfo     bool         `json:"can_change_info"`
+	CanPostMessages   bool         `json:"can_post_messages"`
+	CanEditMessages   bool         `json:"can_edit_messages"`
+	CanDeleteMessages bool         `json:"can_delete_messages"`
+	CanInviteUsers    bool         `json:"can_invite_users"`
+	CanPostStories    bool         `json:"can_post_stories"`
+	CanEditStories    bool         `json:"can_edit_stories"`
+	CanPinMessages    bool         `json:"can_pin_messages"`
 }
 
 type TelegramMessageUpdate struct {
-	MessageId int          `json:"message_id"`
-	From      TelegramUser `json:"from"`
-	Chat      TelegramChat `json:"chat"`
-	Date      int          `json:"date"`
-	Text      string       `json:"text"`
+	MessageId int              `json:"message_id"`
+	From      TelegramUser     `json:"from"`
+	Chat      TelegramChat     `json:"chat"`
+	Date      int              `json:"date"`
+	Text      string           `json:"text"`
+	Contact   *TelegramContact `json:"contact,omitempty"`
 }
 
 type BotMemberUpdated struct {
 	Chat          TelegramChat                    `json:"chat"`
 	From          TelegramUser                    `json:"from"`
 	Date          int                             `json:"date"`
+	OldChatMember TelegramChatMemberAdministrator `json:"old_chat_member"`
 	NewChatMember TelegramChatMemberAdministrator `json:"new_chat_member"`
 }
 
 type CallbackQuery struct {
-	Id   string       `json:"id"`
-	From TelegramUser `json:"from"`
-	Data string       `json:"data"`
+	Id      string                `json:"id"`
+	From    TelegramUser          `json:"from"`
+	Message TelegramMessageUpdate `json:"message"`
+	Data    string                `json:"data"`
 }
 
 type TelegramUpdate struct {
@@ -87,19 +157,35 @@ type TelegramUpdate struct {
 
 type KeyboardButton struct {
 	Text                         string `json:"text"`
-	Url                          string `json:"url"`
-	CallbackData                 string `json:"callback_data"`
-	SwitchInlineQueryCurrentChat string `json:"switch_inline_query_current_chat"`
+	Url                          string `json:"url,omitempty"`
+	CallbackData                 string `json:"callback_data,omitempty"`
+	SwitchInlineQueryCurrentChat string `json:"switch_inline_query_current_chat,omitempty"`
+	RequestContact               bool   `json:"request_contact,omitempty"`
 }
 
 type ReplyMarkup struct {
 	InlineKeyboard [][]KeyboardButton `json:"inline_keyboard"`
 }
 
+// ReplyKeyboard takes the place of the keyboard of the customer, the only kind of keyboard with
+// buttons that share their phone.
+type ReplyKeyboard struct {
+	Keyboard        [][]KeyboardButton `json:"keyboard"`
+	OneTimeKeyboard bool               `json:"one_time_keyboard"`
+	ResizeKeyboard  bool               `json:"resize_keyboard"`
+}
+
+// RemoveKeyboard gives the customer their keyboard back.
+type RemoveKeyboard struct {
+	RemoveKeyboard bool `json:"remove_keyboard"`
+}
+
+// TelegramMessage carries its buttons in ReplyMarkup: a ReplyMarkup with the inline ones, a
+// ReplyKeyboard or a RemoveKeyboard.
 type TelegramMessage struct {
-	ChatId         int         `json:"chat_id"`
-	Text           string      `json:"text"`
-	ParseMode      string      `json:"parse_mode"`
-	ProtectContent bool        `json:"protect_content"`
-	ReplyMarkup    ReplyMarkup `json:"reply_markup"`
+	ChatId         int    `json:"chat_id"`
+	Text           string `json:"text"`
+	ParseMode      string `json:"parse_mode"`
+	ProtectContent bool   `json:"protect_content"`
+	ReplyMarkup    any    `json:"reply_markup"`
 }
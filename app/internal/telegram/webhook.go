@@ -0,0 +1,27 @@
+package telegram
+
+import (
+	"crypto/subtle"
+
+	"github.com/rotisserie/eris"
+)
+
+var InvalidSecretToken = eris.New("Invalid webhook secret token")
+
+// SecretTokenHeader carries the secret_token the webhook was registered with on every update
+// Telegram posts.
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// VerifySecretToken checks the secret token of an update, so only Telegram can post to the
+// webhook.
+func VerifySecretToken(header string, secret string) error {
+	if secret == "" {
+		return InvalidSecretToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(header), []byte(secret)) != 1 {
+		return InvalidSecretToken
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package telegram
+
+import "testing"
+
+func TestVerifySecretToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		secret string
+		valid  bool
+	}{
+		{"matching token", "s3cret", "s3cret", true},
+		{"wrong token", "guess", "s3cret", false},
+		{"missing header", "", "s3cret", false},
+		{"no secret configured", "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySecretToken(test.header, test.secret)
+
+			if test.valid && err != nil {
+				t.Errorf("got %v, want the update accepted", err)
+			}
+
+			if !test.valid && err != InvalidSecretToken {
+				t.Errorf("got %v, want InvalidSecretToken", err)
+			}
+		})
+	}
+}
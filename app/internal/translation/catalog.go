@@ -0,0 +1,214 @@
+package translation
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// DefaultLocale is the language of the texts missing in a locale and of the chats whose language
+// is not supported.
+const DefaultLocale = "es"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Params are the values interpolated in a message, written as {name} in the catalog.
+type Params map[string]any
+
+// message is an entry of the catalog, either a plain text or the forms of a plural.
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &m.plural); err != nil {
+		return eris.Wrap(err, "A message must be a text or an object with its plural forms")
+	}
+
+	if _, ok := m.plural[pluralOther]; !ok {
+		return eris.Errorf("Plural message without the %s form", pluralOther)
+	}
+
+	return nil
+}
+
+// Catalog holds the messages of every locale by key.
+type Catalog struct {
+	messages map[string]map[string]message
+}
+
+// LoadCatalog reads the catalogs embedded in locales, one JSON file per locale named after it.
+func LoadCatalog() (*Catalog, error) {
+	files, err := locales.ReadDir("locales")
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error reading the locales")
+	}
+
+	catalog := &Catalog{messages: make(map[string]map[string]message, len(files))}
+
+	for _, file := range files {
+		content, err := locales.ReadFile(path.Join("locales", file.Name()))
+
+		if err != nil {
+			return nil, eris.Wrapf(err, "Error reading the locale %s", file.Name())
+		}
+
+		var messages map[string]message
+
+		if err := json.Unmarshal(content, &messages); err != nil {
+			return nil, eris.Wrapf(err, "Error parsing the locale %s", file.Name())
+		}
+
+		catalog.messages[strings.TrimSuffix(file.Name(), path.Ext(file.Name()))] = messages
+	}
+
+	if _, ok := catalog.messages[DefaultLocale]; !ok {
+		return nil, eris.Errorf("Missing the catalog of the default locale %s", DefaultLocale)
+	}
+
+	return catalog, nil
+}
+
+func (c *Catalog) Locales() []string {
+	locales := make([]string, 0, len(c.messages))
+
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+
+	return locales
+}
+
+func (c *Catalog) Keys(locale string) []string {
+	keys := make([]string, 0, len(c.messages[locale]))
+
+	for key := range c.messages[locale] {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Translate returns the message of the key in the locale with the params interpolated. Keys
+// missing in the locale fall back to the default locale, and to the key itself when no catalog
+// has them so a missing text shows up instead of breaking the message.
+func (c *Catalog) Translate(locale string, key string, params Params) string {
+	entry, ok := c.lookup(locale, key)
+
+	if !ok {
+		return key
+	}
+
+	text := entry.text
+
+	if entry.plural != nil {
+		text = entry.plural[pluralOther]
+	}
+
+	return interpolate(text, params)
+}
+
+// Plural returns the form of the message that goes with count, which is also available to the
+// message as {count}.
+func (c *Catalog) Plural(locale string, key string, count int, params Params) string {
+	entry, ok := c.lookup(locale, key)
+
+	if !ok {
+		return key
+	}
+
+	withCount := Params{"count": count}
+
+	for name, value := range params {
+		withCount[name] = value
+	}
+
+	if entry.plural == nil {
+		return interpolate(entry.text, withCount)
+	}
+
+	text, ok := entry.plural[pluralCategory(locale, count)]
+
+	if !ok {
+		text = entry.plural[pluralOther]
+	}
+
+	return interpolate(text, withCount)
+}
+
+// ResolveLocale returns the first supported locale of the candidates, which can carry a region as
+// in en-GB, or the default locale when none is supported.
+func (c *Catalog) ResolveLocale(candidates ...string) string {
+	for _, candidate := range candidates {
+		language, _, _ := strings.Cut(strings.ReplaceAll(candidate, "_", "-"), "-")
+		language = strings.ToLower(strings.TrimSpace(language))
+
+		if _, ok := c.messages[language]; ok {
+			return language
+		}
+	}
+
+	return DefaultLocale
+}
+
+func (c *Catalog) lookup(locale string, key string) (message, bool) {
+	if entry, ok := c.messages[locale][key]; ok {
+		return entry, true
+	}
+
+	entry, ok := c.messages[DefaultLocale][key]
+
+	return entry, ok
+}
+
+func interpolate(text string, params Params) string {
+	if len(params) == 0 {
+		return text
+	}
+
+	replacements := make([]string, 0, len(params)*2)
+
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+const (
+	pluralOne   = "one"
+	pluralOther = "other"
+)
+
+// pluralCategory follows the CLDR rules of the shipped locales for whole numbers: French uses the
+// singular for 0 and 1, the rest only for 1.
+func pluralCategory(locale string, count int) string {
+	switch locale {
+	case "fr":
+		if count == 0 || count == 1 {
+			return pluralOne
+		}
+	default:
+		if count == 1 {
+			return pluralOne
+		}
+	}
+
+	return pluralOther
+}
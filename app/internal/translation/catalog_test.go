@@ -0,0 +1,163 @@
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+var shippedLocales = []string{"ca", "en", "es", "fr"}
+
+func loadCatalog(t *testing.T) *Catalog {
+	t.Helper()
+
+	catalog, err := LoadCatalog()
+
+	if err != nil {
+		t.Fatalf("loading the catalog: %v", err)
+	}
+
+	return catalog
+}
+
+func TestCatalogShipsTheSupportedLocales(t *testing.T) {
+	catalog := loadCatalog(t)
+
+	if got := catalog.Locales(); !slices.Equal(got, shippedLocales) {
+		t.Fatalf("locales = %v, want %v", got, shippedLocales)
+	}
+}
+
+// Every locale must have every key of the default locale and nothing else, with the same kind of
+// message and the same placeholders, so a text added in one language is added in all of them.
+func TestCatalogLocalesHaveTheSameKeys(t *testing.T) {
+	catalog := loadCatalog(t)
+	reference := catalog.messages[DefaultLocale]
+
+	for _, locale := range catalog.Locales() {
+		messages := catalog.messages[locale]
+
+		for key, want := range reference {
+			got, ok := messages[key]
+
+			if !ok {
+				t.Errorf("%s: missing key %s", locale, key)
+
+				continue
+			}
+
+			if (got.plural == nil) != (want.plural == nil) {
+				t.Errorf("%s: %s is plural in one locale and plain text in the other", locale, key)
+
+				continue
+			}
+
+			if !slices.Equal(placeholders(got), placeholders(want)) {
+				t.Errorf("%s: %s has placeholders %v, want %v", locale, key, placeholders(got), placeholders(want))
+			}
+		}
+
+		for key := range messages {
+			if _, ok := reference[key]; !ok {
+				t.Errorf("%s: key %s is not in the %s catalog", locale, key, DefaultLocale)
+			}
+		}
+	}
+}
+
+func TestCatalogHasTheMonths(t *testing.T) {
+	service := NewService()
+
+	for _, locale := range shippedLocales {
+		for month := time.January; month <= time.December; month++ {
+			if name := service.Month(locale, month); name == "" || name == fmt.Sprintf("month.%d", month) {
+				t.Errorf("%s: missing the name of month %d", locale, month)
+			}
+		}
+	}
+}
+
+func TestTranslateInterpolatesAndFallsBack(t *testing.T) {
+	catalog := loadCatalog(t)
+
+	tests := []struct {
+		name   string
+		locale string
+		key    string
+		params Params
+		want   string
+	}{
+		{"interpolates", "en", "chat.linked", Params{"business": "Barbería"}, "Chat linked to Barbería"},
+		{"unsupported locale", "de", "button.back", nil, "Atrás"},
+		{"unknown key", "en", "missing.key", nil, "missing.key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catalog.Translate(tt.locale, tt.key, tt.params); got != tt.want {
+				t.Errorf("Translate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPluralRules(t *testing.T) {
+	catalog := loadCatalog(t)
+
+	tests := []struct {
+		locale string
+		count  int
+		want   string
+	}{
+		{"es", 1, "Elegir fecha (1 servicio) 📅"},
+		{"es", 2, "Elegir fecha (2 servicios) 📅"},
+		{"en", 0, "Pick a date (0 services) 📅"},
+		{"fr", 0, "Choisir une date (0 service) 📅"},
+		{"fr", 3, "Choisir une date (3 services) 📅"},
+	}
+
+	for _, tt := range tests {
+		if got := catalog.Plural(tt.locale, "button.choose_date", tt.count, nil); got != tt.want {
+			t.Errorf("Plural(%s, %d) = %q, want %q", tt.locale, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	catalog := loadCatalog(t)
+
+	tests := []struct {
+		candidates []string
+		want       string
+	}{
+		{[]string{"en-GB", "es"}, "en"},
+		{[]string{"pt-BR", "ca"}, "ca"},
+		{[]string{"", "FR"}, "fr"},
+		{[]string{"de"}, DefaultLocale},
+		{nil, DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		if got := catalog.ResolveLocale(tt.candidates...); got != tt.want {
+			t.Errorf("ResolveLocale(%v) = %s, want %s", tt.candidates, got, tt.want)
+		}
+	}
+}
+
+func placeholders(entry message) []string {
+	text := entry.text
+
+	if entry.plural != nil {
+		text = entry.plural[pluralOther]
+	}
+
+	found := placeholderPattern.FindAllString(text, -1)
+
+	slices.Sort(found)
+
+	return slices.Compact(found)
+}
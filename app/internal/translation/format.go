@@ -0,0 +1,134 @@
+package translation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// currency is how an amount of a currency is written, whatever the locale.
+type currency struct {
+	symbol   string
+	decimals int
+}
+
+var currencies = map[string]currency{
+	"EUR": {symbol: "€", decimals: 2},
+	"USD": {symbol: "$", decimals: 2},
+	"GBP": {symbol: "£", decimals: 2},
+	"CHF": {symbol: "CHF", decimals: 2},
+	"MXN": {symbol: "MX$", decimals: 2},
+	"JPY": {symbol: "¥", decimals: 0},
+}
+
+func (s *Service) Weekday(locale string, weekday time.Weekday) string {
+	return s.catalog.Translate(locale, fmt.Sprintf("weekday.%d", weekday), nil)
+}
+
+func (s *Service) WeekdayShort(locale string, weekday time.Weekday) string {
+	return s.catalog.Translate(locale, fmt.Sprintf("weekday_short.%d", weekday), nil)
+}
+
+// FormatDate writes a date in full, e.g. lunes 5 de enero.
+func (s *Service) FormatDate(locale string, date time.Time) string {
+	return s.catalog.Translate(locale, "format.date", Params{
+		"weekday": s.Weekday(locale, date.Weekday()),
+		"day":     date.Day(),
+		"month":   s.catalog.Translate(locale, fmt.Sprintf("date_month.%d", date.Month()), nil),
+	})
+}
+
+// FormatDayMonth writes a date short enough for a button, e.g. Lun 5 Ene.
+func (s *Service) FormatDayMonth(locale string, date time.Time) string {
+	return s.catalog.Translate(locale, "format.day_month", Params{
+		"weekday": s.WeekdayShort(locale, date.Weekday()),
+		"day":     date.Day(),
+		"month":   s.catalog.Translate(locale, fmt.Sprintf("month_short.%d", date.Month()), nil),
+	})
+}
+
+// FormatTime writes the time of the day in the 12 or 24 hour clock the locale uses.
+func (s *Service) FormatTime(locale string, date time.Time) string {
+	return date.Format(s.catalog.Translate(locale, "format.time_layout", nil))
+}
+
+// RelativeDay names the date after now when it is today or tomorrow, in the location of date.
+func (s *Service) RelativeDay(locale string, date time.Time, now time.Time) (string, bool) {
+	now = now.In(date.Location())
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, date.Location())
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	switch {
+	case day.Equal(today):
+		return s.catalog.Translate(locale, "date.today", nil), true
+	case day.Equal(today.AddDate(0, 0, 1)):
+		return s.catalog.Translate(locale, "date.tomorrow", nil), true
+	}
+
+	return "", false
+}
+
+// FormatRelativeDate writes today or tomorrow by name and any other date in full.
+func (s *Service) FormatRelativeDate(locale string, date time.Time, now time.Time) string {
+	if relative, ok := s.RelativeDay(locale, date, now); ok {
+		return relative
+	}
+
+	return s.FormatDate(locale, date)
+}
+
+// FormatMoney writes an amount given in the minor units of the currency, e.g. 1850 EUR is
+// 18,50 € in Spanish and €18.50 in English. Unknown currencies are written with their code.
+func (s *Service) FormatMoney(locale string, amount int, code string) string {
+	info, ok := currencies[code]
+
+	if !ok {
+		info = currency{symbol: code, decimals: 2}
+	}
+
+	sign := ""
+
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	unit := 1
+
+	for range info.decimals {
+		unit *= 10
+	}
+
+	formatted := groupThousands(amount/unit, s.catalog.Translate(locale, "format.group_separator", nil))
+
+	if info.decimals > 0 {
+		formatted += s.catalog.Translate(locale, "format.decimal_separator", nil)
+		formatted += fmt.Sprintf("%0*d", info.decimals, amount%unit)
+	}
+
+	return s.catalog.Translate(locale, "format.money", Params{
+		"amount": sign + formatted,
+		"symbol": info.symbol,
+	})
+}
+
+func groupThousands(number int, separator string) string {
+	digits := strconv.Itoa(number)
+
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var grouped strings.Builder
+
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString(separator)
+		}
+
+		grouped.WriteRune(digit)
+	}
+
+	return grouped.String()
+}
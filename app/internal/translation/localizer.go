@@ -0,0 +1,73 @@
+package translation
+
+import (
+	"time"
+)
+
+// Localizer writes the texts of the bots in the language of whoever reads them.
+type Localizer struct {
+	lang   TranslationService
+	Locale string
+}
+
+// NewLocalizer resolves the locale from the candidates in order, e.g. the language of the
+// Telegram user and then that of the business.
+func NewLocalizer(lang TranslationService, candidates ...string) Localizer {
+	return Localizer{lang: lang, Locale: lang.ResolveLocale(candidates...)}
+}
+
+// T returns the text of the key unescaped, the Markdown builder escapes what goes in a message.
+func (l Localizer) T(key string, params Params) string {
+	return l.lang.Translate(l.Locale, key, params)
+}
+
+func (l Localizer) Plural(key string, count int, params Params) string {
+	return l.lang.Plural(l.Locale, key, count, params)
+}
+
+func (l Localizer) Month(month time.Month) string {
+	return l.lang.Month(l.Locale, month)
+}
+
+func (l Localizer) MonthShort(month time.Month) string {
+	return l.lang.MonthShort(l.Locale, month)
+}
+
+// Date writes a date in full, e.g. lunes 5 de enero.
+func (l Localizer) Date(date time.Time) string {
+	return l.lang.FormatDate(l.Locale, date)
+}
+
+// DayMonth writes a date short enough for a button, e.g. Lun 5 Ene.
+func (l Localizer) DayMonth(date time.Time) string {
+	return l.lang.FormatDayMonth(l.Locale, date)
+}
+
+// RelativeDate writes today and tomorrow by name and any other date in full.
+func (l Localizer) RelativeDate(date time.Time, now time.Time) string {
+	return l.lang.FormatRelativeDate(l.Locale, date, now)
+}
+
+// DayButton labels the button of a day of the date picker.
+func (l Localizer) DayButton(date time.Time, now time.Time) string {
+	if relative, ok := l.lang.RelativeDay(l.Locale, date, now); ok {
+		return relative
+	}
+
+	return l.DayMonth(date)
+}
+
+// Time writes the time of the day in the 12 or 24 hour clock of the locale.
+func (l Localizer) Time(date time.Time) string {
+	return l.lang.FormatTime(l.Locale, date)
+}
+
+// Hour is how a time already written with Time goes next to a date.
+func (l Localizer) Hour(hour string) string {
+	return l.T("format.hour", Params{"hour": hour})
+}
+
+// Money writes an amount in the minor units of the currency.
+func (l Localizer) Money(amount int, currency string) string {
+	return l.lang.FormatMoney(l.Locale, amount, currency)
+}
@@ -1,6 +1,11 @@
 package translation
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
 
 var SpanishMonths = map[time.Month]string{
 	time.January:   "Enero",
@@ -20,12 +25,37 @@ var SpanishMonths = map[time.Month]string{
 type TranslationService interface {
 	GetSpanishMonth(month time.Month) string
 	GetSpanishMonthShortForm(month time.Month) string
+	Translate(locale string, key string, params Params) string
+	Plural(locale string, key string, count int, params Params) string
+	Month(locale string, month time.Month) string
+	MonthShort(locale string, month time.Month) string
+	ResolveLocale(candidates ...string) string
+	Weekday(locale string, weekday time.Weekday) string
+	WeekdayShort(locale string, weekday time.Weekday) string
+	FormatDate(locale string, date time.Time) string
+	FormatDayMonth(locale string, date time.Time) string
+	FormatTime(locale string, date time.Time) string
+	RelativeDay(locale string, date time.Time, now time.Time) (string, bool)
+	FormatRelativeDate(locale string, date time.Time, now time.Time) string
+	FormatMoney(locale string, amount int, code string) string
 }
 
-type Service struct{}
+type Service struct {
+	catalog *Catalog
+}
 
+// NewService loads the embedded message catalog. The catalog ships with the binary, so failing
+// to read it is a build error and not something to recover from.
 func NewService() *Service {
-	return &Service{}
+	catalog, err := LoadCatalog()
+
+	if err != nil {
+		panic(eris.ToString(err, true))
+	}
+
+	return &Service{
+		catalog: catalog,
+	}
 }
 
 // GetSpanishMonth retrieves the Spanish name for a given month.
@@ -66,18 +96,39 @@ func (s *Service) GetSpanishMonth(month time.Month) string {
 //
 // Returns:
 //   - string: The Spanish short form name of the month.
-//     If the month is invalid or not found, returns an empty string.
+//     If the month is invalid, returns the key of the missing text.
 //
 // Behavior:
-//   - Uses the predefined SpanishMonths map for translations
+//   - Uses the short month names of the Spanish catalog, which are whole words and not a
+//     byte slice of the full name
 //   - Handles all standard time.Month values (January through December)
-//   - Returns an empty string for out-of-range or undefined month values
 //
 // Examples:
 //
-//	name := GetSpanishMonth(time.January)   // Returns "Ene"
-//	name := GetSpanishMonth(time.December)  // Returns "Dic"
-//	name := GetSpanishMonth(0)              // Returns ""
+//	name := GetSpanishMonthShortForm(time.January)   // Returns "Ene"
+//	name := GetSpanishMonthShortForm(time.December)  // Returns "Dic"
 func (s *Service) GetSpanishMonthShortForm(month time.Month) string {
-	return SpanishMonths[month][:3]
+	return s.MonthShort(DefaultLocale, month)
+}
+
+func (s *Service) Translate(locale string, key string, params Params) string {
+	return s.catalog.Translate(locale, key, params)
+}
+
+func (s *Service) Plural(locale string, key string, count int, params Params) string {
+	return s.catalog.Plural(locale, key, count, params)
+}
+
+func (s *Service) Month(locale string, month time.Month) string {
+	return s.catalog.Translate(locale, fmt.Sprintf("month.%d", month), nil)
+}
+
+func (s *Service) MonthShort(locale string, month time.Month) string {
+	return s.catalog.Translate(locale, fmt.Sprintf("month_short.%d", month), nil)
+}
+
+// ResolveLocale picks the language to talk to someone in, from the most to the least specific
+// of the candidates, e.g. the language of the Telegram user and then that of the business.
+func (s *Service) ResolveLocale(candidates ...string) string {
+	return s.catalog.ResolveLocale(candidates...)
 }
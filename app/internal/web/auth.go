@@ -0,0 +1,225 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type RegisterRequest struct {
+	Name         string `json:"name" validate:"required,max=255"`
+	OwnerName    string `json:"ownerName" validate:"required,max=255"`
+	ContactPhone string `json:"contactPhone" validate:"required,max=36"`
+	Email        string `json:"email" validate:"required,email,max=60"`
+	Password     string `json:"password" validate:"required,min=8,max=72"`
+	Address      string `json:"address" validate:"required,max=255"`
+	Country      string `json:"country" validate:"required,iso3166_1_alpha2"`
+	Lang         string `json:"lang" validate:"required,oneof=es en ca fr"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required,max=128"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required,max=128"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required,max=128"`
+	Everywhere   bool   `json:"everywhere"`
+}
+
+type AuthController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	service   auth.AuthService
+}
+
+func NewAuthController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service auth.AuthService,
+) *AuthController {
+	return &AuthController{
+		logger:    logger,
+		validator: validator,
+		service:   service,
+	}
+}
+
+func (c *AuthController) Register() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		var request RegisterRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		owner := &business.Business{
+			Name:         request.Name,
+			ContactPhone: request.ContactPhone,
+			Email:        request.Email,
+			Address:      request.Address,
+			Country:      request.Country,
+			Lang:         request.Lang,
+		}
+
+		user, err := c.service.Register(ctx, owner, request.OwnerName, request.Password)
+
+		if err != nil {
+			if eris.Is(err, business.BusinessAlreadyExists) || eris.Is(err, auth.UserAlreadyExists) {
+				ctx.JSON(http.StatusConflict, NewErrorResponse(http.StatusConflict, "A business with this email already exists"))
+
+				return
+			}
+
+			c.logger.Error("Error registering business", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"business": owner, "user": user})
+	}
+}
+
+func (c *AuthController) Login() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		var request LoginRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		tokens, err := c.service.Login(ctx, request.Email, request.Password)
+
+		if err != nil {
+			if eris.Is(err, auth.InvalidCredentials) {
+				ctx.JSON(http.StatusUnauthorized, NewErrorResponse(http.StatusUnauthorized, "Invalid email or password"))
+
+				return
+			}
+
+			c.logger.Error("Error logging in business", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, tokens)
+	}
+}
+
+func (c *AuthController) Refresh() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		var request RefreshRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		tokens, err := c.service.Refresh(ctx, request.RefreshToken)
+
+		if err != nil {
+			if eris.Is(err, auth.InvalidToken) || eris.Is(err, auth.RefreshTokenReused) {
+				ctx.JSON(http.StatusUnauthorized, NewErrorResponse(http.StatusUnauthorized, "Invalid refresh token"))
+
+				return
+			}
+
+			c.logger.Error("Error refreshing tokens", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, tokens)
+	}
+}
+
+// AcceptInvitation creates the invited user and logs them in.
+func (c *AuthController) AcceptInvitation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		var request AcceptInvitationRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		tokens, err := c.service.AcceptInvitation(ctx, request.Token, request.Name, request.Password)
+
+		if err != nil {
+			switch {
+			case eris.Is(err, auth.InvitationNotFound):
+				ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Invitation not found or already used"))
+			case eris.Is(err, auth.InvitationExpired):
+				ctx.JSON(http.StatusGone, NewErrorResponse(http.StatusGone, "Invitation expired"))
+			case eris.Is(err, auth.UserAlreadyExists):
+				ctx.JSON(http.StatusConflict, NewErrorResponse(http.StatusConflict, "A user with this email already exists"))
+			default:
+				c.logger.Error("Error accepting invitation", "trace_id", traceID, "error", eris.ToString(err, true))
+
+				ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+			}
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, tokens)
+	}
+}
+
+// Logout revokes the session of the refresh token, or every session of the user when asked
+// to. Unknown tokens are treated as already logged out.
+func (c *AuthController) Logout() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		var request LogoutRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		if err := c.service.Logout(ctx, request.RefreshToken, request.Everywhere); err != nil {
+			if eris.Is(err, auth.InvalidToken) {
+				ctx.Status(http.StatusNoContent)
+
+				return
+			}
+
+			c.logger.Error("Error logging out", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+)
+
+type BookingController struct {
+	logger  *slog.Logger
+	service booking.BookingService
+}
+
+func NewBookingController(logger *slog.Logger, service booking.BookingService) *BookingController {
+	return &BookingController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Agenda lists the bookings between from and to. Users allowed to see the whole agenda can
+// filter it by employee, the rest only get the bookings of the employee they are linked to.
+func (c *BookingController) Agenda() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		businessID, businessErr := strconv.Atoi(ctx.Param("id"))
+		from, fromErr := time.Parse(time.RFC3339, ctx.Query("from"))
+		to, toErr := time.Parse(time.RFC3339, ctx.Query("to"))
+
+		if businessErr != nil || fromErr != nil || toErr != nil || !from.Before(to) {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		employeeID := claims.EmployeeID
+
+		if claims.Role.Can(auth.PermissionViewAgenda) {
+			employeeID, _ = strconv.Atoi(ctx.DefaultQuery("employeeId", "0"))
+		}
+
+		if employeeID == 0 && !claims.Role.Can(auth.PermissionViewAgenda) {
+			ctx.JSON(http.StatusForbidden, NewErrorResponse(http.StatusForbidden, "Your user is not linked to an employee"))
+
+			return
+		}
+
+		bookings, err := c.service.GetAgenda(ctx, businessID, employeeID, from, to)
+
+		if err != nil {
+			c.logger.Error("Error fetching agenda", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, bookings)
+	}
+}
+
+// NoShow marks that the customer did not turn up. Staff can only do it on their own bookings.
+func (c *BookingController) NoShow() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		found, err := c.service.GetBooking(ctx, ctx.Param("bookingId"))
+
+		if err != nil && !eris.Is(err, booking.BookingNotFound) {
+			c.logger.Error("Error fetching booking", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		if err != nil || found.BusinessID != claims.BusinessID {
+			ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Booking not found"))
+
+			return
+		}
+
+		if !claims.Role.Can(auth.PermissionViewAgenda) && (claims.EmployeeID == 0 || found.EmployeeID != claims.EmployeeID) {
+			ctx.JSON(http.StatusForbidden, NewErrorResponse(http.StatusForbidden, "The booking belongs to another employee"))
+
+			return
+		}
+
+		if err := c.service.MarkNoShow(ctx, found); err != nil {
+			if eris.Is(err, booking.BookingNotStarted) || eris.Is(err, booking.BookingIsCancelled) {
+				ctx.JSON(http.StatusConflict, NewErrorResponse(http.StatusConflict, err.Error()))
+
+				return
+			}
+
+			c.logger.Error("Error marking booking as no-show", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, found)
+	}
+}
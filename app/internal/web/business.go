@@ -0,0 +1,518 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type BusinessRequest struct {
+	Name             string `json:"name" validate:"required,max=255"`
+	ContactPhone     string `json:"contactPhone" validate:"required,max=36"`
+	Email            string `json:"email" validate:"required,email,max=60"`
+	Address          string `json:"address" validate:"required,max=255"`
+	Country          string `json:"country" validate:"required,iso3166_1_alpha2"`
+	Lang             string `json:"lang" validate:"required,oneof=es en ca fr"`
+	RequiresApproval bool   `json:"requiresApproval"`
+}
+
+type ServiceRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Price    int    `json:"price" validate:"min=0"`
+	Currency string `json:"currency" validate:"required,iso4217"`
+	Duration int    `json:"duration" validate:"required,min=5,max=1440"`
+	Category string `json:"category" validate:"max=100"`
+}
+
+type OpeningHoursRequest struct {
+	Hours []OpeningHoursItem `json:"hours" validate:"max=50,dive"`
+}
+
+type OpeningHoursItem struct {
+	Weekday int    `json:"weekday" validate:"min=0,max=6"`
+	Open    string `json:"open" validate:"required,datetime=15:04"`
+	Close   string `json:"close" validate:"required,datetime=15:04"`
+}
+
+type HolidayRequest struct {
+	Date string `json:"date" validate:"required,datetime=2006-01-02"`
+	Name string `json:"name" validate:"max=255"`
+}
+
+type MessageTemplateRequest struct {
+	Body string `json:"body" validate:"required,max=1000"`
+}
+
+type BusinessController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	service   business.BusinessService
+}
+
+func NewBusinessController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service business.BusinessService,
+) *BusinessController {
+	return &BusinessController{
+		logger:    logger,
+		validator: validator,
+		service:   service,
+	}
+}
+
+/*
+================================================================================
+PROFILE
+================================================================================
+*/
+
+func (c *BusinessController) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		found, err := c.service.GetBusinessByID(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching business")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, found)
+	}
+}
+
+func (c *BusinessController) Update() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request BusinessRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		updated, err := c.service.UpdateBusiness(ctx, &business.Business{
+			Id:               businessID,
+			Name:             request.Name,
+			ContactPhone:     request.ContactPhone,
+			Email:            request.Email,
+			Address:          request.Address,
+			Country:          request.Country,
+			Lang:             request.Lang,
+			RequiresApproval: request.RequiresApproval,
+		})
+
+		if err != nil {
+			c.fail(ctx, err, "Error updating business")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, updated)
+	}
+}
+
+/*
+================================================================================
+SERVICE CATALOG
+================================================================================
+*/
+
+func (c *BusinessController) Services() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		services, err := c.service.GetServices(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching business services")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, services)
+	}
+}
+
+func (c *BusinessController) CreateService() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request ServiceRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		service := &business.ServiceCatalog{
+			Name:       request.Name,
+			Price:      request.Price,
+			Currency:   request.Currency,
+			Duration:   request.Duration,
+			Category:   request.Category,
+			BusinessId: businessID,
+		}
+
+		if err := c.service.CreateService(ctx, service); err != nil {
+			c.fail(ctx, err, "Error creating service")
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, service)
+	}
+}
+
+func (c *BusinessController) UpdateService() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, businessErr := strconv.Atoi(ctx.Param("id"))
+		serviceID, serviceErr := strconv.Atoi(ctx.Param("serviceId"))
+
+		if businessErr != nil || serviceErr != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request ServiceRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		updated, err := c.service.UpdateService(ctx, &business.ServiceCatalog{
+			Id:         serviceID,
+			Name:       request.Name,
+			Price:      request.Price,
+			Currency:   request.Currency,
+			Duration:   request.Duration,
+			Category:   request.Category,
+			BusinessId: businessID,
+		})
+
+		if err != nil {
+			c.fail(ctx, err, "Error updating service")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, updated)
+	}
+}
+
+func (c *BusinessController) DeleteService() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, businessErr := strconv.Atoi(ctx.Param("id"))
+		serviceID, serviceErr := strconv.Atoi(ctx.Param("serviceId"))
+
+		if businessErr != nil || serviceErr != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		if err := c.service.DeleteService(ctx, businessID, serviceID); err != nil {
+			c.fail(ctx, err, "Error deleting service")
+
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+/*
+================================================================================
+OPENING HOURS AND HOLIDAYS
+================================================================================
+*/
+
+func (c *BusinessController) OpeningHours() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		hours, err := c.service.GetOpeningHours(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching opening hours")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"hours": hours})
+	}
+}
+
+// SetOpeningHours replaces the whole weekly schedule, an empty list closes the business every day.
+func (c *BusinessController) SetOpeningHours() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request OpeningHoursRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		hours := make([]*business.OpeningHours, len(request.Hours))
+
+		for i, item := range request.Hours {
+			hours[i] = &business.OpeningHours{
+				Weekday: time.Weekday(item.Weekday),
+				Open:    item.Open,
+				Close:   item.Close,
+			}
+		}
+
+		if err := c.service.SetOpeningHours(ctx, businessID, hours); err != nil {
+			c.fail(ctx, err, "Error storing opening hours")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"hours": hours})
+	}
+}
+
+func (c *BusinessController) Holidays() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		holidays, err := c.service.GetHolidays(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching holidays")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, holidays)
+	}
+}
+
+func (c *BusinessController) AddHoliday() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request HolidayRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		holiday := &business.Holiday{
+			BusinessId: businessID,
+			Date:       request.Date,
+			Name:       request.Name,
+		}
+
+		if err := c.service.AddHoliday(ctx, holiday); err != nil {
+			c.fail(ctx, err, "Error storing holiday")
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, holiday)
+	}
+}
+
+func (c *BusinessController) DeleteHoliday() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, businessErr := strconv.Atoi(ctx.Param("id"))
+		holidayID, holidayErr := strconv.Atoi(ctx.Param("holidayId"))
+
+		if businessErr != nil || holidayErr != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		if err := c.service.DeleteHoliday(ctx, businessID, holidayID); err != nil {
+			c.fail(ctx, err, "Error deleting holiday")
+
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+/*
+================================================================================
+MESSAGE TEMPLATES
+================================================================================
+*/
+
+// MessageTemplates lists the messages the business can customise with the template it has for
+// each, an empty body for the ones still using the catalog text.
+func (c *BusinessController) MessageTemplates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		templates, err := c.service.GetMessageTemplates(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching message templates")
+
+			return
+		}
+
+		byKey := make(map[string]*business.MessageTemplate, len(templates))
+
+		for _, messageTemplate := range templates {
+			byKey[messageTemplate.Key] = messageTemplate
+		}
+
+		response := make([]*business.MessageTemplate, 0, len(business.MessageTemplateKeys))
+
+		for _, key := range business.MessageTemplateKeys {
+			if messageTemplate, ok := byKey[key]; ok {
+				response = append(response, messageTemplate)
+
+				continue
+			}
+
+			response = append(response, &business.MessageTemplate{BusinessId: businessID, Key: key})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"templates": response, "variables": business.SampleTemplateData})
+	}
+}
+
+func (c *BusinessController) SetMessageTemplate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request MessageTemplateRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		messageTemplate := &business.MessageTemplate{
+			BusinessId: businessID,
+			Key:        ctx.Param("key"),
+			Body:       request.Body,
+		}
+
+		if err := c.service.SetMessageTemplate(ctx, messageTemplate); err != nil {
+			c.fail(ctx, err, "Error storing message template")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, messageTemplate)
+	}
+}
+
+// DeleteMessageTemplate goes back to the catalog text for the message.
+func (c *BusinessController) DeleteMessageTemplate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		if err := c.service.DeleteMessageTemplate(ctx, businessID, ctx.Param("key")); err != nil {
+			c.fail(ctx, err, "Error deleting message template")
+
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// fail maps the domain errors of the business package to their status and logs the rest.
+func (c *BusinessController) fail(ctx *gin.Context, err error, message string) {
+	switch {
+	case eris.Is(err, business.BusinessNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Business not found"))
+	case eris.Is(err, business.ServiceNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Service not found"))
+	case eris.Is(err, business.HolidayNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Holiday not found"))
+	case eris.Is(err, business.MessageTemplateNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Message template not found"))
+	case eris.Is(err, business.InvalidOpeningHours), eris.Is(err, business.InvalidMessageTemplate):
+		ctx.JSON(http.StatusUnprocessableEntity, NewErrorResponse(http.StatusUnprocessableEntity, err.Error()))
+	default:
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		c.logger.Error(message, "trace_id", traceID, "error", eris.ToString(err, true))
+
+		ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+	}
+}
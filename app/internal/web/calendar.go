@@ -0,0 +1,92 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/adriein/hastypal/internal/caldav"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type CaldavConnectionRequest struct {
+	Url      string `json:"url" validate:"required,url,max=1024"`
+	Username string `json:"username" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,max=255"`
+}
+
+type CalendarController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	service   calendar.CalendarService
+}
+
+func NewCalendarController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service calendar.CalendarService,
+) *CalendarController {
+	return &CalendarController{
+		logger:    logger,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// ConnectCaldav switches the calendar of the business to a CalDAV collection once the server
+// confirms the credentials can read it.
+func (c *CalendarController) ConnectCaldav() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request CaldavConnectionRequest
+
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		if err := c.validator.Struct(request); err != nil {
+			ctx.JSON(http.StatusBadRequest, ValidationErrorResponse(err))
+
+			return
+		}
+
+		connection := &calendar.Connection{
+			BusinessID: businessID,
+			Provider:   calendar.ProviderCaldav,
+			Url:        request.Url,
+			Username:   request.Username,
+			Password:   request.Password,
+		}
+
+		if err := c.service.Connect(ctx, connection); err != nil {
+			if eris.Is(err, caldav.NotACalendar) {
+				ctx.JSON(http.StatusUnprocessableEntity, NewErrorResponse(http.StatusUnprocessableEntity, "The URL is not a calendar collection"))
+
+				return
+			}
+
+			c.logger.Error("Error connecting caldav calendar", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusBadGateway, NewErrorResponse(http.StatusBadGateway, "The calendar server could not be reached"))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"provider": connection.Provider, "url": connection.Url})
+	}
+}
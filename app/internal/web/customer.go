@@ -0,0 +1,126 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/adriein/hastypal/internal/customer"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type CustomerNotesRequest struct {
+	Notes string   `json:"notes" validate:"max=2000"`
+	Tags  []string `json:"tags" validate:"max=20"`
+}
+
+type CustomerController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	service   customer.CustomerService
+}
+
+func NewCustomerController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service customer.CustomerService,
+) *CustomerController {
+	return &CustomerController{
+		logger:    logger,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// Get lists the customers of the business, the ones who visit last first.
+func (c *CustomerController) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		customers, err := c.service.GetCustomers(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching customers")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, customers)
+	}
+}
+
+func (c *CustomerController) Profile() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		found, err := c.service.GetCustomer(ctx, businessID, ctx.Param("customerId"))
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching customer")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, found)
+	}
+}
+
+// Annotate replaces the notes and tags the business keeps about the customer.
+func (c *CustomerController) Annotate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request CustomerNotesRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		updated, err := c.service.AnnotateCustomer(ctx, businessID, ctx.Param("customerId"), request.Notes, request.Tags)
+
+		if err != nil {
+			c.fail(ctx, err, "Error updating customer")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, updated)
+	}
+}
+
+// fail maps the domain errors of the customer package to their status and logs the rest.
+func (c *CustomerController) fail(ctx *gin.Context, err error, message string) {
+	switch {
+	case eris.Is(err, customer.CustomerNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Customer not found"))
+	case eris.Is(err, customer.InvalidCustomer):
+		ctx.JSON(http.StatusUnprocessableEntity, NewErrorResponse(http.StatusUnprocessableEntity, err.Error()))
+	default:
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		c.logger.Error(message, "trace_id", traceID, "error", eris.ToString(err, true))
+
+		ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+	}
+}
@@ -0,0 +1,925 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/pkg/constants"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/adriein/hastypal/ui/views"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+const (
+	sessionCookie = "hastypal_session"
+	refreshCookie = "hastypal_refresh"
+	dashboardPath = "/dashboard"
+	loginPath     = "/dashboard/login"
+	dateLayout    = "2006-01-02"
+)
+
+type DashboardController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	auth      auth.AuthService
+	business  business.BusinessService
+	booking   booking.BookingService
+	appUrl    string
+}
+
+func NewDashboardController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	auth auth.AuthService,
+	business business.BusinessService,
+	booking booking.BookingService,
+	appUrl string,
+) *DashboardController {
+	return &DashboardController{
+		logger:    logger,
+		validator: validator,
+		auth:      auth,
+		business:  business,
+		booking:   booking,
+		appUrl:    strings.TrimSuffix(appUrl, "/"),
+	}
+}
+
+/*
+================================================================================
+SESSION
+================================================================================
+*/
+
+func (c *DashboardController) LoginPage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.HTML(http.StatusOK, "", views.Login("", ""))
+	}
+}
+
+func (c *DashboardController) Login() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.PostForm("email")
+
+		tokens, err := c.auth.Login(ctx, email, ctx.PostForm("password"))
+
+		if err != nil {
+			if eris.Is(err, auth.InvalidCredentials) {
+				ctx.HTML(http.StatusUnauthorized, "", views.Login(email, "Email o contraseña incorrectos"))
+
+				return
+			}
+
+			c.logError(ctx, err, "Error logging in to the dashboard")
+
+			ctx.HTML(http.StatusInternalServerError, "", views.Login(email, "No se ha podido iniciar sesión, inténtalo de nuevo"))
+
+			return
+		}
+
+		setSession(ctx, tokens)
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/agenda")
+	}
+}
+
+func (c *DashboardController) Logout() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if refresh, err := ctx.Cookie(refreshCookie); err == nil {
+			if err := c.auth.Logout(ctx, refresh, false); err != nil && !eris.Is(err, auth.InvalidToken) {
+				c.logError(ctx, err, "Error logging out of the dashboard")
+			}
+		}
+
+		clearSession(ctx)
+
+		ctx.Redirect(http.StatusSeeOther, loginPath)
+	}
+}
+
+func (c *DashboardController) InvitationPage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.HTML(http.StatusOK, "", views.AcceptInvitation(ctx.Param("token"), "", ""))
+	}
+}
+
+// AcceptInvitation creates the invited user from the link of the invitation email and opens
+// their dashboard session.
+func (c *DashboardController) AcceptInvitation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := ctx.Param("token")
+		name := strings.TrimSpace(ctx.PostForm("name"))
+		password := ctx.PostForm("password")
+
+		if name == "" || len(password) < 8 || len(password) > 72 {
+			ctx.HTML(http.StatusUnprocessableEntity, "", views.AcceptInvitation(token, name, "Indica tu nombre y una contraseña de 8 a 72 caracteres"))
+
+			return
+		}
+
+		tokens, err := c.auth.AcceptInvitation(ctx, token, name, password)
+
+		if err != nil {
+			switch {
+			case eris.Is(err, auth.InvitationNotFound):
+				ctx.HTML(http.StatusNotFound, "", views.AcceptInvitation(token, name, "La invitación no existe o ya se ha usado"))
+			case eris.Is(err, auth.InvitationExpired):
+				ctx.HTML(http.StatusGone, "", views.AcceptInvitation(token, name, "La invitación ha caducado, pide una nueva"))
+			case eris.Is(err, auth.UserAlreadyExists):
+				ctx.HTML(http.StatusConflict, "", views.AcceptInvitation(token, name, "Ya existe un usuario con este email"))
+			default:
+				c.logError(ctx, err, "Error accepting invitation")
+
+				ctx.HTML(http.StatusInternalServerError, "", views.AcceptInvitation(token, name, "No se ha podido crear la cuenta, inténtalo de nuevo"))
+			}
+
+			return
+		}
+
+		setSession(ctx, tokens)
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/agenda")
+	}
+}
+
+// Allow renders the forbidden page to the users whose role does not grant the permission.
+func (c *DashboardController) Allow(permission auth.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		if claims.Role.Can(permission) {
+			ctx.Next()
+
+			return
+		}
+
+		page, err := c.page(ctx, "Acceso denegado", "")
+
+		if err != nil {
+			c.fail(ctx, err, "Error rendering the forbidden page")
+			ctx.Abort()
+
+			return
+		}
+
+		ctx.HTML(http.StatusForbidden, "", views.Forbidden(page))
+		ctx.Abort()
+	}
+}
+
+/*
+================================================================================
+AGENDA
+================================================================================
+*/
+
+func (c *DashboardController) Agenda() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+		location := requestLocation(ctx)
+		day := startOfDay(parseDate(ctx.Query("date"), location))
+
+		page, err := c.page(ctx, "Agenda", "agenda")
+
+		if err != nil {
+			c.fail(ctx, err, "Error rendering the agenda")
+
+			return
+		}
+
+		rows, ok := c.agenda(ctx, claims, day, day.AddDate(0, 0, 1), page)
+
+		if !ok {
+			return
+		}
+
+		ctx.HTML(http.StatusOK, "", views.Agenda(views.AgendaPage{
+			Page:     page,
+			Label:    views.DateLabel(day),
+			Previous: day.AddDate(0, 0, -1).Format(dateLayout),
+			Next:     day.AddDate(0, 0, 1).Format(dateLayout),
+			Bookings: rows,
+		}))
+	}
+}
+
+func (c *DashboardController) Week() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+		location := requestLocation(ctx)
+		day := startOfDay(parseDate(ctx.Query("date"), location))
+		monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		sunday := monday.AddDate(0, 0, 6)
+
+		page, err := c.page(ctx, "Semana", "week")
+
+		if err != nil {
+			c.fail(ctx, err, "Error rendering the week")
+
+			return
+		}
+
+		rows, ok := c.agenda(ctx, claims, monday, monday.AddDate(0, 0, 7), page)
+
+		if !ok {
+			return
+		}
+
+		today := startOfDay(time.Now().In(location))
+		days := make([]views.WeekDay, 7)
+
+		for i := range days {
+			date := monday.AddDate(0, 0, i)
+
+			days[i] = views.WeekDay{
+				Label:    views.DateLabel(date),
+				Today:    date.Equal(today),
+				Bookings: make([]views.BookingRow, 0),
+			}
+		}
+
+		for _, row := range rows {
+			for i := range days {
+				if row.Day == days[i].Label {
+					days[i].Bookings = append(days[i].Bookings, row)
+				}
+			}
+		}
+
+		ctx.HTML(http.StatusOK, "", views.Week(views.WeekPage{
+			Page:     page,
+			Label:    fmt.Sprintf("%s - %s", views.DateLabel(monday), views.DateLabel(sunday)),
+			Previous: monday.AddDate(0, 0, -7).Format(dateLayout),
+			Next:     monday.AddDate(0, 0, 7).Format(dateLayout),
+			Days:     days,
+		}))
+	}
+}
+
+/*
+================================================================================
+BOOKINGS
+================================================================================
+*/
+
+func (c *DashboardController) Booking() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c.renderBooking(ctx, http.StatusOK, "")
+	}
+}
+
+func (c *DashboardController) CancelBooking() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		found, ok := c.ownBooking(ctx)
+
+		if !ok {
+			return
+		}
+
+		if found.IsCancelled() {
+			c.renderBooking(ctx, http.StatusConflict, "La reserva ya está cancelada")
+
+			return
+		}
+
+		if err := c.booking.CancelBooking(ctx, found); err != nil {
+			c.fail(ctx, err, "Error cancelling booking")
+
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/bookings/"+found.ID)
+	}
+}
+
+func (c *DashboardController) NoShow() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		found, ok := c.ownBooking(ctx)
+
+		if !ok {
+			return
+		}
+
+		if err := c.booking.MarkNoShow(ctx, found); err != nil {
+			if eris.Is(err, booking.BookingNotStarted) {
+				c.renderBooking(ctx, http.StatusConflict, "La reserva todavía no ha empezado")
+
+				return
+			}
+
+			if eris.Is(err, booking.BookingIsCancelled) {
+				c.renderBooking(ctx, http.StatusConflict, "La reserva está cancelada")
+
+				return
+			}
+
+			c.fail(ctx, err, "Error marking booking as no-show")
+
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/bookings/"+found.ID)
+	}
+}
+
+/*
+================================================================================
+SERVICE CATALOG
+================================================================================
+*/
+
+func (c *DashboardController) Services() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c.renderServices(ctx, http.StatusOK, "")
+	}
+}
+
+func (c *DashboardController) CreateService() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		service, message := c.serviceForm(ctx)
+
+		if message != "" {
+			c.renderServices(ctx, http.StatusUnprocessableEntity, message)
+
+			return
+		}
+
+		service.BusinessId = claims.BusinessID
+
+		if err := c.business.CreateService(ctx, service); err != nil {
+			c.fail(ctx, err, "Error creating service")
+
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/services")
+	}
+}
+
+func (c *DashboardController) UpdateService() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		serviceID, err := strconv.Atoi(ctx.Param("serviceId"))
+
+		if err != nil {
+			c.renderServices(ctx, http.StatusBadRequest, "Servicio desconocido")
+
+			return
+		}
+
+		service, message := c.serviceForm(ctx)
+
+		if message != "" {
+			c.renderServices(ctx, http.StatusUnprocessableEntity, message)
+
+			return
+		}
+
+		service.Id = serviceID
+		service.BusinessId = claims.BusinessID
+
+		if _, err := c.business.UpdateService(ctx, service); err != nil {
+			if eris.Is(err, business.ServiceNotFound) {
+				c.renderServices(ctx, http.StatusNotFound, "Servicio desconocido")
+
+				return
+			}
+
+			c.fail(ctx, err, "Error updating service")
+
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/services")
+	}
+}
+
+func (c *DashboardController) DeleteService() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		serviceID, err := strconv.Atoi(ctx.Param("serviceId"))
+
+		if err != nil {
+			c.renderServices(ctx, http.StatusBadRequest, "Servicio desconocido")
+
+			return
+		}
+
+		if err := c.business.DeleteService(ctx, claims.BusinessID, serviceID); err != nil && !eris.Is(err, business.ServiceNotFound) {
+			c.fail(ctx, err, "Error deleting service")
+
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/services")
+	}
+}
+
+/*
+================================================================================
+OPENING HOURS
+================================================================================
+*/
+
+func (c *DashboardController) Hours() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		hours, err := c.business.GetOpeningHours(ctx, claims.BusinessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching opening hours")
+
+			return
+		}
+
+		c.renderHours(ctx, http.StatusOK, hoursByDay(hours), "")
+	}
+}
+
+// SetHours reads one field per weekday with its ranges separated by commas, "09:00-14:00, 16:00-20:00".
+func (c *DashboardController) SetHours() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		ranges := make(map[time.Weekday]string, 7)
+		hours := make([]*business.OpeningHours, 0)
+
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			value := strings.TrimSpace(ctx.PostForm("day-" + strconv.Itoa(int(weekday))))
+			ranges[weekday] = value
+
+			if value == "" {
+				continue
+			}
+
+			for _, item := range strings.Split(value, ",") {
+				open, closing, found := strings.Cut(strings.TrimSpace(item), "-")
+
+				if !found {
+					c.renderHours(ctx, http.StatusUnprocessableEntity, ranges, "Formato de horario no válido: "+item)
+
+					return
+				}
+
+				hours = append(hours, &business.OpeningHours{
+					Weekday: weekday,
+					Open:    strings.TrimSpace(open),
+					Close:   strings.TrimSpace(closing),
+				})
+			}
+		}
+
+		if err := c.business.SetOpeningHours(ctx, claims.BusinessID, hours); err != nil {
+			if eris.Is(err, business.InvalidOpeningHours) {
+				c.renderHours(ctx, http.StatusUnprocessableEntity, ranges, err.Error())
+
+				return
+			}
+
+			c.fail(ctx, err, "Error saving opening hours")
+
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/hours")
+	}
+}
+
+/*
+================================================================================
+WEB WIDGET
+================================================================================
+*/
+
+// Widget shows the snippet that embeds the booking page of the business in its own website.
+func (c *DashboardController) Widget() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		page, err := c.page(ctx, "Reservas web", "widget")
+
+		if err != nil {
+			c.fail(ctx, err, "Error rendering the web widget")
+
+			return
+		}
+
+		link := fmt.Sprintf("%s/book/%d", c.appUrl, claims.BusinessID)
+
+		ctx.HTML(http.StatusOK, "", views.WidgetSnippet(views.WidgetSnippetPage{
+			Page:   page,
+			Link:   link,
+			Script: fmt.Sprintf(`<script src="%s/ui/static/widget.js" data-business="%d" async></script>`, c.appUrl, claims.BusinessID),
+			Frame: fmt.Sprintf(
+				`<iframe src="%s" title="%s" loading="lazy" style="width: 100%%; height: 36rem; border: 0"></iframe>`,
+				link,
+				html.EscapeString(page.BusinessName),
+			),
+		}))
+	}
+}
+
+/*
+================================================================================
+RENDERING
+================================================================================
+*/
+
+func (c *DashboardController) page(ctx *gin.Context, title string, active string) (views.Page, error) {
+	claims, _ := auth.ClaimsFrom(ctx)
+
+	owner, err := c.business.GetBusinessByID(ctx, claims.BusinessID)
+
+	if err != nil {
+		return views.Page{}, eris.Wrap(err, "Error fetching the business of the dashboard")
+	}
+
+	user, err := c.auth.GetUser(ctx, claims.BusinessID, claims.UserID)
+
+	if err != nil {
+		return views.Page{}, eris.Wrap(err, "Error fetching the user of the dashboard")
+	}
+
+	return views.Page{
+		Title:        title,
+		BusinessName: owner.Name,
+		UserName:     user.Name,
+		Active:       active,
+	}, nil
+}
+
+// agenda returns the bookings between from and to the user is allowed to see, the whole agenda
+// or the one of the employee they are linked to.
+func (c *DashboardController) agenda(
+	ctx *gin.Context,
+	claims *auth.Claims,
+	from time.Time,
+	to time.Time,
+	page views.Page,
+) ([]views.BookingRow, bool) {
+	employeeID := 0
+
+	if !claims.Role.Can(auth.PermissionViewAgenda) {
+		if claims.EmployeeID == 0 {
+			ctx.HTML(http.StatusForbidden, "", views.Forbidden(page))
+
+			return nil, false
+		}
+
+		employeeID = claims.EmployeeID
+	}
+
+	bookings, err := c.booking.GetAgenda(ctx, claims.BusinessID, employeeID, from, to)
+
+	if err != nil {
+		c.fail(ctx, err, "Error fetching agenda")
+
+		return nil, false
+	}
+
+	rows, err := c.rows(ctx, claims.BusinessID, bookings)
+
+	if err != nil {
+		c.fail(ctx, err, "Error rendering agenda")
+
+		return nil, false
+	}
+
+	return rows, true
+}
+
+func (c *DashboardController) rows(ctx *gin.Context, businessID int, bookings []*booking.Booking) ([]views.BookingRow, error) {
+	location := requestLocation(ctx)
+
+	services, err := c.business.GetServices(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the services of the business")
+	}
+
+	employees, err := c.business.GetEmployees(ctx, businessID)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error fetching the employees of the business")
+	}
+
+	servicesByID := make(map[string]*business.ServiceCatalog, len(services))
+	employeeNames := make(map[int]string, len(employees))
+
+	for _, service := range services {
+		servicesByID[strconv.Itoa(service.Id)] = service
+	}
+
+	for _, employee := range employees {
+		employeeNames[employee.Id] = employee.Name
+	}
+
+	rows := make([]views.BookingRow, len(bookings))
+
+	for i, found := range bookings {
+		start := found.Date.In(location)
+		duration := calendar.DefaultSlotDuration
+		serviceName := found.ServiceID
+
+		if service, ok := servicesByID[found.ServiceID]; ok {
+			serviceName = service.Name
+			duration = time.Duration(service.Duration) * time.Minute
+		}
+
+		if len(found.Items) > 0 {
+			serviceName = strings.Join(found.ServiceNames(), " + ")
+			duration = found.Duration(duration)
+		}
+
+		rows[i] = views.BookingRow{
+			ID:       found.ID,
+			Day:      views.DateLabel(start),
+			Start:    start.Format(business.HourLayout),
+			End:      start.Add(duration).Format(business.HourLayout),
+			Customer: found.CustomerName,
+			Service:  serviceName,
+			Employee: employeeNames[found.EmployeeID],
+			Status:   found.Status,
+		}
+	}
+
+	return rows, nil
+}
+
+// ownBooking fetches the booking of the path, answering itself when it does not belong to the
+// business or, for staff, to the employee of the user.
+func (c *DashboardController) ownBooking(ctx *gin.Context) (*booking.Booking, bool) {
+	claims, _ := auth.ClaimsFrom(ctx)
+
+	found, err := c.booking.GetBooking(ctx, ctx.Param("bookingId"))
+
+	if err != nil && !eris.Is(err, booking.BookingNotFound) {
+		c.fail(ctx, err, "Error fetching booking")
+
+		return nil, false
+	}
+
+	visible := err == nil && found.BusinessID == claims.BusinessID
+
+	if visible && !claims.Role.Can(auth.PermissionViewAgenda) {
+		visible = claims.EmployeeID != 0 && found.EmployeeID == claims.EmployeeID
+	}
+
+	if !visible {
+		ctx.Redirect(http.StatusSeeOther, dashboardPath+"/agenda")
+
+		return nil, false
+	}
+
+	return found, true
+}
+
+func (c *DashboardController) renderBooking(ctx *gin.Context, status int, message string) {
+	claims, _ := auth.ClaimsFrom(ctx)
+
+	found, ok := c.ownBooking(ctx)
+
+	if !ok {
+		return
+	}
+
+	page, err := c.page(ctx, "Reserva", "agenda")
+
+	if err != nil {
+		c.fail(ctx, err, "Error rendering booking")
+
+		return
+	}
+
+	rows, err := c.rows(ctx, claims.BusinessID, []*booking.Booking{found})
+
+	if err != nil {
+		c.fail(ctx, err, "Error rendering booking")
+
+		return
+	}
+
+	ctx.HTML(status, "", views.Booking(views.BookingPage{
+		Page:      page,
+		Booking:   rows[0],
+		CanCancel: claims.Role.Can(auth.PermissionManageBookings) && found.Status == booking.StatusConfirmed,
+		CanNoShow: claims.Role.Can(auth.PermissionMarkNoShow) && found.Status == booking.StatusConfirmed,
+		Error:     message,
+	}))
+}
+
+func (c *DashboardController) renderServices(ctx *gin.Context, status int, message string) {
+	claims, _ := auth.ClaimsFrom(ctx)
+
+	page, err := c.page(ctx, "Servicios", "services")
+
+	if err != nil {
+		c.fail(ctx, err, "Error rendering services")
+
+		return
+	}
+
+	services, err := c.business.GetServices(ctx, claims.BusinessID)
+
+	if err != nil {
+		c.fail(ctx, err, "Error fetching services")
+
+		return
+	}
+
+	rows := make([]views.ServiceRow, len(services))
+
+	for i, service := range services {
+		rows[i] = views.ServiceRow{
+			ID:       service.Id,
+			Name:     service.Name,
+			Price:    views.FormatPrice(service.Price),
+			Currency: service.Currency,
+			Duration: service.Duration,
+			Category: service.Category,
+		}
+	}
+
+	ctx.HTML(status, "", views.Services(views.ServicesPage{
+		Page:     page,
+		Services: rows,
+		CanEdit:  claims.Role.Can(auth.PermissionEditCatalog),
+		Error:    message,
+	}))
+}
+
+func (c *DashboardController) renderHours(ctx *gin.Context, status int, ranges map[time.Weekday]string, message string) {
+	claims, _ := auth.ClaimsFrom(ctx)
+
+	page, err := c.page(ctx, "Horario", "hours")
+
+	if err != nil {
+		c.fail(ctx, err, "Error rendering opening hours")
+
+		return
+	}
+
+	days := make([]views.HoursDay, 0, 7)
+
+	for i := range 7 {
+		weekday := time.Weekday((i + 1) % 7)
+
+		days = append(days, views.HoursDay{
+			Weekday: int(weekday),
+			Label:   views.WeekdayLabel(weekday),
+			Ranges:  ranges[weekday],
+		})
+	}
+
+	ctx.HTML(status, "", views.Hours(views.HoursPage{
+		Page:    page,
+		Days:    days,
+		CanEdit: claims.Role.Can(auth.PermissionEditHours),
+		Error:   message,
+	}))
+}
+
+// serviceForm reads the service of the catalog form, returning the message to show when it is
+// not valid. Prices are typed in their decimal form.
+func (c *DashboardController) serviceForm(ctx *gin.Context) (*business.ServiceCatalog, string) {
+	price, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(ctx.PostForm("price")), ",", ".", 1), 64)
+
+	if err != nil || price < 0 {
+		return nil, "Precio no válido"
+	}
+
+	duration, err := strconv.Atoi(ctx.PostForm("duration"))
+
+	if err != nil {
+		return nil, "Duración no válida"
+	}
+
+	request := ServiceRequest{
+		Name:     strings.TrimSpace(ctx.PostForm("name")),
+		Price:    int(math.Round(price * 100)),
+		Currency: strings.ToUpper(strings.TrimSpace(ctx.PostForm("currency"))),
+		Duration: duration,
+		Category: strings.TrimSpace(ctx.PostForm("category")),
+	}
+
+	if err := c.validator.Struct(request); err != nil {
+		return nil, "Revisa los datos del servicio: nombre, moneda ISO, duración entre 5 y 1440 minutos y categoría de hasta 100 caracteres"
+	}
+
+	return &business.ServiceCatalog{
+		Name:     request.Name,
+		Price:    request.Price,
+		Currency: request.Currency,
+		Duration: request.Duration,
+		Category: request.Category,
+	}, ""
+}
+
+func (c *DashboardController) fail(ctx *gin.Context, err error, message string) {
+	c.logError(ctx, err, message)
+
+	ctx.String(http.StatusInternalServerError, "Se ha producido un error, inténtalo de nuevo más tarde")
+}
+
+func (c *DashboardController) logError(ctx *gin.Context, err error, message string) {
+	traceID := ctx.Value(middleware.TraceIDKey)
+
+	c.logger.Error(message, "trace_id", traceID, "error", eris.ToString(err, true))
+}
+
+/*
+================================================================================
+HELPERS
+================================================================================
+*/
+
+// setSession stores the tokens in cookies only the dashboard reads. SameSite keeps other sites
+// from posting the dashboard forms with them.
+func setSession(ctx *gin.Context, tokens *auth.TokenPair) {
+	secure := os.Getenv(constants.Env) == constants.Pro
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    tokens.AccessToken,
+		Path:     dashboardPath,
+		MaxAge:   int(auth.AccessTokenTtl.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     refreshCookie,
+		Value:    tokens.RefreshToken,
+		Path:     dashboardPath,
+		MaxAge:   int(auth.RefreshTokenTtl.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearSession(ctx *gin.Context) {
+	for _, name := range []string{sessionCookie, refreshCookie} {
+		http.SetCookie(ctx.Writer, &http.Cookie{
+			Name:     name,
+			Path:     dashboardPath,
+			MaxAge:   -1,
+			HttpOnly: true,
+		})
+	}
+}
+
+func requestLocation(ctx *gin.Context) *time.Location {
+	if location, ok := ctx.Value(middleware.TimezoneKey).(*time.Location); ok {
+		return location
+	}
+
+	return time.UTC
+}
+
+func parseDate(value string, location *time.Location) time.Time {
+	if date, err := time.ParseInLocation(dateLayout, value, location); err == nil {
+		return date
+	}
+
+	return time.Now().In(location)
+}
+
+func startOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
+
+func hoursByDay(hours []*business.OpeningHours) map[time.Weekday]string {
+	ranges := make(map[time.Weekday]string, 7)
+
+	for _, hour := range hours {
+		value := hour.Open + "-" + hour.Close
+
+		if ranges[hour.Weekday] != "" {
+			value = ranges[hour.Weekday] + ", " + value
+		}
+
+		ranges[hour.Weekday] = value
+	}
+
+	return ranges
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/pkg/vendor"
+	"github.com/gin-gonic/gin"
+)
+
+// The fakes embed the interfaces they stand for, a test calling any other method panics.
+
+type fakeAuthService struct {
+	auth.AuthService
+	sessions map[string]*auth.Claims
+}
+
+func (f *fakeAuthService) ParseAccessToken(accessToken string) (*auth.Claims, error) {
+	claims, ok := f.sessions[accessToken]
+
+	if !ok {
+		return nil, auth.InvalidToken
+	}
+
+	return claims, nil
+}
+
+func (f *fakeAuthService) GetUser(_ context.Context, businessID int, userID string) (*auth.User, error) {
+	return &auth.User{ID: userID, BusinessID: businessID, Name: "Marta"}, nil
+}
+
+type fakeBusinessService struct {
+	business.BusinessService
+}
+
+func (f *fakeBusinessService) GetBusinessByID(_ context.Context, ID int) (*business.Business, error) {
+	return &business.Business{Id: ID, Name: "Barbería Pérez"}, nil
+}
+
+type fakeBookingService struct {
+	booking.BookingService
+	found     *booking.Booking
+	cancelled bool
+}
+
+func (f *fakeBookingService) GetBooking(_ context.Context, bookingID string) (*booking.Booking, error) {
+	if f.found == nil || f.found.ID != bookingID {
+		return nil, booking.BookingNotFound
+	}
+
+	return f.found, nil
+}
+
+func (f *fakeBookingService) CancelBooking(_ context.Context, found *booking.Booking) error {
+	f.cancelled = true
+
+	return nil
+}
+
+// dashboardRouter mounts the cancel route the way the server does, behind the dashboard session
+// and the permission to manage bookings.
+func dashboardRouter(bookings *fakeBookingService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	authentication := &fakeAuthService{sessions: map[string]*auth.Claims{
+		"manager":  {BusinessID: 7, UserID: "u1", Role: auth.RoleManager},
+		"staff":    {BusinessID: 7, UserID: "u2", Role: auth.RoleStaff, EmployeeID: 3},
+		"outsider": {BusinessID: 8, UserID: "u3", Role: auth.RoleOwner},
+	}}
+
+	dashboard := NewDashboardController(
+		slog.New(slog.DiscardHandler),
+		nil,
+		authentication,
+		&fakeBusinessService{},
+		bookings,
+		"https://hastypal.com",
+	)
+
+	router := gin.New()
+	router.HTMLRender = vendor.Default
+
+	panel := router.Group(dashboardPath, DashboardSession(authentication))
+	panel.POST("/bookings/:bookingId/cancel", dashboard.Allow(auth.PermissionManageBookings), dashboard.CancelBooking())
+
+	return router
+}
+
+func TestDashboardCancelBooking(t *testing.T) {
+	tests := []struct {
+		name      string
+		session   string
+		status    int
+		location  string
+		cancelled bool
+	}{
+		{
+			name:      "member of the business",
+			session:   "manager",
+			status:    http.StatusSeeOther,
+			location:  "/dashboard/bookings/b1",
+			cancelled: true,
+		},
+		{
+			name:     "member of another business",
+			session:  "outsider",
+			status:   http.StatusSeeOther,
+			location: "/dashboard/agenda",
+		},
+		{
+			name:    "member without the permission",
+			session: "staff",
+			status:  http.StatusForbidden,
+		},
+		{
+			name:     "no session",
+			status:   http.StatusSeeOther,
+			location: loginPath,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bookings := &fakeBookingService{found: &booking.Booking{
+				ID:         "b1",
+				BusinessID: 7,
+				EmployeeID: 3,
+				Status:     booking.StatusConfirmed,
+				Date:       time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC),
+			}}
+
+			request := httptest.NewRequest(http.MethodPost, "/dashboard/bookings/b1/cancel", nil)
+
+			if test.session != "" {
+				request.AddCookie(&http.Cookie{Name: sessionCookie, Value: test.session})
+			}
+
+			recorder := httptest.NewRecorder()
+
+			dashboardRouter(bookings).ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.status)
+			}
+
+			if location := recorder.Header().Get("Location"); location != test.location {
+				t.Errorf("location = %q, want %q", location, test.location)
+			}
+
+			if bookings.cancelled != test.cancelled {
+				t.Errorf("cancelled = %t, want %t", bookings.cancelled, test.cancelled)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/google"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type EmployeeCalendarRequest struct {
+	CalendarId string `json:"calendarId" validate:"required,max=255"`
+}
+
+type EmployeeController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	business  business.BusinessService
+	calendar  calendar.CalendarService
+	google    google.GoogleService
+}
+
+func NewEmployeeController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	business business.BusinessService,
+	calendar calendar.CalendarService,
+	google google.GoogleService,
+) *EmployeeController {
+	return &EmployeeController{
+		logger:    logger,
+		validator: validator,
+		business:  business,
+		calendar:  calendar,
+		google:    google,
+	}
+}
+
+func (c *EmployeeController) Calendars() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		calendars, err := c.google.ListCalendars(ctx, businessID)
+
+		if err != nil {
+			c.logger.Error("Error listing google calendars", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, calendars)
+	}
+}
+
+func (c *EmployeeController) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		employees, err := c.business.GetEmployees(ctx, businessID)
+
+		if err != nil {
+			c.logger.Error("Error fetching employees", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, employees)
+	}
+}
+
+// AssignCalendar maps the employee to one of the calendars of the business connection. Google
+// calendars are also watched so changes made there reach the bookings of the employee.
+func (c *EmployeeController) AssignCalendar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		businessID, businessErr := strconv.Atoi(ctx.Param("id"))
+		employeeID, employeeErr := strconv.Atoi(ctx.Param("employeeId"))
+
+		if businessErr != nil || employeeErr != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request EmployeeCalendarRequest
+
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		if err := c.validator.Struct(request); err != nil {
+			ctx.JSON(http.StatusBadRequest, ValidationErrorResponse(err))
+
+			return
+		}
+
+		connection, err := c.calendar.GetConnection(ctx, businessID)
+
+		if err != nil {
+			if eris.Is(err, calendar.ConnectionNotFound) {
+				ctx.JSON(http.StatusConflict, NewErrorResponse(http.StatusConflict, "The business has no calendar connected"))
+
+				return
+			}
+
+			c.logger.Error("Error fetching calendar connection", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		isGoogle := connection.Provider == calendar.ProviderGoogle
+
+		if isGoogle {
+			calendars, err := c.google.ListCalendars(ctx, businessID)
+
+			if err != nil {
+				c.logger.Error("Error listing google calendars", "trace_id", traceID, "error", eris.ToString(err, true))
+
+				ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+				return
+			}
+
+			isWritable := slices.ContainsFunc(calendars, func(calendar *google.Calendar) bool {
+				return calendar.Id == request.CalendarId
+			})
+
+			if !isWritable {
+				ctx.JSON(http.StatusUnprocessableEntity, NewErrorResponse(http.StatusUnprocessableEntity, "The connected account cannot write to the calendar"))
+
+				return
+			}
+		}
+
+		employee, err := c.business.AssignEmployeeCalendar(ctx, businessID, employeeID, request.CalendarId)
+
+		if err != nil {
+			if eris.Is(err, business.EmployeeNotFound) {
+				ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Employee not found"))
+
+				return
+			}
+
+			c.logger.Error("Error assigning employee calendar", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		if isGoogle {
+			if err := c.google.WatchCalendar(ctx, businessID, request.CalendarId); err != nil {
+				c.logger.Error("Error watching employee calendar", "trace_id", traceID, "error", eris.ToString(err, true))
+
+				ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, employee)
+	}
+}
+
+func (c *EmployeeController) FreeBusy() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		businessID, businessErr := strconv.Atoi(ctx.Param("id"))
+		employeeID, employeeErr := strconv.Atoi(ctx.Param("employeeId"))
+		from, fromErr := time.Parse(time.RFC3339, ctx.Query("from"))
+		to, toErr := time.Parse(time.RFC3339, ctx.Query("to"))
+
+		if businessErr != nil || employeeErr != nil || fromErr != nil || toErr != nil || !from.Before(to) {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		employee, err := c.business.GetEmployee(ctx, businessID, employeeID)
+
+		if err != nil {
+			if eris.Is(err, business.EmployeeNotFound) {
+				ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Employee not found"))
+
+				return
+			}
+
+			c.logger.Error("Error fetching employee", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		calendarID := employee.CalendarId
+
+		busy, err := c.calendar.FreeBusy(ctx, businessID, []string{calendarID}, from, to)
+
+		if err != nil {
+			c.logger.Error("Error fetching employee free/busy", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"calendarId": calendarID, "busy": busy[calendarID]})
+	}
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adriein/hastypal/internal/feed"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+)
+
+const feedExtension = ".ics"
+
+type FeedController struct {
+	logger  *slog.Logger
+	service feed.FeedService
+}
+
+func NewFeedController(logger *slog.Logger, service feed.FeedService) *FeedController {
+	return &FeedController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Subscribe serves the iCalendar feed behind the secret URL. Calendar apps poll it, so it
+// answers 304 whenever their cached copy is still current.
+func (c *FeedController) Subscribe() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		token, found := strings.CutSuffix(ctx.Param("file"), feedExtension)
+
+		if !found || token == "" {
+			ctx.Status(http.StatusNotFound)
+
+			return
+		}
+
+		document, err := c.service.Render(ctx, token)
+
+		if err != nil {
+			if eris.Is(err, feed.FeedNotFound) {
+				ctx.Status(http.StatusNotFound)
+
+				return
+			}
+
+			c.logger.Error("Error rendering booking feed", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		ctx.Header("ETag", document.ETag)
+		ctx.Header("Last-Modified", document.LastModified.Format(http.TimeFormat))
+		ctx.Header("Cache-Control", "private, max-age=300")
+
+		if isNotModified(ctx.Request, document) {
+			ctx.Status(http.StatusNotModified)
+
+			return
+		}
+
+		ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(document.Body))
+	}
+}
+
+func (c *FeedController) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		businessFeed, err := c.service.GetFeed(ctx, businessID)
+
+		if err != nil {
+			c.logger.Error("Error fetching booking feed", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"url": feedURL(ctx, businessFeed.Token)})
+	}
+}
+
+// Regenerate revokes the current URL of the feed and returns a new one.
+func (c *FeedController) Regenerate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		businessFeed, err := c.service.RegenerateFeed(ctx, businessID)
+
+		if err != nil {
+			c.logger.Error("Error regenerating booking feed", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"url": feedURL(ctx, businessFeed.Token)})
+	}
+}
+
+func isNotModified(req *http.Request, document *feed.Document) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+
+			if tag == document.ETag || tag == "*" {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+
+	return err == nil && !document.LastModified.After(since)
+}
+
+func feedURL(ctx *gin.Context, token string) string {
+	scheme := "https"
+
+	if ctx.Request.TLS == nil && ctx.GetHeader("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+
+	return fmt.Sprintf("%s://%s/feeds/%s%s", scheme, ctx.Request.Host, token, feedExtension)
+}
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/calendarsync"
+	"github.com/adriein/hastypal/internal/google"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+)
+
+type GoogleController struct {
+	logger  *slog.Logger
+	service google.GoogleService
+	sync    calendarsync.CalendarSyncService
+}
+
+func NewGoogleController(
+	logger *slog.Logger,
+	service google.GoogleService,
+	sync calendarsync.CalendarSyncService,
+) *GoogleController {
+	return &GoogleController{
+		logger:  logger,
+		service: service,
+		sync:    sync,
+	}
+}
+
+// Auth starts the authorization of the business calendar and returns the Google consent URL to
+// send the member to.
+func (c *GoogleController) Auth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		authUrl, err := c.service.Authenticate(ctx, claims.BusinessID, claims.SessionID)
+
+		if err != nil {
+			c.logger.Error("Error building google authentication url", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"url": authUrl})
+	}
+}
+
+// AuthCallback receives the code and state Google sent back to the redirect page, from the same
+// session that started the authorization.
+func (c *GoogleController) AuthCallback() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		state := ctx.Query("state")
+		code := ctx.Query("code")
+
+		if state == "" || code == "" {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		if err := c.service.Authorize(ctx, claims.BusinessID, claims.SessionID, state, code); err != nil {
+			if eris.Is(err, google.InvalidOAuthState) {
+				ctx.JSON(http.StatusForbidden, NewErrorResponse(http.StatusForbidden, "Invalid authorization state"))
+
+				return
+			}
+
+			c.logger.Error("Error authorizing google calendar access", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+// Notification receives the Google Calendar push notifications. Google only expects a 2xx
+// answer, any other status makes it retry the delivery with exponential backoff.
+func (c *GoogleController) Notification() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		channelID := ctx.GetHeader("X-Goog-Channel-ID")
+		channelToken := ctx.GetHeader("X-Goog-Channel-Token")
+		resourceID := ctx.GetHeader("X-Goog-Resource-ID")
+		resourceState := ctx.GetHeader("X-Goog-Resource-State")
+
+		if resourceState == google.ResourceStateSync {
+			ctx.Status(http.StatusOK)
+
+			return
+		}
+
+		if err := c.sync.HandleNotification(ctx, channelID, channelToken, resourceID); err != nil {
+			if eris.Is(err, google.ChannelNotFound) {
+				c.logger.Warn("Google notification for unknown channel", "trace_id", traceID, "channel_id", channelID)
+
+				ctx.Status(http.StatusOK)
+
+				return
+			}
+
+			c.logger.Error("Error handling google calendar notification", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.Status(http.StatusInternalServerError)
+
+			return
+		}
+
+		ctx.Status(http.StatusOK)
+	}
+}
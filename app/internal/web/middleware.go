@@ -0,0 +1,115 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/pkg/constants"
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticated rejects the requests without a valid bearer access token and stores its claims
+// under constants.ClaimsContextKey for the handlers.
+func Authenticated(service auth.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, found := strings.CutPrefix(ctx.GetHeader("Authorization"), auth.TokenType+" ")
+
+		if !found || token == "" {
+			ctx.Header("WWW-Authenticate", auth.TokenType)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, NewErrorResponse(http.StatusUnauthorized, "Missing access token"))
+
+			return
+		}
+
+		claims, err := service.ParseAccessToken(token)
+
+		if err != nil {
+			ctx.Header("WWW-Authenticate", auth.TokenType+` error="invalid_token"`)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, NewErrorResponse(http.StatusUnauthorized, "Invalid access token"))
+
+			return
+		}
+
+		ctx.Set(constants.ClaimsContextKey, claims)
+
+		ctx.Next()
+	}
+}
+
+// BusinessMember only lets through the requests on the business the token was issued for, read
+// from the :id path parameter.
+func BusinessMember() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, ok := auth.ClaimsFrom(ctx)
+
+		if !ok || strconv.Itoa(claims.BusinessID) != ctx.Param("id") {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, NewErrorResponse(http.StatusForbidden, "Access to this business is not allowed"))
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Allow only lets through the users whose role grants the permission.
+func Allow(permission auth.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, ok := auth.ClaimsFrom(ctx)
+
+		if !ok || !claims.Role.Can(permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, NewErrorResponse(http.StatusForbidden, "Your role does not allow this action"))
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// DashboardSession authenticates the dashboard pages with the cookies set on login. An expired
+// access token is renewed with the refresh cookie, any other failure goes back to the login page.
+func DashboardSession(service auth.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, err := sessionClaims(ctx, service)
+
+		if err != nil {
+			clearSession(ctx)
+
+			ctx.Redirect(http.StatusSeeOther, loginPath)
+			ctx.Abort()
+
+			return
+		}
+
+		ctx.Set(constants.ClaimsContextKey, claims)
+
+		ctx.Next()
+	}
+}
+
+func sessionClaims(ctx *gin.Context, service auth.AuthService) (*auth.Claims, error) {
+	if token, err := ctx.Cookie(sessionCookie); err == nil {
+		if claims, err := service.ParseAccessToken(token); err == nil {
+			return claims, nil
+		}
+	}
+
+	refresh, err := ctx.Cookie(refreshCookie)
+
+	if err != nil {
+		return nil, auth.InvalidToken
+	}
+
+	tokens, err := service.Refresh(ctx, refresh)
+
+	if err != nil {
+		return nil, err
+	}
+
+	setSession(ctx, tokens)
+
+	return service.ParseAccessToken(tokens.AccessToken)
+}
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/notification"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type NotificationSettingsRequest struct {
+	Channels []string `json:"channels" validate:"max=4"`
+}
+
+// NotificationPreferenceRequest names the customer the way the notifications do, e.g.
+// "telegram:123456", "whatsapp:34600111222" or "phone:+34600111222".
+type NotificationPreferenceRequest struct {
+	Customer string   `json:"customer" validate:"required,max=100"`
+	Channels []string `json:"channels" validate:"max=4"`
+}
+
+type NotificationController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	service   notification.NotificationService
+}
+
+func NewNotificationController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service notification.NotificationService,
+) *NotificationController {
+	return &NotificationController{
+		logger:    logger,
+		validator: validator,
+		service:   service,
+	}
+}
+
+func (c *NotificationController) Settings() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		settings, err := c.service.GetSettings(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching notification settings")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, settings)
+	}
+}
+
+// SetSettings replaces the channels the business notifies its customers through.
+func (c *NotificationController) SetSettings() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request NotificationSettingsRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		settings := &notification.Settings{
+			BusinessID: businessID,
+			Channels:   nonNil(request.Channels),
+		}
+
+		if err := c.service.SetSettings(ctx, settings); err != nil {
+			c.fail(ctx, err, "Error storing notification settings")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, settings)
+	}
+}
+
+func (c *NotificationController) SetPreference() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		var request NotificationPreferenceRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		preference := &notification.Preference{
+			BusinessID:  businessID,
+			CustomerKey: request.Customer,
+			Channels:    nonNil(request.Channels),
+		}
+
+		if err := c.service.SetPreference(ctx, preference); err != nil {
+			c.fail(ctx, err, "Error storing notification preference")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, preference)
+	}
+}
+
+// Deliveries lists every attempt to notify the customer of the booking, the latest first.
+func (c *NotificationController) Deliveries() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		deliveries, err := c.service.GetBookingDeliveries(ctx, businessID, ctx.Param("bookingId"))
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching notification deliveries")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, deliveries)
+	}
+}
+
+// fail maps the domain errors of the notification package to their status and logs the rest.
+func (c *NotificationController) fail(ctx *gin.Context, err error, message string) {
+	switch {
+	case eris.Is(err, booking.BookingNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Booking not found"))
+	case eris.Is(err, notification.InvalidChannel):
+		ctx.JSON(http.StatusUnprocessableEntity, NewErrorResponse(http.StatusUnprocessableEntity, err.Error()))
+	default:
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		c.logger.Error(message, "trace_id", traceID, "error", eris.ToString(err, true))
+
+		ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+	}
+}
+
+// nonNil stores an empty list of channels as such, not as NULL.
+func nonNil(channels []string) []string {
+	if channels == nil {
+		return []string{}
+	}
+
+	return channels
+}
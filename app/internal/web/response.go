@@ -0,0 +1,72 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorResponse is the body of every failed API request. Fields carries the failed validation
+// rule of each invalid request field.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+func NewErrorResponse(status int, message string) ErrorResponse {
+	return ErrorResponse{
+		Error: ErrorDetail{
+			Code:    strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_")),
+			Message: message,
+		},
+	}
+}
+
+// ErrorResponseFor builds the error of a status that needs no further explanation.
+func ErrorResponseFor(status int) ErrorResponse {
+	return NewErrorResponse(status, http.StatusText(status))
+}
+
+func ValidationErrorResponse(err error) ErrorResponse {
+	response := NewErrorResponse(http.StatusBadRequest, "The request is not valid")
+
+	var validationErrors validator.ValidationErrors
+
+	if errors.As(err, &validationErrors) {
+		response.Error.Fields = make(map[string]string, len(validationErrors))
+
+		for _, fieldErr := range validationErrors {
+			// The namespace starts with the request struct name, e.g. ServiceRequest.price
+			_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+
+			response.Error.Fields[field] = fieldErr.Tag()
+		}
+	}
+
+	return response
+}
+
+// bindJSON decodes and validates the JSON body, answering the request itself when it is not valid.
+func bindJSON(ctx *gin.Context, validate *validator.Validate, request any) bool {
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse(http.StatusBadRequest, "The request body is not valid JSON"))
+
+		return false
+	}
+
+	if err := validate.Struct(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, ValidationErrorResponse(err))
+
+		return false
+	}
+
+	return true
+}
@@ -1,24 +1,40 @@
 package web
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/conversation"
 	"github.com/adriein/hastypal/internal/telegram"
+	"github.com/adriein/hastypal/pkg/constants"
 	"github.com/adriein/hastypal/pkg/middleware"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/rotisserie/eris"
 )
 
 type TelegramController struct {
-	logger  *slog.Logger
-	service telegram.TelegramService
+	logger    *slog.Logger
+	validator *validator.Validate
+	service   telegram.TelegramService
+	secret    string
 }
 
-func NewTelegramController(logger *slog.Logger, service telegram.TelegramService) *TelegramController {
+func NewTelegramController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service telegram.TelegramService,
+	secret string,
+) *TelegramController {
 	return &TelegramController{
-		logger:  logger,
-		service: service,
+		logger:    logger,
+		validator: validator,
+		service:   service,
+		secret:    secret,
 	}
 }
 
@@ -26,6 +42,12 @@ func (c *TelegramController) Post() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		traceID := ctx.Value(middleware.TraceIDKey)
 
+		if err := telegram.VerifySecretToken(ctx.GetHeader(telegram.SecretTokenHeader), c.secret); err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponseFor(http.StatusUnauthorized))
+
+			return
+		}
+
 		var update telegram.TelegramUpdate
 
 		if err := ctx.ShouldBindJSON(&update); err != nil {
@@ -47,3 +69,139 @@ func (c *TelegramController) Post() gin.HandlerFunc {
 		ctx.JSON(http.StatusOK, gin.H{})
 	}
 }
+
+type LinkCodeResponse struct {
+	*telegram.ChatLinkCode
+	Command string `json:"command"`
+}
+
+// LinkCode generates the one-time code to send with /link from the chat that has to receive the
+// notifications of the business.
+func (c *TelegramController) LinkCode() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		code, err := c.service.CreateLinkCode(ctx, claims.BusinessID, claims.UserID)
+
+		if err != nil {
+			c.logger.Error("Error creating chat link code", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, LinkCodeResponse{
+			ChatLinkCode: code,
+			Command:      fmt.Sprintf("%s %s", constants.LinkCommand, code.Code),
+		})
+	}
+}
+
+func (c *TelegramController) Chats() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		chats, err := c.service.GetChats(ctx, claims.BusinessID)
+
+		if err != nil {
+			c.logger.Error("Error fetching business chats", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, chats)
+	}
+}
+
+func (c *TelegramController) Unlink() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		chatID, err := strconv.Atoi(ctx.Param("chatId"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		if err := c.service.UnlinkChat(ctx, claims.BusinessID, chatID); err != nil {
+			if eris.Is(err, telegram.ChatNotLinked) {
+				ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Chat not found"))
+
+				return
+			}
+
+			c.logger.Error("Error unlinking business chat", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+type StartLinkRequest struct {
+	ServiceID  int    `json:"serviceId" validate:"min=0"`
+	EmployeeID int    `json:"employeeId" validate:"min=0"`
+	Source     string `json:"source" validate:"omitempty,max=24"`
+}
+
+// StartLink builds a deep link to the bot that can preselect a service and an employee and
+// attributes the bookings it brings to a marketing source.
+func (c *TelegramController) StartLink() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		var request StartLinkRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		payload := conversation.StartPayload{
+			BusinessID: claims.BusinessID,
+			ServiceID:  request.ServiceID,
+			EmployeeID: request.EmployeeID,
+			Source:     request.Source,
+		}
+
+		link, err := c.service.StartLink(ctx, payload)
+
+		if err != nil {
+			startLinkFailed(ctx, c.logger, err)
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"url": link, "payload": payload})
+	}
+}
+
+// startLinkFailed answers the errors of building a start link, the same for every chat channel.
+func startLinkFailed(ctx *gin.Context, logger *slog.Logger, err error) {
+	traceID := ctx.Value(middleware.TraceIDKey)
+
+	switch {
+	case eris.Is(err, conversation.InvalidStartPayload):
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse(http.StatusBadRequest, "The source can only have lowercase letters, digits, _ and -"))
+	case eris.Is(err, conversation.StartPayloadTooLong):
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse(http.StatusBadRequest, "The link carries too much information, shorten the source"))
+	case eris.Is(err, business.ServiceNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Service not found"))
+	case eris.Is(err, business.EmployeeNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Employee not found"))
+	default:
+		logger.Error("Error building start link", "trace_id", traceID, "error", eris.ToString(err, true))
+
+		ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+	}
+}
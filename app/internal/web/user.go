@@ -0,0 +1,166 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type InvitationRequest struct {
+	Email      string `json:"email" validate:"required,email,max=60"`
+	Role       string `json:"role" validate:"required,oneof=owner manager staff"`
+	EmployeeID int    `json:"employeeId" validate:"min=0"`
+}
+
+type RoleRequest struct {
+	Role       string `json:"role" validate:"required,oneof=owner manager staff"`
+	EmployeeID int    `json:"employeeId" validate:"min=0"`
+}
+
+type UserController struct {
+	logger    *slog.Logger
+	validator *validator.Validate
+	service   auth.AuthService
+}
+
+func NewUserController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service auth.AuthService,
+) *UserController {
+	return &UserController{
+		logger:    logger,
+		validator: validator,
+		service:   service,
+	}
+}
+
+func (c *UserController) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		users, err := c.service.GetUsers(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching users")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, users)
+	}
+}
+
+func (c *UserController) Invitations() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, err := strconv.Atoi(ctx.Param("id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponseFor(http.StatusBadRequest))
+
+			return
+		}
+
+		invitations, err := c.service.GetInvitations(ctx, businessID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error fetching invitations")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, invitations)
+	}
+}
+
+func (c *UserController) Invite() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		var request InvitationRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		invitation, err := c.service.Invite(ctx, claims, request.Email, auth.Role(request.Role), request.EmployeeID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error inviting user")
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, invitation)
+	}
+}
+
+func (c *UserController) ChangeRole() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		var request RoleRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		user, err := c.service.ChangeRole(ctx, claims, ctx.Param("userId"), auth.Role(request.Role), request.EmployeeID)
+
+		if err != nil {
+			c.fail(ctx, err, "Error changing the role of the user")
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, user)
+	}
+}
+
+func (c *UserController) Remove() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		if err := c.service.RemoveUser(ctx, claims, ctx.Param("userId")); err != nil {
+			c.fail(ctx, err, "Error removing user")
+
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func (c *UserController) fail(ctx *gin.Context, err error, message string) {
+	switch {
+	case eris.Is(err, auth.UserNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "User not found"))
+	case eris.Is(err, business.EmployeeNotFound):
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(http.StatusNotFound, "Employee not found"))
+	case eris.Is(err, auth.RoleNotAllowed):
+		ctx.JSON(http.StatusForbidden, NewErrorResponse(http.StatusForbidden, "Your role cannot assign this role"))
+	case eris.Is(err, auth.UserAlreadyExists):
+		ctx.JSON(http.StatusConflict, NewErrorResponse(http.StatusConflict, "A user with this email already exists"))
+	case eris.Is(err, auth.LastOwner):
+		ctx.JSON(http.StatusConflict, NewErrorResponse(http.StatusConflict, "A business needs at least one owner"))
+	default:
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		c.logger.Error(message, "trace_id", traceID, "error", eris.ToString(err, true))
+
+		ctx.JSON(http.StatusInternalServerError, ErrorResponseFor(http.StatusInternalServerError))
+	}
+}
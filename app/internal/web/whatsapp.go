@@ -0,0 +1,134 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/adriein/hastypal/internal/auth"
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/whatsapp"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rotisserie/eris"
+)
+
+type WhatsappController struct {
+	logger      *slog.Logger
+	validator   *validator.Validate
+	service     whatsapp.WhatsappService
+	appSecret   string
+	verifyToken string
+}
+
+func NewWhatsappController(
+	logger *slog.Logger,
+	validator *validator.Validate,
+	service whatsapp.WhatsappService,
+	appSecret string,
+	verifyToken string,
+) *WhatsappController {
+	return &WhatsappController{
+		logger:      logger,
+		validator:   validator,
+		service:     service,
+		appSecret:   appSecret,
+		verifyToken: verifyToken,
+	}
+}
+
+// Verify answers the subscription check Meta makes when the webhook is configured in the app.
+func (c *WhatsappController) Verify() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		challenge, err := whatsapp.VerifySubscription(
+			ctx.Query("hub.mode"),
+			ctx.Query("hub.verify_token"),
+			ctx.Query("hub.challenge"),
+			c.verifyToken,
+		)
+
+		if err != nil {
+			ctx.JSON(http.StatusForbidden, ErrorResponseFor(http.StatusForbidden))
+
+			return
+		}
+
+		ctx.String(http.StatusOK, challenge)
+	}
+}
+
+func (c *WhatsappController) Post() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		traceID := ctx.Value(middleware.TraceIDKey)
+
+		// The signature covers the raw body, it has to be read before binding it.
+		body, err := io.ReadAll(ctx.Request.Body)
+
+		if err != nil {
+			c.logger.Error("Error reading whatsapp webhook body", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{})
+
+			return
+		}
+
+		if err := whatsapp.VerifySignature(body, ctx.GetHeader("X-Hub-Signature-256"), c.appSecret); err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponseFor(http.StatusUnauthorized))
+
+			return
+		}
+
+		var payload whatsapp.WebhookPayload
+
+		if err := json.Unmarshal(body, &payload); err != nil {
+			c.logger.Error("Error binding request to whatsapp webhook struct", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{})
+
+			return
+		}
+
+		if err := c.service.HandleWebhook(ctx, payload); err != nil {
+			c.logger.Error("Error handling whatsapp webhook", "trace_id", traceID, "error", eris.ToString(err, true))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{})
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+// StartLink builds a wa.me link that opens the booking conversation on the WhatsApp number, with
+// the same options as the Telegram deep links.
+func (c *WhatsappController) StartLink() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, _ := auth.ClaimsFrom(ctx)
+
+		var request StartLinkRequest
+
+		if !bindJSON(ctx, c.validator, &request) {
+			return
+		}
+
+		payload := conversation.StartPayload{
+			BusinessID: claims.BusinessID,
+			ServiceID:  request.ServiceID,
+			EmployeeID: request.EmployeeID,
+			Source:     request.Source,
+		}
+
+		link, err := c.service.StartLink(ctx, payload)
+
+		if err != nil {
+			startLinkFailed(ctx, c.logger, err)
+
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"url": link, "payload": payload})
+	}
+}
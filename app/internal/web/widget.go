@@ -0,0 +1,266 @@
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/adriein/hastypal/pkg/constants"
+	"github.com/adriein/hastypal/pkg/middleware"
+	"github.com/adriein/hastypal/ui/views"
+	"github.com/gin-gonic/gin"
+	"github.com/rotisserie/eris"
+)
+
+// WidgetChannel fits the booking conversation to a page, where the whole catalog and all the free
+// times of a day fit at once.
+var WidgetChannel = conversation.Channel{
+	Name:        booking.ChannelWeb,
+	MaxServices: 12,
+	DaysPerPage: constants.DaysPerPage,
+}
+
+// The customers of the web widget have no chat, their sessions and bookings go without one.
+const widgetChatID = 0
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{5,24}$`)
+
+// WidgetController serves the public booking page of the businesses, rendering the prompts of the
+// conversation engine as pages whose links are the actions of the choices.
+type WidgetController struct {
+	logger *slog.Logger
+	engine *conversation.Engine
+}
+
+func NewWidgetController(logger *slog.Logger, engine *conversation.Engine) *WidgetController {
+	return &WidgetController{
+		logger: logger,
+		engine: engine,
+	}
+}
+
+// Start opens the booking page of the business. It takes the same options as the start links in
+// the query, ?service=3&employee=2&source=web.
+func (c *WidgetController) Start() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		businessID, _ := strconv.Atoi(ctx.Param("businessId"))
+		serviceID, _ := strconv.Atoi(ctx.Query("service"))
+		employeeID, _ := strconv.Atoi(ctx.Query("employee"))
+
+		payload := conversation.StartPayload{
+			BusinessID: businessID,
+			ServiceID:  serviceID,
+			EmployeeID: employeeID,
+			Source:     ctx.Query("source"),
+		}
+
+		customer := conversation.Customer{Locale: acceptedLanguage(ctx)}
+
+		prompt, err := c.engine.Start(ctx, widgetChatID, customer, payload.Encode())
+
+		if err != nil {
+			c.fail(ctx, err, "Error starting the booking page")
+
+			return
+		}
+
+		status := http.StatusOK
+
+		if prompt.Step == conversation.StepInvalidLink {
+			status = http.StatusNotFound
+		}
+
+		c.render(ctx, status, prompt, nil)
+	}
+}
+
+// Step shows the step of the choice the customer picked, ?action= being the action of the choice.
+func (c *WidgetController) Step() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		prompt, err := c.engine.Handle(ctx, widgetChatID, conversation.Customer{}, ctx.Query("action"))
+
+		if err != nil {
+			c.fail(ctx, err, "Error moving the booking page to the next step")
+
+			return
+		}
+
+		if prompt == nil {
+			ctx.AbortWithStatus(http.StatusNotFound)
+
+			return
+		}
+
+		c.render(ctx, http.StatusOK, prompt, nil)
+	}
+}
+
+// Book registers the booking with the contact details of the confirmation form. Details that are
+// not valid show the confirmation again, the engine does not book without a name.
+func (c *WidgetController) Book() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		contact := &views.ContactForm{
+			Name:  strings.TrimSpace(ctx.PostForm("name")),
+			Phone: strings.TrimSpace(ctx.PostForm("phone")),
+			Email: strings.TrimSpace(ctx.PostForm("email")),
+		}
+
+		customer := conversation.Customer{Name: contact.Name, Phone: contact.Phone, Email: contact.Email}
+
+		if !validContact(contact) {
+			customer = conversation.Customer{}
+		}
+
+		prompt, err := c.engine.Handle(ctx, widgetChatID, customer, ctx.PostForm("action"))
+
+		if err != nil {
+			c.fail(ctx, err, "Error booking from the booking page")
+
+			return
+		}
+
+		if prompt == nil {
+			ctx.AbortWithStatus(http.StatusNotFound)
+
+			return
+		}
+
+		status := http.StatusOK
+
+		if prompt.Step == conversation.StepConfirmation {
+			contact.Error = prompt.Localizer.T("widget.invalid_contact", nil)
+			status = http.StatusUnprocessableEntity
+		}
+
+		c.render(ctx, status, prompt, contact)
+	}
+}
+
+// render lays out a prompt as a page: the options as a list, or a grid for the days and times,
+// and the confirm choice as the form that asks for the contact details.
+func (c *WidgetController) render(ctx *gin.Context, status int, prompt *conversation.Prompt, contact *views.ContactForm) {
+	l := prompt.Localizer
+
+	page := views.BookingWidget{
+		Lang:       l.Locale,
+		Title:      "Hastypal",
+		Lines:      make([]views.WidgetLine, 0, len(prompt.Lines)),
+		Grid:       prompt.Step == conversation.StepDates || prompt.Step == conversation.StepHours,
+		Options:    make([]views.WidgetChoice, 0),
+		Next:       make([]views.WidgetChoice, 0),
+		Navigation: make([]views.WidgetChoice, 0),
+	}
+
+	businessID := ctx.Param("businessId")
+
+	if prompt.Business != nil {
+		businessID = strconv.Itoa(prompt.Business.Id)
+		page.Business = prompt.Business.Name
+		page.Title = l.T("widget.title", translation.Params{"business": prompt.Business.Name})
+	}
+
+	for _, line := range prompt.Lines {
+		// The options show their details themselves.
+		if line.Listing {
+			continue
+		}
+
+		page.Lines = append(page.Lines, views.WidgetLine{
+			Emoji:  line.Emoji,
+			Label:  line.Label,
+			Text:   line.Text,
+			Strong: line.Strong,
+		})
+	}
+
+	for _, choice := range prompt.Choices {
+		link := views.WidgetChoice{
+			Label:    choice.Label,
+			Detail:   choice.Detail,
+			Href:     stepUrl(businessID, choice.Action),
+			Selected: choice.Selected,
+		}
+
+		switch {
+		case choice.Kind == conversation.ChoiceOption:
+			page.Options = append(page.Options, link)
+		case choice.Kind == conversation.ChoiceNavigation:
+			page.Navigation = append(page.Navigation, link)
+		case prompt.Step == conversation.StepConfirmation:
+			if contact == nil {
+				contact = &views.ContactForm{}
+			}
+
+			contact.Url = fmt.Sprintf("/book/%s/step", businessID)
+			contact.Action = choice.Action
+			contact.Instructions = l.T("widget.contact_instructions", nil)
+			contact.NameLabel = l.T("widget.name", nil)
+			contact.PhoneLabel = l.T("widget.phone", nil)
+			contact.EmailLabel = l.T("widget.email", nil)
+			contact.Submit = choice.Label
+
+			page.Contact = contact
+		default:
+			page.Next = append(page.Next, link)
+		}
+	}
+
+	if prompt.Step == conversation.StepExpired {
+		page.Next = append(page.Next, views.WidgetChoice{
+			Label: l.T("button.start_again", nil),
+			Href:  fmt.Sprintf("/book/%s", businessID),
+		})
+	}
+
+	ctx.HTML(status, "", views.Widget(page))
+}
+
+func (c *WidgetController) fail(ctx *gin.Context, err error, message string) {
+	traceID := ctx.Value(middleware.TraceIDKey)
+
+	c.logger.Error(message, "trace_id", traceID, "error", eris.ToString(err, true))
+
+	businessID, _ := strconv.Atoi(ctx.Param("businessId"))
+
+	l := c.engine.BusinessLocalizer(ctx, businessID, acceptedLanguage(ctx))
+
+	ctx.String(http.StatusInternalServerError, l.T("widget.error", nil))
+}
+
+func stepUrl(businessID string, action string) string {
+	return fmt.Sprintf("/book/%s/step?action=%s", businessID, url.QueryEscape(action))
+}
+
+// validContact asks for a name and a phone, the email is optional but has to be a plain address
+// when given, it is where the invite goes.
+func validContact(contact *views.ContactForm) bool {
+	if contact.Name == "" || utf8.RuneCountInString(contact.Name) > 100 || !phonePattern.MatchString(contact.Phone) {
+		return false
+	}
+
+	if contact.Email == "" {
+		return true
+	}
+
+	address, err := mail.ParseAddress(contact.Email)
+
+	return err == nil && address.Address == contact.Email && len(contact.Email) <= 255
+}
+
+// acceptedLanguage is the language the browser prefers the most, the catalog resolves it or falls
+// back to the language of the business.
+func acceptedLanguage(ctx *gin.Context) string {
+	language, _, _ := strings.Cut(ctx.GetHeader("Accept-Language"), ",")
+	language, _, _ = strings.Cut(language, ";")
+
+	return strings.TrimSpace(language)
+}
@@ -0,0 +1,101 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// The Graph API answers with this code when a free form message is sent to a user outside the
+// customer window.
+const reEngagementErrorCode = 131047
+
+var OutsideCustomerWindow = eris.New("The user is outside the customer window")
+
+type WhatsappApi interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// CloudApi sends messages from the business number through the Graph API, url is the versioned
+// Graph API root, e.g. https://graph.facebook.com/v21.0.
+type CloudApi struct {
+	url           string
+	token         string
+	phoneNumberID string
+	client        *http.Client
+}
+
+func NewCloudApi(url string, token string, phoneNumberID string) *CloudApi {
+	return &CloudApi{
+		url:           url,
+		token:         token,
+		phoneNumberID: phoneNumberID,
+		client:        &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+type graphErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    int    `json:"code"`
+	} `json:"error"`
+}
+
+func (api *CloudApi) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+
+	if err != nil {
+		return eris.Wrap(err, "Error marshaling struct")
+	}
+
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/%s/messages", api.url, api.phoneNumberID),
+		bytes.NewBuffer(body),
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error creating http request")
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("Authorization", "Bearer "+api.token)
+
+	response, err := api.client.Do(request)
+
+	if err != nil {
+		return eris.Wrap(err, "Error performing http request")
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return eris.Wrap(err, "Error reading http body buffer")
+	}
+
+	var data graphErrorResponse
+
+	if err := json.Unmarshal(responseBody, &data); err != nil {
+		return eris.Errorf("Error sending whatsapp message, status: %d", response.StatusCode)
+	}
+
+	if data.Error.Code == reEngagementErrorCode {
+		return eris.Wrap(OutsideCustomerWindow, data.Error.Message)
+	}
+
+	return eris.Errorf("Error sending whatsapp message, code: %d, Description: %s", data.Error.Code, data.Error.Message)
+}
@@ -0,0 +1,120 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/rotisserie/eris"
+)
+
+// graphStub stands for the Graph API, it records the messages it gets and answers each one with
+// the next of the error codes it was given, or with success once they run out.
+type graphStub struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	paths    []string
+	tokens   []string
+	messages []Message
+	errors   []int
+}
+
+func newGraphStub(t *testing.T, errorCodes ...int) *graphStub {
+	t.Helper()
+
+	stub := &graphStub{errors: errorCodes}
+
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+
+		if err != nil {
+			t.Errorf("reading the request body: %v", err)
+		}
+
+		var message Message
+
+		if err := json.Unmarshal(body, &message); err != nil {
+			t.Errorf("the request body is not a message: %v", err)
+		}
+
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+
+		stub.paths = append(stub.paths, r.Method+" "+r.URL.Path)
+		stub.tokens = append(stub.tokens, r.Header.Get("Authorization"))
+		stub.messages = append(stub.messages, message)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if len(stub.errors) > 0 {
+			code := stub.errors[0]
+			stub.errors = stub.errors[1:]
+
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"Re-engagement message","type":"OAuthException","code":` + strconv.Itoa(code) + `}}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+	}))
+
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+func (g *graphStub) api() *CloudApi {
+	return NewCloudApi(g.server.URL+"/v21.0", "graph-token", "1098765")
+}
+
+func (g *graphStub) sent() []Message {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]Message(nil), g.messages...)
+}
+
+func TestCloudApiPostsToThePhoneNumber(t *testing.T) {
+	stub := newGraphStub(t)
+
+	if err := stub.api().Send(context.Background(), NewTextMessage("34600111222", "Hola")); err != nil {
+		t.Fatalf("sending the message: %v", err)
+	}
+
+	if len(stub.paths) != 1 || stub.paths[0] != "POST /v21.0/1098765/messages" {
+		t.Errorf("requests %v, want one POST to /v21.0/1098765/messages", stub.paths)
+	}
+
+	if stub.tokens[0] != "Bearer graph-token" {
+		t.Errorf("authorization %q, want the bearer token", stub.tokens[0])
+	}
+
+	message := stub.sent()[0]
+
+	if message.MessagingProduct != "whatsapp" || message.To != "34600111222" || message.Text.Body != "Hola" {
+		t.Errorf("unexpected message %+v", message)
+	}
+}
+
+func TestCloudApiTellsTheClosedCustomerWindow(t *testing.T) {
+	stub := newGraphStub(t, reEngagementErrorCode, 100)
+
+	err := stub.api().Send(context.Background(), NewTextMessage("34600111222", "Hola"))
+
+	if !eris.Is(err, OutsideCustomerWindow) {
+		t.Errorf("got %v, want OutsideCustomerWindow", err)
+	}
+
+	err = stub.api().Send(context.Background(), NewTextMessage("34600111222", "Hola"))
+
+	if err == nil || eris.Is(err, OutsideCustomerWindow) {
+		t.Errorf("got %v, want any other error", err)
+	}
+}
@@ -0,0 +1,98 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+var ContactNotFound = eris.New("WhatsApp contact not found")
+
+type ContactRepository interface {
+	Touch(ctx context.Context, contact *Contact) error
+	GetByWaID(ctx context.Context, waID string) (*Contact, error)
+}
+
+type PgContactRepository struct {
+	connection *sql.DB
+}
+
+func NewPgContactRepository(connection *sql.DB) *PgContactRepository {
+	return &PgContactRepository{
+		connection: connection,
+	}
+}
+
+// Touch records the last message of the contact, keeping the name it had when the new message
+// comes without one.
+func (r *PgContactRepository) Touch(ctx context.Context, contact *Contact) error {
+	query := `
+		INSERT INTO ha_whatsapp_contacts (
+			hawc_wa_id,
+			hawc_name,
+			hawc_last_inbound,
+			hawc_date_add
+		)
+		VALUES ($1, NULLIF($2, ''), $3, $4)
+		ON CONFLICT (hawc_wa_id) DO UPDATE SET
+			hawc_name = COALESCE(EXCLUDED.hawc_name, ha_whatsapp_contacts.hawc_name),
+			hawc_last_inbound = GREATEST(EXCLUDED.hawc_last_inbound, ha_whatsapp_contacts.hawc_last_inbound);
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	_, err := r.connection.ExecContext(
+		ctxTimeout,
+		query,
+		contact.WaId,
+		contact.Name,
+		contact.LastInbound,
+		contact.DateAdd,
+	)
+
+	if err != nil {
+		return eris.Wrap(err, "Error saving whatsapp contact")
+	}
+
+	return nil
+}
+
+func (r *PgContactRepository) GetByWaID(ctx context.Context, waID string) (*Contact, error) {
+	query := `
+		SELECT
+			hawc_wa_id,
+			COALESCE(hawc_name, ''),
+			hawc_last_inbound,
+			hawc_date_add
+		FROM
+			ha_whatsapp_contacts
+		WHERE
+			hawc_wa_id = $1;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var contact Contact
+
+	err := r.connection.QueryRowContext(ctxTimeout, query, waID).Scan(
+		&contact.WaId,
+		&contact.Name,
+		&contact.LastInbound,
+		&contact.DateAdd,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ContactNotFound
+		}
+
+		return nil, eris.Wrap(err, "Failed to query whatsapp contact")
+	}
+
+	return &contact, nil
+}
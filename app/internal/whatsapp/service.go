@@ -0,0 +1,455 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/notification"
+	"github.com/adriein/hastypal/internal/translation"
+	"github.com/rotisserie/eris"
+)
+
+type WhatsappService interface {
+	HandleWebhook(ctx context.Context, payload WebhookPayload) error
+	StartLink(ctx context.Context, payload conversation.StartPayload) (string, error)
+	SendReminder(ctx context.Context, bookingID string) error
+	notification.ChannelSender
+}
+
+// ReminderTemplate is the template that reminds a customer outside the customer window of their
+// booking. It has to be approved in the WhatsApp Business account in every language of the bot,
+// with four parameters in its body: the customer, the business, the date and the hour.
+const ReminderTemplate = "booking_reminder"
+
+// ConversationChannel pages the booking conversation so the rows and the navigation fit the ten
+// rows of a list.
+var ConversationChannel = conversation.Channel{
+	Name:         booking.ChannelWhatsapp,
+	MaxServices:  8,
+	DaysPerPage:  7,
+	HoursPerPage: 7,
+}
+
+// Service runs the booking conversation on WhatsApp. The customer is told apart by their WhatsApp
+// ID, which is their phone number and takes the place of the Telegram chat in the sessions.
+type Service struct {
+	logger   *slog.Logger
+	business business.BusinessService
+	booking  booking.BookingService
+	lang     translation.TranslationService
+	contacts ContactRepository
+	api      WhatsappApi
+	engine   *conversation.Engine
+	number   string
+}
+
+func NewService(
+	logger *slog.Logger,
+	business business.BusinessService,
+	booking booking.BookingService,
+	lang translation.TranslationService,
+	contacts ContactRepository,
+	api WhatsappApi,
+	engine *conversation.Engine,
+	number string,
+) *Service {
+	return &Service{
+		logger:   logger,
+		business: business,
+		booking:  booking,
+		lang:     lang,
+		contacts: contacts,
+		api:      api,
+		engine:   engine,
+		number:   number,
+	}
+}
+
+/*
+================================================================================
+WHATSAPP WEBHOOK HANDLER
+================================================================================
+*/
+
+func (s *Service) HandleWebhook(ctx context.Context, payload WebhookPayload) error {
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			if change.Field != "messages" {
+				continue
+			}
+
+			names := make(map[string]string, len(change.Value.Contacts))
+
+			for _, contact := range change.Value.Contacts {
+				names[contact.WaId] = contact.Profile.Name
+			}
+
+			for _, message := range change.Value.Messages {
+				if err := s.handleMessage(ctx, message, names[message.From]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) handleMessage(ctx context.Context, message InboundMessage, name string) error {
+	contact := &Contact{
+		WaId:        message.From,
+		Name:        name,
+		LastInbound: messageTime(message.Timestamp),
+		DateAdd:     time.Now().UTC(),
+	}
+
+	if err := s.contacts.Touch(ctx, contact); err != nil {
+		return eris.Wrap(err, "Error recording the whatsapp contact")
+	}
+
+	chatID, err := strconv.Atoi(message.From)
+
+	if err != nil {
+		return eris.Wrapf(err, "Unexpected WhatsApp ID %s", message.From)
+	}
+
+	customer := conversation.Customer{Name: name}
+
+	switch {
+	case message.Type == "text" && message.Text != nil:
+		// The start text of the links ends with the start payload.
+		rawPayload := ""
+
+		if fields := strings.Fields(message.Text.Body); len(fields) > 0 {
+			rawPayload = fields[len(fields)-1]
+		}
+
+		prompt, err := s.engine.Start(ctx, chatID, customer, rawPayload)
+
+		if err != nil {
+			return err
+		}
+
+		return s.sendPrompt(ctx, prompt)
+	case message.Type == "interactive" && message.Interactive != nil:
+		reply := message.Interactive.ListReply
+
+		if reply == nil {
+			reply = message.Interactive.ButtonReply
+		}
+
+		if reply == nil {
+			return nil
+		}
+
+		// The ids of the rows and buttons are the actions of the choices of the engine.
+		prompt, err := s.engine.Handle(ctx, chatID, customer, reply.Id)
+
+		if err != nil || prompt == nil {
+			return err
+		}
+
+		return s.sendPrompt(ctx, prompt)
+	}
+
+	// Images, locations or reactions have no place in the booking conversation.
+	return nil
+}
+
+/*
+================================================================================
+WHATSAPP BOOKING CONVERSATION
+================================================================================
+*/
+
+// sendPrompt renders a step of the booking conversation. The choices go in a list, or as reply
+// buttons when they are a few and none of them is an option, and the closing steps are plain texts.
+func (s *Service) sendPrompt(ctx context.Context, prompt *conversation.Prompt) error {
+	to := strconv.Itoa(prompt.ChatID)
+	l := prompt.Localizer
+
+	header := ""
+
+	if prompt.Business != nil {
+		header = prompt.Business.Name
+	}
+
+	text := promptText(prompt)
+
+	// There are no link buttons in a chat, the customer gets the start link in the text.
+	if prompt.Step == conversation.StepExpired && prompt.Business != nil {
+		text = paragraphs(text, s.startUrl(prompt.Business, conversation.StartPayload{BusinessID: prompt.Business.Id}))
+	}
+
+	options := prompt.Options(conversation.ChoiceOption)
+
+	switch {
+	case len(prompt.Choices) == 0:
+		return s.send(ctx, NewTextMessage(to, headed(header, text)))
+	case len(options) == 0 && len(prompt.Choices) <= maxButtons:
+		replies := make([]Reply, len(prompt.Choices))
+
+		for i, choice := range prompt.Choices {
+			replies[i] = Reply{Id: choice.Action, Title: choice.Label}
+		}
+
+		return s.send(ctx, NewButtonMessage(to, header, text, replies...))
+	}
+
+	// The way forward and the navigation always make it into the list, the options fill the rest.
+	next := prompt.Options(conversation.ChoiceNext)
+	navigation := prompt.Options(conversation.ChoiceNavigation)
+
+	options = options[:min(len(options), max(maxListRows-len(next)-len(navigation), 0))]
+
+	rows := make([]Row, 0, maxListRows)
+
+	for _, choice := range append(append(next, options...), navigation...) {
+		title := choice.Label
+
+		if choice.Selected {
+			title = "✅ " + title
+		}
+
+		rows = append(rows, Row{Id: choice.Action, Title: title, Description: choice.Detail})
+	}
+
+	return s.send(ctx, NewListMessage(to, header, text, l.T("button.see_options", nil), Section{Rows: rows}))
+}
+
+// promptText writes the lines of a prompt with the formatting of WhatsApp. The listing lines are
+// left out, the rows of the list already show the details of the choices.
+func promptText(prompt *conversation.Prompt) string {
+	parts := make([]string, 0, len(prompt.Lines))
+
+	for _, line := range prompt.Lines {
+		if line.Listing {
+			continue
+		}
+
+		var text string
+
+		switch {
+		case line.Strong:
+			text = bold(line.Text)
+		case line.Label != "":
+			text = bold(line.Label) + " " + line.Text
+		default:
+			text = line.Text
+		}
+
+		if line.Emoji != "" {
+			text = line.Emoji + " " + text
+		}
+
+		parts = append(parts, text)
+	}
+
+	return paragraphs(parts...)
+}
+
+// StartLink builds a wa.me link that opens a chat with the business number with the start text
+// already written, so the customer only has to send it.
+func (s *Service) StartLink(ctx context.Context, payload conversation.StartPayload) (string, error) {
+	if err := s.engine.CheckStartLink(ctx, payload); err != nil {
+		return "", err
+	}
+
+	owner, err := s.business.GetBusinessByID(ctx, payload.BusinessID)
+
+	if err != nil {
+		return "", eris.Wrap(err, "Error fetching business")
+	}
+
+	return s.startUrl(owner, payload), nil
+}
+
+func (s *Service) startUrl(owner *business.Business, payload conversation.StartPayload) string {
+	l := s.localizer(owner.Lang)
+
+	text := l.T("conversation.start_text", nil) + " " + payload.Encode()
+
+	// wa.me takes the spaces of the text as %20 and not as the + of a query string.
+	return fmt.Sprintf("https://wa.me/%s?text=%s", s.number, strings.ReplaceAll(url.QueryEscape(text), "+", "%20"))
+}
+
+/*
+================================================================================
+WHATSAPP REMINDERS
+================================================================================
+*/
+
+// SendReminder reminds the customer of a booking made on WhatsApp. Within the customer window it
+// is a free form message with the reminder text of the business, outside it the reminder template.
+func (s *Service) SendReminder(ctx context.Context, bookingID string) error {
+	found, err := s.booking.GetBooking(ctx, bookingID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching booking")
+	}
+
+	if found.Channel != booking.ChannelWhatsapp || found.IsCancelled() {
+		return nil
+	}
+
+	owner, err := s.business.GetBusinessByID(ctx, found.BusinessID)
+
+	if err != nil {
+		return eris.Wrap(err, "Error fetching business")
+	}
+
+	location, err := time.LoadLocation("Europe/Madrid")
+
+	if err != nil {
+		return eris.Wrap(err, "Error loading time location")
+	}
+
+	localDate := found.Date.In(location)
+
+	l := s.localizer(found.Locale, owner.Lang)
+
+	to := strconv.Itoa(found.ChatID)
+	date := l.Date(localDate)
+	hour := l.Time(localDate)
+
+	contact, err := s.contacts.GetByWaID(ctx, to)
+
+	if err != nil && !eris.Is(err, ContactNotFound) {
+		return eris.Wrap(err, "Error fetching the whatsapp contact")
+	}
+
+	if contact != nil && contact.InCustomerWindow(time.Now()) {
+		data := business.TemplateData{
+			Customer: found.CustomerName,
+			Business: owner.Name,
+			Service:  strings.Join(found.ServiceNames(), " + "),
+			Date:     date,
+			Hour:     hour,
+		}
+
+		text, err := s.engine.BusinessText(ctx, l, owner.Id, business.TemplateReminder, data)
+
+		if err != nil {
+			return err
+		}
+
+		err = s.api.Send(ctx, NewTextMessage(to, headed(owner.Name, "⏰ "+text)))
+
+		// The window may have closed since the contact was last seen, the template still gets through.
+		if !eris.Is(err, OutsideCustomerWindow) {
+			return s.wrapSendError(err)
+		}
+	}
+
+	message := NewTemplateMessage(to, ReminderTemplate, l.Locale, found.CustomerName, owner.Name, date, hour)
+
+	return s.send(ctx, message)
+}
+
+/*
+================================================================================
+WHATSAPP CUSTOMER NOTIFICATIONS
+================================================================================
+*/
+
+// NotificationAddress reaches the customers who booked from WhatsApp, whose chat is their number.
+func (s *Service) NotificationAddress(recipient notification.Recipient) string {
+	if recipient.Channel != booking.ChannelWhatsapp || recipient.ChatID == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(recipient.ChatID)
+}
+
+// SendNotification tells the customer about their booking. The reminder has a template to reach
+// them outside the customer window, the rest of the notifications cannot reach them then.
+func (s *Service) SendNotification(ctx context.Context, message *notification.Message) error {
+	if message.Notification.Type == notification.TypeReminder {
+		return s.SendReminder(ctx, message.Booking.ID)
+	}
+
+	contact, err := s.contacts.GetByWaID(ctx, message.Address)
+
+	if err != nil && !eris.Is(err, ContactNotFound) {
+		return eris.Wrap(err, "Error fetching the whatsapp contact")
+	}
+
+	if contact == nil || !contact.InCustomerWindow(time.Now()) {
+		return eris.Wrap(notification.Undeliverable, "The customer is outside the customer window")
+	}
+
+	err = s.api.Send(ctx, NewTextMessage(message.Address, headed(message.Business.Name, message.Text)))
+
+	if eris.Is(err, OutsideCustomerWindow) {
+		return eris.Wrap(notification.Undeliverable, err.Error())
+	}
+
+	return s.wrapSendError(err)
+}
+
+/*
+================================================================================
+WHATSAPP HELPERS
+================================================================================
+*/
+
+func (s *Service) localizer(candidates ...string) translation.Localizer {
+	return translation.NewLocalizer(s.lang, candidates...)
+}
+
+func (s *Service) send(ctx context.Context, message Message) error {
+	return s.wrapSendError(s.api.Send(ctx, message))
+}
+
+func (s *Service) wrapSendError(err error) error {
+	if err != nil {
+		return eris.Wrap(err, "Error sending whatsapp message")
+	}
+
+	return nil
+}
+
+// messageTime reads the unix timestamp of a message, a message without one counts as sent now.
+func messageTime(timestamp string) time.Time {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return time.Now().UTC()
+	}
+
+	return time.Unix(seconds, 0).UTC()
+}
+
+func bold(text string) string {
+	return "*" + text + "*"
+}
+
+func paragraphs(parts ...string) string {
+	filtered := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		if part != "" {
+			filtered = append(filtered, part)
+		}
+	}
+
+	return strings.Join(filtered, "\n\n")
+}
+
+// headed puts the name of the business on top of a text message, the interactive ones show it in
+// their header. Messages sent before a business is known go without it.
+func headed(businessName string, text string) string {
+	if businessName == "" {
+		return text
+	}
+
+	return paragraphs(bold(businessName), text)
+}
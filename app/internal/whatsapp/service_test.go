@@ -0,0 +1,313 @@
+package whatsapp
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adriein/hastypal/internal/booking"
+	"github.com/adriein/hastypal/internal/business"
+	"github.com/adriein/hastypal/internal/calendar"
+	"github.com/adriein/hastypal/internal/conversation"
+	"github.com/adriein/hastypal/internal/customer"
+	"github.com/adriein/hastypal/internal/translation"
+)
+
+// The fakes embed the interfaces they stand for, a test calling any other method panics.
+
+type fakeBusinessService struct {
+	business.BusinessService
+	found    *business.Business
+	services []*business.ServiceCatalog
+}
+
+func (f *fakeBusinessService) GetBusinessByID(_ context.Context, ID int) (*business.Business, error) {
+	if f.found == nil || f.found.Id != ID {
+		return nil, business.BusinessNotFound
+	}
+
+	return f.found, nil
+}
+
+func (f *fakeBusinessService) GetServices(_ context.Context, _ int) ([]*business.ServiceCatalog, error) {
+	return f.services, nil
+}
+
+func (f *fakeBusinessService) RenderMessage(
+	_ context.Context,
+	_ int,
+	_ string,
+	_ business.TemplateData,
+) (string, bool, error) {
+	return "", false, nil
+}
+
+type fakeBookingService struct {
+	booking.BookingService
+	session *booking.Session
+	found   *booking.Booking
+}
+
+func (f *fakeBookingService) InitSession(_ context.Context, businessID int, chatID int, origin booking.Origin) (string, error) {
+	f.session = &booking.Session{
+		Id:         "5f1c",
+		BusinessId: businessID,
+		ChatId:     chatID,
+		Channel:    origin.Channel,
+		DateUpd:    time.Now().UTC(),
+	}
+
+	return f.session.Id, nil
+}
+
+func (f *fakeBookingService) GetCurrentSession(_ context.Context, _ string) (*booking.Session, error) {
+	return f.session, nil
+}
+
+func (f *fakeBookingService) GetBooking(_ context.Context, _ string) (*booking.Booking, error) {
+	return f.found, nil
+}
+
+type fakeCustomerService struct {
+	customer.CustomerService
+}
+
+type fakeCalendarService struct {
+	calendar.CalendarService
+}
+
+type fakeContacts struct {
+	contacts map[string]*Contact
+}
+
+func (f *fakeContacts) Touch(_ context.Context, contact *Contact) error {
+	f.contacts[contact.WaId] = contact
+
+	return nil
+}
+
+func (f *fakeContacts) GetByWaID(_ context.Context, waID string) (*Contact, error) {
+	if contact, ok := f.contacts[waID]; ok {
+		return contact, nil
+	}
+
+	return nil, ContactNotFound
+}
+
+func newTestService(t *testing.T, stub *graphStub) (*Service, *fakeBookingService, *fakeContacts) {
+	t.Helper()
+
+	businesses := &fakeBusinessService{
+		found: &business.Business{Id: 7, Name: "Barbería Pérez", Lang: "es"},
+		services: []*business.ServiceCatalog{
+			{Id: 1, Name: "Corte", Price: 1850, Currency: "EUR", Duration: 30},
+			{Id: 2, Name: "Barba", Price: 900, Currency: "EUR", Duration: 15},
+		},
+	}
+	bookings := &fakeBookingService{}
+	contacts := &fakeContacts{contacts: map[string]*Contact{}}
+
+	logger := slog.New(slog.DiscardHandler)
+	lang := translation.NewService()
+
+	service := NewService(
+		logger,
+		businesses,
+		bookings,
+		lang,
+		contacts,
+		stub.api(),
+		conversation.NewEngine(
+			logger,
+			businesses,
+			bookings,
+			&fakeCalendarService{},
+			&fakeCustomerService{},
+			lang,
+			ConversationChannel,
+		),
+		"34930000000",
+	)
+
+	return service, bookings, contacts
+}
+
+func textPayload(from string, name string, text string) WebhookPayload {
+	return WebhookPayload{
+		Object: "whatsapp_business_account",
+		Entry: []WebhookEntry{{
+			Id: "1098765",
+			Changes: []WebhookChange{{
+				Field: "messages",
+				Value: WebhookValue{
+					MessagingProduct: "whatsapp",
+					Contacts:         []WebhookContact{{WaId: from, Profile: WebhookProfile{Name: name}}},
+					Messages: []InboundMessage{{
+						From:      from,
+						Id:        "wamid.in",
+						Timestamp: "1767261600",
+						Type:      "text",
+						Text:      &TextBody{Body: text},
+					}},
+				},
+			}},
+		}},
+	}
+}
+
+func TestWebhookAnswersAnUnknownLink(t *testing.T) {
+	stub := newGraphStub(t)
+	service, _, contacts := newTestService(t, stub)
+
+	if err := service.HandleWebhook(context.Background(), textPayload("34600111222", "Ana", "hola")); err != nil {
+		t.Fatalf("handling the webhook: %v", err)
+	}
+
+	contact := contacts.contacts["34600111222"]
+
+	if contact == nil || contact.Name != "Ana" || !contact.LastInbound.Equal(time.Unix(1767261600, 0)) {
+		t.Errorf("the contact was not recorded, got %+v", contact)
+	}
+
+	sent := stub.sent()
+
+	if len(sent) != 1 || sent[0].Type != "text" || !strings.Contains(sent[0].Text.Body, "No reconozco este enlace.") {
+		t.Errorf("got %+v, want the invalid link text", sent)
+	}
+}
+
+func TestWebhookStartsTheConversationFromTheStartText(t *testing.T) {
+	stub := newGraphStub(t)
+	service, bookings, _ := newTestService(t, stub)
+
+	start := "Quiero reservar " + conversation.StartPayload{BusinessID: 7, Source: "instagram"}.Encode()
+
+	if err := service.HandleWebhook(context.Background(), textPayload("34600111222", "Ana", start)); err != nil {
+		t.Fatalf("handling the webhook: %v", err)
+	}
+
+	if bookings.session == nil || bookings.session.Channel != booking.ChannelWhatsapp || bookings.session.ChatId != 34600111222 {
+		t.Fatalf("got session %+v, want a whatsapp session for the number", bookings.session)
+	}
+
+	sent := stub.sent()
+
+	if len(sent) != 1 || sent[0].Interactive == nil || sent[0].Interactive.Type != "list" {
+		t.Fatalf("got %+v, want the list of services", sent)
+	}
+
+	interactive := sent[0].Interactive
+
+	if !strings.Contains(interactive.Body.Text, "Ana") || interactive.Header.Text != "Barbería Pérez" {
+		t.Errorf("the welcome is missing, got %+v", interactive)
+	}
+
+	serviceRows := interactive.Action.Sections[0].Rows
+
+	if len(serviceRows) != 2 || serviceRows[0].Id != "/service?session=5f1c&category=&toggle=1" {
+		t.Errorf("unexpected rows %+v", serviceRows)
+	}
+}
+
+func TestStartLinkWritesTheStartText(t *testing.T) {
+	service, _, _ := newTestService(t, newGraphStub(t))
+
+	link, err := service.StartLink(context.Background(), conversation.StartPayload{BusinessID: 7, ServiceID: 2})
+
+	if err != nil {
+		t.Fatalf("building the link: %v", err)
+	}
+
+	parsed, err := url.Parse(link)
+
+	if err != nil || parsed.Host != "wa.me" || parsed.Path != "/34930000000" {
+		t.Fatalf("got %q, want a wa.me link to the number", link)
+	}
+
+	if strings.Contains(parsed.RawQuery, "+") {
+		t.Errorf("spaces have to go as %%20, got %q", parsed.RawQuery)
+	}
+
+	fields := strings.Fields(parsed.Query().Get("text"))
+	payload, err := conversation.ParseStartPayload(fields[len(fields)-1])
+
+	if err != nil || payload.BusinessID != 7 || payload.ServiceID != 2 {
+		t.Errorf("got payload %+v, %v from %q", payload, err, link)
+	}
+}
+
+func TestReminderFallsBackToTheTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastInbound time.Duration
+		errors      []int
+		sent        []string
+	}{
+		{"inside the customer window", time.Hour, nil, []string{"text"}},
+		{"outside the customer window", 30 * time.Hour, nil, []string{"template"}},
+		{"window closed since the last message", time.Hour, []int{reEngagementErrorCode}, []string{"text", "template"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newGraphStub(t, test.errors...)
+			service, bookings, contacts := newTestService(t, stub)
+
+			bookings.found = &booking.Booking{
+				ID:           "b1",
+				BusinessID:   7,
+				ChatID:       34600111222,
+				CustomerName: "Ana",
+				Status:       booking.StatusConfirmed,
+				Channel:      booking.ChannelWhatsapp,
+				Items:        []booking.Item{{ServiceID: 1, Name: "Corte"}},
+				Date:         time.Date(2026, 3, 3, 9, 30, 0, 0, time.UTC),
+			}
+
+			contacts.contacts["34600111222"] = &Contact{WaId: "34600111222", LastInbound: time.Now().Add(-test.lastInbound)}
+
+			if err := service.SendReminder(context.Background(), "b1"); err != nil {
+				t.Fatalf("sending the reminder: %v", err)
+			}
+
+			sent := stub.sent()
+			types := make([]string, len(sent))
+
+			for i, message := range sent {
+				types[i] = message.Type
+			}
+
+			if strings.Join(types, ",") != strings.Join(test.sent, ",") {
+				t.Fatalf("sent %v, want %v", types, test.sent)
+			}
+
+			last := sent[len(sent)-1]
+
+			if last.Type == "template" {
+				parameters := last.Template.Components[0].Parameters
+
+				if last.Template.Name != ReminderTemplate || len(parameters) != 4 || parameters[3].Text != "10:30" {
+					t.Errorf("unexpected template %+v", last.Template)
+				}
+			}
+		})
+	}
+}
+
+func TestReminderSkipsTelegramBookings(t *testing.T) {
+	stub := newGraphStub(t)
+	service, bookings, _ := newTestService(t, stub)
+
+	bookings.found = &booking.Booking{ID: "b1", BusinessID: 7, ChatID: 991, Channel: booking.ChannelTelegram}
+
+	if err := service.SendReminder(context.Background(), "b1"); err != nil {
+		t.Fatalf("sending the reminder: %v", err)
+	}
+
+	if sent := stub.sent(); len(sent) != 0 {
+		t.Errorf("sent %+v to a telegram customer", sent)
+	}
+}
@@ -0,0 +1,57 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+var (
+	InvalidSignature   = eris.New("Invalid webhook signature")
+	InvalidVerifyToken = eris.New("Invalid webhook verify token")
+)
+
+const subscribeMode = "subscribe"
+
+// VerifySubscription answers the request Meta sends when the webhook is set up in the app, it
+// echoes the challenge back only when the token is the one configured for the app.
+func VerifySubscription(mode string, token string, challenge string, verifyToken string) (string, error) {
+	if mode != subscribeMode || verifyToken == "" {
+		return "", InvalidVerifyToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(verifyToken)) != 1 {
+		return "", InvalidVerifyToken
+	}
+
+	return challenge, nil
+}
+
+// VerifySignature checks the X-Hub-Signature-256 header, the HMAC-SHA256 of the raw body signed
+// with the app secret, so only Meta can post to the webhook.
+func VerifySignature(body []byte, header string, appSecret string) error {
+	signature, found := strings.CutPrefix(header, "sha256=")
+
+	if !found || appSecret == "" {
+		return InvalidSignature
+	}
+
+	expected, err := hex.DecodeString(signature)
+
+	if err != nil {
+		return InvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return InvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySubscription(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		token       string
+		verifyToken string
+		valid       bool
+	}{
+		{"matching token", "subscribe", "s3cret", "s3cret", true},
+		{"wrong token", "subscribe", "guess", "s3cret", false},
+		{"wrong mode", "unsubscribe", "s3cret", "s3cret", false},
+		{"no token configured", "subscribe", "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			challenge, err := VerifySubscription(test.mode, test.token, "1158201444", test.verifyToken)
+
+			if test.valid && (err != nil || challenge != "1158201444") {
+				t.Errorf("got %q, %v, want the challenge back", challenge, err)
+			}
+
+			if !test.valid && err != InvalidVerifyToken {
+				t.Errorf("got %v, want InvalidVerifyToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"object":"whatsapp_business_account","entry":[]}`)
+
+	tests := []struct {
+		name   string
+		header string
+		secret string
+		valid  bool
+	}{
+		{"signed with the app secret", sign(body, "app-secret"), "app-secret", true},
+		{"signed with another secret", sign(body, "other"), "app-secret", false},
+		{"signed another body", sign([]byte(`{}`), "app-secret"), "app-secret", false},
+		{"without prefix", sign(body, "app-secret")[len("sha256="):], "app-secret", false},
+		{"not hexadecimal", "sha256=zz", "app-secret", false},
+		{"missing header", "", "app-secret", false},
+		{"no secret configured", sign(body, ""), "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySignature(body, test.header, test.secret)
+
+			if test.valid && err != nil {
+				t.Errorf("got %v, want a valid signature", err)
+			}
+
+			if !test.valid && err != InvalidSignature {
+				t.Errorf("got %v, want InvalidSignature", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package whatsapp
+
+import (
+	"strings"
+	"time"
+)
+
+// Limits of the Cloud API for interactive messages, it rejects the messages that go over them.
+const (
+	maxListRows       = 10
+	maxButtons        = 3
+	maxHeaderLength   = 60
+	maxBodyLength     = 1024
+	maxRowTitle       = 24
+	maxRowDescription = 72
+	maxButtonTitle    = 20
+)
+
+// WhatsApp lets a business write free form messages to a user within 24 hours of the last message
+// the user sent, after that only approved template messages get through.
+const customerWindow = 24 * time.Hour
+
+const (
+	messagingProduct = "whatsapp"
+	recipientType    = "individual"
+)
+
+/*
+================================================================================
+WEBHOOK
+================================================================================
+*/
+
+// WebhookPayload is what Meta posts to the webhook, the messages of the users come in the
+// changes of the messages field.
+type WebhookPayload struct {
+	Object string         `json:"object"`
+	Entry  []WebhookEntry `json:"entry"`
+}
+
+type WebhookEntry struct {
+	Id      string          `json:"id"`
+	Changes []WebhookChange `json:"changes"`
+}
+
+type WebhookChange struct {
+	Field string       `json:"field"`
+	Value WebhookValue `json:"value"`
+}
+
+type WebhookValue struct {
+	MessagingProduct string           `json:"messaging_product"`
+	Metadata         WebhookMetadata  `json:"metadata"`
+	Contacts         []WebhookContact `json:"contacts"`
+	Messages         []InboundMessage `json:"messages"`
+}
+
+type WebhookMetadata struct {
+	DisplayPhoneNumber string `json:"display_phone_number"`
+	PhoneNumberId      string `json:"phone_number_id"`
+}
+
+type WebhookContact struct {
+	WaId    string         `json:"wa_id"`
+	Profile WebhookProfile `json:"profile"`
+}
+
+type WebhookProfile struct {
+	Name string `json:"name"`
+}
+
+// InboundMessage is a message a user sent to the business number. From is the WhatsApp ID of
+// the user, their phone number with the country code and without the plus sign.
+type InboundMessage struct {
+	From        string              `json:"from"`
+	Id          string              `json:"id"`
+	Timestamp   string              `json:"timestamp"`
+	Type        string              `json:"type"`
+	Text        *TextBody           `json:"text,omitempty"`
+	Interactive *InboundInteractive `json:"interactive,omitempty"`
+}
+
+// InboundInteractive is the row of a list or the button a user picked.
+type InboundInteractive struct {
+	Type        string `json:"type"`
+	ListReply   *Reply `json:"list_reply,omitempty"`
+	ButtonReply *Reply `json:"button_reply,omitempty"`
+}
+
+type Reply struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+/*
+================================================================================
+MESSAGES
+================================================================================
+*/
+
+// Message is what the Cloud API sends to a user: a text, an interactive list or buttons, or a
+// template approved beforehand for the users outside the customer window.
+type Message struct {
+	MessagingProduct string       `json:"messaging_product"`
+	RecipientType    string       `json:"recipient_type"`
+	To               string       `json:"to"`
+	Type             string       `json:"type"`
+	Text             *TextBody    `json:"text,omitempty"`
+	Interactive      *Interactive `json:"interactive,omitempty"`
+	Template         *Template    `json:"template,omitempty"`
+}
+
+type TextBody struct {
+	Body       string `json:"body"`
+	PreviewUrl bool   `json:"preview_url,omitempty"`
+}
+
+type Interactive struct {
+	Type   string            `json:"type"`
+	Header *InteractiveText  `json:"header,omitempty"`
+	Body   InteractiveText   `json:"body"`
+	Action InteractiveAction `json:"action"`
+}
+
+type InteractiveText struct {
+	Type string `json:"type,omitempty"`
+	Text string `json:"text"`
+}
+
+type InteractiveAction struct {
+	Button   string    `json:"button,omitempty"`
+	Sections []Section `json:"sections,omitempty"`
+	Buttons  []Button  `json:"buttons,omitempty"`
+}
+
+type Section struct {
+	Title string `json:"title,omitempty"`
+	Rows  []Row  `json:"rows"`
+}
+
+type Row struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+type Button struct {
+	Type  string `json:"type"`
+	Reply Reply  `json:"reply"`
+}
+
+type Template struct {
+	Name       string              `json:"name"`
+	Language   TemplateLanguage    `json:"language"`
+	Components []TemplateComponent `json:"components,omitempty"`
+}
+
+type TemplateLanguage struct {
+	Code string `json:"code"`
+}
+
+type TemplateComponent struct {
+	Type       string              `json:"type"`
+	Parameters []TemplateParameter `json:"parameters"`
+}
+
+type TemplateParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func NewTextMessage(to string, body string) Message {
+	return Message{
+		MessagingProduct: messagingProduct,
+		RecipientType:    recipientType,
+		To:               to,
+		Type:             "text",
+		Text:             &TextBody{Body: body},
+	}
+}
+
+// NewListMessage shows the rows behind a button that opens the list. Rows past the limit of the
+// Cloud API are left out and texts too long for it are cut.
+func NewListMessage(to string, header string, body string, button string, sections ...Section) Message {
+	trimmed := make([]Section, 0, len(sections))
+	remaining := maxListRows
+
+	for _, section := range sections {
+		rows := make([]Row, 0, len(section.Rows))
+
+		for _, row := range section.Rows[:min(len(section.Rows), remaining)] {
+			rows = append(rows, Row{
+				Id:          row.Id,
+				Title:       truncate(row.Title, maxRowTitle),
+				Description: truncate(row.Description, maxRowDescription),
+			})
+		}
+
+		remaining -= len(rows)
+
+		if len(rows) > 0 {
+			trimmed = append(trimmed, Section{Title: truncate(section.Title, maxRowTitle), Rows: rows})
+		}
+	}
+
+	return Message{
+		MessagingProduct: messagingProduct,
+		RecipientType:    recipientType,
+		To:               to,
+		Type:             "interactive",
+		Interactive: &Interactive{
+			Type:   "list",
+			Header: interactiveHeader(header),
+			Body:   InteractiveText{Text: truncate(body, maxBodyLength)},
+			Action: InteractiveAction{Button: truncate(button, maxButtonTitle), Sections: trimmed},
+		},
+	}
+}
+
+// NewButtonMessage shows up to three reply buttons under the body.
+func NewButtonMessage(to string, header string, body string, replies ...Reply) Message {
+	buttons := make([]Button, 0, maxButtons)
+
+	for _, reply := range replies[:min(len(replies), maxButtons)] {
+		buttons = append(buttons, Button{
+			Type:  "reply",
+			Reply: Reply{Id: reply.Id, Title: truncate(reply.Title, maxButtonTitle)},
+		})
+	}
+
+	return Message{
+		MessagingProduct: messagingProduct,
+		RecipientType:    recipientType,
+		To:               to,
+		Type:             "interactive",
+		Interactive: &Interactive{
+			Type:   "button",
+			Header: interactiveHeader(header),
+			Body:   InteractiveText{Text: truncate(body, maxBodyLength)},
+			Action: InteractiveAction{Buttons: buttons},
+		},
+	}
+}
+
+// NewTemplateMessage sends an approved template in the language with the parameters of its body
+// in order.
+func NewTemplateMessage(to string, name string, language string, parameters ...string) Message {
+	components := make([]TemplateComponent, 0, 1)
+
+	if len(parameters) > 0 {
+		body := TemplateComponent{Type: "body", Parameters: make([]TemplateParameter, len(parameters))}
+
+		for i, parameter := range parameters {
+			body.Parameters[i] = TemplateParameter{Type: "text", Text: parameter}
+		}
+
+		components = append(components, body)
+	}
+
+	return Message{
+		MessagingProduct: messagingProduct,
+		RecipientType:    recipientType,
+		To:               to,
+		Type:             "template",
+		Template: &Template{
+			Name:       name,
+			Language:   TemplateLanguage{Code: language},
+			Components: components,
+		},
+	}
+}
+
+func interactiveHeader(header string) *InteractiveText {
+	if header == "" {
+		return nil
+	}
+
+	return &InteractiveText{Type: "text", Text: truncate(header, maxHeaderLength)}
+}
+
+// truncate cuts the text to the length in characters, ending it with an ellipsis when it is cut.
+func truncate(text string, length int) string {
+	runes := []rune(text)
+
+	if len(runes) <= length {
+		return text
+	}
+
+	return strings.TrimSpace(string(runes[:length-1])) + "…"
+}
+
+/*
+================================================================================
+CONTACTS
+================================================================================
+*/
+
+// Contact is a WhatsApp user that wrote to the business number.
+type Contact struct {
+	WaId        string
+	Name        string
+	LastInbound time.Time
+	DateAdd     time.Time
+}
+
+// InCustomerWindow tells whether free form messages still reach the contact.
+func (c *Contact) InCustomerWindow(now time.Time) bool {
+	return now.Sub(c.LastInbound) < customerWindow
+}
@@ -0,0 +1,116 @@
+package whatsapp
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func rows(prefix string, count int) []Row {
+	result := make([]Row, count)
+
+	for i := range result {
+		result[i] = Row{
+			Id:          prefix + string(rune('a'+i)),
+			Title:       strings.Repeat("Corte de pelo ", 3),
+			Description: strings.Repeat("18,50 € · 45 min ", 6),
+		}
+	}
+
+	return result
+}
+
+func TestListMessageFitsTheCloudApiLimits(t *testing.T) {
+	message := NewListMessage(
+		"34600111222",
+		strings.Repeat("Barbería ", 10),
+		strings.Repeat("x", 2000),
+		"Ver las opciones disponibles",
+		Section{Title: "Selección", Rows: rows("s", 1)},
+		Section{Title: "Servicios", Rows: rows("c", 12)},
+		Section{Title: "Más", Rows: rows("m", 2)},
+	)
+
+	interactive := message.Interactive
+	sections := interactive.Action.Sections
+
+	if len(sections) != 2 {
+		t.Fatalf("got %d sections, want the empty one left out", len(sections))
+	}
+
+	total := 0
+
+	for _, section := range sections {
+		for _, row := range section.Rows {
+			total++
+
+			if utf8.RuneCountInString(row.Title) > maxRowTitle {
+				t.Errorf("row title %q is longer than %d", row.Title, maxRowTitle)
+			}
+
+			if utf8.RuneCountInString(row.Description) > maxRowDescription {
+				t.Errorf("row description %q is longer than %d", row.Description, maxRowDescription)
+			}
+		}
+	}
+
+	if total != maxListRows {
+		t.Errorf("got %d rows, want %d", total, maxListRows)
+	}
+
+	if sections[0].Rows[0].Id != "sa" {
+		t.Errorf("the first section lost its row, got %+v", sections[0])
+	}
+
+	if utf8.RuneCountInString(interactive.Header.Text) > maxHeaderLength ||
+		utf8.RuneCountInString(interactive.Body.Text) > maxBodyLength ||
+		utf8.RuneCountInString(interactive.Action.Button) > maxButtonTitle {
+		t.Errorf("header, body or button over the limits: %+v", interactive)
+	}
+
+	if !strings.HasSuffix(interactive.Action.Button, "…") {
+		t.Errorf("button %q was cut without an ellipsis", interactive.Action.Button)
+	}
+}
+
+func TestButtonMessageKeepsThreeButtons(t *testing.T) {
+	message := NewButtonMessage(
+		"34600111222",
+		"",
+		"¿Confirmas la cita?",
+		Reply{Id: "/book?session=1", Title: "Confirmar"},
+		Reply{Id: "/hours?session=1", Title: "Volver a las horas disponibles"},
+		Reply{Id: "/dates?session=1", Title: "Fechas"},
+		Reply{Id: "/service?session=1", Title: "Servicios"},
+	)
+
+	buttons := message.Interactive.Action.Buttons
+
+	if len(buttons) != maxButtons {
+		t.Fatalf("got %d buttons, want %d", len(buttons), maxButtons)
+	}
+
+	if message.Interactive.Header != nil {
+		t.Errorf("got header %+v, want none", message.Interactive.Header)
+	}
+
+	if buttons[1].Type != "reply" || utf8.RuneCountInString(buttons[1].Reply.Title) > maxButtonTitle {
+		t.Errorf("unexpected button %+v", buttons[1])
+	}
+}
+
+func TestTemplateMessageParameters(t *testing.T) {
+	message := NewTemplateMessage("34600111222", ReminderTemplate, "es", "Ana", "Barbería", "martes 3 de marzo", "10:30")
+
+	template := message.Template
+
+	if message.Type != "template" || template.Name != ReminderTemplate || template.Language.Code != "es" {
+		t.Fatalf("unexpected template %+v", template)
+	}
+
+	parameters := template.Components[0].Parameters
+
+	if len(parameters) != 4 || parameters[0].Text != "Ana" || parameters[3].Text != "10:30" {
+		t.Errorf("unexpected parameters %+v", parameters)
+	}
+}
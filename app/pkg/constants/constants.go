@@ -8,11 +8,32 @@ const (
 	Env                      = "ENV"
 	Pro                      = "PRO"
 	WhatsappBusinessApiToken = "WHATSAPP_BUSINESS_API_TOKEN"
+	WhatsappApiUrl           = "WHATSAPP_API_URL"
+	WhatsappPhoneNumberId    = "WHATSAPP_PHONE_NUMBER_ID"
+	WhatsappNumber           = "WHATSAPP_NUMBER"
+	WhatsappAppSecret        = "WHATSAPP_APP_SECRET"
+	WhatsappVerifyToken      = "WHATSAPP_VERIFY_TOKEN"
 	TelegramApiToken         = "TELEGRAM_API_TOKEN"
 	TelegramApiBotUrl        = "TELEGRAM_BOT_API_URL"
+	TelegramBotName          = "TELEGRAM_BOT_NAME"
+	TelegramWebhookSecret    = "TELEGRAM_WEBHOOK_SECRET"
+	TelegramWebhookUrl       = "TELEGRAM_WEBHOOK_URL"
 	GoogleClientId           = "GOOGLE_CLIENT_ID"
 	GoogleClientSecret       = "GOOGLE_CLIENT_SECRET"
+	GoogleCalendarWebhookUrl = "GOOGLE_CALENDAR_WEBHOOK_URL"
+	GoogleRedirectUrl        = "GOOGLE_REDIRECT_URL"
 	JwtKey                   = "JWT_KEY"
+	EncryptionKey            = "ENCRYPTION_KEY"
+	AppUrl                   = "APP_URL"
+	SmtpHost                 = "SMTP_HOST"
+	SmtpPort                 = "SMTP_PORT"
+	SmtpUsername             = "SMTP_USERNAME"
+	SmtpPassword             = "SMTP_PASSWORD"
+	SmtpFrom                 = "SMTP_FROM"
+	SmtpRequireTls           = "SMTP_REQUIRE_TLS"
+	SmsGatewayUrl            = "SMS_GATEWAY_URL"
+	SmsGatewayToken          = "SMS_GATEWAY_TOKEN"
+	SmsFrom                  = "SMS_FROM"
 	Version                  = "Version"
 )
 
@@ -36,6 +57,7 @@ const (
 const (
 	TelegramMessageField       string = "Message"
 	TelegramCallbackQueryField string = "CallbackQuery"
+	TelegramMyChatMemberField  string = "MyChatMember"
 	TelegramMarkdown           string = "MarkdownV2"
 )
 
@@ -48,6 +70,13 @@ const (
 	HoursCommand        string = "/hours"
 	ConfirmationCommand string = "/confirmation"
 	FinishCommand       string = "/book"
+	LinkCommand         string = "/link"
+	ApproveCommand      string = "/approve"
+	RejectCommand       string = "/reject"
+	AgendaCommand       string = "/agenda"
+	BlockCommand        string = "/block"
+	HolidayCommand      string = "/holiday"
+	StatsCommand        string = "/stats"
 )
 
 // Domain
@@ -56,6 +85,7 @@ const (
 	DaysPerPage        int = 15
 	MinAllowedDatePage int = 0
 	MaxAllowedDatePage int = 23
+	MaxServicesPerMenu int = 6
 )
 
 type contextKey string
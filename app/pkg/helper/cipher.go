@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// sealedPrefix marks the values sealed by a Cipher, the ones without it were stored in clear
+// before the values were encrypted.
+const sealedPrefix = "enc:v1:"
+
+// Cipher seals the secrets stored at rest with AES-256-GCM under a key derived from the app key.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+func NewCipher(key string) (*Cipher, error) {
+	if key == "" {
+		return nil, eris.New("The encryption key is empty")
+	}
+
+	derived := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(derived[:])
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error creating the block cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, eris.Wrap(err, "Error creating the GCM cipher")
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts the value with a random nonce. An empty value stays empty.
+func (c *Cipher) Seal(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", eris.Wrap(err, "Error generating the nonce")
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value sealed by Seal. Values stored in clear are returned as they are, they
+// are sealed the next time they are saved.
+func (c *Cipher) Open(value string) (string, error) {
+	encoded, found := strings.CutPrefix(value, sealedPrefix)
+
+	if !found {
+		return value, nil
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return "", eris.Wrap(err, "Error decoding the sealed value")
+	}
+
+	nonceSize := c.aead.NonceSize()
+
+	if len(sealed) < nonceSize {
+		return "", eris.New("The sealed value is too short")
+	}
+
+	opened, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+
+	if err != nil {
+		return "", eris.Wrap(err, "Error opening the sealed value")
+	}
+
+	return string(opened), nil
+}
@@ -0,0 +1,38 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCipherSealsAndOpens(t *testing.T) {
+	cipher, err := NewCipher("app key")
+
+	if err != nil {
+		t.Fatalf("new cipher: %v", err)
+	}
+
+	sealed, err := cipher.Seal("caldav password")
+
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	if strings.Contains(sealed, "caldav password") || !strings.HasPrefix(sealed, sealedPrefix) {
+		t.Errorf("sealed = %q", sealed)
+	}
+
+	if opened, err := cipher.Open(sealed); err != nil || opened != "caldav password" {
+		t.Errorf("open = %q, %v", opened, err)
+	}
+
+	if opened, err := cipher.Open("stored in clear"); err != nil || opened != "stored in clear" {
+		t.Errorf("open of a value in clear = %q, %v", opened, err)
+	}
+
+	other, _ := NewCipher("another key")
+
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("another key should not open the value")
+	}
+}
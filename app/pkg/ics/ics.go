@@ -0,0 +1,364 @@
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rotisserie/eris"
+)
+
+// Minimal RFC 5545 support: enough to publish bookings as VEVENTs and to read the busy
+// periods of the events stored in a CalDAV collection.
+
+const (
+	ProductID       = "-//Hastypal//Hastypal Bookings//EN"
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+	MethodPublish   = "PUBLISH"
+	// A request adds the event to the calendar of the attendees, or updates the one they have
+	// when the sequence is higher. A cancel removes it.
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+const (
+	utcLayout     = "20060102T150405Z"
+	localLayout   = "20060102T150405"
+	dateLayout    = "20060102"
+	maxLineOctets = 75
+)
+
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Status       string
+	Start        time.Time
+	End          time.Time
+	Transparent  bool
+	Sequence     int
+	LastModified time.Time
+	Organizer    *Participant
+	Attendees    []Participant
+}
+
+// Participant is the organizer or an attendee of an event, reached by email.
+type Participant struct {
+	Name  string
+	Email string
+}
+
+// Calendar is an iCalendar stream. Method is left empty for the resources stored in a CalDAV
+// collection, which must not carry one.
+type Calendar struct {
+	Name   string
+	Method string
+	Events []*Event
+}
+
+// Write renders the calendar as an iCalendar stream with CRLF line endings and folded lines.
+func (c *Calendar) Write() string {
+	var builder strings.Builder
+
+	writeLine(&builder, "BEGIN:VCALENDAR")
+	writeLine(&builder, "VERSION:2.0")
+	writeLine(&builder, "PRODID:"+ProductID)
+	writeLine(&builder, "CALSCALE:GREGORIAN")
+
+	if c.Method != "" {
+		writeLine(&builder, "METHOD:"+c.Method)
+	}
+
+	if c.Name != "" {
+		writeLine(&builder, "X-WR-CALNAME:"+EscapeText(c.Name))
+	}
+
+	now := time.Now().UTC()
+
+	for _, event := range c.Events {
+		// A stable DTSTAMP keeps the output byte for byte identical while the events do not change.
+		stamp := now
+
+		if !event.LastModified.IsZero() {
+			stamp = event.LastModified.UTC()
+		}
+
+		writeLine(&builder, "BEGIN:VEVENT")
+		writeLine(&builder, "UID:"+EscapeText(event.UID))
+		writeLine(&builder, "DTSTAMP:"+stamp.Format(utcLayout))
+		writeLine(&builder, "DTSTART:"+event.Start.UTC().Format(utcLayout))
+		writeLine(&builder, "DTEND:"+event.End.UTC().Format(utcLayout))
+		writeLine(&builder, "SUMMARY:"+EscapeText(event.Summary))
+
+		if event.Description != "" {
+			writeLine(&builder, "DESCRIPTION:"+EscapeText(event.Description))
+		}
+
+		if event.Location != "" {
+			writeLine(&builder, "LOCATION:"+EscapeText(event.Location))
+		}
+
+		if event.Status != "" {
+			writeLine(&builder, "STATUS:"+event.Status)
+		}
+
+		if event.Transparent {
+			writeLine(&builder, "TRANSP:TRANSPARENT")
+		}
+
+		if event.Sequence > 0 {
+			writeLine(&builder, fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+		}
+
+		if !event.LastModified.IsZero() {
+			writeLine(&builder, "LAST-MODIFIED:"+event.LastModified.UTC().Format(utcLayout))
+		}
+
+		if event.Organizer != nil && event.Organizer.Email != "" {
+			writeLine(&builder, fmt.Sprintf("ORGANIZER;CN=%s:mailto:%s", quoteParam(event.Organizer.Name), event.Organizer.Email))
+		}
+
+		for _, attendee := range event.Attendees {
+			writeLine(&builder, fmt.Sprintf("ATTENDEE;CN=%s;ROLE=REQ-PARTICIPANT:mailto:%s", quoteParam(attendee.Name), attendee.Email))
+		}
+
+		writeLine(&builder, "END:VEVENT")
+	}
+
+	writeLine(&builder, "END:VCALENDAR")
+
+	return builder.String()
+}
+
+// EscapeText escapes a TEXT value as defined in RFC 5545 section 3.3.11.
+func EscapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+
+	return replacer.Replace(value)
+}
+
+// quoteParam quotes a parameter value, which cannot hold double quotes at all.
+func quoteParam(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
+
+func unescapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	)
+
+	return replacer.Replace(value)
+}
+
+// writeLine folds the content line at 75 octets without splitting multi-byte characters.
+func writeLine(builder *strings.Builder, line string) {
+	octets := 0
+
+	for _, char := range line {
+		size := utf8.RuneLen(char)
+
+		if octets+size > maxLineOctets {
+			builder.WriteString("\r\n ")
+			octets = 1
+		}
+
+		builder.WriteRune(char)
+		octets += size
+	}
+
+	builder.WriteString("\r\n")
+}
+
+// Parse reads the VEVENTs of an iCalendar stream. Floating times are read in the given location.
+func Parse(data string, location *time.Location) ([]*Event, error) {
+	lines, err := unfold(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*Event, 0)
+
+	var current *Event
+
+	for _, line := range lines {
+		name, params, value := splitLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+		case name == "END" && value == "VEVENT":
+			if current != nil {
+				if current.End.IsZero() {
+					current.End = current.Start
+				}
+
+				events = append(events, current)
+			}
+
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = unescapeText(value)
+		case name == "SUMMARY":
+			current.Summary = unescapeText(value)
+		case name == "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case name == "LOCATION":
+			current.Location = unescapeText(value)
+		case name == "STATUS":
+			current.Status = strings.ToUpper(value)
+		case name == "TRANSP":
+			current.Transparent = strings.EqualFold(value, "TRANSPARENT")
+		case name == "DTSTART":
+			if current.Start, err = parseDateTime(value, params, location); err != nil {
+				return nil, eris.Wrapf(err, "Error parsing DTSTART of event %s", current.UID)
+			}
+		case name == "DTEND":
+			if current.End, err = parseDateTime(value, params, location); err != nil {
+				return nil, eris.Wrapf(err, "Error parsing DTEND of event %s", current.UID)
+			}
+		case name == "DURATION":
+			duration, err := parseDuration(value)
+
+			if err != nil {
+				return nil, eris.Wrapf(err, "Error parsing DURATION of event %s", current.UID)
+			}
+
+			current.End = current.Start.Add(duration)
+		}
+	}
+
+	return events, nil
+}
+
+func unfold(data string) ([]string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lines := make([]string, 0)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, eris.Wrap(err, "Error reading iCalendar data")
+	}
+
+	return lines, nil
+}
+
+func splitLine(line string) (string, map[string]string, string) {
+	nameAndParams, value, _ := strings.Cut(line, ":")
+
+	parts := strings.Split(nameAndParams, ";")
+	params := make(map[string]string, len(parts)-1)
+
+	for _, param := range parts[1:] {
+		key, paramValue, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(paramValue, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, value
+}
+
+func parseDateTime(value string, params map[string]string, location *time.Location) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, value, location)
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(utcLayout, value)
+	}
+
+	if tzid, ok := params["TZID"]; ok {
+		if tzLocation, err := time.LoadLocation(tzid); err == nil {
+			return time.ParseInLocation(localLayout, value, tzLocation)
+		}
+	}
+
+	return time.ParseInLocation(localLayout, value, location)
+}
+
+// parseDuration supports the dur-day, dur-time and dur-week forms of RFC 5545 section 3.3.6.
+func parseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+	}
+
+	value = strings.TrimLeft(value, "+-")
+
+	if !strings.HasPrefix(value, "P") {
+		return 0, eris.Errorf("Invalid duration %s", value)
+	}
+
+	var (
+		total   time.Duration
+		number  int
+		inTime  bool
+		hasUnit bool
+	)
+
+	for _, char := range value[1:] {
+		switch {
+		case char >= '0' && char <= '9':
+			number = number*10 + int(char-'0')
+
+			continue
+		case char == 'T':
+			inTime = true
+
+			continue
+		case char == 'W':
+			total += time.Duration(number) * 7 * 24 * time.Hour
+		case char == 'D':
+			total += time.Duration(number) * 24 * time.Hour
+		case char == 'H' && inTime:
+			total += time.Duration(number) * time.Hour
+		case char == 'M' && inTime:
+			total += time.Duration(number) * time.Minute
+		case char == 'S' && inTime:
+			total += time.Duration(number) * time.Second
+		default:
+			return 0, eris.Errorf("Invalid duration %s", value)
+		}
+
+		number = 0
+		hasUnit = true
+	}
+
+	if !hasUnit {
+		return 0, eris.Errorf("Invalid duration %s", value)
+	}
+
+	return sign * total, nil
+}
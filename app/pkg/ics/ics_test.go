@@ -0,0 +1,92 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWriteEncodesTheInvite(t *testing.T) {
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+
+	invite := &Calendar{
+		Method: MethodCancel,
+		Events: []*Event{{
+			UID:          "b1@hastypal",
+			Sequence:     3,
+			Summary:      "Peluquería Marta",
+			Description:  "Corte, barba; lavado",
+			Location:     "Carrer Major 1\nBarcelona",
+			Status:       StatusCancelled,
+			Start:        time.Date(2026, 3, 5, 10, 0, 0, 0, madrid),
+			End:          time.Date(2026, 3, 5, 10, 30, 0, 0, madrid),
+			LastModified: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+			Organizer:    &Participant{Name: `Marta "la barbera"`, Email: "marta@example.com"},
+			Attendees:    []Participant{{Name: "Ana", Email: "ana@example.com"}},
+		}},
+	}
+
+	encoded := invite.Write()
+
+	for _, want := range []string{
+		"METHOD:CANCEL\r\n",
+		"STATUS:CANCELLED\r\n",
+		"SEQUENCE:3\r\n",
+		"DTSTAMP:20260301T120000Z\r\n",
+		"DTSTART:20260305T090000Z\r\n",
+		"DTEND:20260305T093000Z\r\n",
+		`DESCRIPTION:Corte\, barba\; lavado` + "\r\n",
+		`LOCATION:Carrer Major 1\nBarcelona` + "\r\n",
+		`ORGANIZER;CN="Marta 'la barbera'":mailto:marta@example.com` + "\r\n",
+		`ATTENDEE;CN="Ana";ROLE=REQ-PARTICIPANT:mailto:ana@example.com` + "\r\n",
+	} {
+		if !strings.Contains(encoded, want) {
+			t.Errorf("invite lacks %q:\n%s", want, encoded)
+		}
+	}
+
+	if !strings.HasPrefix(encoded, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(encoded, "END:VCALENDAR\r\n") {
+		t.Errorf("invite is not a calendar:\n%s", encoded)
+	}
+}
+
+func TestWriteLineKeepsTheLinesWithinTheLimit(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("Depilación con cera ", 12)
+
+	var builder strings.Builder
+
+	writeLine(&builder, line)
+
+	folded := builder.String()
+
+	if !strings.HasSuffix(folded, "\r\n") {
+		t.Fatalf("folded line does not end the line: %q", folded)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+
+	if len(lines) < 2 {
+		t.Fatalf("line was not folded: %q", folded)
+	}
+
+	for i, part := range lines {
+		if len(part) > maxLineOctets {
+			t.Errorf("line %d has %d octets", i, len(part))
+		}
+
+		if !utf8.ValidString(part) {
+			t.Errorf("line %d splits a character: %q", i, part)
+		}
+
+		if i > 0 && !strings.HasPrefix(part, " ") {
+			t.Errorf("line %d does not continue with a space: %q", i, part)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", "")
+
+	if unfolded != line {
+		t.Errorf("unfolded = %q, want %q", unfolded, line)
+	}
+}
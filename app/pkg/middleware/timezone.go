@@ -17,6 +17,8 @@ func TimeZone() gin.HandlerFunc {
 				ctx.Set(TimezoneKey, userLoc)
 
 				ctx.Next()
+
+				return
 			}
 		}
 
@@ -0,0 +1,8 @@
+// Lets the server render dates in the timezone of the browser, read by middleware.TimeZone.
+(function () {
+  var zone = Intl.DateTimeFormat().resolvedOptions().timeZone;
+
+  if (zone && document.cookie.indexOf("User_Tz=" + zone) === -1) {
+    document.cookie = "User_Tz=" + zone + "; path=/; max-age=31536000; samesite=lax";
+  }
+})();
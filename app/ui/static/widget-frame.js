@@ -0,0 +1,13 @@
+// Tells the page that embeds the booking page how tall it is, read by widget.js.
+(function () {
+  if (window.parent === window) {
+    return;
+  }
+
+  function resize() {
+    window.parent.postMessage({ hastypal: "resize", height: document.documentElement.scrollHeight }, "*");
+  }
+
+  window.addEventListener("load", resize);
+  window.addEventListener("resize", resize);
+})();
@@ -0,0 +1,37 @@
+// Embeds the booking page of a business where the script tag is, e.g.
+// <script src="https://hastypal.com/ui/static/widget.js" data-business="7" async></script>
+// data-service, data-employee and data-source preselect what the start links do.
+(function () {
+  var script = document.currentScript;
+
+  if (!script || !script.dataset.business) {
+    return;
+  }
+
+  var origin = new URL(script.src).origin;
+  var params = new URLSearchParams();
+
+  ["service", "employee", "source"].forEach(function (name) {
+    if (script.dataset[name]) {
+      params.set(name, script.dataset[name]);
+    }
+  });
+
+  var query = params.toString();
+  var frame = document.createElement("iframe");
+
+  frame.src = origin + "/book/" + encodeURIComponent(script.dataset.business) + (query ? "?" + query : "");
+  frame.title = "Hastypal";
+  frame.loading = "lazy";
+  frame.style.width = "100%";
+  frame.style.height = "36rem";
+  frame.style.border = "0";
+
+  script.parentNode.insertBefore(frame, script.nextSibling);
+
+  window.addEventListener("message", function (event) {
+    if (event.origin === origin && event.source === frame.contentWindow && event.data && event.data.hastypal === "resize") {
+      frame.style.height = event.data.height + "px";
+    }
+  });
+})();
@@ -0,0 +1,189 @@
+package views
+
+import (
+	"fmt"
+	"time"
+)
+
+// Page carries what the layout of every dashboard page needs.
+type Page struct {
+	Title        string
+	BusinessName string
+	UserName     string
+	Active       string
+}
+
+type BookingRow struct {
+	ID       string
+	Day      string
+	Start    string
+	End      string
+	Customer string
+	Service  string
+	Employee string
+	Status   string
+}
+
+type AgendaPage struct {
+	Page
+	Label    string
+	Previous string
+	Next     string
+	Bookings []BookingRow
+}
+
+type WeekDay struct {
+	Label    string
+	Today    bool
+	Bookings []BookingRow
+}
+
+type WeekPage struct {
+	Page
+	Label    string
+	Previous string
+	Next     string
+	Days     []WeekDay
+}
+
+type BookingPage struct {
+	Page
+	Booking   BookingRow
+	CanCancel bool
+	CanNoShow bool
+	Error     string
+}
+
+type ServiceRow struct {
+	ID       int
+	Name     string
+	Price    string
+	Currency string
+	Duration int
+	Category string
+}
+
+type ServicesPage struct {
+	Page
+	Services []ServiceRow
+	CanEdit  bool
+	Error    string
+}
+
+type HoursDay struct {
+	Weekday int
+	Label   string
+	Ranges  string
+}
+
+type HoursPage struct {
+	Page
+	Days    []HoursDay
+	CanEdit bool
+	Error   string
+}
+
+type WidgetSnippetPage struct {
+	Page
+	Link   string
+	Script string
+	Frame  string
+}
+
+// BookingWidget is a step of the public booking page of a business, the page the web widget
+// embeds. The texts come in the language of the customer.
+type BookingWidget struct {
+	Lang       string
+	Title      string
+	Business   string
+	Lines      []WidgetLine
+	Grid       bool
+	Options    []WidgetChoice
+	Next       []WidgetChoice
+	Navigation []WidgetChoice
+	Contact    *ContactForm
+}
+
+type WidgetLine struct {
+	Emoji  string
+	Label  string
+	Text   string
+	Strong bool
+}
+
+type WidgetChoice struct {
+	Label    string
+	Detail   string
+	Href     string
+	Selected bool
+}
+
+// ContactForm asks the customer who the booking is for in place of the confirm button. Action is
+// the one of the confirm choice, posted back with the contact details.
+type ContactForm struct {
+	Url          string
+	Action       string
+	Instructions string
+	NameLabel    string
+	PhoneLabel   string
+	EmailLabel   string
+	Submit       string
+	Name         string
+	Phone        string
+	Email        string
+	Error        string
+}
+
+var weekdays = map[time.Weekday]string{
+	time.Monday:    "Lunes",
+	time.Tuesday:   "Martes",
+	time.Wednesday: "Miércoles",
+	time.Thursday:  "Jueves",
+	time.Friday:    "Viernes",
+	time.Saturday:  "Sábado",
+	time.Sunday:    "Domingo",
+}
+
+var months = map[time.Month]string{
+	time.January:   "enero",
+	time.February:  "febrero",
+	time.March:     "marzo",
+	time.April:     "abril",
+	time.May:       "mayo",
+	time.June:      "junio",
+	time.July:      "julio",
+	time.August:    "agosto",
+	time.September: "septiembre",
+	time.October:   "octubre",
+	time.November:  "noviembre",
+	time.December:  "diciembre",
+}
+
+var statuses = map[string]string{
+	"pending":   "Pendiente",
+	"confirmed": "Confirmada",
+	"cancelled": "Cancelada",
+	"no_show":   "No presentado",
+}
+
+func WeekdayLabel(day time.Weekday) string {
+	return weekdays[day]
+}
+
+// DateLabel formats a date the way the dashboard shows it, "Lunes 3 de marzo".
+func DateLabel(date time.Time) string {
+	return fmt.Sprintf("%s %d de %s", weekdays[date.Weekday()], date.Day(), months[date.Month()])
+}
+
+func StatusLabel(status string) string {
+	if label, ok := statuses[status]; ok {
+		return label
+	}
+
+	return status
+}
+
+// FormatPrice turns a price in minor units into its decimal form.
+func FormatPrice(price int) string {
+	return fmt.Sprintf("%d.%02d", price/100, price%100)
+}